@@ -10,6 +10,9 @@ import (
 
 const AppVersion = "1.0.0"
 
+// defaultMockPaymentSecret signs mock webhooks in development and test; any other environment must set its own
+const defaultMockPaymentSecret = "mock-payment-secret"
+
 // GetBaseURL returns the base URL for the application
 func GetBaseURL() string {
 	return getEnv("BASE_URL", "http://localhost:8080")
@@ -24,6 +27,13 @@ type Config struct {
 	DBName      string
 	JWTSecret   string
 	StartupTime time.Time
+	AppEnv      string // development, test or production
+
+	// Payment providers: "method=provider" pairs, e.g. "card=mock,ewallet=mock".
+	// Methods not listed complete immediately.
+	PaymentProviderMethods string
+	MockPaymentEnabled     bool   // Registers the mock provider and its simulate endpoint
+	MockPaymentSecret      string // Signs the mock provider's webhooks
 }
 
 func LoadConfig() (*Config, error) {
@@ -39,11 +49,26 @@ func LoadConfig() (*Config, error) {
 		DBName:      getEnv("DB_NAME", "myposcore"),
 		JWTSecret:   getEnv("JWT_SECRET", "default-secret-key"),
 		StartupTime: time.Now(),
+		AppEnv:      getEnv("APP_ENV", "production"),
+
+		PaymentProviderMethods: getEnv("PAYMENT_PROVIDER_METHODS", ""),
+		MockPaymentEnabled:     getEnv("MOCK_PAYMENT_ENABLED", "false") == "true",
+		MockPaymentSecret:      getEnv("MOCK_PAYMENT_SECRET", defaultMockPaymentSecret),
+	}
+
+	// Anyone knowing the default secret could sign webhooks that complete mock payments
+	if config.MockPaymentEnabled && !config.IsDevelopment() && config.MockPaymentSecret == defaultMockPaymentSecret {
+		return nil, fmt.Errorf("MOCK_PAYMENT_SECRET must be set when the mock payment provider is enabled in %s", config.AppEnv)
 	}
 
 	return config, nil
 }
 
+// IsDevelopment reports whether the server runs in development or test mode
+func (c *Config) IsDevelopment() bool {
+	return c.AppEnv == "development" || c.AppEnv == "test"
+}
+
 func (c *Config) GetDSN() string {
 	return fmt.Sprintf(
 		"host=%s port=%s user=%s password=%s dbname=%s sslmode=disable",
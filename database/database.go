@@ -41,9 +41,44 @@ func InitDB(cfg *config.Config) error {
 		&models.User{},
 		&models.Category{},
 		&models.Product{},
+		&models.Customer{},
+		&models.LoyaltyProgram{},
+		&models.LoyaltyCategoryMultiplier{},
+		&models.LoyaltyTier{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderStatusHistory{},
+		&models.KitchenEvent{},
+		&models.OrderNumberFormat{},
+		&models.OrderNumberSequence{},
+		&models.OrderNumberReservation{},
+		&models.CashShift{},
+		&models.CashMovement{},
 		&models.Payment{},
+		&models.Refund{},
+		&models.RefundItem{},
+		&models.LoyaltyTransaction{},
+		&models.GiftCard{},
+		&models.GiftCardTransaction{},
+		&models.ProductStock{},
+		&models.StockMovement{},
+		&models.StockTransfer{},
+		&models.StockTransferItem{},
+		&models.Supplier{},
+		&models.PurchaseOrder{},
+		&models.PurchaseOrderItem{},
+		&models.GoodsReceipt{},
+		&models.GoodsReceiptItem{},
+		&models.StockAlert{},
+		&models.Promotion{},
+		&models.OrderPromotion{},
+		&models.TaxRule{},
+		&models.TaxRuleExemption{},
+		&models.OrderTax{},
+		&models.TipPreset{},
+		&models.ReceiptTemplate{},
+		&models.QRISMerchant{},
+		&models.IdempotencyKey{},
 		&models.TermsAndConditions{},
 		&models.FAQ{},
 	)
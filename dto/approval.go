@@ -0,0 +1,21 @@
+package dto
+
+// SupervisorApproval is attached to requests for actions a cashier may only take with a supervisor's consent
+type SupervisorApproval struct {
+	ApproverID uint   `json:"approver_id" binding:"required"`
+	PIN        string `json:"pin" binding:"required"`
+}
+
+type SupervisorApprovalResponse struct {
+	Action          string `json:"action"`
+	RequestedBy     uint   `json:"requested_by"`
+	RequestedByName string `json:"requested_by_name"`
+	ApprovedBy      uint   `json:"approved_by"`
+	ApprovedByName  string `json:"approved_by_name"`
+	ApprovedAt      string `json:"approved_at"`
+}
+
+type OpenCashDrawerRequest struct {
+	Reason   string              `json:"reason" binding:"required"`
+	Approval *SupervisorApproval `json:"approval" binding:"required"`
+}
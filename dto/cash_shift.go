@@ -0,0 +1,90 @@
+package dto
+
+import "myposcore/money"
+
+type OpenCashShiftRequest struct {
+	OpeningFloat money.Amount `json:"opening_float" binding:"gte=0"`
+	Notes        string       `json:"notes"`
+}
+
+// CashDenominationCount is how many notes or coins of one value were counted in the drawer
+type CashDenominationCount struct {
+	Value money.Amount `json:"value" binding:"gt=0"`
+	Count int          `json:"count" binding:"gte=0"`
+}
+
+type CloseCashShiftRequest struct {
+	Denominations []CashDenominationCount `json:"denominations" binding:"required,dive"`
+	Notes         string                  `json:"notes"`
+}
+
+type CreateCashMovementRequest struct {
+	Type   string       `json:"type" binding:"required,oneof=pay_in pay_out"`
+	Amount money.Amount `json:"amount" binding:"required,gt=0"`
+	Reason string       `json:"reason" binding:"required"`
+}
+
+type CashMovementResponse struct {
+	ID            uint         `json:"id"`
+	ShiftID       uint         `json:"shift_id"`
+	Type          string       `json:"type"`
+	Amount        money.Amount `json:"amount"`
+	Reason        string       `json:"reason"`
+	CreatedAt     string       `json:"created_at"`
+	CreatedBy     *uint        `json:"created_by,omitempty"`
+	CreatedByName *string      `json:"created_by_name,omitempty"`
+}
+
+type CashShiftResponse struct {
+	ID            uint                    `json:"id"`
+	TenantID      uint                    `json:"tenant_id"`
+	BranchID      uint                    `json:"branch_id"`
+	UserID        uint                    `json:"user_id"`
+	UserName      string                  `json:"user_name"`
+	Status        string                  `json:"status"`
+	OpeningFloat  money.Amount            `json:"opening_float"`
+	OpeningNotes  string                  `json:"opening_notes"`
+	OpenedAt      string                  `json:"opened_at"`
+	ClosedAt      *string                 `json:"closed_at"`
+	ClosedBy      *uint                   `json:"closed_by"`
+	ClosedByName  *string                 `json:"closed_by_name,omitempty"`
+	ClosingNotes  string                  `json:"closing_notes"`
+	Denominations []CashDenominationCount `json:"denominations"`
+	ExpectedCash  *money.Amount           `json:"expected_cash"` // Set once closed
+	CountedCash   *money.Amount           `json:"counted_cash"`
+	Variance      *money.Amount           `json:"variance"`
+	ZNumber       *int                    `json:"z_number"`
+}
+
+// CashShiftMethodTotal sums the payments or refunds of one method within a shift
+type CashShiftMethodTotal struct {
+	Method string       `json:"method"`
+	Count  int          `json:"count"`
+	Amount money.Amount `json:"amount"`
+}
+
+// CashShiftReportResponse is the X report of an open shift (a reading that leaves it open) or the
+// Z report of a closed one
+type CashShiftReportResponse struct {
+	ReportType    string                  `json:"report_type"` // X, Z
+	Shift         CashShiftResponse       `json:"shift"`
+	OrderCount    int                     `json:"order_count"`
+	Payments      []CashShiftMethodTotal  `json:"payments"`
+	TotalPayments money.Amount            `json:"total_payments"`
+	Refunds       []CashShiftMethodTotal  `json:"refunds"`
+	TotalRefunds  money.Amount            `json:"total_refunds"`
+	Movements     []CashMovementResponse  `json:"movements"`
+	OpeningFloat  money.Amount            `json:"opening_float"`
+	CashSales     money.Amount            `json:"cash_sales"`
+	CashRefunds   money.Amount            `json:"cash_refunds"`
+	CashTips      money.Amount            `json:"cash_tips"`
+	TotalTips     money.Amount            `json:"total_tips"`
+	Tips          []TipStaffTotal         `json:"tips"` // Per staff member, for payouts
+	PayIns        money.Amount            `json:"pay_ins"`
+	PayOuts       money.Amount            `json:"pay_outs"`
+	ExpectedCash  money.Amount            `json:"expected_cash"`
+	CountedCash   *money.Amount           `json:"counted_cash"`
+	Variance      *money.Amount           `json:"variance"`
+	Denominations []CashDenominationCount `json:"denominations,omitempty"`
+	GeneratedAt   string                  `json:"generated_at"`
+}
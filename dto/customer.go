@@ -0,0 +1,66 @@
+package dto
+
+import "myposcore/money"
+
+type CreateCustomerRequest struct {
+	Name      string   `json:"name" binding:"required"`
+	Phone     string   `json:"phone"`
+	Email     string   `json:"email" binding:"omitempty,email"`
+	Birthday  string   `json:"birthday" binding:"omitempty,datetime=2006-01-02"` // YYYY-MM-DD
+	Notes     string   `json:"notes"`
+	Tags      []string `json:"tags"`
+	CreatedBy *uint    `json:"-"` // Set internally, not from request
+}
+
+type UpdateCustomerRequest struct {
+	Name      *string  `json:"name" binding:"omitempty,min=1"`
+	Phone     *string  `json:"phone"`
+	Email     *string  `json:"email" binding:"omitempty,email"`
+	Birthday  *string  `json:"birthday" binding:"omitempty,datetime=2006-01-02"` // Empty string clears it
+	Notes     *string  `json:"notes"`
+	Tags      []string `json:"tags"` // Replaces all tags when present
+	UpdatedBy *uint    `json:"-"`    // Set internally, not from request
+}
+
+type CustomerResponse struct {
+	ID             uint     `json:"id"`
+	TenantID       uint     `json:"tenant_id"`
+	Name           string   `json:"name"`
+	Phone          string   `json:"phone"`
+	Email          string   `json:"email"`
+	Birthday       *string  `json:"birthday"`
+	Notes          string   `json:"notes"`
+	Tags           []string `json:"tags"`
+	LoyaltyPoints  int      `json:"loyalty_points"`
+	LifetimePoints int      `json:"lifetime_points"`
+	LoyaltyTier    string   `json:"loyalty_tier"`
+	CreatedAt      string   `json:"created_at"`
+	UpdatedAt      string   `json:"updated_at"`
+	CreatedBy      *uint    `json:"created_by,omitempty"`
+	CreatedByName  *string  `json:"created_by_name,omitempty"`
+	UpdatedBy      *uint    `json:"updated_by,omitempty"`
+	UpdatedByName  *string  `json:"updated_by_name,omitempty"`
+}
+
+// CustomerStats summarises the orders linked to a customer. Cancelled and voided orders are excluded
+// and refunds are deducted from the lifetime spend.
+type CustomerStats struct {
+	LifetimeSpend money.Amount `json:"lifetime_spend"`
+	VisitCount    int64        `json:"visit_count"`
+	LastVisit     *string      `json:"last_visit"`
+}
+
+// CustomerOrderHistory is one page of the customer's orders, newest first
+type CustomerOrderHistory struct {
+	Page       int             `json:"page"`
+	PageSize   int             `json:"page_size"`
+	TotalItems int64           `json:"total_items"`
+	TotalPages int             `json:"total_pages"`
+	Orders     []OrderResponse `json:"orders"`
+}
+
+type CustomerDetailResponse struct {
+	CustomerResponse
+	Stats        CustomerStats        `json:"stats"`
+	OrderHistory CustomerOrderHistory `json:"order_history"`
+}
@@ -0,0 +1,53 @@
+package dto
+
+import "myposcore/money"
+
+type GiftCardResponse struct {
+	ID             uint         `json:"id"`
+	Code           string       `json:"code"`
+	Type           string       `json:"type"` // gift_card, store_credit
+	BranchID       uint         `json:"branch_id"`
+	CustomerID     *uint        `json:"customer_id,omitempty"`
+	CustomerName   *string      `json:"customer_name,omitempty"`
+	InitialBalance money.Amount `json:"initial_balance"`
+	Balance        money.Amount `json:"balance"`
+	ExpiresAt      *string      `json:"expires_at,omitempty"`
+	Status         string       `json:"status"` // active, expired, voided
+	OrderID        *uint        `json:"order_id,omitempty"`
+	RefundID       *uint        `json:"refund_id,omitempty"`
+	CreatedAt      string       `json:"created_at"`
+	CreatedBy      *uint        `json:"created_by,omitempty"`
+}
+
+// GiftCardBalanceResponse answers a balance inquiry; it leaves out who holds the card
+type GiftCardBalanceResponse struct {
+	Code      string       `json:"code"`
+	Type      string       `json:"type"`
+	Balance   money.Amount `json:"balance"`
+	ExpiresAt *string      `json:"expires_at,omitempty"`
+	Status    string       `json:"status"`
+}
+
+type GiftCardTransactionResponse struct {
+	ID           uint         `json:"id"`
+	Type         string       `json:"type"` // issue, redeem, reverse_redeem, void
+	Amount       money.Amount `json:"amount"`
+	BalanceAfter money.Amount `json:"balance_after"`
+	BranchID     *uint        `json:"branch_id,omitempty"`
+	OrderID      *uint        `json:"order_id,omitempty"`
+	PaymentID    *uint        `json:"payment_id,omitempty"`
+	RefundID     *uint        `json:"refund_id,omitempty"`
+	Description  string       `json:"description"`
+	CreatedAt    string       `json:"created_at"`
+	CreatedBy    *uint        `json:"created_by,omitempty"`
+}
+
+// GiftCardDetailResponse is a card with one page of its ledger, newest first
+type GiftCardDetailResponse struct {
+	GiftCardResponse
+	Page         int                           `json:"page"`
+	PageSize     int                           `json:"page_size"`
+	TotalItems   int64                         `json:"total_items"`
+	TotalPages   int                           `json:"total_pages"`
+	Transactions []GiftCardTransactionResponse `json:"transactions"`
+}
@@ -0,0 +1,62 @@
+package dto
+
+import "myposcore/money"
+
+// ReorderPointRequest sets a product's reorder point, or a branch's own one when branch_id is given.
+// Left out values are 0 (no low-stock alerts) for the product and fall back to the product's for a branch.
+type ReorderPointRequest struct {
+	BranchID        *uint `json:"branch_id"`
+	MinStock        *int  `json:"min_stock" binding:"omitempty,min=0"`
+	ReorderQuantity *int  `json:"reorder_quantity" binding:"omitempty,min=0"`
+}
+
+type ReorderPointResponse struct {
+	ProductID                uint  `json:"product_id"`
+	MinStock                 int   `json:"min_stock"`        // The product's
+	ReorderQuantity          int   `json:"reorder_quantity"` // The product's
+	BranchID                 *uint `json:"branch_id,omitempty"`
+	BranchMinStock           *int  `json:"branch_min_stock,omitempty"`
+	BranchReorderQuantity    *int  `json:"branch_reorder_quantity,omitempty"`
+	EffectiveMinStock        int   `json:"effective_min_stock"` // At the branch, or the product's without one
+	EffectiveReorderQuantity int   `json:"effective_reorder_quantity"`
+}
+
+type StockAlertResponse struct {
+	ID          uint    `json:"id"`
+	BranchID    uint    `json:"branch_id"`
+	BranchName  string  `json:"branch_name"`
+	ProductID   uint    `json:"product_id"`
+	ProductName string  `json:"product_name"`
+	SKU         string  `json:"sku"`
+	Type        string  `json:"type"`   // low_stock, out_of_stock
+	Status      string  `json:"status"` // open, resolved
+	Quantity    int     `json:"quantity"`
+	MinStock    int     `json:"min_stock"`
+	RaisedAt    string  `json:"raised_at"`
+	ResolvedAt  *string `json:"resolved_at"`
+}
+
+// ReorderSuggestion is a product to order for a branch, sized to cover the sales expected over the
+// cover days on top of its reorder point
+type ReorderSuggestion struct {
+	ProductID         uint         `json:"product_id"`
+	ProductName       string       `json:"product_name"`
+	SKU               string       `json:"sku"`
+	Stock             int          `json:"stock"`
+	OnOrder           int          `json:"on_order"` // Still to be received on open purchase orders
+	MinStock          int          `json:"min_stock"`
+	ReorderQuantity   int          `json:"reorder_quantity"`
+	SoldQuantity      int          `json:"sold_quantity"` // Net of refunds over the sales window
+	AverageDailySales float64      `json:"average_daily_sales"`
+	DaysOfCover       *float64     `json:"days_of_cover"` // Days the stock lasts at that rate, null without sales
+	SuggestedQuantity int          `json:"suggested_quantity"`
+	UnitCost          money.Amount `json:"unit_cost"`
+	EstimatedCost     money.Amount `json:"estimated_cost"`
+}
+
+type ReorderSuggestionsResponse struct {
+	BranchID  uint                `json:"branch_id"`
+	Days      int                 `json:"days"`       // Sales window
+	CoverDays int                 `json:"cover_days"` // Days the suggested stock should last
+	Items     []ReorderSuggestion `json:"items"`
+}
@@ -0,0 +1,9 @@
+package dto
+
+type BumpOrderRequest struct {
+	Status string `json:"status"` // Empty = next kitchen status (pending -> confirmed -> preparing -> ready)
+}
+
+type BumpOrderItemRequest struct {
+	Status string `json:"status" binding:"omitempty,oneof=pending preparing ready"` // Empty = next kitchen status (pending -> preparing -> ready)
+}
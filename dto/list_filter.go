@@ -0,0 +1,26 @@
+package dto
+
+import "myposcore/money"
+
+// ListFilter holds the query parameters shared by the order and payment listings.
+// Dates are interpreted in the branch timezone unless they carry an explicit offset.
+type ListFilter struct {
+	Page           int
+	PerPage        int
+	DateFrom       string   // YYYY-MM-DD or YYYY-MM-DD HH:MM[:SS]
+	DateTo         string   // a bare date includes the whole day
+	Statuses       []string // any of the given statuses
+	PaymentMethods []string
+	UserID         *uint // cashier who took the order
+	CustomerID     *uint
+	MinAmount      *money.Amount
+	MaxAmount      *money.Amount
+	OrderNumber    string // order number prefix
+	SortBy         string
+	SortDir        string // asc or desc
+}
+
+// Offset returns the number of rows to skip for the requested page
+func (f *ListFilter) Offset() int {
+	return (f.Page - 1) * f.PerPage
+}
@@ -0,0 +1,69 @@
+package dto
+
+import "myposcore/money"
+
+type LoyaltyCategoryMultiplierRequest struct {
+	CategoryID uint    `json:"category_id" binding:"required"`
+	Multiplier float64 `json:"multiplier" binding:"required,gt=0"`
+}
+
+type LoyaltyTierRequest struct {
+	Name           string  `json:"name" binding:"required"`
+	MinPoints      int     `json:"min_points" binding:"min=0"` // Lifetime points needed
+	EarnMultiplier float64 `json:"earn_multiplier" binding:"required,gt=0"`
+}
+
+// SaveLoyaltyProgramRequest replaces the tenant's loyalty rules, including all category multipliers and tiers
+type SaveLoyaltyProgramRequest struct {
+	IsActive            bool                               `json:"is_active"`
+	PointsPerUnit       float64                            `json:"points_per_unit" binding:"min=0"` // e.g. 0.01 = 1 point per 100 spent
+	RedeemValue         money.Amount                       `json:"redeem_value" binding:"min=0"`    // Currency value of one point
+	MinRedeemPoints     int                                `json:"min_redeem_points" binding:"min=0"`
+	ExpiryDays          int                                `json:"expiry_days" binding:"min=0"` // 0 = never
+	CategoryMultipliers []LoyaltyCategoryMultiplierRequest `json:"category_multipliers" binding:"dive"`
+	Tiers               []LoyaltyTierRequest               `json:"tiers" binding:"dive"`
+}
+
+type LoyaltyProgramResponse struct {
+	TenantID            uint                               `json:"tenant_id"`
+	IsActive            bool                               `json:"is_active"`
+	PointsPerUnit       float64                            `json:"points_per_unit"`
+	RedeemValue         money.Amount                       `json:"redeem_value"`
+	MinRedeemPoints     int                                `json:"min_redeem_points"`
+	ExpiryDays          int                                `json:"expiry_days"`
+	CategoryMultipliers []LoyaltyCategoryMultiplierRequest `json:"category_multipliers"`
+	Tiers               []LoyaltyTierRequest               `json:"tiers"`
+	UpdatedAt           *string                            `json:"updated_at,omitempty"`
+	UpdatedBy           *uint                              `json:"updated_by,omitempty"`
+}
+
+type LoyaltyTransactionResponse struct {
+	ID           uint    `json:"id"`
+	Type         string  `json:"type"`
+	Points       int     `json:"points"`
+	BalanceAfter int     `json:"balance_after"`
+	BranchID     *uint   `json:"branch_id,omitempty"`
+	OrderID      *uint   `json:"order_id,omitempty"`
+	PaymentID    *uint   `json:"payment_id,omitempty"`
+	RefundID     *uint   `json:"refund_id,omitempty"`
+	ExpiresAt    *string `json:"expires_at,omitempty"`
+	Description  string  `json:"description"`
+	CreatedAt    string  `json:"created_at"`
+	CreatedBy    *uint   `json:"created_by,omitempty"`
+}
+
+// CustomerLoyaltyResponse is a member's balance with one page of their ledger, newest first
+type CustomerLoyaltyResponse struct {
+	CustomerID     uint                         `json:"customer_id"`
+	Name           string                       `json:"name"`
+	Phone          string                       `json:"phone"`
+	Points         int                          `json:"points"`
+	PointsValue    money.Amount                 `json:"points_value"` // Currency value when redeemed
+	LifetimePoints int                          `json:"lifetime_points"`
+	Tier           string                       `json:"tier"`
+	Page           int                          `json:"page"`
+	PageSize       int                          `json:"page_size"`
+	TotalItems     int64                        `json:"total_items"`
+	TotalPages     int                          `json:"total_pages"`
+	Transactions   []LoyaltyTransactionResponse `json:"transactions"`
+}
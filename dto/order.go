@@ -1,9 +1,14 @@
 package dto
 
+import "myposcore/money"
+
 type CreateOrderRequest struct {
-	Items     []OrderItemRequest `json:"items" binding:"required,min=1"`
-	Notes     string             `json:"notes"`
-	CreatedBy *uint              `json:"-"` // Set internally, not from request
+	Items       []OrderItemRequest `json:"items" binding:"required,min=1"`
+	Notes       string             `json:"notes"`
+	CouponCode  string             `json:"coupon_code"`
+	CustomerID  *uint              `json:"customer_id"`  // Optional customer from the directory
+	MemberPhone string             `json:"member_phone"` // Alternative to customer_id: loyalty member's phone
+	CreatedBy   *uint              `json:"-"`            // Set internally, not from request
 }
 
 type OrderItemRequest struct {
@@ -12,29 +17,97 @@ type OrderItemRequest struct {
 }
 
 type OrderResponse struct {
-	ID            uint                `json:"id"`
-	TenantID      uint                `json:"tenant_id"`
-	BranchID      uint                `json:"branch_id"`
-	UserID        uint                `json:"user_id"`
-	OrderNumber   string              `json:"order_number"`
-	TotalAmount   float64             `json:"total_amount"`
-	Status        string              `json:"status"`
-	Notes         string              `json:"notes"`
-	OrderItems    []OrderItemResponse `json:"order_items"`
-	CreatedAt     string              `json:"created_at"`
-	UpdatedAt     string              `json:"updated_at"`
-	CreatedBy     *uint               `json:"created_by,omitempty"`
-	CreatedByName *string             `json:"created_by_name,omitempty"`
-	UpdatedBy     *uint               `json:"updated_by,omitempty"`
-	UpdatedByName *string             `json:"updated_by_name,omitempty"`
+	ID             uint                     `json:"id"`
+	TenantID       uint                     `json:"tenant_id"`
+	BranchID       uint                     `json:"branch_id"`
+	UserID         uint                     `json:"user_id"`
+	CustomerID     *uint                    `json:"customer_id,omitempty"`
+	OrderNumber    string                   `json:"order_number"`
+	GrossAmount    money.Amount             `json:"gross_amount"`
+	DiscountAmount money.Amount             `json:"discount_amount"`
+	SubtotalAmount money.Amount             `json:"subtotal_amount"`
+	ServiceCharge  money.Amount             `json:"service_charge_amount"`
+	TaxAmount      money.Amount             `json:"tax_amount"`
+	InclusiveTax   money.Amount             `json:"inclusive_tax_amount"`
+	TotalAmount    money.Amount             `json:"total_amount"`
+	PaidAmount     money.Amount             `json:"paid_amount"`
+	BalanceDue     money.Amount             `json:"balance_due"`
+	RefundedAmount money.Amount             `json:"refunded_amount"`
+	CouponCode     string                   `json:"coupon_code,omitempty"`
+	ManualDiscount *OrderManualDiscount     `json:"manual_discount,omitempty"`
+	Promotions     []OrderPromotionResponse `json:"promotions,omitempty"`
+	Taxes          []OrderTaxResponse       `json:"taxes,omitempty"`
+	Status         string                   `json:"status"`
+	Notes          string                   `json:"notes"`
+	Version        int                      `json:"version"` // Send back when changing items
+	OrderItems     []OrderItemResponse      `json:"order_items"`
+	CreatedAt      string                   `json:"created_at"`
+	UpdatedAt      string                   `json:"updated_at"`
+	CreatedBy      *uint                    `json:"created_by,omitempty"`
+	CreatedByName  *string                  `json:"created_by_name,omitempty"`
+	UpdatedBy      *uint                    `json:"updated_by,omitempty"`
+	UpdatedByName  *string                  `json:"updated_by_name,omitempty"`
 }
 
 type OrderItemResponse struct {
-	ID          uint    `json:"id"`
-	ProductID   uint    `json:"product_id"`
-	ProductName string  `json:"product_name"`
-	ProductSKU  string  `json:"product_sku"`
-	Quantity    int     `json:"quantity"`
-	Price       float64 `json:"price"`
-	Subtotal    float64 `json:"subtotal"`
+	ID             uint         `json:"id"`
+	ProductID      uint         `json:"product_id"`
+	ProductName    string       `json:"product_name"`
+	ProductSKU     string       `json:"product_sku"`
+	Quantity       int          `json:"quantity"`
+	Price          money.Amount `json:"price"`
+	Subtotal       money.Amount `json:"subtotal"`
+	DiscountAmount money.Amount `json:"discount_amount"`
+	NetAmount      money.Amount `json:"net_amount"`
+	PromotionID    *uint        `json:"promotion_id,omitempty"`
+	KitchenStatus  string       `json:"kitchen_status"`
+}
+
+type AddOrderItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Quantity  int  `json:"quantity" binding:"required,min=1"`
+	Version   int  `json:"version" binding:"required"` // Order version the terminal last saw
+}
+
+type UpdateOrderItemRequest struct {
+	Quantity int                 `json:"quantity" binding:"required,min=1"`
+	Version  int                 `json:"version" binding:"required"` // Order version the terminal last saw
+	Approval *SupervisorApproval `json:"approval"`                   // Required when lowering the quantity
+}
+
+type RemoveOrderItemRequest struct {
+	Approval *SupervisorApproval `json:"approval"`
+}
+
+type UpdateOrderStatusRequest struct {
+	Status   string              `json:"status" binding:"required,oneof=pending confirmed preparing ready completed cancelled voided"`
+	Reason   string              `json:"reason"`
+	Approval *SupervisorApproval `json:"approval"` // Required when cancelling or voiding an order that has payments
+}
+
+type ApplyManualDiscountRequest struct {
+	Type     string              `json:"type" binding:"omitempty,oneof=amount percentage"` // Empty with value 0 removes the discount
+	Value    money.Amount        `json:"value" binding:"min=0"`
+	Reason   string              `json:"reason"`
+	Version  int                 `json:"version" binding:"required"`
+	Approval *SupervisorApproval `json:"approval"` // Required unless the discount is removed
+}
+
+type OrderManualDiscount struct {
+	Type       string       `json:"type"`
+	Value      money.Amount `json:"value"`
+	Amount     money.Amount `json:"amount"`
+	Reason     string       `json:"reason"`
+	ApprovedBy *uint        `json:"approved_by,omitempty"`
+}
+
+type OrderStatusHistoryResponse struct {
+	ID            uint    `json:"id"`
+	OrderID       uint    `json:"order_id"`
+	FromStatus    string  `json:"from_status"`
+	ToStatus      string  `json:"to_status"`
+	Reason        string  `json:"reason"`
+	ChangedBy     *uint   `json:"changed_by,omitempty"`
+	ChangedByName *string `json:"changed_by_name,omitempty"`
+	CreatedAt     string  `json:"created_at"`
 }
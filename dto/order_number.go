@@ -0,0 +1,41 @@
+package dto
+
+type SaveOrderNumberFormatRequest struct {
+	BranchID   *uint  `json:"branch_id"` // Empty = all branches
+	Prefix     string `json:"prefix" binding:"max=20"`
+	BranchCode string `json:"branch_code" binding:"max=20"` // Empty = zero-padded branch ID
+	DateFormat string `json:"date_format" binding:"max=20"` // Go layout, e.g. 20060102; empty = no date part; without a day in it the counter never resets
+	Padding    int    `json:"padding" binding:"min=1,max=10"`
+	Separator  string `json:"separator" binding:"max=5"`
+	UpdatedBy  *uint  `json:"-"` // Set internally, not from request
+}
+
+type OrderNumberFormatResponse struct {
+	ID         uint   `json:"id"`
+	TenantID   uint   `json:"tenant_id"`
+	BranchID   *uint  `json:"branch_id"`
+	Prefix     string `json:"prefix"`
+	BranchCode string `json:"branch_code"`
+	DateFormat string `json:"date_format"`
+	Padding    int    `json:"padding"`
+	Separator  string `json:"separator"`
+	Example    string `json:"example"`
+}
+
+type ReserveOrderNumbersRequest struct {
+	ClientID  string `json:"client_id" binding:"required"`
+	Count     int    `json:"count" binding:"required,min=1,max=1000"`
+	Date      string `json:"date"` // YYYY-MM-DD, empty = today in the branch's timezone
+	CreatedBy *uint  `json:"-"`    // Set internally, not from request
+}
+
+type OrderNumberReservationResponse struct {
+	ID           uint     `json:"id"`
+	BranchID     uint     `json:"branch_id"`
+	ClientID     string   `json:"client_id"`
+	SequenceDate string   `json:"sequence_date"` // Empty when the branch's counter never resets
+	StartNumber  int      `json:"start_number"`
+	EndNumber    int      `json:"end_number"`
+	OrderNumbers []string `json:"order_numbers"`
+	CreatedAt    string   `json:"created_at"`
+}
@@ -1,53 +1,110 @@
 package dto
 
+import "myposcore/money"
+
+// CreatePaymentRequest - amount is what the customer tenders, including any tip
 type CreatePaymentRequest struct {
-	OrderID       uint    `json:"order_id" binding:"required"`
-	Amount        float64 `json:"amount" binding:"required,gt=0"`
-	PaymentMethod string  `json:"payment_method" binding:"required,oneof=cash card transfer qris"`
-	Notes         string  `json:"notes"`
-	CreatedBy     *uint   `json:"-"` // Set internally, not from request
+	OrderID       uint         `json:"order_id" binding:"required"`
+	Amount        money.Amount `json:"amount" binding:"required,gt=0"`
+	PaymentMethod string       `json:"payment_method" binding:"required,oneof=cash card transfer qris ewallet loyalty_points gift_card"` // loyalty_points redeems the customer's points for amount
+	GiftCardCode  string       `json:"gift_card_code"`                                                                                   // Card to redeem when payment_method is gift_card
+	Notes         string       `json:"notes"`
+	CreatedBy     *uint        `json:"-"` // Set internally, not from request
+	PaymentTipRequest
 }
 
 type PaymentResponse struct {
-	ID            uint    `json:"id"`
-	OrderID       uint    `json:"order_id"`
-	Amount        float64 `json:"amount"`
-	PaymentMethod string  `json:"payment_method"`
-	Status        string  `json:"status"`
-	Notes         string  `json:"notes"`
-	CreatedAt     string  `json:"created_at"`
-	UpdatedAt     string  `json:"updated_at"`
-	CreatedBy     *uint   `json:"created_by,omitempty"`
-	CreatedByName *string `json:"created_by_name,omitempty"`
-	UpdatedBy     *uint   `json:"updated_by,omitempty"`
-	UpdatedByName *string `json:"updated_by_name,omitempty"`
+	ID             uint         `json:"id"`
+	OrderID        uint         `json:"order_id"`
+	Amount         money.Amount `json:"amount"`
+	TenderedAmount money.Amount `json:"tendered_amount"`
+	ChangeAmount   money.Amount `json:"change_amount"`
+	RoundingAmount money.Amount `json:"rounding_amount"` // Cash rounding written off
+	TipAmount      money.Amount `json:"tip_amount"`      // Gratuity, not part of amount
+	TipUserID      *uint        `json:"tip_user_id"`
+	RefundedAmount money.Amount `json:"refunded_amount"`
+	PaymentMethod  string       `json:"payment_method"`
+	ShiftID        *uint        `json:"shift_id"` // Cash shift the payment was taken in
+	Status         string       `json:"status"`
+	Notes          string       `json:"notes"`
+	CreatedAt      string       `json:"created_at"`
+	UpdatedAt      string       `json:"updated_at"`
+	CreatedBy      *uint        `json:"created_by,omitempty"`
+	CreatedByName  *string      `json:"created_by_name,omitempty"`
+	UpdatedBy      *uint        `json:"updated_by,omitempty"`
+	UpdatedByName  *string      `json:"updated_by_name,omitempty"`
+
+	Provider *PaymentProviderDetail `json:"provider,omitempty"`
+}
+
+// PaymentProviderDetail shows how a provider payment is collected; terminals display the action URL
+// or QR string while the payment is pending
+type PaymentProviderDetail struct {
+	Name          string  `json:"name"`
+	Reference     string  `json:"reference"`
+	ActionURL     string  `json:"action_url,omitempty"`
+	QRString      string  `json:"qr_string,omitempty"`
+	ExpiresAt     *string `json:"expires_at,omitempty"`
+	FailureReason string  `json:"failure_reason,omitempty"`
+}
+
+// SimulateMockPaymentRequest settles a pending payment of the built-in mock provider
+type SimulateMockPaymentRequest struct {
+	PaymentID     uint   `json:"payment_id" binding:"required"`
+	Status        string `json:"status" binding:"required,oneof=completed failed"`
+	FailureReason string `json:"failure_reason"`
 }
 
 type PaymentDetailResponse struct {
-	ID            uint               `json:"id"`
-	OrderID       uint               `json:"order_id"`
-	OrderNumber   string             `json:"order_number"`
-	Amount        float64            `json:"amount"`
-	PaymentMethod string             `json:"payment_method"`
-	Status        string             `json:"status"`
-	Notes         string             `json:"notes"`
-	Change        float64            `json:"change"`
-	CreatedAt     string             `json:"created_at"`
-	Order         PaymentOrderDetail `json:"order"`
+	ID             uint                   `json:"id"`
+	OrderID        uint                   `json:"order_id"`
+	OrderNumber    string                 `json:"order_number"`
+	Amount         money.Amount           `json:"amount"`
+	RefundedAmount money.Amount           `json:"refunded_amount"`
+	PaymentMethod  string                 `json:"payment_method"`
+	ShiftID        *uint                  `json:"shift_id"`
+	Status         string                 `json:"status"`
+	Notes          string                 `json:"notes"`
+	TenderedAmount money.Amount           `json:"tendered_amount"`
+	Change         money.Amount           `json:"change"`
+	RoundingAmount money.Amount           `json:"rounding_amount"`
+	TipAmount      money.Amount           `json:"tip_amount"`
+	TipUserID      *uint                  `json:"tip_user_id"`
+	PaidAmount     money.Amount           `json:"paid_amount"` // Sum of payments on the order so far
+	BalanceDue     money.Amount           `json:"balance_due"`
+	CreatedAt      string                 `json:"created_at"`
+	Provider       *PaymentProviderDetail `json:"provider,omitempty"`
+	Order          PaymentOrderDetail     `json:"order"`
+}
+
+// OrderPaymentsResponse - Payments of an order with the running balance
+type OrderPaymentsResponse struct {
+	OrderID     uint              `json:"order_id"`
+	OrderNumber string            `json:"order_number"`
+	Status      string            `json:"status"`
+	TotalAmount money.Amount      `json:"total_amount"`
+	PaidAmount  money.Amount      `json:"paid_amount"`
+	BalanceDue  money.Amount      `json:"balance_due"`
+	Payments    []PaymentResponse `json:"payments"`
 }
 
 type PaymentOrderDetail struct {
-	TotalAmount float64                  `json:"total_amount"`
-	Status      string                   `json:"status"`
-	Notes       string                   `json:"notes"`
-	OrderItems  []PaymentOrderItemDetail `json:"order_items"`
-	CashierName string                   `json:"cashier_name"`
-	BranchName  string                   `json:"branch_name"`
+	SubtotalAmount money.Amount             `json:"subtotal_amount"`
+	ServiceCharge  money.Amount             `json:"service_charge_amount"`
+	TaxAmount      money.Amount             `json:"tax_amount"`
+	InclusiveTax   money.Amount             `json:"inclusive_tax_amount"`
+	Taxes          []OrderTaxResponse       `json:"taxes,omitempty"`
+	TotalAmount    money.Amount             `json:"total_amount"`
+	Status         string                   `json:"status"`
+	Notes          string                   `json:"notes"`
+	OrderItems     []PaymentOrderItemDetail `json:"order_items"`
+	CashierName    string                   `json:"cashier_name"`
+	BranchName     string                   `json:"branch_name"`
 }
 
 type PaymentOrderItemDetail struct {
-	ProductName string  `json:"product_name"`
-	Quantity    int     `json:"quantity"`
-	Price       float64 `json:"price"`
-	Subtotal    float64 `json:"subtotal"`
+	ProductName string       `json:"product_name"`
+	Quantity    int          `json:"quantity"`
+	Price       money.Amount `json:"price"`
+	Subtotal    money.Amount `json:"subtotal"`
 }
@@ -1,25 +1,33 @@
 package dto
 
+import "myposcore/money"
+
 type CreateProductRequest struct {
-	Name        string  `json:"name" binding:"required"`
-	Description string  `json:"description"`
-	CategoryID  *uint   `json:"category_id"`
-	SKU         string  `json:"sku"`
-	Price       float64 `json:"price" binding:"required,min=0"`
-	Stock       int     `json:"stock" binding:"min=0"`
-	IsActive    bool    `json:"is_active"`
-	CreatedBy   *uint   `json:"-"` // Set internally, not from request
+	Name        string       `json:"name" binding:"required"`
+	Description string       `json:"description"`
+	CategoryID  *uint        `json:"category_id"`
+	SKU         string       `json:"sku"`
+	Price       money.Amount `json:"price" binding:"required,min=0"`
+	Stock       int          `json:"stock" binding:"min=0"`
+	IsActive    bool         `json:"is_active"`
+	CreatedBy   *uint        `json:"-"` // Set internally, not from request
+
+	IsGiftCard           bool `json:"is_gift_card"`                            // Selling it issues a gift card worth the price
+	GiftCardValidityDays int  `json:"gift_card_validity_days" binding:"min=0"` // 0 = cards never expire
 }
 
 type UpdateProductRequest struct {
-	Name        string  `json:"name"`
-	Description string  `json:"description"`
-	CategoryID  *uint   `json:"category_id"`
-	SKU         string  `json:"sku"`
-	Price       float64 `json:"price" binding:"omitempty,min=0"`
-	Stock       int     `json:"stock" binding:"omitempty,min=0"`
-	IsActive    *bool   `json:"is_active"`
-	UpdatedBy   *uint   `json:"-"` // Set internally, not from request
+	Name        string       `json:"name"`
+	Description string       `json:"description"`
+	CategoryID  *uint        `json:"category_id"`
+	SKU         string       `json:"sku"`
+	Price       money.Amount `json:"price" binding:"omitempty,min=0"`
+	Stock       *int         `json:"stock" binding:"omitempty,min=0"` // Recorded as a stock adjustment
+	IsActive    *bool        `json:"is_active"`
+	UpdatedBy   *uint        `json:"-"` // Set internally, not from request
+
+	IsGiftCard           *bool `json:"is_gift_card"`
+	GiftCardValidityDays *int  `json:"gift_card_validity_days" binding:"omitempty,min=0"`
 }
 
 type ProductResponse struct {
@@ -30,7 +38,8 @@ type ProductResponse struct {
 	CategoryID     *uint            `json:"category_id"`
 	CategoryDetail *CategorySummary `json:"category_detail,omitempty"`
 	SKU            string           `json:"sku"`
-	Price          float64          `json:"price"`
+	Price          money.Amount     `json:"price"`
+	CostPrice      money.Amount     `json:"cost_price"` // Weighted average purchase cost
 	Stock          int              `json:"stock"`
 	Image          string           `json:"image"`
 	IsActive       bool             `json:"is_active"`
@@ -40,6 +49,23 @@ type ProductResponse struct {
 	CreatedByName  *string          `json:"created_by_name,omitempty"`
 	UpdatedBy      *uint            `json:"updated_by,omitempty"`
 	UpdatedByName  *string          `json:"updated_by_name,omitempty"`
+
+	IsGiftCard           bool `json:"is_gift_card"`
+	GiftCardValidityDays int  `json:"gift_card_validity_days"`
+
+	// The product's reorder point, 0 = no low-stock alerts; branches can set their own
+	MinStock        int `json:"min_stock"`
+	ReorderQuantity int `json:"reorder_quantity"`
+
+	// Stock above is at the caller's branch; include_branches adds the total and the stock at every branch
+	TotalStock   *int                 `json:"total_stock,omitempty"`
+	BranchStocks []ProductBranchStock `json:"branch_stocks,omitempty"`
+}
+
+type ProductBranchStock struct {
+	BranchID   uint   `json:"branch_id"`
+	BranchName string `json:"branch_name"`
+	Stock      int    `json:"stock"`
 }
 
 type CategorySummary struct {
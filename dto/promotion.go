@@ -0,0 +1,87 @@
+package dto
+
+import (
+	"myposcore/money"
+	"time"
+)
+
+type CreatePromotionRequest struct {
+	Name          string       `json:"name" binding:"required"`
+	Description   string       `json:"description"`
+	BranchID      *uint        `json:"branch_id"`
+	Type          string       `json:"type" binding:"required,oneof=percentage fixed buy_x_get_y"`
+	DiscountValue money.Amount `json:"discount_value" binding:"min=0"`
+	MaxDiscount   money.Amount `json:"max_discount" binding:"min=0"`
+	CategoryID    *uint        `json:"category_id"`
+	ProductID     *uint        `json:"product_id"`
+	BuyQuantity   int          `json:"buy_quantity" binding:"min=0"`
+	GetQuantity   int          `json:"get_quantity" binding:"min=0"`
+	MinSpend      money.Amount `json:"min_spend" binding:"min=0"`
+	StartDate     *time.Time   `json:"start_date"`
+	EndDate       *time.Time   `json:"end_date"`
+	StartTime     string       `json:"start_time"` // HH:MM
+	EndTime       string       `json:"end_time"`   // HH:MM
+	CouponCode    string       `json:"coupon_code"`
+	UsageLimit    int          `json:"usage_limit" binding:"min=0"`
+	IsActive      *bool        `json:"is_active"`
+	CreatedBy     *uint        `json:"-"` // Set internally, not from request
+}
+
+type UpdatePromotionRequest struct {
+	Name          *string       `json:"name"`
+	Description   *string       `json:"description"`
+	BranchID      *uint         `json:"branch_id"`
+	Type          *string       `json:"type" binding:"omitempty,oneof=percentage fixed buy_x_get_y"`
+	DiscountValue *money.Amount `json:"discount_value" binding:"omitempty,min=0"`
+	MaxDiscount   *money.Amount `json:"max_discount" binding:"omitempty,min=0"`
+	CategoryID    *uint         `json:"category_id"`
+	ProductID     *uint         `json:"product_id"`
+	BuyQuantity   *int          `json:"buy_quantity" binding:"omitempty,min=0"`
+	GetQuantity   *int          `json:"get_quantity" binding:"omitempty,min=0"`
+	MinSpend      *money.Amount `json:"min_spend" binding:"omitempty,min=0"`
+	StartDate     *time.Time    `json:"start_date"`
+	EndDate       *time.Time    `json:"end_date"`
+	StartTime     *string       `json:"start_time"`
+	EndTime       *string       `json:"end_time"`
+	CouponCode    *string       `json:"coupon_code"`
+	UsageLimit    *int          `json:"usage_limit" binding:"omitempty,min=0"`
+	IsActive      *bool         `json:"is_active"`
+	UpdatedBy     *uint         `json:"-"` // Set internally, not from request
+}
+
+type PromotionResponse struct {
+	ID            uint         `json:"id"`
+	TenantID      uint         `json:"tenant_id"`
+	BranchID      *uint        `json:"branch_id"`
+	Name          string       `json:"name"`
+	Description   string       `json:"description"`
+	Type          string       `json:"type"`
+	DiscountValue money.Amount `json:"discount_value"`
+	MaxDiscount   money.Amount `json:"max_discount"`
+	CategoryID    *uint        `json:"category_id"`
+	ProductID     *uint        `json:"product_id"`
+	BuyQuantity   int          `json:"buy_quantity"`
+	GetQuantity   int          `json:"get_quantity"`
+	MinSpend      money.Amount `json:"min_spend"`
+	StartDate     *string      `json:"start_date"`
+	EndDate       *string      `json:"end_date"`
+	StartTime     string       `json:"start_time"`
+	EndTime       string       `json:"end_time"`
+	CouponCode    string       `json:"coupon_code"`
+	UsageLimit    int          `json:"usage_limit"`
+	UsageCount    int          `json:"usage_count"`
+	IsActive      bool         `json:"is_active"`
+	CreatedAt     string       `json:"created_at"`
+	UpdatedAt     string       `json:"updated_at"`
+	CreatedBy     *uint        `json:"created_by,omitempty"`
+	CreatedByName *string      `json:"created_by_name,omitempty"`
+	UpdatedBy     *uint        `json:"updated_by,omitempty"`
+	UpdatedByName *string      `json:"updated_by_name,omitempty"`
+}
+
+type OrderPromotionResponse struct {
+	PromotionID    uint         `json:"promotion_id"`
+	PromotionName  string       `json:"promotion_name"`
+	CouponCode     string       `json:"coupon_code,omitempty"`
+	DiscountAmount money.Amount `json:"discount_amount"`
+}
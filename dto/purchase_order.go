@@ -0,0 +1,87 @@
+package dto
+
+import "myposcore/money"
+
+type PurchaseOrderItemRequest struct {
+	ProductID uint         `json:"product_id" binding:"required"`
+	Quantity  int          `json:"quantity" binding:"required,gt=0"`
+	UnitCost  money.Amount `json:"unit_cost" binding:"gte=0"`
+}
+
+// CreatePurchaseOrderRequest drafts a purchase order; the receiving branch defaults to the current branch
+type CreatePurchaseOrderRequest struct {
+	SupplierID uint                       `json:"supplier_id" binding:"required"`
+	BranchID   *uint                      `json:"branch_id"`
+	ExpectedAt string                     `json:"expected_at" binding:"omitempty,datetime=2006-01-02"` // YYYY-MM-DD
+	Notes      string                     `json:"notes"`
+	Items      []PurchaseOrderItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// GoodsReceiptItemRequest is a delivered quantity of a purchase order item. unit_cost overrides the
+// ordered cost when the supplier invoiced a different one.
+type GoodsReceiptItemRequest struct {
+	ItemID   uint          `json:"item_id" binding:"required"`
+	Quantity int           `json:"quantity" binding:"required,gt=0"`
+	UnitCost *money.Amount `json:"unit_cost" binding:"omitempty,gte=0"`
+}
+
+type CreateGoodsReceiptRequest struct {
+	Items []GoodsReceiptItemRequest `json:"items" binding:"required,min=1,dive"`
+	Notes string                    `json:"notes"`
+}
+
+// PurchaseOrderNotesRequest carries the notes of closing or cancelling a purchase order
+type PurchaseOrderNotesRequest struct {
+	Notes string `json:"notes"`
+}
+
+type PurchaseOrderItemResponse struct {
+	ID               uint         `json:"id"`
+	ProductID        uint         `json:"product_id"`
+	ProductName      string       `json:"product_name"`
+	SKU              string       `json:"sku"`
+	Quantity         int          `json:"quantity"`
+	UnitCost         money.Amount `json:"unit_cost"`
+	Subtotal         money.Amount `json:"subtotal"`
+	ReceivedQuantity int          `json:"received_quantity"`
+}
+
+type GoodsReceiptItemResponse struct {
+	ID                  uint         `json:"id"`
+	PurchaseOrderItemID uint         `json:"purchase_order_item_id"`
+	ProductID           uint         `json:"product_id"`
+	Quantity            int          `json:"quantity"`
+	UnitCost            money.Amount `json:"unit_cost"`
+}
+
+type GoodsReceiptResponse struct {
+	ID             uint                       `json:"id"`
+	BranchID       uint                       `json:"branch_id"`
+	Notes          string                     `json:"notes"`
+	ReceivedAt     string                     `json:"received_at"`
+	ReceivedBy     *uint                      `json:"received_by,omitempty"`
+	ReceivedByName *string                    `json:"received_by_name,omitempty"`
+	Items          []GoodsReceiptItemResponse `json:"items"`
+}
+
+type PurchaseOrderResponse struct {
+	ID            uint                        `json:"id"`
+	BranchID      uint                        `json:"branch_id"`
+	BranchName    string                      `json:"branch_name"`
+	SupplierID    uint                        `json:"supplier_id"`
+	SupplierName  string                      `json:"supplier_name"`
+	Status        string                      `json:"status"` // draft, ordered, partially_received, received, closed, cancelled
+	Notes         string                      `json:"notes"`
+	ExpectedAt    *string                     `json:"expected_at"`
+	TotalAmount   money.Amount                `json:"total_amount"`
+	OrderedAt     *string                     `json:"ordered_at"`
+	OrderedBy     *uint                       `json:"ordered_by"`
+	ClosedAt      *string                     `json:"closed_at"`
+	ClosedBy      *uint                       `json:"closed_by"`
+	ClosedNotes   string                      `json:"closed_notes"`
+	CreatedAt     string                      `json:"created_at"`
+	CreatedBy     *uint                       `json:"created_by,omitempty"`
+	CreatedByName *string                     `json:"created_by_name,omitempty"`
+	Items         []PurchaseOrderItemResponse `json:"items"`
+	Receipts      []GoodsReceiptResponse      `json:"receipts,omitempty"`
+}
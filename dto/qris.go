@@ -0,0 +1,71 @@
+package dto
+
+import "myposcore/money"
+
+type SaveQRISMerchantRequest struct {
+	BranchID         *uint  `json:"branch_id"` // Empty = all branches
+	MerchantName     string `json:"merchant_name" binding:"required,max=25"`
+	MerchantCity     string `json:"merchant_city" binding:"required,max=15"`
+	PostalCode       string `json:"postal_code" binding:"max=10"`
+	CategoryCode     string `json:"category_code" binding:"required,len=4,numeric"`
+	AcquirerDomain   string `json:"acquirer_domain" binding:"required,max=32"`
+	MerchantPAN      string `json:"merchant_pan" binding:"required,max=19"`
+	MerchantID       string `json:"merchant_id" binding:"required,max=15"`
+	NMID             string `json:"nmid" binding:"required,max=15"`
+	MerchantCriteria string `json:"merchant_criteria" binding:"required,oneof=UMI UKE UME UBE URE"`
+	TerminalLabel    string `json:"terminal_label" binding:"max=25"`
+	UpdatedBy        *uint  `json:"-"` // Set internally, not from request
+}
+
+type QRISMerchantResponse struct {
+	ID               uint   `json:"id"`
+	TenantID         uint   `json:"tenant_id"`
+	BranchID         *uint  `json:"branch_id"`
+	MerchantName     string `json:"merchant_name"`
+	MerchantCity     string `json:"merchant_city"`
+	PostalCode       string `json:"postal_code"`
+	CategoryCode     string `json:"category_code"`
+	AcquirerDomain   string `json:"acquirer_domain"`
+	MerchantPAN      string `json:"merchant_pan"`
+	MerchantID       string `json:"merchant_id"`
+	NMID             string `json:"nmid"`
+	MerchantCriteria string `json:"merchant_criteria"`
+	TerminalLabel    string `json:"terminal_label"`
+}
+
+type QRISResponse struct {
+	Type        string        `json:"type"` // static, dynamic
+	Payload     string        `json:"payload"`
+	Image       string        `json:"image"` // PNG as a data URI
+	Amount      *money.Amount `json:"amount,omitempty"`
+	OrderID     *uint         `json:"order_id,omitempty"`
+	OrderNumber string        `json:"order_number,omitempty"`
+	BranchID    uint          `json:"branch_id"`
+}
+
+type ParseQRISRequest struct {
+	Payload string `json:"payload" binding:"required,max=512"`
+}
+
+type QRISFieldResponse struct {
+	ID       string              `json:"id"`
+	Value    string              `json:"value"`
+	Children []QRISFieldResponse `json:"children,omitempty"`
+}
+
+type ParseQRISResponse struct {
+	Type         string              `json:"type"`
+	MerchantName string              `json:"merchant_name"`
+	MerchantCity string              `json:"merchant_city"`
+	PostalCode   string              `json:"postal_code"`
+	CategoryCode string              `json:"category_code"`
+	Currency     string              `json:"currency"`
+	CountryCode  string              `json:"country_code"`
+	Amount       *money.Amount       `json:"amount"`
+	NMID         string              `json:"nmid"`
+	BillNumber   string              `json:"bill_number"`
+	Reference    string              `json:"reference"`
+	Terminal     string              `json:"terminal"`
+	CRC          string              `json:"crc"`
+	Fields       []QRISFieldResponse `json:"fields"`
+}
@@ -0,0 +1,24 @@
+package dto
+
+type SaveReceiptTemplateRequest struct {
+	BranchID     *uint  `json:"branch_id"` // Empty = all branches
+	HeaderText   string `json:"header_text" binding:"max=500"`
+	FooterText   string `json:"footer_text" binding:"max=500"`
+	ShowLogo     bool   `json:"show_logo"`
+	ShowTaxLines bool   `json:"show_tax_lines"`
+	ShowCashier  bool   `json:"show_cashier"`
+	PaperWidth   int    `json:"paper_width" binding:"required,oneof=58 80"`
+	UpdatedBy    *uint  `json:"-"` // Set internally, not from request
+}
+
+type ReceiptTemplateResponse struct {
+	ID           uint   `json:"id"`
+	TenantID     uint   `json:"tenant_id"`
+	BranchID     *uint  `json:"branch_id"`
+	HeaderText   string `json:"header_text"`
+	FooterText   string `json:"footer_text"`
+	ShowLogo     bool   `json:"show_logo"`
+	ShowTaxLines bool   `json:"show_tax_lines"`
+	ShowCashier  bool   `json:"show_cashier"`
+	PaperWidth   int    `json:"paper_width"`
+}
@@ -0,0 +1,48 @@
+package dto
+
+import "myposcore/money"
+
+type CreateRefundRequest struct {
+	Amount       money.Amount        `json:"amount" binding:"min=0"`                                                                 // Empty = items share, or the full remaining amount
+	RefundMethod string              `json:"refund_method" binding:"omitempty,oneof=cash card transfer qris gift_card store_credit"` // Empty = original payment method; store_credit issues a store credit card
+	Reason       string              `json:"reason" binding:"required"`
+	Restock      bool                `json:"restock"`
+	Approval     *SupervisorApproval `json:"approval" binding:"required"`
+	Items        []RefundItemRequest `json:"items" binding:"dive"`
+	CreatedBy    *uint               `json:"-"` // Set internally, not from request
+}
+
+type RefundItemRequest struct {
+	OrderItemID uint `json:"order_item_id" binding:"required"`
+	Quantity    int  `json:"quantity" binding:"required,min=1"`
+}
+
+type RefundItemResponse struct {
+	ID          uint         `json:"id"`
+	OrderItemID uint         `json:"order_item_id"`
+	ProductID   uint         `json:"product_id"`
+	ProductName string       `json:"product_name"`
+	Quantity    int          `json:"quantity"`
+	Amount      money.Amount `json:"amount"`
+	Restocked   bool         `json:"restocked"`
+}
+
+type RefundResponse struct {
+	ID             uint                 `json:"id"`
+	OrderID        uint                 `json:"order_id"`
+	PaymentID      uint                 `json:"payment_id"`
+	Amount         money.Amount         `json:"amount"`
+	RefundMethod   string               `json:"refund_method"`
+	Reason         string               `json:"reason"`
+	Restock        bool                 `json:"restock"`
+	Status         string               `json:"status"`                   // pending while the payment provider is refunding, completed or failed
+	FailureReason  string               `json:"failure_reason,omitempty"` // Why the provider refused the refund
+	Items          []RefundItemResponse `json:"items"`
+	GiftCardID     *uint                `json:"gift_card_id,omitempty"`   // Card credited by a gift_card or store_credit refund
+	GiftCardCode   *string              `json:"gift_card_code,omitempty"` // Code to hand the customer for store credit
+	ApprovedBy     *uint                `json:"approved_by,omitempty"`
+	ApprovedByName *string              `json:"approved_by_name,omitempty"`
+	CreatedAt      string               `json:"created_at"`
+	CreatedBy      *uint                `json:"created_by,omitempty"`
+	CreatedByName  *string              `json:"created_by_name,omitempty"`
+}
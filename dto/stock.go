@@ -0,0 +1,36 @@
+package dto
+
+// StockAdjustmentRequest corrects stock by quantity, or sets it to counted_quantity for a stocktake
+type StockAdjustmentRequest struct {
+	Quantity        int    `json:"quantity"`                                   // Units added, negative to remove
+	CountedQuantity *int   `json:"counted_quantity" binding:"omitempty,min=0"` // Units counted on the shelf
+	Reason          string `json:"reason" binding:"required"`
+}
+
+type StockMovementResponse struct {
+	ID            uint    `json:"id"`
+	BranchID      uint    `json:"branch_id"`
+	ProductID     uint    `json:"product_id"`
+	Type          string  `json:"type"` // sale, refund, adjustment, receipt, transfer, stocktake, sync
+	Quantity      int     `json:"quantity"`
+	BalanceAfter  int     `json:"balance_after"`
+	Reason        string  `json:"reason"`
+	ReferenceType string  `json:"reference_type,omitempty"`
+	ReferenceID   *uint   `json:"reference_id,omitempty"`
+	CreatedAt     string  `json:"created_at"`
+	CreatedBy     *uint   `json:"created_by,omitempty"`
+	CreatedByName *string `json:"created_by_name,omitempty"`
+}
+
+// StockMovementHistoryResponse is a product's stock with one page of its movements, newest first
+type StockMovementHistoryResponse struct {
+	ProductID   uint                    `json:"product_id"`
+	ProductName string                  `json:"product_name"`
+	BranchID    *uint                   `json:"branch_id,omitempty"`
+	Stock       int                     `json:"stock"` // At the branch, or over all branches without one
+	Page        int                     `json:"page"`
+	PageSize    int                     `json:"page_size"`
+	TotalItems  int64                   `json:"total_items"`
+	TotalPages  int                     `json:"total_pages"`
+	Movements   []StockMovementResponse `json:"movements"`
+}
@@ -0,0 +1,77 @@
+package dto
+
+type StockTransferItemRequest struct {
+	ProductID uint `json:"product_id" binding:"required"`
+	Quantity  int  `json:"quantity" binding:"required,gt=0"`
+}
+
+// CreateStockTransferRequest drafts a transfer; the source defaults to the current branch
+type CreateStockTransferRequest struct {
+	FromBranchID *uint                      `json:"from_branch_id"`
+	ToBranchID   uint                       `json:"to_branch_id" binding:"required"`
+	Notes        string                     `json:"notes"`
+	Items        []StockTransferItemRequest `json:"items" binding:"required,min=1,dive"`
+}
+
+// ReceiveStockTransferItem is the quantity of a transfer item counted at the destination
+type ReceiveStockTransferItem struct {
+	ItemID           uint   `json:"item_id" binding:"required"`
+	ReceivedQuantity *int   `json:"received_quantity" binding:"required,min=0"`
+	Note             string `json:"note"` // Why the count differs from the quantity sent
+}
+
+// ReceiveStockTransferRequest needs a count for every item on the transfer
+type ReceiveStockTransferRequest struct {
+	Items []ReceiveStockTransferItem `json:"items" binding:"required,min=1,dive"`
+	Notes string                     `json:"notes"`
+}
+
+// StockTransferNotesRequest carries the notes of closing or cancelling a transfer
+type StockTransferNotesRequest struct {
+	Notes string `json:"notes"`
+}
+
+type StockTransferItemResponse struct {
+	ID               uint   `json:"id"`
+	ProductID        uint   `json:"product_id"`
+	ProductName      string `json:"product_name"`
+	SKU              string `json:"sku"`
+	Quantity         int    `json:"quantity"`
+	ReceivedQuantity *int   `json:"received_quantity"`
+	Discrepancy      int    `json:"discrepancy"` // Received - sent; negative = short
+	DiscrepancyNote  string `json:"discrepancy_note,omitempty"`
+}
+
+type StockTransferResponse struct {
+	ID             uint                        `json:"id"`
+	FromBranchID   uint                        `json:"from_branch_id"`
+	FromBranchName string                      `json:"from_branch_name"`
+	ToBranchID     uint                        `json:"to_branch_id"`
+	ToBranchName   string                      `json:"to_branch_name"`
+	Status         string                      `json:"status"` // draft, sent, received, closed, cancelled
+	Notes          string                      `json:"notes"`
+	SentAt         *string                     `json:"sent_at"`
+	SentBy         *uint                       `json:"sent_by"`
+	ReceivedAt     *string                     `json:"received_at"`
+	ReceivedBy     *uint                       `json:"received_by"`
+	ReceivedNotes  string                      `json:"received_notes"`
+	ClosedAt       *string                     `json:"closed_at"`
+	ClosedBy       *uint                       `json:"closed_by"`
+	ClosedNotes    string                      `json:"closed_notes"`
+	CreatedAt      string                      `json:"created_at"`
+	CreatedBy      *uint                       `json:"created_by,omitempty"`
+	CreatedByName  *string                     `json:"created_by_name,omitempty"`
+	Items          []StockTransferItemResponse `json:"items"`
+}
+
+// InTransitStockResponse is the stock of a product sent from one branch to another and not yet received
+type InTransitStockResponse struct {
+	ProductID      uint   `json:"product_id"`
+	ProductName    string `json:"product_name"`
+	FromBranchID   uint   `json:"from_branch_id"`
+	FromBranchName string `json:"from_branch_name"`
+	ToBranchID     uint   `json:"to_branch_id"`
+	ToBranchName   string `json:"to_branch_name"`
+	Quantity       int    `json:"quantity"`
+	Transfers      int    `json:"transfers"` // Open transfers carrying the product
+}
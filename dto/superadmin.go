@@ -1,5 +1,7 @@
 package dto
 
+import "myposcore/money"
+
 type CreateTenantRequest struct {
 	Name        string `json:"name" binding:"required"`
 	Description string `json:"description"`
@@ -28,6 +30,7 @@ type CreateBranchRequest struct {
 	Website     string `json:"website"`
 	Email       string `json:"email"`
 	Phone       string `json:"phone"`
+	Timezone    string `json:"timezone"`
 	Active      bool   `json:"is_active"`
 }
 
@@ -38,6 +41,7 @@ type UpdateBranchRequest struct {
 	Website     string `json:"website"`
 	Email       string `json:"email"`
 	Phone       string `json:"phone"`
+	Timezone    string `json:"timezone"`
 	Active      bool   `json:"is_active"`
 }
 
@@ -75,6 +79,7 @@ type BranchResponse struct {
 	Email         string  `json:"email"`
 	Phone         string  `json:"phone"`
 	Image         string  `json:"image"`
+	Timezone      string  `json:"timezone"`
 	IsActive      bool    `json:"is_active"`
 	CreatedAt     string  `json:"created_at"`
 	UpdatedAt     string  `json:"updated_at"`
@@ -105,11 +110,13 @@ type UserResponse struct {
 }
 
 type DashboardResponse struct {
-	TotalTenants  int64            `json:"total_tenants"`
-	TotalBranches int64            `json:"total_branches"`
-	TotalUsers    int64            `json:"total_users"`
-	TotalProducts int64            `json:"total_products"`
-	Transactions  TransactionStats `json:"transactions"`
+	TotalTenants    int64            `json:"total_tenants"`
+	TotalBranches   int64            `json:"total_branches"`
+	TotalUsers      int64            `json:"total_users"`
+	TotalProducts   int64            `json:"total_products"`
+	Transactions    TransactionStats `json:"transactions"`     // Gross payments
+	Refunds         TransactionStats `json:"refunds"`          // Refunded amounts
+	NetTransactions TransactionStats `json:"net_transactions"` // Payments minus refunds
 }
 
 type OrderStats struct {
@@ -123,23 +130,42 @@ type OrderStats struct {
 }
 
 type PaymentStats struct {
-	AllTime     float64 `json:"all_time"`
-	Today       float64 `json:"today"`
-	Last7Days   float64 `json:"last_7_days"`
-	Last30Days  float64 `json:"last_30_days"`
-	Last90Days  float64 `json:"last_90_days"`
-	Last180Days float64 `json:"last_180_days"`
-	Last360Days float64 `json:"last_360_days"`
+	AllTime     money.Amount `json:"all_time"`
+	Today       money.Amount `json:"today"`
+	Last7Days   money.Amount `json:"last_7_days"`
+	Last30Days  money.Amount `json:"last_30_days"`
+	Last90Days  money.Amount `json:"last_90_days"`
+	Last180Days money.Amount `json:"last_180_days"`
+	Last360Days money.Amount `json:"last_360_days"`
 }
 
 type TransactionStats struct {
-	AllTime     float64 `json:"all_time"`
-	Today       float64 `json:"today"`
-	ThisWeek    float64 `json:"this_week"`
-	ThisMonth   float64 `json:"this_month"`
-	Last7Days   float64 `json:"last_7_days"`
-	Last30Days  float64 `json:"last_30_days"`
-	Last90Days  float64 `json:"last_90_days"`
-	Last180Days float64 `json:"last_180_days"`
-	Last360Days float64 `json:"last_360_days"`
+	AllTime     money.Amount `json:"all_time"`
+	Today       money.Amount `json:"today"`
+	ThisWeek    money.Amount `json:"this_week"`
+	ThisMonth   money.Amount `json:"this_month"`
+	Last7Days   money.Amount `json:"last_7_days"`
+	Last30Days  money.Amount `json:"last_30_days"`
+	Last90Days  money.Amount `json:"last_90_days"`
+	Last180Days money.Amount `json:"last_180_days"`
+	Last360Days money.Amount `json:"last_360_days"`
+}
+
+// SaveCurrencySettingsRequest sets the currency a tenant's amounts are rounded in and how cash
+// totals are rounded to the smallest denomination in circulation
+type SaveCurrencySettingsRequest struct {
+	CurrencyCode          string       `json:"currency_code" binding:"required,len=3,alpha"`
+	CurrencyPrecision     *int         `json:"currency_precision" binding:"required,min=0,max=2"`
+	CashRoundingIncrement money.Amount `json:"cash_rounding_increment" binding:"min=0"`                                // e.g. 100 or 500 IDR; 0 = none
+	CashRoundingMode      string       `json:"cash_rounding_mode" binding:"omitempty,oneof=half_up half_even down up"` // Default half_up
+}
+
+type CurrencySettingsResponse struct {
+	TenantID              uint         `json:"tenant_id"`
+	CurrencyCode          string       `json:"currency_code"`
+	CurrencyPrecision     int          `json:"currency_precision"`
+	CashRoundingIncrement money.Amount `json:"cash_rounding_increment"`
+	CashRoundingMode      string       `json:"cash_rounding_mode"`
+	UpdatedAt             string       `json:"updated_at"`
+	UpdatedBy             *uint        `json:"updated_by,omitempty"`
 }
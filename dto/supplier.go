@@ -0,0 +1,40 @@
+package dto
+
+type CreateSupplierRequest struct {
+	Name        string `json:"name" binding:"required"`
+	ContactName string `json:"contact_name"`
+	Phone       string `json:"phone"`
+	Email       string `json:"email" binding:"omitempty,email"`
+	Address     string `json:"address"`
+	Notes       string `json:"notes"`
+	CreatedBy   *uint  `json:"-"` // Set internally, not from request
+}
+
+type UpdateSupplierRequest struct {
+	Name        *string `json:"name" binding:"omitempty,min=1"`
+	ContactName *string `json:"contact_name"`
+	Phone       *string `json:"phone"`
+	Email       *string `json:"email" binding:"omitempty,email"`
+	Address     *string `json:"address"`
+	Notes       *string `json:"notes"`
+	IsActive    *bool   `json:"is_active"`
+	UpdatedBy   *uint   `json:"-"` // Set internally, not from request
+}
+
+type SupplierResponse struct {
+	ID            uint    `json:"id"`
+	TenantID      uint    `json:"tenant_id"`
+	Name          string  `json:"name"`
+	ContactName   string  `json:"contact_name"`
+	Phone         string  `json:"phone"`
+	Email         string  `json:"email"`
+	Address       string  `json:"address"`
+	Notes         string  `json:"notes"`
+	IsActive      bool    `json:"is_active"`
+	CreatedAt     string  `json:"created_at"`
+	UpdatedAt     string  `json:"updated_at"`
+	CreatedBy     *uint   `json:"created_by,omitempty"`
+	CreatedByName *string `json:"created_by_name,omitempty"`
+	UpdatedBy     *uint   `json:"updated_by,omitempty"`
+	UpdatedByName *string `json:"updated_by_name,omitempty"`
+}
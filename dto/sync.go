@@ -1,6 +1,9 @@
 package dto
 
-import "time"
+import (
+	"myposcore/money"
+	"time"
+)
 
 // ============================================
 // Sync Request/Response DTOs
@@ -30,34 +33,47 @@ type SyncDownloadRequest struct {
 
 // SyncOrderData - Data order dari client
 type SyncOrderData struct {
-	LocalID        string              `json:"local_id" binding:"required"` // UUID dari client
-	OrderNumber    string              `json:"order_number,omitempty"`
-	TotalAmount    float64             `json:"total_amount" binding:"required"`
-	Status         string              `json:"status"`
-	Notes          string              `json:"notes"`
-	Items          []SyncOrderItemData `json:"items" binding:"required,min=1"`
-	LocalTimestamp time.Time           `json:"local_timestamp" binding:"required"`
-	Version        int                 `json:"version"`
+	LocalID             string              `json:"local_id" binding:"required"`     // UUID dari client
+	OrderNumber         string              `json:"order_number,omitempty"`          // From a block this client reserved, empty = numbered on the server
+	TotalAmount         money.Amount        `json:"total_amount" binding:"required"` // Grand total incl. service charge & tax
+	DiscountAmount      money.Amount        `json:"discount_amount"`                 // Discount already applied by the client
+	ServiceChargeAmount money.Amount        `json:"service_charge_amount"`           // Computed by the client
+	TaxAmount           money.Amount        `json:"tax_amount"`                      // Exclusive tax computed by the client
+	InclusiveTaxAmount  money.Amount        `json:"inclusive_tax_amount"`            // Tax already included in prices
+	CouponCode          string              `json:"coupon_code,omitempty"`
+	CustomerID          *uint               `json:"customer_id,omitempty"`  // Customer from the directory, if any
+	MemberPhone         string              `json:"member_phone,omitempty"` // Or the loyalty member's phone
+	Status              string              `json:"status"`
+	Notes               string              `json:"notes"`
+	Items               []SyncOrderItemData `json:"items" binding:"required,min=1"`
+	LocalTimestamp      time.Time           `json:"local_timestamp" binding:"required"`
+	Version             int                 `json:"version"`
+	Approval            *SupervisorApproval `json:"approval,omitempty"` // Required to cancel or void an order that took money
 }
 
 // SyncOrderItemData - Data order item dari client
 type SyncOrderItemData struct {
-	ProductID uint    `json:"product_id" binding:"required"`
-	Quantity  int     `json:"quantity" binding:"required,min=1"`
-	Price     float64 `json:"price" binding:"required"`
-	Subtotal  float64 `json:"subtotal" binding:"required"`
+	ProductID uint         `json:"product_id" binding:"required"`
+	Quantity  int          `json:"quantity" binding:"required,min=1"`
+	Price     money.Amount `json:"price" binding:"required"`
+	Subtotal  money.Amount `json:"subtotal" binding:"required"`
+	Discount  money.Amount `json:"discount_amount"`
 }
 
 // SyncPaymentData - Data payment dari client
 type SyncPaymentData struct {
-	LocalID        string    `json:"local_id" binding:"required"`       // UUID dari client
-	OrderLocalID   string    `json:"order_local_id" binding:"required"` // Reference ke order local_id
-	Amount         float64   `json:"amount" binding:"required"`
-	PaymentMethod  string    `json:"payment_method" binding:"required"`
-	Status         string    `json:"status"`
-	Notes          string    `json:"notes"`
-	LocalTimestamp time.Time `json:"local_timestamp" binding:"required"`
-	Version        int       `json:"version"`
+	LocalID        string       `json:"local_id" binding:"required"`       // UUID dari client
+	OrderLocalID   string       `json:"order_local_id" binding:"required"` // Reference ke order local_id
+	Amount         money.Amount `json:"amount" binding:"required"`         // Applied to the order, excluding change
+	TenderedAmount money.Amount `json:"tendered_amount"`
+	ChangeAmount   money.Amount `json:"change_amount"`
+	RoundingAmount money.Amount `json:"rounding_amount"` // Cash rounding the client wrote off
+	TipAmount      money.Amount `json:"tip_amount"`      // Gratuity on top of amount, for the cashier
+	PaymentMethod  string       `json:"payment_method" binding:"required"`
+	Status         string       `json:"status"`
+	Notes          string       `json:"notes"`
+	LocalTimestamp time.Time    `json:"local_timestamp" binding:"required"`
+	Version        int          `json:"version"`
 }
 
 // SyncTenantData - Data tenant dari client
@@ -109,18 +125,18 @@ type SyncUserData struct {
 
 // SyncProductData - Data product dari client
 type SyncProductData struct {
-	LocalID        string    `json:"local_id" binding:"required"`
-	TenantID       uint      `json:"tenant_id" binding:"required"`
-	CategoryID     uint      `json:"category_id" binding:"required"`
-	Name           string    `json:"name" binding:"required"`
-	Description    string    `json:"description"`
-	SKU            string    `json:"sku"`
-	Price          float64   `json:"price" binding:"required"`
-	Stock          int       `json:"stock"`
-	Image          string    `json:"image"`
-	IsActive       bool      `json:"is_active"`
-	LocalTimestamp time.Time `json:"local_timestamp" binding:"required"`
-	Version        int       `json:"version"`
+	LocalID        string       `json:"local_id" binding:"required"`
+	TenantID       uint         `json:"tenant_id" binding:"required"`
+	CategoryID     uint         `json:"category_id" binding:"required"`
+	Name           string       `json:"name" binding:"required"`
+	Description    string       `json:"description"`
+	SKU            string       `json:"sku"`
+	Price          money.Amount `json:"price" binding:"required"`
+	Stock          int          `json:"stock"` // Ignored, stock moves through synced orders and stock adjustments
+	Image          string       `json:"image"`
+	IsActive       bool         `json:"is_active"`
+	LocalTimestamp time.Time    `json:"local_timestamp" binding:"required"`
+	Version        int          `json:"version"`
 }
 
 // SyncCategoryData - Data category dari client
@@ -0,0 +1,63 @@
+package dto
+
+import "myposcore/money"
+
+type CreateTaxRuleRequest struct {
+	Name              string  `json:"name" binding:"required"`
+	BranchID          *uint   `json:"branch_id"` // Empty = all branches
+	Type              string  `json:"type" binding:"required,oneof=tax service_charge"`
+	Rate              float64 `json:"rate" binding:"min=0,max=100"`
+	IsInclusive       bool    `json:"is_inclusive"`
+	Sequence          int     `json:"sequence"`
+	IsCompound        bool    `json:"is_compound"`
+	ExemptCategoryIDs []uint  `json:"exempt_category_ids"`
+	IsActive          *bool   `json:"is_active"`
+	CreatedBy         *uint   `json:"-"` // Set internally, not from request
+}
+
+type UpdateTaxRuleRequest struct {
+	Name              *string  `json:"name"`
+	Type              *string  `json:"type" binding:"omitempty,oneof=tax service_charge"`
+	Rate              *float64 `json:"rate" binding:"omitempty,min=0,max=100"`
+	IsInclusive       *bool    `json:"is_inclusive"`
+	Sequence          *int     `json:"sequence"`
+	IsCompound        *bool    `json:"is_compound"`
+	ExemptCategoryIDs *[]uint  `json:"exempt_category_ids"`
+	IsActive          *bool    `json:"is_active"`
+	UpdatedBy         *uint    `json:"-"` // Set internally, not from request
+}
+
+type TaxRuleExemptionResponse struct {
+	CategoryID   uint   `json:"category_id"`
+	CategoryName string `json:"category_name"`
+}
+
+type TaxRuleResponse struct {
+	ID            uint                       `json:"id"`
+	TenantID      uint                       `json:"tenant_id"`
+	BranchID      *uint                      `json:"branch_id"`
+	Name          string                     `json:"name"`
+	Type          string                     `json:"type"`
+	Rate          float64                    `json:"rate"`
+	IsInclusive   bool                       `json:"is_inclusive"`
+	Sequence      int                        `json:"sequence"`
+	IsCompound    bool                       `json:"is_compound"`
+	Exemptions    []TaxRuleExemptionResponse `json:"exemptions"`
+	IsActive      bool                       `json:"is_active"`
+	CreatedAt     string                     `json:"created_at"`
+	UpdatedAt     string                     `json:"updated_at"`
+	CreatedBy     *uint                      `json:"created_by,omitempty"`
+	CreatedByName *string                    `json:"created_by_name,omitempty"`
+	UpdatedBy     *uint                      `json:"updated_by,omitempty"`
+	UpdatedByName *string                    `json:"updated_by_name,omitempty"`
+}
+
+type OrderTaxResponse struct {
+	TaxRuleID     *uint        `json:"tax_rule_id"`
+	Name          string       `json:"name"`
+	Type          string       `json:"type"`
+	Rate          float64      `json:"rate"`
+	IsInclusive   bool         `json:"is_inclusive"`
+	TaxableAmount money.Amount `json:"taxable_amount"`
+	Amount        money.Amount `json:"amount"`
+}
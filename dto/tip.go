@@ -0,0 +1,62 @@
+package dto
+
+import "myposcore/money"
+
+// PaymentTipRequest is the optional gratuity on a payment: a custom amount or one of the tenant's presets
+type PaymentTipRequest struct {
+	TipAmount   money.Amount `json:"tip_amount" binding:"gte=0"`
+	TipPresetID *uint        `json:"tip_preset_id"` // Takes precedence over tip_amount
+	TipUserID   *uint        `json:"tip_user_id"`   // Staff member receiving the tip; defaults to the order's cashier
+}
+
+type TipPresetRequest struct {
+	Type    string       `json:"type" binding:"required,oneof=fixed percent"`
+	Amount  money.Amount `json:"amount" binding:"gte=0"`          // Fixed tip
+	Percent float64      `json:"percent" binding:"gte=0,lte=100"` // Percent of the order total
+}
+
+// SaveTipPresetsRequest replaces the tenant's tip presets; they are offered in the given order
+type SaveTipPresetsRequest struct {
+	Presets []TipPresetRequest `json:"presets" binding:"dive"`
+}
+
+type TipPresetResponse struct {
+	ID        uint         `json:"id"`
+	Type      string       `json:"type"`
+	Amount    money.Amount `json:"amount"`
+	Percent   float64      `json:"percent"`
+	SortOrder int          `json:"sort_order"`
+}
+
+// TipShiftTotal sums the tips of a staff member within one cash shift
+type TipShiftTotal struct {
+	ShiftID      *uint        `json:"shift_id"` // nil for tips taken outside a shift
+	OpenedAt     *string      `json:"opened_at,omitempty"`
+	ClosedAt     *string      `json:"closed_at,omitempty"`
+	PaymentCount int          `json:"payment_count"`
+	CashTips     money.Amount `json:"cash_tips"`
+	NonCashTips  money.Amount `json:"non_cash_tips"`
+	TotalTips    money.Amount `json:"total_tips"`
+}
+
+// TipStaffTotal sums the tips owed to a staff member
+type TipStaffTotal struct {
+	UserID       uint            `json:"user_id"`
+	UserName     string          `json:"user_name"`
+	PaymentCount int             `json:"payment_count"`
+	CashTips     money.Amount    `json:"cash_tips"`     // Already in the drawer
+	NonCashTips  money.Amount    `json:"non_cash_tips"` // Card, QRIS and other tenders, to be paid out
+	TotalTips    money.Amount    `json:"total_tips"`
+	Shifts       []TipShiftTotal `json:"shifts,omitempty"`
+}
+
+type TipReportResponse struct {
+	DateFrom    string          `json:"date_from,omitempty"`
+	DateTo      string          `json:"date_to,omitempty"`
+	ShiftID     *uint           `json:"shift_id,omitempty"`
+	CashTips    money.Amount    `json:"cash_tips"`
+	NonCashTips money.Amount    `json:"non_cash_tips"`
+	TotalTips   money.Amount    `json:"total_tips"`
+	Staff       []TipStaffTotal `json:"staff"`
+	GeneratedAt string          `json:"generated_at"`
+}
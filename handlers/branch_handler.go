@@ -122,6 +122,7 @@ func (h *BranchHandler) GetBranch(c *gin.Context) {
 // @Param website formData string false "Branch website"
 // @Param email formData string false "Branch email"
 // @Param phone formData string false "Branch phone"
+// @Param timezone formData string false "Branch timezone (IANA name, e.g. Asia/Jakarta)"
 // @Param is_active formData boolean false "Is active"
 // @Param image formData file false "Branch image"
 // @Success 200 {object} map[string]interface{}
@@ -151,6 +152,7 @@ func (h *BranchHandler) CreateBranch(c *gin.Context) {
 		Website:     c.PostForm("website"),
 		Email:       c.PostForm("email"),
 		Phone:       c.PostForm("phone"),
+		Timezone:    c.PostForm("timezone"),
 		Active:      c.PostForm("is_active") == "true",
 	}
 
@@ -238,6 +240,7 @@ func (h *BranchHandler) CreateBranch(c *gin.Context) {
 // @Param website formData string false "Branch website"
 // @Param email formData string false "Branch email"
 // @Param phone formData string false "Branch phone"
+// @Param timezone formData string false "Branch timezone (IANA name, e.g. Asia/Jakarta)"
 // @Param is_active formData boolean false "Is active"
 // @Param image formData file false "Branch image"
 // @Success 200 {object} map[string]interface{}
@@ -287,6 +290,7 @@ func (h *BranchHandler) UpdateBranch(c *gin.Context) {
 		Website:     c.PostForm("website"),
 		Email:       c.PostForm("email"),
 		Phone:       c.PostForm("phone"),
+		Timezone:    c.PostForm("timezone"),
 		Active:      c.PostForm("is_active") == "true",
 	}
 
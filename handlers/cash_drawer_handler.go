@@ -0,0 +1,66 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/services"
+	"myposcore/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CashDrawerHandler struct {
+	BaseHandler
+	cashDrawerService *services.CashDrawerService
+}
+
+func NewCashDrawerHandler(cfg *config.Config, cashDrawerService *services.CashDrawerService) *CashDrawerHandler {
+	return &CashDrawerHandler{
+		BaseHandler:       BaseHandler{config: cfg},
+		cashDrawerService: cashDrawerService,
+	}
+}
+
+// OpenCashDrawer godoc
+// @Summary Open cash drawer without a sale
+// @Description Authorise opening the cash drawer outside a sale. Needs the user ID and PIN of a supervisor whose role outranks the cashier
+// @Tags cash-drawer
+// @Accept json
+// @Produce json
+// @Param request body dto.OpenCashDrawerRequest true "Reason and supervisor approval"
+// @Success 200 {object} dto.SupervisorApprovalResponse
+// @Router /api/cash-drawer/open [post]
+func (h *CashDrawerHandler) OpenCashDrawer(c *gin.Context) {
+	var req dto.OpenCashDrawerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	currentUserID := c.GetUint("user_id")
+
+	approved, err := h.cashDrawerService.OpenDrawer(tenantID, branchID, req, &currentUserID)
+	if err != nil {
+		if isApprovalError(err) {
+			utils.Forbidden(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Cash drawer opening approved", buildSupervisorApprovalResponse(approved))
+}
+
+func buildSupervisorApprovalResponse(approved *services.ApprovedAction) dto.SupervisorApprovalResponse {
+	return dto.SupervisorApprovalResponse{
+		Action:          approved.Action,
+		RequestedBy:     approved.Requester.ID,
+		RequestedByName: approved.Requester.FullName,
+		ApprovedBy:      approved.Approver.ID,
+		ApprovedByName:  approved.Approver.FullName,
+		ApprovedAt:      approved.ApprovedAt.Format("2006-01-02 15:04:05"),
+	}
+}
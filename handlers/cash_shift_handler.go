@@ -0,0 +1,373 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CashShiftHandler struct {
+	BaseHandler
+	cashShiftService *services.CashShiftService
+}
+
+func NewCashShiftHandler(cfg *config.Config, cashShiftService *services.CashShiftService) *CashShiftHandler {
+	return &CashShiftHandler{
+		BaseHandler:      BaseHandler{config: cfg},
+		cashShiftService: cashShiftService,
+	}
+}
+
+// OpenCashShift godoc
+// @Summary Open a cash shift
+// @Description Start the current user's shift on their branch with the opening float put in the drawer. Cash payments need an open shift.
+// @Tags cash-drawer
+// @Accept json
+// @Produce json
+// @Param request body dto.OpenCashShiftRequest true "Opening float"
+// @Success 200 {object} dto.CashShiftResponse
+// @Router /api/cash-shifts [post]
+func (h *CashShiftHandler) OpenCashShift(c *gin.Context) {
+	var req dto.OpenCashShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	currentUserID := c.GetUint("user_id")
+
+	shift, err := h.cashShiftService.OpenShift(tenantID, branchID, currentUserID, req)
+	if err != nil {
+		if err.Error() == "cash shift already open" {
+			utils.Conflict(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Cash shift opened successfully", buildCashShiftResponse(shift))
+}
+
+// GetCurrentCashShift godoc
+// @Summary Get current cash shift
+// @Description Get the shift the current user has open on their branch
+// @Tags cash-drawer
+// @Produce json
+// @Success 200 {object} dto.CashShiftResponse
+// @Router /api/cash-shifts/current [get]
+func (h *CashShiftHandler) GetCurrentCashShift(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	currentUserID := c.GetUint("user_id")
+
+	shift, err := h.cashShiftService.GetCurrentShift(tenantID, branchID, currentUserID)
+	if err != nil {
+		if err.Error() == "no open cash shift" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Cash shift retrieved successfully", buildCashShiftResponse(shift))
+}
+
+// ListCashShifts godoc
+// @Summary List cash shifts
+// @Description Get paginated shifts of the tenant, newest first
+// @Tags cash-drawer
+// @Produce json
+// @Param branch_id query int false "Only shifts of this branch"
+// @Param user_id query int false "Only shifts of this cashier"
+// @Param status query string false "open or closed"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.PaginationResponse
+// @Router /api/cash-shifts [get]
+func (h *CashShiftHandler) ListCashShifts(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	var branchID, userID uint
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+	if userIDStr := c.Query("user_id"); userIDStr != "" {
+		parsed, err := strconv.ParseUint(userIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid user ID")
+			return
+		}
+		userID = uint(parsed)
+	}
+	status := c.Query("status")
+	if status != "" && status != "open" && status != "closed" {
+		utils.BadRequest(c, "status must be open or closed")
+		return
+	}
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	shifts, total, err := h.cashShiftService.ListShifts(tenantID, branchID, userID, status, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.CashShiftResponse, len(shifts))
+	for i := range shifts {
+		responses[i] = buildCashShiftResponse(&shifts[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":        0,
+		"message":     "Cash shifts retrieved successfully",
+		"page":        pagination.Page,
+		"page_size":   pagination.PageSize,
+		"total_items": total,
+		"total_pages": (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		"data":        responses,
+	})
+}
+
+// GetCashShift godoc
+// @Summary Get cash shift
+// @Tags cash-drawer
+// @Produce json
+// @Param id path int true "Shift ID"
+// @Success 200 {object} dto.CashShiftResponse
+// @Router /api/cash-shifts/{id} [get]
+func (h *CashShiftHandler) GetCashShift(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid shift ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	shift, err := h.cashShiftService.GetShift(uint(shiftID), tenantID)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Cash shift retrieved successfully", buildCashShiftResponse(shift))
+}
+
+// CreateCashMovement godoc
+// @Summary Record a pay-in or pay-out
+// @Description Record cash put into or taken out of the drawer outside a sale, e.g. extra change or paying a supplier
+// @Tags cash-drawer
+// @Accept json
+// @Produce json
+// @Param id path int true "Shift ID"
+// @Param request body dto.CreateCashMovementRequest true "Pay-in or pay-out"
+// @Success 200 {object} dto.CashMovementResponse
+// @Router /api/cash-shifts/{id}/movements [post]
+func (h *CashShiftHandler) CreateCashMovement(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid shift ID")
+		return
+	}
+
+	var req dto.CreateCashMovementRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	movement, err := h.cashShiftService.AddMovement(uint(shiftID), tenantID, req, &currentUserID)
+	if err != nil {
+		if err.Error() == "cash shift not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Cash movement recorded successfully", buildCashMovementResponse(movement))
+}
+
+// CloseCashShift godoc
+// @Summary Close a cash shift
+// @Description Count the drawer out by denomination. The server computes the expected cash and the variance and returns the Z report.
+// @Tags cash-drawer
+// @Accept json
+// @Produce json
+// @Param id path int true "Shift ID"
+// @Param request body dto.CloseCashShiftRequest true "Counted denominations"
+// @Success 200 {object} dto.CashShiftReportResponse
+// @Router /api/cash-shifts/{id}/close [post]
+func (h *CashShiftHandler) CloseCashShift(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid shift ID")
+		return
+	}
+
+	var req dto.CloseCashShiftRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	shift, err := h.cashShiftService.CloseShift(uint(shiftID), tenantID, req, &currentUserID)
+	if err != nil {
+		if err.Error() == "cash shift not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	report, err := h.cashShiftService.GetReport(shift.ID, tenantID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Cash shift closed successfully", buildCashShiftReportResponse(report))
+}
+
+// GetCashShiftReport godoc
+// @Summary Get shift X/Z report
+// @Description Get the X report of an open shift (a reading that leaves it open) or the Z report of a closed one: sales and refunds per method, tips per staff member, pay-ins/outs and the cash reconciliation
+// @Tags cash-drawer
+// @Produce json
+// @Param id path int true "Shift ID"
+// @Success 200 {object} dto.CashShiftReportResponse
+// @Router /api/cash-shifts/{id}/report [get]
+func (h *CashShiftHandler) GetCashShiftReport(c *gin.Context) {
+	shiftID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid shift ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	report, err := h.cashShiftService.GetReport(uint(shiftID), tenantID)
+	if err != nil {
+		if err.Error() == "cash shift not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Cash shift report generated successfully", buildCashShiftReportResponse(report))
+}
+
+func buildCashShiftResponse(shift *models.CashShift) dto.CashShiftResponse {
+	response := dto.CashShiftResponse{
+		ID:            shift.ID,
+		TenantID:      shift.TenantID,
+		BranchID:      shift.BranchID,
+		UserID:        shift.UserID,
+		UserName:      shift.User.FullName,
+		Status:        shift.Status,
+		OpeningFloat:  shift.OpeningFloat,
+		OpeningNotes:  shift.OpeningNotes,
+		OpenedAt:      shift.OpenedAt.Format("2006-01-02 15:04:05"),
+		ClosedBy:      shift.ClosedBy,
+		ClosingNotes:  shift.ClosingNotes,
+		Denominations: services.DecodeCashDenominations(shift),
+	}
+	if shift.Closer != nil {
+		name := shift.Closer.FullName
+		response.ClosedByName = &name
+	}
+	if shift.Status == "closed" {
+		closedAt := shift.ClosedAt.Format("2006-01-02 15:04:05")
+		expected, counted, variance, zNumber := shift.ExpectedCash, shift.CountedCash, shift.Variance, shift.ZNumber
+		response.ClosedAt = &closedAt
+		response.ExpectedCash = &expected
+		response.CountedCash = &counted
+		response.Variance = &variance
+		response.ZNumber = &zNumber
+	}
+	return response
+}
+
+func buildCashMovementResponse(movement *models.CashMovement) dto.CashMovementResponse {
+	var createdByName *string
+	if movement.Creator != nil {
+		name := movement.Creator.FullName
+		createdByName = &name
+	}
+	return dto.CashMovementResponse{
+		ID:            movement.ID,
+		ShiftID:       movement.ShiftID,
+		Type:          movement.Type,
+		Amount:        movement.Amount,
+		Reason:        movement.Reason,
+		CreatedAt:     movement.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:     movement.CreatedBy,
+		CreatedByName: createdByName,
+	}
+}
+
+func buildCashShiftReportResponse(report *services.CashShiftReport) dto.CashShiftReportResponse {
+	shift := buildCashShiftResponse(&report.Shift)
+	response := dto.CashShiftReportResponse{
+		ReportType:    report.ReportType,
+		Shift:         shift,
+		OrderCount:    report.OrderCount,
+		Payments:      report.Payments,
+		TotalPayments: report.TotalPayments,
+		Refunds:       report.Refunds,
+		TotalRefunds:  report.TotalRefunds,
+		Movements:     make([]dto.CashMovementResponse, len(report.Movements)),
+		OpeningFloat:  report.Shift.OpeningFloat,
+		CashSales:     report.CashSales,
+		CashRefunds:   report.CashRefunds,
+		CashTips:      report.CashTips,
+		TotalTips:     report.TotalTips,
+		Tips:          report.Tips,
+		PayIns:        report.PayIns,
+		PayOuts:       report.PayOuts,
+		ExpectedCash:  report.ExpectedCash,
+		CountedCash:   shift.CountedCash,
+		Variance:      shift.Variance,
+		GeneratedAt:   time.Now().Format("2006-01-02 15:04:05"),
+	}
+	if report.ReportType == "Z" {
+		response.Denominations = shift.Denominations
+	}
+	for i := range report.Movements {
+		response.Movements[i] = buildCashMovementResponse(&report.Movements[i])
+	}
+	return response
+}
@@ -0,0 +1,82 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CurrencyHandler struct {
+	BaseHandler
+	currencyService *services.CurrencyService
+}
+
+func NewCurrencyHandler(cfg *config.Config, currencyService *services.CurrencyService) *CurrencyHandler {
+	return &CurrencyHandler{
+		BaseHandler:     BaseHandler{config: cfg},
+		currencyService: currencyService,
+	}
+}
+
+// GetCurrencySettings godoc
+// @Summary Get currency settings
+// @Description Get the currency the tenant's amounts are rounded in and how cash totals are rounded
+// @Tags currency
+// @Produce json
+// @Success 200 {object} dto.CurrencySettingsResponse
+// @Router /api/currency [get]
+func (h *CurrencyHandler) GetCurrencySettings(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	tenant, err := h.currencyService.GetSettings(tenantID)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Currency settings retrieved successfully", buildCurrencySettingsResponse(tenant))
+}
+
+// SaveCurrencySettings godoc
+// @Summary Save currency settings
+// @Description Set the tenant's currency, its minor unit precision and cash rounding, e.g. to the nearest 100 or 500 IDR. Existing amounts are not converted.
+// @Tags currency
+// @Accept json
+// @Produce json
+// @Param request body dto.SaveCurrencySettingsRequest true "Currency settings"
+// @Success 200 {object} dto.CurrencySettingsResponse
+// @Router /api/currency [put]
+func (h *CurrencyHandler) SaveCurrencySettings(c *gin.Context) {
+	var req dto.SaveCurrencySettingsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	tenant, err := h.currencyService.SaveSettings(tenantID, req, &currentUserID)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Currency settings saved successfully", buildCurrencySettingsResponse(tenant))
+}
+
+func buildCurrencySettingsResponse(tenant *models.Tenant) dto.CurrencySettingsResponse {
+	return dto.CurrencySettingsResponse{
+		TenantID:              tenant.ID,
+		CurrencyCode:          tenant.CurrencyCode,
+		CurrencyPrecision:     tenant.CurrencyPrecision,
+		CashRoundingIncrement: tenant.CashRoundingIncrement,
+		CashRoundingMode:      tenant.CashRoundingMode,
+		UpdatedAt:             tenant.UpdatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedBy:             tenant.UpdatedBy,
+	}
+}
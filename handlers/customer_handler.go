@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CustomerHandler struct {
+	BaseHandler
+	customerService *services.CustomerService
+}
+
+func NewCustomerHandler(cfg *config.Config, customerService *services.CustomerService) *CustomerHandler {
+	return &CustomerHandler{
+		BaseHandler:     BaseHandler{config: cfg},
+		customerService: customerService,
+	}
+}
+
+// CreateCustomer godoc
+// @Summary Create a customer
+// @Description Add a customer to the tenant's directory. Phone and email must be unique within the tenant.
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateCustomerRequest true "Customer request"
+// @Success 200 {object} dto.CustomerResponse
+// @Router /api/customers [post]
+func (h *CustomerHandler) CreateCustomer(c *gin.Context) {
+	var req dto.CreateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.CreatedBy = &currentUserID
+
+	customer, err := h.customerService.CreateCustomer(tenantID, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Customer created successfully", buildCustomerResponse(customer))
+}
+
+// GetCustomer godoc
+// @Summary Get customer detail
+// @Description Get a customer with lifetime spend, visit count, last visit and a page of their orders
+// @Tags customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param page query int false "Order history page" default(1)
+// @Param page_size query int false "Orders per page" default(32)
+// @Success 200 {object} dto.CustomerDetailResponse
+// @Router /api/customers/{id} [get]
+func (h *CustomerHandler) GetCustomer(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid customer ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	customer, err := h.customerService.GetCustomer(uint(customerID), tenantID)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	stats, err := h.customerService.GetCustomerStats(customer.ID, tenantID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	orders, total, err := h.customerService.ListCustomerOrders(customer.ID, tenantID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	orderResponses := make([]dto.OrderResponse, len(orders))
+	for i := range orders {
+		orderResponses[i] = buildOrderResponse(&orders[i])
+	}
+
+	utils.Success(c, "Customer retrieved successfully", dto.CustomerDetailResponse{
+		CustomerResponse: buildCustomerResponse(customer),
+		Stats:            *stats,
+		OrderHistory: dto.CustomerOrderHistory{
+			Page:       pagination.Page,
+			PageSize:   pagination.PageSize,
+			TotalItems: total,
+			TotalPages: (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+			Orders:     orderResponses,
+		},
+	})
+}
+
+// ListCustomers godoc
+// @Summary List customers
+// @Description Get paginated customers of the tenant, sorted by name
+// @Tags customers
+// @Produce json
+// @Param search query string false "Search by name, phone or email"
+// @Param tag query string false "Only customers with this tag"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.PaginationResponse
+// @Router /api/customers [get]
+func (h *CustomerHandler) ListCustomers(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	search := c.Query("search")
+	tag := c.Query("tag")
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	customers, total, err := h.customerService.ListCustomers(tenantID, search, tag, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.CustomerResponse, len(customers))
+	for i := range customers {
+		responses[i] = buildCustomerResponse(&customers[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":        0,
+		"message":     "Customers retrieved successfully",
+		"page":        pagination.Page,
+		"page_size":   pagination.PageSize,
+		"total_items": total,
+		"total_pages": (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		"data":        responses,
+	})
+}
+
+// UpdateCustomer godoc
+// @Summary Update customer
+// @Tags customers
+// @Accept json
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param request body dto.UpdateCustomerRequest true "Customer fields to update"
+// @Success 200 {object} dto.CustomerResponse
+// @Router /api/customers/{id} [put]
+func (h *CustomerHandler) UpdateCustomer(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid customer ID")
+		return
+	}
+
+	var req dto.UpdateCustomerRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.UpdatedBy = &currentUserID
+
+	customer, err := h.customerService.UpdateCustomer(uint(customerID), tenantID, req)
+	if err != nil {
+		if err.Error() == "customer not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Customer updated successfully", buildCustomerResponse(customer))
+}
+
+// DeleteCustomer godoc
+// @Summary Delete customer
+// @Description Remove a customer from the directory. Their orders keep the customer link.
+// @Tags customers
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/customers/{id} [delete]
+func (h *CustomerHandler) DeleteCustomer(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid customer ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	if err := h.customerService.DeleteCustomer(uint(customerID), tenantID, &currentUserID); err != nil {
+		if err.Error() == "customer not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithoutData(c, "Customer deleted successfully")
+}
+
+func buildCustomerResponse(customer *models.Customer) dto.CustomerResponse {
+	var createdByName, updatedByName *string
+	if customer.Creator != nil {
+		name := customer.Creator.FullName
+		createdByName = &name
+	}
+	if customer.Updater != nil {
+		name := customer.Updater.FullName
+		updatedByName = &name
+	}
+
+	var birthday *string
+	if customer.Birthday != nil {
+		formatted := customer.Birthday.Format("2006-01-02")
+		birthday = &formatted
+	}
+
+	return dto.CustomerResponse{
+		ID:             customer.ID,
+		TenantID:       customer.TenantID,
+		Name:           customer.Name,
+		Phone:          customer.Phone,
+		Email:          customer.Email,
+		Birthday:       birthday,
+		Notes:          customer.Notes,
+		Tags:           services.DecodeCustomerTags(customer),
+		LoyaltyPoints:  customer.LoyaltyPoints,
+		LifetimePoints: customer.LifetimePoints,
+		LoyaltyTier:    customer.LoyaltyTier,
+		CreatedAt:      customer.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      customer.UpdatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:      customer.CreatedBy,
+		CreatedByName:  createdByName,
+		UpdatedBy:      customer.UpdatedBy,
+		UpdatedByName:  updatedByName,
+	}
+}
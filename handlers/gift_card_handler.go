@@ -0,0 +1,219 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type GiftCardHandler struct {
+	BaseHandler
+	giftCardService *services.GiftCardService
+}
+
+func NewGiftCardHandler(cfg *config.Config, giftCardService *services.GiftCardService) *GiftCardHandler {
+	return &GiftCardHandler{
+		BaseHandler:     BaseHandler{config: cfg},
+		giftCardService: giftCardService,
+	}
+}
+
+// ListGiftCards godoc
+// @Summary List gift cards
+// @Description Get paginated gift cards and store credit of the tenant, newest first
+// @Tags gift-cards
+// @Produce json
+// @Param type query string false "gift_card or store_credit"
+// @Param status query string false "active, expired or voided"
+// @Param customer_id query int false "Only cards held by this customer"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.PaginationResponse
+// @Router /api/gift-cards [get]
+func (h *GiftCardHandler) ListGiftCards(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	var customerID *uint
+	if customerParam := c.Query("customer_id"); customerParam != "" {
+		id, err := strconv.ParseUint(customerParam, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid customer_id")
+			return
+		}
+		cid := uint(id)
+		customerID = &cid
+	}
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	cards, total, err := h.giftCardService.ListGiftCards(tenantID, c.Query("type"), c.Query("status"), customerID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	now := time.Now()
+	responses := make([]dto.GiftCardResponse, len(cards))
+	for i := range cards {
+		responses[i] = buildGiftCardResponse(&cards[i], now)
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":        0,
+		"message":     "Gift cards retrieved successfully",
+		"page":        pagination.Page,
+		"page_size":   pagination.PageSize,
+		"total_items": total,
+		"total_pages": (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		"data":        responses,
+	})
+}
+
+// GetGiftCardBalance godoc
+// @Summary Gift card balance inquiry
+// @Description Look a gift card or store credit up by its code and get its balance. Dashes and spaces in the code are ignored.
+// @Tags gift-cards
+// @Produce json
+// @Param code query string true "Card code"
+// @Success 200 {object} dto.GiftCardBalanceResponse
+// @Router /api/gift-cards/balance [get]
+func (h *GiftCardHandler) GetGiftCardBalance(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	code := c.Query("code")
+	if code == "" {
+		utils.BadRequest(c, "code is required")
+		return
+	}
+
+	card, err := h.giftCardService.GetBalance(tenantID, code)
+	if err != nil {
+		if err.Error() == "gift card not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	response := buildGiftCardResponse(card, time.Now())
+	utils.Success(c, "Gift card balance retrieved successfully", dto.GiftCardBalanceResponse{
+		Code:      response.Code,
+		Type:      response.Type,
+		Balance:   response.Balance,
+		ExpiresAt: response.ExpiresAt,
+		Status:    response.Status,
+	})
+}
+
+// GetGiftCard godoc
+// @Summary Get gift card
+// @Description Get a gift card or store credit with one page of its ledger, newest first
+// @Tags gift-cards
+// @Produce json
+// @Param id path int true "Gift card ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.GiftCardDetailResponse
+// @Router /api/gift-cards/{id} [get]
+func (h *GiftCardHandler) GetGiftCard(c *gin.Context) {
+	giftCardID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid gift card ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	card, err := h.giftCardService.GetGiftCard(uint(giftCardID), tenantID)
+	if err != nil {
+		if err.Error() == "gift card not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	transactions, total, err := h.giftCardService.ListTransactions(card.ID, tenantID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.GiftCardTransactionResponse, len(transactions))
+	for i, transaction := range transactions {
+		responses[i] = dto.GiftCardTransactionResponse{
+			ID:           transaction.ID,
+			Type:         transaction.Type,
+			Amount:       transaction.Amount,
+			BalanceAfter: transaction.BalanceAfter,
+			BranchID:     transaction.BranchID,
+			OrderID:      transaction.OrderID,
+			PaymentID:    transaction.PaymentID,
+			RefundID:     transaction.RefundID,
+			Description:  transaction.Description,
+			CreatedAt:    transaction.CreatedAt.Format("2006-01-02 15:04:05"),
+			CreatedBy:    transaction.CreatedBy,
+		}
+	}
+
+	utils.Success(c, "Gift card retrieved successfully", dto.GiftCardDetailResponse{
+		GiftCardResponse: buildGiftCardResponse(card, time.Now()),
+		Page:             pagination.Page,
+		PageSize:         pagination.PageSize,
+		TotalItems:       total,
+		TotalPages:       (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		Transactions:     responses,
+	})
+}
+
+func buildGiftCardResponse(card *models.GiftCard, now time.Time) dto.GiftCardResponse {
+	var expiresAt, customerName *string
+	if card.ExpiresAt != nil {
+		formatted := card.ExpiresAt.Format("2006-01-02 15:04:05")
+		expiresAt = &formatted
+	}
+	if card.Customer != nil {
+		name := card.Customer.Name
+		customerName = &name
+	}
+
+	return dto.GiftCardResponse{
+		ID:             card.ID,
+		Code:           card.Code,
+		Type:           card.Type,
+		BranchID:       card.BranchID,
+		CustomerID:     card.CustomerID,
+		CustomerName:   customerName,
+		InitialBalance: card.InitialBalance,
+		Balance:        card.Balance,
+		ExpiresAt:      expiresAt,
+		Status:         services.GiftCardStatus(card, now),
+		OrderID:        card.OrderID,
+		RefundID:       card.RefundID,
+		CreatedAt:      card.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:      card.CreatedBy,
+	}
+}
@@ -0,0 +1,192 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type InventoryHandler struct {
+	BaseHandler
+	inventoryService *services.InventoryService
+}
+
+func NewInventoryHandler(cfg *config.Config, inventoryService *services.InventoryService) *InventoryHandler {
+	return &InventoryHandler{
+		BaseHandler:      BaseHandler{config: cfg},
+		inventoryService: inventoryService,
+	}
+}
+
+// SetReorderPoint godoc
+// @Summary Set a reorder point
+// @Description Set the minimum stock at which a product raises low-stock alerts and the quantity worth ordering. With branch_id it sets the branch's own values; left out values then fall back to the product's.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param request body dto.ReorderPointRequest true "Reorder point"
+// @Success 200 {object} dto.ReorderPointResponse
+// @Router /api/products/{id}/reorder-point [put]
+func (h *InventoryHandler) SetReorderPoint(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid product ID")
+		return
+	}
+
+	var req dto.ReorderPointRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	reorderPoint, err := h.inventoryService.SetReorderPoint(uint(productID), tenantID, req, &currentUserID)
+	if err != nil {
+		if err.Error() == "product not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Reorder point updated successfully", reorderPoint)
+}
+
+// ListStockAlerts godoc
+// @Summary List stock alerts
+// @Description Get paginated low-stock and out-of-stock alerts of the tenant, newest first. Alerts are raised in the background as stock falls to the reorder points or runs out.
+// @Tags stock
+// @Produce json
+// @Param branch_id query int false "Only alerts of this branch"
+// @Param type query string false "low_stock or out_of_stock"
+// @Param status query string false "open, resolved or all" default(open)
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.PaginationResponse
+// @Router /api/inventory/alerts [get]
+func (h *InventoryHandler) ListStockAlerts(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	var branchID uint
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+	status := c.DefaultQuery("status", services.StockAlertOpen)
+	if status == "all" {
+		status = ""
+	}
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	alerts, total, err := h.inventoryService.ListAlerts(tenantID, branchID, c.Query("type"), status, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.StockAlertResponse, len(alerts))
+	for i := range alerts {
+		responses[i] = buildStockAlertResponse(&alerts[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":        0,
+		"message":     "Stock alerts retrieved successfully",
+		"page":        pagination.Page,
+		"page_size":   pagination.PageSize,
+		"total_items": total,
+		"total_pages": (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		"data":        responses,
+	})
+}
+
+// GetReorderSuggestions godoc
+// @Summary Get suggested reorders
+// @Description Suggest what to order for a branch from its recent sales velocity, its reorder points and what is still on order
+// @Tags stock
+// @Produce json
+// @Param branch_id query int false "Branch to order for, the current branch by default"
+// @Param days query int false "Days of sales the velocity is measured over" default(30)
+// @Param cover_days query int false "Days the ordered stock should last" default(14)
+// @Success 200 {object} dto.ReorderSuggestionsResponse
+// @Router /api/inventory/reorder-suggestions [get]
+func (h *InventoryHandler) GetReorderSuggestions(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+
+	days, err := strconv.Atoi(c.DefaultQuery("days", "30"))
+	if err != nil || days < 1 || days > 365 {
+		utils.BadRequest(c, "days must be between 1 and 365")
+		return
+	}
+	coverDays, err := strconv.Atoi(c.DefaultQuery("cover_days", "14"))
+	if err != nil || coverDays < 1 || coverDays > 365 {
+		utils.BadRequest(c, "cover_days must be between 1 and 365")
+		return
+	}
+
+	suggestions, err := h.inventoryService.ReorderSuggestions(tenantID, branchID, days, coverDays)
+	if err != nil {
+		if err.Error() == "branch not found or doesn't belong to this tenant" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Reorder suggestions retrieved successfully", suggestions)
+}
+
+func buildStockAlertResponse(alert *models.StockAlert) dto.StockAlertResponse {
+	response := dto.StockAlertResponse{
+		ID:          alert.ID,
+		BranchID:    alert.BranchID,
+		ProductID:   alert.ProductID,
+		ProductName: alert.Product.Name,
+		SKU:         alert.Product.SKU,
+		Type:        alert.Type,
+		Status:      alert.Status,
+		Quantity:    alert.Quantity,
+		MinStock:    alert.MinStock,
+		RaisedAt:    alert.RaisedAt.Format("2006-01-02 15:04:05"),
+	}
+	if alert.Branch != nil {
+		response.BranchName = alert.Branch.Name
+	}
+	if alert.ResolvedAt != nil {
+		resolvedAt := alert.ResolvedAt.Format("2006-01-02 15:04:05")
+		response.ResolvedAt = &resolvedAt
+	}
+	return response
+}
@@ -0,0 +1,270 @@
+package handlers
+
+import (
+	"fmt"
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+const (
+	// kitchenPollInterval is how often the stream checks for new events
+	kitchenPollInterval = time.Second
+	// kitchenHeartbeatInterval keeps idle connections open through proxies
+	kitchenHeartbeatInterval = 15 * time.Second
+	// kitchenEventBatch caps how many events are sent per poll
+	kitchenEventBatch = 100
+)
+
+type KitchenHandler struct {
+	BaseHandler
+	kitchenService *services.KitchenService
+	orderService   *services.OrderService
+}
+
+func NewKitchenHandler(cfg *config.Config, kitchenService *services.KitchenService, orderService *services.OrderService) *KitchenHandler {
+	return &KitchenHandler{
+		BaseHandler:    BaseHandler{config: cfg},
+		kitchenService: kitchenService,
+		orderService:   orderService,
+	}
+}
+
+// kitchenBranchID returns the branch of the kitchen screen: the branch_id query parameter or the user's branch
+func kitchenBranchID(c *gin.Context) (uint, error) {
+	branchID := c.GetUint("branch_id")
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			return 0, fmt.Errorf("invalid branch ID")
+		}
+		branchID = uint(parsed)
+	}
+	if branchID == 0 {
+		return 0, fmt.Errorf("branch_id is required")
+	}
+	return branchID, nil
+}
+
+// StreamKitchenEvents godoc
+// @Summary Kitchen display event stream
+// @Description Server-Sent Events stream of order changes of a branch (order.created, order.item_added, order.item_updated, order.item_removed, order.status_changed, order.cancelled, order.item_bumped). Event ids follow the order in which changes were committed. Send the Last-Event-ID header (or last_event_id query parameter) to resume after a reconnect; without it only new events are sent
+// @Tags kitchen
+// @Produce text/event-stream
+// @Param branch_id query int false "Branch ID, defaults to the current branch"
+// @Param last_event_id query int false "Resume after this event id"
+// @Success 200 {string} string "Event stream"
+// @Router /api/kitchen/stream [get]
+func (h *KitchenHandler) StreamKitchenEvents(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID, err := kitchenBranchID(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	lastEventID := c.GetHeader("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = c.Query("last_event_id")
+	}
+	var lastID uint
+	if lastEventID != "" {
+		parsed, err := strconv.ParseUint(lastEventID, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid last event ID")
+			return
+		}
+		lastID = uint(parsed)
+	} else {
+		lastID, err = h.kitchenService.LatestEventSequence(tenantID, branchID)
+		if err != nil {
+			utils.InternalError(c, err.Error())
+			return
+		}
+	}
+
+	c.Header("Content-Type", "text/event-stream")
+	c.Header("Cache-Control", "no-cache")
+	c.Header("Connection", "keep-alive")
+	c.Header("X-Accel-Buffering", "no")
+	c.Status(http.StatusOK)
+
+	// Tell EventSource clients how long to wait before reconnecting
+	fmt.Fprintf(c.Writer, "retry: 3000\n\n")
+	c.Writer.Flush()
+
+	poll := time.NewTicker(kitchenPollInterval)
+	defer poll.Stop()
+	heartbeat := time.NewTicker(kitchenHeartbeatInterval)
+	defer heartbeat.Stop()
+
+	for {
+		events, err := h.kitchenService.NextEvents(tenantID, branchID, lastID, kitchenEventBatch)
+		if err != nil {
+			fmt.Fprintf(c.Writer, "event: error\ndata: %q\n\n", err.Error())
+			c.Writer.Flush()
+			return
+		}
+		for _, event := range events {
+			lastID = *event.Sequence
+			fmt.Fprintf(c.Writer, "id: %d\nevent: %s\ndata: %s\n\n", lastID, event.Type, event.Data)
+		}
+		if len(events) > 0 {
+			c.Writer.Flush()
+		}
+		// A full batch means more events are waiting
+		if len(events) >= kitchenEventBatch {
+			continue
+		}
+
+		select {
+		case <-c.Request.Context().Done():
+			return
+		case <-heartbeat.C:
+			fmt.Fprintf(c.Writer, ": heartbeat\n\n")
+			c.Writer.Flush()
+		case <-poll.C:
+		}
+	}
+}
+
+// GetKitchenOrders godoc
+// @Summary List kitchen orders
+// @Description List the orders a kitchen display shows (pending, confirmed, preparing and ready), oldest first. Screens load this before opening the stream
+// @Tags kitchen
+// @Produce json
+// @Param branch_id query int false "Branch ID, defaults to the current branch"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/kitchen/orders [get]
+func (h *KitchenHandler) GetKitchenOrders(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID, err := kitchenBranchID(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	// The latest event id lets the screen open the stream exactly where this snapshot ends
+	lastEventID, err := h.kitchenService.LatestEventSequence(tenantID, branchID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	filter := &dto.ListFilter{
+		Page:     1,
+		PerPage:  100,
+		Statuses: services.KitchenOrderStatuses,
+		SortBy:   "created_at",
+		SortDir:  "asc",
+	}
+	orders, _, err := h.orderService.ListOrders(tenantID, branchID, filter)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.OrderResponse, len(orders))
+	for i := range orders {
+		responses[i] = buildOrderResponse(&orders[i])
+	}
+
+	utils.Success(c, "Kitchen orders retrieved successfully", gin.H{
+		"items":         responses,
+		"last_event_id": lastEventID,
+	})
+}
+
+// BumpKitchenOrder godoc
+// @Summary Bump kitchen order
+// @Description Move an order to its next kitchen status (pending -> confirmed -> preparing -> ready), or to the given status. The change is pushed to every screen of the branch
+// @Tags kitchen
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param request body dto.BumpOrderRequest false "Target status"
+// @Success 200 {object} dto.OrderResponse
+// @Router /api/kitchen/orders/{id}/bump [post]
+func (h *KitchenHandler) BumpKitchenOrder(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
+	}
+
+	var req dto.BumpOrderRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	order, err := h.kitchenService.BumpOrder(uint(orderID), tenantID, req.Status, &currentUserID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			utils.NotFound(c, "Order not found")
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Order bumped successfully", buildOrderResponse(order))
+}
+
+// BumpKitchenOrderItem godoc
+// @Summary Bump kitchen order item
+// @Description Move one item of an order to its next kitchen status (pending -> preparing -> ready), or to the given status, so stations can mark dishes ready one by one. The change is pushed to every screen of the branch as order.item_bumped
+// @Tags kitchen
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param item_id path int true "Order item ID"
+// @Param request body dto.BumpOrderItemRequest false "Target status"
+// @Success 200 {object} dto.OrderItemResponse
+// @Router /api/kitchen/orders/{id}/items/{item_id}/bump [post]
+func (h *KitchenHandler) BumpKitchenOrderItem(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order item ID")
+		return
+	}
+
+	var req dto.BumpOrderItemRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	item, err := h.kitchenService.BumpOrderItem(uint(orderID), uint(itemID), tenantID, req.Status, &currentUserID)
+	if err != nil {
+		if err.Error() == "order not found" || err.Error() == "order item not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Order item bumped successfully", buildOrderItemResponse(item))
+}
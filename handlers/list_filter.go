@@ -0,0 +1,98 @@
+package handlers
+
+import (
+	"errors"
+	"myposcore/dto"
+	"myposcore/money"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+)
+
+// parseListFilter reads the filter, sort and pagination query parameters shared by the list endpoints:
+// page, per_page, date_from, date_to, status, payment_method, user_id (or cashier_id), customer_id,
+// min_amount, max_amount, order_number, sort_by and sort_dir. status and payment_method accept comma separated values.
+func parseListFilter(c *gin.Context) (*dto.ListFilter, error) {
+	filter := &dto.ListFilter{
+		DateFrom:       strings.TrimSpace(c.Query("date_from")),
+		DateTo:         strings.TrimSpace(c.Query("date_to")),
+		Statuses:       splitQueryList(c.Query("status")),
+		PaymentMethods: splitQueryList(c.Query("payment_method")),
+		OrderNumber:    strings.TrimSpace(c.Query("order_number")),
+		SortBy:         strings.TrimSpace(c.Query("sort_by")),
+		SortDir:        strings.ToLower(strings.TrimSpace(c.DefaultQuery("sort_dir", "desc"))),
+	}
+
+	// Parse pagination parameters
+	filter.Page, _ = strconv.Atoi(c.DefaultQuery("page", "1"))
+	filter.PerPage, _ = strconv.Atoi(c.DefaultQuery("per_page", "32"))
+	if filter.Page < 1 {
+		filter.Page = 1
+	}
+	if filter.PerPage < 1 || filter.PerPage > 100 {
+		filter.PerPage = 32
+	}
+
+	userParam := c.Query("user_id")
+	if userParam == "" {
+		userParam = c.Query("cashier_id")
+	}
+	if userParam != "" {
+		userID, err := strconv.ParseUint(userParam, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid user_id")
+		}
+		uid := uint(userID)
+		filter.UserID = &uid
+	}
+
+	if customerParam := c.Query("customer_id"); customerParam != "" {
+		customerID, err := strconv.ParseUint(customerParam, 10, 32)
+		if err != nil {
+			return nil, errors.New("invalid customer_id")
+		}
+		cid := uint(customerID)
+		filter.CustomerID = &cid
+	}
+
+	var err error
+	if filter.MinAmount, err = parseAmountQuery(c, "min_amount"); err != nil {
+		return nil, err
+	}
+	if filter.MaxAmount, err = parseAmountQuery(c, "max_amount"); err != nil {
+		return nil, err
+	}
+	if filter.MinAmount != nil && filter.MaxAmount != nil && *filter.MinAmount > *filter.MaxAmount {
+		return nil, errors.New("min_amount cannot be greater than max_amount")
+	}
+
+	if filter.SortDir != "asc" && filter.SortDir != "desc" {
+		return nil, errors.New("sort_dir must be asc or desc")
+	}
+
+	return filter, nil
+}
+
+func parseAmountQuery(c *gin.Context, key string) (*money.Amount, error) {
+	value := c.Query(key)
+	if value == "" {
+		return nil, nil
+	}
+	amount, err := money.Parse(value)
+	if err != nil || amount < 0 {
+		return nil, errors.New("invalid " + key)
+	}
+	return &amount, nil
+}
+
+// splitQueryList splits a comma separated query value, dropping empty entries
+func splitQueryList(value string) []string {
+	var values []string
+	for _, v := range strings.Split(value, ",") {
+		if v = strings.TrimSpace(v); v != "" {
+			values = append(values, v)
+		}
+	}
+	return values
+}
@@ -0,0 +1,218 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"myposcore/services"
+	"myposcore/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type LoyaltyHandler struct {
+	BaseHandler
+	loyaltyService  *services.LoyaltyService
+	customerService *services.CustomerService
+}
+
+func NewLoyaltyHandler(cfg *config.Config, loyaltyService *services.LoyaltyService, customerService *services.CustomerService) *LoyaltyHandler {
+	return &LoyaltyHandler{
+		BaseHandler:     BaseHandler{config: cfg},
+		loyaltyService:  loyaltyService,
+		customerService: customerService,
+	}
+}
+
+// GetLoyaltyProgram godoc
+// @Summary Get loyalty program
+// @Description Get the tenant's earn rate, redemption value, point expiry, category multipliers and tiers
+// @Tags loyalty
+// @Produce json
+// @Success 200 {object} dto.LoyaltyProgramResponse
+// @Router /api/loyalty/program [get]
+func (h *LoyaltyHandler) GetLoyaltyProgram(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	program, err := h.loyaltyService.GetProgram(tenantID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Loyalty program retrieved successfully", buildLoyaltyProgramResponse(program))
+}
+
+// SaveLoyaltyProgram godoc
+// @Summary Save loyalty program
+// @Description Replace the tenant's loyalty rules, including all category multipliers and tiers. Balances are kept.
+// @Tags loyalty
+// @Accept json
+// @Produce json
+// @Param request body dto.SaveLoyaltyProgramRequest true "Loyalty program"
+// @Success 200 {object} dto.LoyaltyProgramResponse
+// @Router /api/loyalty/program [put]
+func (h *LoyaltyHandler) SaveLoyaltyProgram(c *gin.Context) {
+	var req dto.SaveLoyaltyProgramRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	program, err := h.loyaltyService.SaveProgram(tenantID, req, &currentUserID)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Loyalty program saved successfully", buildLoyaltyProgramResponse(program))
+}
+
+// LookupLoyaltyMember godoc
+// @Summary Find loyalty member by phone
+// @Description Identify a member at the till by phone number to attach them to an order
+// @Tags loyalty
+// @Produce json
+// @Param phone query string true "Member phone number"
+// @Success 200 {object} dto.CustomerResponse
+// @Router /api/loyalty/members [get]
+func (h *LoyaltyHandler) LookupLoyaltyMember(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	phone := c.Query("phone")
+	if phone == "" {
+		utils.BadRequest(c, "phone query parameter is required")
+		return
+	}
+
+	customer, err := h.loyaltyService.FindMemberByPhone(tenantID, phone)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Loyalty member retrieved successfully", buildCustomerResponse(customer))
+}
+
+// GetCustomerLoyalty godoc
+// @Summary Get customer loyalty balance and history
+// @Description Get the member's point balance, tier and a page of their loyalty ledger, newest first
+// @Tags loyalty
+// @Produce json
+// @Param id path int true "Customer ID"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.CustomerLoyaltyResponse
+// @Router /api/customers/{id}/loyalty [get]
+func (h *LoyaltyHandler) GetCustomerLoyalty(c *gin.Context) {
+	customerID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid customer ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	customer, err := h.customerService.GetCustomer(uint(customerID), tenantID)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	program, err := h.loyaltyService.GetProgram(tenantID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	transactions, total, err := h.loyaltyService.ListTransactions(customer.ID, tenantID, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.LoyaltyTransactionResponse, len(transactions))
+	for i := range transactions {
+		responses[i] = buildLoyaltyTransactionResponse(&transactions[i])
+	}
+
+	var pointsValue money.Amount
+	if customer.LoyaltyPoints > 0 {
+		pointsValue = program.Program.RedeemValue.Mul(customer.LoyaltyPoints)
+	}
+
+	utils.Success(c, "Customer loyalty retrieved successfully", dto.CustomerLoyaltyResponse{
+		CustomerID:     customer.ID,
+		Name:           customer.Name,
+		Phone:          customer.Phone,
+		Points:         customer.LoyaltyPoints,
+		PointsValue:    pointsValue,
+		LifetimePoints: customer.LifetimePoints,
+		Tier:           customer.LoyaltyTier,
+		Page:           pagination.Page,
+		PageSize:       pagination.PageSize,
+		TotalItems:     total,
+		TotalPages:     (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		Transactions:   responses,
+	})
+}
+
+func buildLoyaltyProgramResponse(config *services.LoyaltyProgramConfig) dto.LoyaltyProgramResponse {
+	response := dto.LoyaltyProgramResponse{
+		TenantID:            config.Program.TenantID,
+		IsActive:            config.Program.IsActive,
+		PointsPerUnit:       config.Program.PointsPerUnit,
+		RedeemValue:         config.Program.RedeemValue,
+		MinRedeemPoints:     config.Program.MinRedeemPoints,
+		ExpiryDays:          config.Program.ExpiryDays,
+		CategoryMultipliers: make([]dto.LoyaltyCategoryMultiplierRequest, len(config.CategoryMultipliers)),
+		Tiers:               make([]dto.LoyaltyTierRequest, len(config.Tiers)),
+		UpdatedBy:           config.Program.UpdatedBy,
+	}
+	for i, m := range config.CategoryMultipliers {
+		response.CategoryMultipliers[i] = dto.LoyaltyCategoryMultiplierRequest{CategoryID: m.CategoryID, Multiplier: m.Multiplier}
+	}
+	for i, t := range config.Tiers {
+		response.Tiers[i] = dto.LoyaltyTierRequest{Name: t.Name, MinPoints: t.MinPoints, EarnMultiplier: t.EarnMultiplier}
+	}
+	if config.Program.ID != 0 {
+		updatedAt := config.Program.UpdatedAt.Format("2006-01-02 15:04:05")
+		response.UpdatedAt = &updatedAt
+	}
+	return response
+}
+
+func buildLoyaltyTransactionResponse(entry *models.LoyaltyTransaction) dto.LoyaltyTransactionResponse {
+	var expiresAt *string
+	if entry.ExpiresAt != nil {
+		formatted := entry.ExpiresAt.Format("2006-01-02 15:04:05")
+		expiresAt = &formatted
+	}
+	return dto.LoyaltyTransactionResponse{
+		ID:           entry.ID,
+		Type:         entry.Type,
+		Points:       entry.Points,
+		BalanceAfter: entry.BalanceAfter,
+		BranchID:     entry.BranchID,
+		OrderID:      entry.OrderID,
+		PaymentID:    entry.PaymentID,
+		RefundID:     entry.RefundID,
+		ExpiresAt:    expiresAt,
+		Description:  entry.Description,
+		CreatedAt:    entry.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:    entry.CreatedBy,
+	}
+}
@@ -1,8 +1,10 @@
 package handlers
 
 import (
+	"errors"
 	"myposcore/config"
 	"myposcore/dto"
+	"myposcore/models"
 	"myposcore/services"
 	"myposcore/utils"
 	"strconv"
@@ -30,7 +32,11 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	}
 
 	tenantID := c.GetUint("tenant_id")
-	branchID := c.GetUint("branch_id")
+	branchID, err := requiredStockBranchID(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
 	userID := c.GetUint("user_id")
 
 	// Convert items to service format
@@ -48,40 +54,17 @@ func (h *OrderHandler) CreateOrder(c *gin.Context) {
 	currentUserID := c.GetUint("user_id")
 	req.CreatedBy = &currentUserID
 
-	order, err := h.orderService.CreateOrder(tenantID, branchID, userID, req.CreatedBy, items)
+	order, err := h.orderService.CreateOrder(tenantID, branchID, userID, req.CreatedBy, items, req.CouponCode, req.CustomerID, req.MemberPhone)
 	if err != nil {
+		if err.Error() == "branch not found or doesn't belong to this tenant" {
+			utils.NotFound(c, err.Error())
+			return
+		}
 		utils.InternalError(c, err.Error())
 		return
 	}
 
-	// Build response
-	response := dto.OrderResponse{
-		ID:          order.ID,
-		TenantID:    order.TenantID,
-		BranchID:    order.BranchID,
-		UserID:      order.UserID,
-		OrderNumber: order.OrderNumber,
-		TotalAmount: order.TotalAmount,
-		Status:      order.Status,
-		Notes:       order.Notes,
-		CreatedAt:   order.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:   order.UpdatedAt.Format("2006-01-02 15:04:05"),
-	}
-
-	// Add order items
-	orderItems := make([]dto.OrderItemResponse, len(order.OrderItems))
-	for i, item := range order.OrderItems {
-		orderItems[i] = dto.OrderItemResponse{
-			ID:          item.ID,
-			ProductID:   item.ProductID,
-			ProductName: item.Product.Name,
-			ProductSKU:  item.Product.SKU,
-			Quantity:    item.Quantity,
-			Price:       item.Price,
-			Subtotal:    item.Subtotal,
-		}
-	}
-	response.OrderItems = orderItems
+	response := buildOrderResponse(order)
 
 	utils.Success(c, "Success", response)
 }
@@ -101,127 +84,361 @@ func (h *OrderHandler) GetOrder(c *gin.Context) {
 		return
 	}
 
-	var createdByName, updatedByName *string
-	if order.Creator != nil {
-		name := order.Creator.FullName
-		createdByName = &name
+	response := buildOrderResponse(order)
+
+	utils.Success(c, "Success", response)
+}
+
+func (h *OrderHandler) ListOrders(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+
+	filter, err := parseListFilter(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
 	}
-	if order.Updater != nil {
-		name := order.Updater.FullName
-		updatedByName = &name
+
+	orders, total, err := h.orderService.ListOrders(tenantID, branchID, filter)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidListFilter) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
 	}
 
 	// Build response
-	response := dto.OrderResponse{
-		ID:            order.ID,
-		TenantID:      order.TenantID,
-		BranchID:      order.BranchID,
-		UserID:        order.UserID,
-		OrderNumber:   order.OrderNumber,
-		TotalAmount:   order.TotalAmount,
-		Status:        order.Status,
-		Notes:         order.Notes,
-		CreatedAt:     order.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:     order.UpdatedAt.Format("2006-01-02 15:04:05"),
-		CreatedBy:     order.CreatedBy,
-		CreatedByName: createdByName,
-		UpdatedBy:     order.UpdatedBy,
-		UpdatedByName: updatedByName,
+	responses := make([]dto.OrderResponse, len(orders))
+	for i := range orders {
+		responses[i] = buildOrderResponse(&orders[i])
 	}
 
-	// Add order items
-	orderItems := make([]dto.OrderItemResponse, len(order.OrderItems))
-	for i, item := range order.OrderItems {
-		orderItems[i] = dto.OrderItemResponse{
-			ID:          item.ID,
-			ProductID:   item.ProductID,
-			ProductName: item.Product.Name,
-			ProductSKU:  item.Product.SKU,
-			Quantity:    item.Quantity,
-			Price:       item.Price,
-			Subtotal:    item.Subtotal,
-		}
+	totalPages := (int(total) + filter.PerPage - 1) / filter.PerPage
+	utils.Success(c, "Orders retrieved successfully", gin.H{
+		"items": responses,
+		"pagination": gin.H{
+			"page":        filter.Page,
+			"per_page":    filter.PerPage,
+			"total":       total,
+			"total_pages": totalPages,
+		},
+	})
+}
+
+func (h *OrderHandler) UpdateOrderStatus(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
 	}
-	response.OrderItems = orderItems
 
-	utils.Success(c, "Success", response)
-}
+	var req dto.UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
 
-func (h *OrderHandler) ListOrders(c *gin.Context) {
 	tenantID := c.GetUint("tenant_id")
-	branchID := c.GetUint("branch_id")
-
-	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "32"))
+	currentUserID := c.GetUint("user_id")
 
-	if page < 1 {
-		page = 1
+	order, err := h.orderService.UpdateOrderStatus(uint(orderID), tenantID, req.Status, req.Reason, req.Approval, &currentUserID)
+	if err != nil {
+		if err.Error() == "order not found" {
+			utils.NotFound(c, "Order not found")
+			return
+		}
+		if isApprovalError(err) {
+			utils.Forbidden(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
 	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 32
+
+	utils.Success(c, "Order status updated successfully", buildOrderResponse(order))
+}
+
+func (h *OrderHandler) GetOrderStatusHistory(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
 	}
 
-	orders, total, err := h.orderService.ListOrders(tenantID, branchID, page, perPage)
+	tenantID := c.GetUint("tenant_id")
+
+	history, err := h.orderService.GetOrderStatusHistory(uint(orderID), tenantID)
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
 	}
 
-	// Build response
-	responses := make([]dto.OrderResponse, len(orders))
-	for i, order := range orders {
-		var createdByName, updatedByName *string
-		if order.Creator != nil {
-			name := order.Creator.FullName
-			createdByName = &name
+	responses := make([]dto.OrderStatusHistoryResponse, len(history))
+	for i, entry := range history {
+		var changedByName *string
+		if entry.Changer != nil {
+			name := entry.Changer.FullName
+			changedByName = &name
 		}
-		if order.Updater != nil {
-			name := order.Updater.FullName
-			updatedByName = &name
+		responses[i] = dto.OrderStatusHistoryResponse{
+			ID:            entry.ID,
+			OrderID:       entry.OrderID,
+			FromStatus:    entry.FromStatus,
+			ToStatus:      entry.ToStatus,
+			Reason:        entry.Reason,
+			ChangedBy:     entry.ChangedBy,
+			ChangedByName: changedByName,
+			CreatedAt:     entry.CreatedAt.Format("2006-01-02 15:04:05"),
 		}
+	}
+
+	utils.Success(c, "Success", responses)
+}
+
+func (h *OrderHandler) AddOrderItem(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
+	}
+
+	var req dto.AddOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
 
-		responses[i] = dto.OrderResponse{
-			ID:            order.ID,
-			TenantID:      order.TenantID,
-			BranchID:      order.BranchID,
-			UserID:        order.UserID,
-			OrderNumber:   order.OrderNumber,
-			TotalAmount:   order.TotalAmount,
-			Status:        order.Status,
-			Notes:         order.Notes,
-			CreatedAt:     order.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt:     order.UpdatedAt.Format("2006-01-02 15:04:05"),
-			CreatedBy:     order.CreatedBy,
-			CreatedByName: createdByName,
-			UpdatedBy:     order.UpdatedBy,
-			UpdatedByName: updatedByName,
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	order, err := h.orderService.AddOrderItem(uint(orderID), tenantID, req.ProductID, req.Quantity, req.Version, &currentUserID)
+	if err != nil {
+		respondOrderItemError(c, err)
+		return
+	}
+
+	utils.Success(c, "Order item added successfully", buildOrderResponse(order))
+}
+
+func (h *OrderHandler) UpdateOrderItem(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order item ID")
+		return
+	}
+
+	var req dto.UpdateOrderItemRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	order, err := h.orderService.UpdateOrderItemQuantity(uint(orderID), tenantID, uint(itemID), req.Quantity, req.Version, req.Approval, &currentUserID)
+	if err != nil {
+		respondOrderItemError(c, err)
+		return
+	}
+
+	utils.Success(c, "Order item updated successfully", buildOrderResponse(order))
+}
+
+func (h *OrderHandler) RemoveOrderItem(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
+	}
+	itemID, err := strconv.ParseUint(c.Param("item_id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order item ID")
+		return
+	}
+	version, err := strconv.Atoi(c.Query("version"))
+	if err != nil {
+		utils.BadRequest(c, "version query parameter is required")
+		return
+	}
+
+	// The supervisor approval travels in the request body
+	var req dto.RemoveOrderItemRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
 		}
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	order, err := h.orderService.RemoveOrderItem(uint(orderID), tenantID, uint(itemID), version, req.Approval, &currentUserID)
+	if err != nil {
+		respondOrderItemError(c, err)
+		return
+	}
+
+	utils.Success(c, "Order item removed successfully", buildOrderResponse(order))
+}
+
+func (h *OrderHandler) ApplyManualDiscount(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
+	}
+
+	var req dto.ApplyManualDiscountRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	order, err := h.orderService.ApplyManualDiscount(uint(orderID), tenantID, req, &currentUserID)
+	if err != nil {
+		respondOrderItemError(c, err)
+		return
+	}
+
+	utils.Success(c, "Manual discount applied successfully", buildOrderResponse(order))
+}
+
+// respondOrderItemError maps errors of the order item endpoints to HTTP responses
+func respondOrderItemError(c *gin.Context, err error) {
+	switch {
+	case errors.Is(err, services.ErrOrderVersionConflict):
+		utils.Conflict(c, err.Error())
+	case isApprovalError(err):
+		utils.Forbidden(c, err.Error())
+	case err.Error() == "order not found", err.Error() == "order item not found":
+		utils.NotFound(c, err.Error())
+	default:
+		utils.BadRequest(c, err.Error())
+	}
+}
+
+// isApprovalError reports whether err is a missing or rejected supervisor approval
+func isApprovalError(err error) bool {
+	return errors.Is(err, services.ErrApprovalRequired) || errors.Is(err, services.ErrApprovalDenied)
+}
+
+// buildOrderResponse converts an order with its preloaded items and audit users into the API response
+func buildOrderResponse(order *models.Order) dto.OrderResponse {
+	var createdByName, updatedByName *string
+	if order.Creator != nil {
+		name := order.Creator.FullName
+		createdByName = &name
+	}
+	if order.Updater != nil {
+		name := order.Updater.FullName
+		updatedByName = &name
+	}
 
-		// Add order items
-		orderItems := make([]dto.OrderItemResponse, len(order.OrderItems))
-		for j, item := range order.OrderItems {
-			orderItems[j] = dto.OrderItemResponse{
-				ID:          item.ID,
-				ProductID:   item.ProductID,
-				ProductName: item.Product.Name,
-				ProductSKU:  item.Product.SKU,
-				Quantity:    item.Quantity,
-				Price:       item.Price,
-				Subtotal:    item.Subtotal,
-			}
+	response := dto.OrderResponse{
+		ID:             order.ID,
+		TenantID:       order.TenantID,
+		BranchID:       order.BranchID,
+		UserID:         order.UserID,
+		CustomerID:     order.CustomerID,
+		OrderNumber:    order.OrderNumber,
+		GrossAmount:    order.GrossAmount,
+		DiscountAmount: order.DiscountAmount,
+		SubtotalAmount: order.SubtotalAmount,
+		ServiceCharge:  order.ServiceChargeAmount,
+		TaxAmount:      order.TaxAmount,
+		InclusiveTax:   order.InclusiveTaxAmount,
+		TotalAmount:    order.TotalAmount,
+		PaidAmount:     order.PaidAmount,
+		BalanceDue:     order.TotalAmount - order.PaidAmount,
+		RefundedAmount: order.RefundedAmount,
+		CouponCode:     order.CouponCode,
+		Status:         order.Status,
+		Notes:          order.Notes,
+		Version:        order.Version,
+		CreatedAt:      order.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      order.UpdatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:      order.CreatedBy,
+		CreatedByName:  createdByName,
+		UpdatedBy:      order.UpdatedBy,
+		UpdatedByName:  updatedByName,
+	}
+
+	if response.BalanceDue < 0 {
+		response.BalanceDue = 0
+	}
+
+	if order.ManualDiscountType != "" {
+		response.ManualDiscount = &dto.OrderManualDiscount{
+			Type:       order.ManualDiscountType,
+			Value:      order.ManualDiscountValue,
+			Amount:     order.ManualDiscountAmount,
+			Reason:     order.ManualDiscountReason,
+			ApprovedBy: order.ManualDiscountApprovedBy,
 		}
-		responses[i].OrderItems = orderItems
 	}
 
-	totalPages := (int(total) + perPage - 1) / perPage
-	utils.Success(c, "Orders retrieved successfully", gin.H{
-		"items": responses,
-		"pagination": gin.H{
-			"page":        page,
-			"per_page":    perPage,
-			"total":       total,
-			"total_pages": totalPages,
-		},
-	})
+	// Add order items
+	orderItems := make([]dto.OrderItemResponse, len(order.OrderItems))
+	for i := range order.OrderItems {
+		orderItems[i] = buildOrderItemResponse(&order.OrderItems[i])
+	}
+	response.OrderItems = orderItems
+
+	// Add applied promotions
+	for _, promo := range order.Promotions {
+		response.Promotions = append(response.Promotions, dto.OrderPromotionResponse{
+			PromotionID:    promo.PromotionID,
+			PromotionName:  promo.PromotionName,
+			CouponCode:     promo.CouponCode,
+			DiscountAmount: promo.DiscountAmount,
+		})
+	}
+
+	response.Taxes = buildOrderTaxResponses(order.Taxes)
+
+	return response
+}
+
+func buildOrderItemResponse(item *models.OrderItem) dto.OrderItemResponse {
+	return dto.OrderItemResponse{
+		ID:             item.ID,
+		ProductID:      item.ProductID,
+		ProductName:    item.Product.Name,
+		ProductSKU:     item.Product.SKU,
+		Quantity:       item.Quantity,
+		Price:          item.Price,
+		Subtotal:       item.Subtotal,
+		DiscountAmount: item.DiscountAmount,
+		NetAmount:      item.NetAmount,
+		PromotionID:    item.PromotionID,
+		KitchenStatus:  item.KitchenStatus,
+	}
+}
+
+func buildOrderTaxResponses(taxes []models.OrderTax) []dto.OrderTaxResponse {
+	var responses []dto.OrderTaxResponse
+	for _, tax := range taxes {
+		responses = append(responses, dto.OrderTaxResponse{
+			TaxRuleID:     tax.TaxRuleID,
+			Name:          tax.Name,
+			Type:          tax.Type,
+			Rate:          tax.Rate,
+			IsInclusive:   tax.IsInclusive,
+			TaxableAmount: tax.TaxableAmount,
+			Amount:        tax.Amount,
+		})
+	}
+	return responses
 }
@@ -0,0 +1,141 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrderNumberHandler struct {
+	BaseHandler
+	orderNumberService *services.OrderNumberService
+}
+
+func NewOrderNumberHandler(cfg *config.Config, orderNumberService *services.OrderNumberService) *OrderNumberHandler {
+	return &OrderNumberHandler{
+		BaseHandler:        BaseHandler{config: cfg},
+		orderNumberService: orderNumberService,
+	}
+}
+
+// GetOrderNumberFormat godoc
+// @Summary Get order number format
+// @Description Get the order number format used by a branch (its own, the tenant wide one or the default)
+// @Tags order-numbers
+// @Produce json
+// @Param branch_id query int false "Branch ID, defaults to the current branch"
+// @Success 200 {object} dto.OrderNumberFormatResponse
+// @Router /api/order-numbers/format [get]
+func (h *OrderNumberHandler) GetOrderNumberFormat(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+
+	format, err := h.orderNumberService.GetFormat(tenantID, branchID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Order number format retrieved successfully", buildOrderNumberFormatResponse(format, branchID))
+}
+
+// SaveOrderNumberFormat godoc
+// @Summary Save order number format
+// @Description Create or update the order number format of the tenant, or of a single branch when branch_id is set
+// @Tags order-numbers
+// @Accept json
+// @Produce json
+// @Param request body dto.SaveOrderNumberFormatRequest true "Order number format"
+// @Success 200 {object} dto.OrderNumberFormatResponse
+// @Router /api/order-numbers/format [put]
+func (h *OrderNumberHandler) SaveOrderNumberFormat(c *gin.Context) {
+	var req dto.SaveOrderNumberFormatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.UpdatedBy = &currentUserID
+
+	format, err := h.orderNumberService.SaveFormat(tenantID, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	branchID := c.GetUint("branch_id")
+	if format.BranchID != nil {
+		branchID = *format.BranchID
+	}
+
+	utils.Success(c, "Order number format saved successfully", buildOrderNumberFormatResponse(format, branchID))
+}
+
+// ReserveOrderNumbers godoc
+// @Summary Reserve a block of order numbers
+// @Description Reserve consecutive order numbers of the current branch for an offline client. Orders synced with these numbers keep them
+// @Tags order-numbers
+// @Accept json
+// @Produce json
+// @Param request body dto.ReserveOrderNumbersRequest true "Reservation request"
+// @Success 200 {object} dto.OrderNumberReservationResponse
+// @Router /api/order-numbers/reservations [post]
+func (h *OrderNumberHandler) ReserveOrderNumbers(c *gin.Context) {
+	var req dto.ReserveOrderNumbersRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	currentUserID := c.GetUint("user_id")
+	req.CreatedBy = &currentUserID
+
+	reservation, numbers, err := h.orderNumberService.ReserveBlock(tenantID, branchID, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Order numbers reserved successfully", dto.OrderNumberReservationResponse{
+		ID:           reservation.ID,
+		BranchID:     reservation.BranchID,
+		ClientID:     reservation.ClientID,
+		SequenceDate: reservation.SequenceDate,
+		StartNumber:  reservation.StartNumber,
+		EndNumber:    reservation.EndNumber,
+		OrderNumbers: numbers,
+		CreatedAt:    reservation.CreatedAt.Format("2006-01-02 15:04:05"),
+	})
+}
+
+func buildOrderNumberFormatResponse(format *models.OrderNumberFormat, branchID uint) dto.OrderNumberFormatResponse {
+	return dto.OrderNumberFormatResponse{
+		ID:         format.ID,
+		TenantID:   format.TenantID,
+		BranchID:   format.BranchID,
+		Prefix:     format.Prefix,
+		BranchCode: format.BranchCode,
+		DateFormat: format.DateFormat,
+		Padding:    format.Padding,
+		Separator:  format.Separator,
+		Example:    services.FormatOrderNumber(format, branchID, time.Now(), 1),
+	}
+}
@@ -1,6 +1,7 @@
 package handlers
 
 import (
+	"errors"
 	"myposcore/config"
 	"myposcore/dto"
 	"myposcore/services"
@@ -36,9 +37,13 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 	currentUserID := c.GetUint("user_id")
 	req.CreatedBy = &currentUserID
 
-	payment, err := h.paymentService.CreatePayment(req.OrderID, req.Amount, req.PaymentMethod, req.Notes, tenantID, branchID, req.CreatedBy)
+	payment, err := h.paymentService.CreatePayment(req.OrderID, req.Amount, req.PaymentMethod, req.Notes, req.GiftCardCode, req.PaymentTipRequest, tenantID, branchID, req.CreatedBy)
 	if err != nil {
-		utils.InternalError(c, err.Error())
+		if err.Error() == "order not found" || err.Error() == "gift card not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
 		return
 	}
 
@@ -60,29 +65,42 @@ func (h *PaymentHandler) CreatePayment(c *gin.Context) {
 		}
 	}
 
-	// Calculate change
-	change := req.Amount - order.TotalAmount
-	if change < 0 {
-		change = 0
+	balanceDue := order.TotalAmount - order.PaidAmount
+	if balanceDue < 0 {
+		balanceDue = 0
 	}
 
 	response := dto.PaymentDetailResponse{
-		ID:            payment.ID,
-		OrderID:       payment.OrderID,
-		OrderNumber:   order.OrderNumber,
-		Amount:        payment.Amount,
-		PaymentMethod: payment.PaymentMethod,
-		Status:        payment.Status,
-		Notes:         payment.Notes,
-		Change:        change,
-		CreatedAt:     payment.CreatedAt.Format("2006-01-02 15:04:05"),
+		ID:             payment.ID,
+		OrderID:        payment.OrderID,
+		OrderNumber:    order.OrderNumber,
+		Amount:         payment.Amount,
+		RefundedAmount: payment.RefundedAmount,
+		PaymentMethod:  payment.PaymentMethod,
+		ShiftID:        payment.ShiftID,
+		Status:         payment.Status,
+		Notes:          payment.Notes,
+		TenderedAmount: payment.TenderedAmount,
+		Change:         payment.ChangeAmount,
+		RoundingAmount: payment.RoundingAmount,
+		TipAmount:      payment.TipAmount,
+		TipUserID:      payment.TipUserID,
+		PaidAmount:     order.PaidAmount,
+		BalanceDue:     balanceDue,
+		CreatedAt:      payment.CreatedAt.Format("2006-01-02 15:04:05"),
+		Provider:       buildPaymentProviderDetail(payment),
 		Order: dto.PaymentOrderDetail{
-			TotalAmount: order.TotalAmount,
-			Status:      order.Status,
-			Notes:       order.Notes,
-			OrderItems:  orderItems,
-			CashierName: order.User.FullName,
-			BranchName:  order.Branch.Name,
+			SubtotalAmount: order.SubtotalAmount,
+			ServiceCharge:  order.ServiceChargeAmount,
+			TaxAmount:      order.TaxAmount,
+			InclusiveTax:   order.InclusiveTaxAmount,
+			Taxes:          buildOrderTaxResponses(order.Taxes),
+			TotalAmount:    order.TotalAmount,
+			Status:         order.Status,
+			Notes:          order.Notes,
+			OrderItems:     orderItems,
+			CashierName:    order.User.FullName,
+			BranchName:     order.Branch.Name,
 		},
 	}
 
@@ -115,18 +133,26 @@ func (h *PaymentHandler) GetPayment(c *gin.Context) {
 	}
 
 	response := dto.PaymentResponse{
-		ID:            payment.ID,
-		OrderID:       payment.OrderID,
-		Amount:        payment.Amount,
-		PaymentMethod: payment.PaymentMethod,
-		Status:        payment.Status,
-		Notes:         payment.Notes,
-		CreatedAt:     payment.CreatedAt.Format("2006-01-02 15:04:05"),
-		UpdatedAt:     payment.UpdatedAt.Format("2006-01-02 15:04:05"),
-		CreatedBy:     payment.CreatedBy,
-		CreatedByName: createdByName,
-		UpdatedBy:     payment.UpdatedBy,
-		UpdatedByName: updatedByName,
+		ID:             payment.ID,
+		OrderID:        payment.OrderID,
+		Amount:         payment.Amount,
+		RefundedAmount: payment.RefundedAmount,
+		TenderedAmount: payment.TenderedAmount,
+		ChangeAmount:   payment.ChangeAmount,
+		RoundingAmount: payment.RoundingAmount,
+		TipAmount:      payment.TipAmount,
+		TipUserID:      payment.TipUserID,
+		PaymentMethod:  payment.PaymentMethod,
+		ShiftID:        payment.ShiftID,
+		Status:         payment.Status,
+		Notes:          payment.Notes,
+		CreatedAt:      payment.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      payment.UpdatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:      payment.CreatedBy,
+		CreatedByName:  createdByName,
+		UpdatedBy:      payment.UpdatedBy,
+		UpdatedByName:  updatedByName,
+		Provider:       buildPaymentProviderDetail(payment),
 	}
 
 	utils.Success(c, "Success", response)
@@ -141,8 +167,12 @@ func (h *PaymentHandler) GetPaymentsByOrder(c *gin.Context) {
 
 	tenantID := c.GetUint("tenant_id")
 
-	payments, err := h.paymentService.GetPaymentsByOrder(uint(orderID), tenantID)
+	payments, order, err := h.paymentService.GetPaymentsByOrder(uint(orderID), tenantID)
 	if err != nil {
+		if err.Error() == "order not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
 		utils.InternalError(c, err.Error())
 		return
 	}
@@ -150,37 +180,57 @@ func (h *PaymentHandler) GetPaymentsByOrder(c *gin.Context) {
 	responses := make([]dto.PaymentResponse, len(payments))
 	for i, payment := range payments {
 		responses[i] = dto.PaymentResponse{
-			ID:            payment.ID,
-			OrderID:       payment.OrderID,
-			Amount:        payment.Amount,
-			PaymentMethod: payment.PaymentMethod,
-			Status:        payment.Status,
-			Notes:         payment.Notes,
-			CreatedAt:     payment.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt:     payment.UpdatedAt.Format("2006-01-02 15:04:05"),
+			ID:             payment.ID,
+			OrderID:        payment.OrderID,
+			Amount:         payment.Amount,
+			RefundedAmount: payment.RefundedAmount,
+			TenderedAmount: payment.TenderedAmount,
+			ChangeAmount:   payment.ChangeAmount,
+			RoundingAmount: payment.RoundingAmount,
+			TipAmount:      payment.TipAmount,
+			TipUserID:      payment.TipUserID,
+			PaymentMethod:  payment.PaymentMethod,
+			ShiftID:        payment.ShiftID,
+			Status:         payment.Status,
+			Notes:          payment.Notes,
+			CreatedAt:      payment.CreatedAt.Format("2006-01-02 15:04:05"),
+			UpdatedAt:      payment.UpdatedAt.Format("2006-01-02 15:04:05"),
+			Provider:       buildPaymentProviderDetail(&payments[i]),
 		}
 	}
 
-	utils.Success(c, "Success", responses)
+	balanceDue := order.TotalAmount - order.PaidAmount
+	if balanceDue < 0 {
+		balanceDue = 0
+	}
+
+	utils.Success(c, "Success", dto.OrderPaymentsResponse{
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		Status:      order.Status,
+		TotalAmount: order.TotalAmount,
+		PaidAmount:  order.PaidAmount,
+		BalanceDue:  balanceDue,
+		Payments:    responses,
+	})
 }
 
 func (h *PaymentHandler) ListPayments(c *gin.Context) {
 	tenantID := c.GetUint("tenant_id")
 	branchID := c.GetUint("branch_id")
 
-	// Parse pagination parameters
-	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
-	perPage, _ := strconv.Atoi(c.DefaultQuery("per_page", "32"))
-
-	if page < 1 {
-		page = 1
-	}
-	if perPage < 1 || perPage > 100 {
-		perPage = 32
+	filter, err := parseListFilter(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
 	}
 
-	payments, total, err := h.paymentService.ListPayments(tenantID, branchID, page, perPage)
+	payments, total, err := h.paymentService.ListPayments(tenantID, branchID, filter)
 	if err != nil {
+		if errors.Is(err, services.ErrInvalidListFilter) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
 		utils.InternalError(c, err.Error())
 		return
 	}
@@ -198,27 +248,35 @@ func (h *PaymentHandler) ListPayments(c *gin.Context) {
 		}
 
 		responses[i] = dto.PaymentResponse{
-			ID:            payment.ID,
-			OrderID:       payment.OrderID,
-			Amount:        payment.Amount,
-			PaymentMethod: payment.PaymentMethod,
-			Status:        payment.Status,
-			Notes:         payment.Notes,
-			CreatedAt:     payment.CreatedAt.Format("2006-01-02 15:04:05"),
-			UpdatedAt:     payment.UpdatedAt.Format("2006-01-02 15:04:05"),
-			CreatedBy:     payment.CreatedBy,
-			CreatedByName: createdByName,
-			UpdatedBy:     payment.UpdatedBy,
-			UpdatedByName: updatedByName,
+			ID:             payment.ID,
+			OrderID:        payment.OrderID,
+			Amount:         payment.Amount,
+			RefundedAmount: payment.RefundedAmount,
+			TenderedAmount: payment.TenderedAmount,
+			ChangeAmount:   payment.ChangeAmount,
+			RoundingAmount: payment.RoundingAmount,
+			TipAmount:      payment.TipAmount,
+			TipUserID:      payment.TipUserID,
+			PaymentMethod:  payment.PaymentMethod,
+			ShiftID:        payment.ShiftID,
+			Status:         payment.Status,
+			Notes:          payment.Notes,
+			CreatedAt:      payment.CreatedAt.Format("2006-01-02 15:04:05"),
+			UpdatedAt:      payment.UpdatedAt.Format("2006-01-02 15:04:05"),
+			CreatedBy:      payment.CreatedBy,
+			CreatedByName:  createdByName,
+			UpdatedBy:      payment.UpdatedBy,
+			UpdatedByName:  updatedByName,
+			Provider:       buildPaymentProviderDetail(&payments[i]),
 		}
 	}
 
-	totalPages := (int(total) + perPage - 1) / perPage
+	totalPages := (int(total) + filter.PerPage - 1) / filter.PerPage
 	utils.Success(c, "Payments retrieved successfully", gin.H{
 		"items": responses,
 		"pagination": gin.H{
-			"page":        page,
-			"per_page":    perPage,
+			"page":        filter.Page,
+			"per_page":    filter.PerPage,
 			"total":       total,
 			"total_pages": totalPages,
 		},
@@ -251,3 +309,53 @@ func (h *PaymentHandler) GetPaymentPerformance(c *gin.Context) {
 
 	utils.Success(c, "Success", performance)
 }
+
+// RefreshPaymentStatus godoc
+// @Summary Refresh provider payment status
+// @Description Ask the payment provider for the status of a pending payment, for when its callback is late or lost
+// @Tags payments
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Success 200 {object} dto.PaymentResponse
+// @Router /api/payments/{id}/refresh-status [post]
+func (h *PaymentHandler) RefreshPaymentStatus(c *gin.Context) {
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid payment ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	payment, err := h.paymentService.RefreshPaymentStatus(uint(paymentID), tenantID, &currentUserID)
+	if err != nil {
+		if err.Error() == "payment not found" {
+			utils.NotFound(c, "Payment not found")
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Payment status refreshed successfully", dto.PaymentResponse{
+		ID:             payment.ID,
+		OrderID:        payment.OrderID,
+		Amount:         payment.Amount,
+		RefundedAmount: payment.RefundedAmount,
+		TenderedAmount: payment.TenderedAmount,
+		ChangeAmount:   payment.ChangeAmount,
+		RoundingAmount: payment.RoundingAmount,
+		TipAmount:      payment.TipAmount,
+		TipUserID:      payment.TipUserID,
+		PaymentMethod:  payment.PaymentMethod,
+		ShiftID:        payment.ShiftID,
+		Status:         payment.Status,
+		Notes:          payment.Notes,
+		CreatedAt:      payment.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      payment.UpdatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:      payment.CreatedBy,
+		UpdatedBy:      payment.UpdatedBy,
+		Provider:       buildPaymentProviderDetail(payment),
+	})
+}
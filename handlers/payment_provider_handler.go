@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentProviderHandler struct {
+	BaseHandler
+	paymentService *services.PaymentService
+	mockProvider   *services.MockPaymentProvider
+}
+
+func NewPaymentProviderHandler(cfg *config.Config, paymentService *services.PaymentService, mockProvider *services.MockPaymentProvider) *PaymentProviderHandler {
+	return &PaymentProviderHandler{
+		BaseHandler:    BaseHandler{config: cfg},
+		paymentService: paymentService,
+		mockProvider:   mockProvider,
+	}
+}
+
+// HandleWebhook godoc
+// @Summary Payment provider webhook
+// @Description Receives a provider's signed status callback and completes or fails the matching pending payment
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param provider path string true "Provider name, e.g. mock"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/payments/webhooks/{provider} [post]
+func (h *PaymentProviderHandler) HandleWebhook(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		utils.BadRequest(c, "Failed to read request body")
+		return
+	}
+
+	payment, err := h.paymentService.HandleProviderCallback(c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		switch {
+		case errors.Is(err, services.ErrPaymentProviderNotFound):
+			utils.NotFound(c, err.Error())
+		case errors.Is(err, services.ErrInvalidCallbackSignature):
+			utils.Unauthorized(c, err.Error())
+		case err.Error() == "payment not found":
+			utils.NotFound(c, err.Error())
+		default:
+			utils.BadRequest(c, err.Error())
+		}
+		return
+	}
+
+	utils.Success(c, "Callback processed successfully", gin.H{
+		"payment_id": payment.ID,
+		"status":     payment.Status,
+	})
+}
+
+// SimulateMockPayment godoc
+// @Summary Settle a mock provider payment
+// @Description Complete or fail a pending payment of the built-in mock provider. The mock signs a webhook
+// @Description exactly like a real gateway would and it is processed through the webhook path.
+// @Description Only registered when MOCK_PAYMENT_ENABLED=true.
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param request body dto.SimulateMockPaymentRequest true "Payment and outcome"
+// @Success 200 {object} dto.PaymentResponse
+// @Router /api/payments/providers/mock/simulate [post]
+func (h *PaymentProviderHandler) SimulateMockPayment(c *gin.Context) {
+	var req dto.SimulateMockPaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	payment, err := h.paymentService.GetPayment(req.PaymentID, tenantID)
+	if err != nil {
+		utils.NotFound(c, "Payment not found")
+		return
+	}
+	if payment.Provider != h.mockProvider.Name() {
+		utils.BadRequest(c, "Payment is not collected by the mock provider")
+		return
+	}
+
+	header, body, err := h.mockProvider.SimulateCallback(payment.ProviderReference, req.Status, req.FailureReason)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	payment, err = h.paymentService.HandleProviderCallback(h.mockProvider.Name(), header, body)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Mock payment settled successfully", dto.PaymentResponse{
+		ID:             payment.ID,
+		OrderID:        payment.OrderID,
+		Amount:         payment.Amount,
+		RefundedAmount: payment.RefundedAmount,
+		TenderedAmount: payment.TenderedAmount,
+		ChangeAmount:   payment.ChangeAmount,
+		RoundingAmount: payment.RoundingAmount,
+		TipAmount:      payment.TipAmount,
+		TipUserID:      payment.TipUserID,
+		PaymentMethod:  payment.PaymentMethod,
+		ShiftID:        payment.ShiftID,
+		Status:         payment.Status,
+		Notes:          payment.Notes,
+		CreatedAt:      payment.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:      payment.UpdatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:      payment.CreatedBy,
+		UpdatedBy:      payment.UpdatedBy,
+		Provider:       buildPaymentProviderDetail(payment),
+	})
+}
+
+func buildPaymentProviderDetail(payment *models.Payment) *dto.PaymentProviderDetail {
+	if payment.Provider == "" {
+		return nil
+	}
+	var expiresAt *string
+	if payment.ProviderExpiresAt != nil {
+		formatted := payment.ProviderExpiresAt.Format("2006-01-02 15:04:05")
+		expiresAt = &formatted
+	}
+	return &dto.PaymentProviderDetail{
+		Name:          payment.Provider,
+		Reference:     payment.ProviderReference,
+		ActionURL:     payment.ProviderActionURL,
+		QRString:      payment.ProviderQRString,
+		ExpiresAt:     expiresAt,
+		FailureReason: payment.FailureReason,
+	}
+}
@@ -6,6 +6,7 @@ import (
 	"myposcore/config"
 	"myposcore/dto"
 	"myposcore/models"
+	"myposcore/money"
 	"myposcore/services"
 	"myposcore/utils"
 	"net/http"
@@ -43,6 +44,25 @@ func mapCategoryToDTO(category *models.Category) *dto.CategorySummary {
 	}
 }
 
+// Helper function to map the stock at every branch to DTO, only when include_branches was asked for
+func mapBranchStocksToDTO(product *models.Product, includeBranches bool) (*int, []dto.ProductBranchStock) {
+	if !includeBranches {
+		return nil, nil
+	}
+	total := product.Stock
+	stocks := make([]dto.ProductBranchStock, len(product.BranchStocks))
+	for i, stock := range product.BranchStocks {
+		stocks[i] = dto.ProductBranchStock{
+			BranchID: stock.BranchID,
+			Stock:    stock.Quantity,
+		}
+		if stock.Branch != nil {
+			stocks[i].BranchName = stock.Branch.Name
+		}
+	}
+	return &total, stocks
+}
+
 // ListProducts godoc
 // @Summary List all products
 // @Description Get list of all products for the tenant with optional filters
@@ -50,6 +70,8 @@ func mapCategoryToDTO(category *models.Category) *dto.CategorySummary {
 // @Accept json
 // @Produce json
 // @Param search query string false "Search by name, description, or SKU"
+// @Param include_branches query bool false "Add the total stock and the stock at every branch"
+// @Param branch_id query int false "Branch whose stock is shown, for users without a branch"
 // @Param page query int false "Page number" default(1)
 // @Param page_size query int false "Items per page" default(32)
 // @Success 200 {object} dto.PaginationResponse
@@ -63,6 +85,7 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 
 	// Get query parameters
 	search := c.Query("search")
+	includeBranches := c.Query("include_branches") == "true"
 
 	// Parse pagination parameters
 	var pagination dto.PaginationRequest
@@ -72,7 +95,13 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
 	}
 
-	products, total, err := h.service.ListProducts(tenantID.(uint), search, pagination.Page, pagination.PageSize)
+	branchID, err := stockBranchID(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	products, total, err := h.service.ListProducts(tenantID.(uint), branchID, search, includeBranches, pagination.Page, pagination.PageSize)
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
@@ -89,6 +118,7 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 			name := product.Updater.FullName
 			updatedByName = &name
 		}
+		totalStock, branchStocks := mapBranchStocksToDTO(&product, includeBranches)
 
 		response = append(response, dto.ProductResponse{
 			ID:          product.ID,
@@ -100,7 +130,8 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 			CategoryDetail: mapCategoryToDTO(product.CategoryDetail),
 			SKU:            product.SKU,
 			Price:          product.Price,
-			Stock:          product.Stock,
+			CostPrice:      product.CostPrice,
+			Stock:          product.BranchStock,
 			Image:          utils.GetFullImageURL(product.Image),
 			IsActive:       product.IsActive,
 			CreatedAt:      product.CreatedAt.Format("2006-01-02 15:04:05"),
@@ -109,6 +140,15 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 			CreatedByName:  createdByName,
 			UpdatedBy:      product.UpdatedBy,
 			UpdatedByName:  updatedByName,
+
+			IsGiftCard:           product.IsGiftCard,
+			GiftCardValidityDays: product.GiftCardValidityDays,
+
+			MinStock:        product.MinStock,
+			ReorderQuantity: product.ReorderQuantity,
+
+			TotalStock:   totalStock,
+			BranchStocks: branchStocks,
 		})
 	}
 
@@ -131,6 +171,8 @@ func (h *ProductHandler) ListProducts(c *gin.Context) {
 // @Produce json
 // @Param category_id path int true "Category ID"
 // @Param search query string false "Search by name or SKU"
+// @Param include_branches query bool false "Add the total stock and the stock at every branch"
+// @Param branch_id query int false "Branch whose stock is shown, for users without a branch"
 // @Param page query int false "Page number (default: 1)"
 // @Param page_size query int false "Page size (default: 10)"
 // @Success 200 {object} dto.PaginationResponse
@@ -147,6 +189,7 @@ func (h *ProductHandler) ListProductsByCategoryID(c *gin.Context) {
 
 	// Get query parameters
 	search := c.Query("search")
+	includeBranches := c.Query("include_branches") == "true"
 
 	// Parse pagination parameters
 	var pagination dto.PaginationRequest
@@ -154,7 +197,13 @@ func (h *ProductHandler) ListProductsByCategoryID(c *gin.Context) {
 		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
 	}
 
-	products, total, err := h.service.ListProductsByCategoryID(tenantID.(uint), uint(categoryID), search, pagination.Page, pagination.PageSize)
+	branchID, err := stockBranchID(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	products, total, err := h.service.ListProductsByCategoryID(tenantID.(uint), uint(categoryID), branchID, search, includeBranches, pagination.Page, pagination.PageSize)
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
@@ -171,6 +220,7 @@ func (h *ProductHandler) ListProductsByCategoryID(c *gin.Context) {
 			name := product.Updater.FullName
 			updatedByName = &name
 		}
+		totalStock, branchStocks := mapBranchStocksToDTO(&product, includeBranches)
 
 		response = append(response, dto.ProductResponse{
 			ID:          product.ID,
@@ -182,7 +232,8 @@ func (h *ProductHandler) ListProductsByCategoryID(c *gin.Context) {
 			CategoryDetail: mapCategoryToDTO(product.CategoryDetail),
 			SKU:            product.SKU,
 			Price:          product.Price,
-			Stock:          product.Stock,
+			CostPrice:      product.CostPrice,
+			Stock:          product.BranchStock,
 			Image:          utils.GetFullImageURL(product.Image),
 			IsActive:       product.IsActive,
 			CreatedAt:      product.CreatedAt.Format("2006-01-02 15:04:05"),
@@ -191,6 +242,15 @@ func (h *ProductHandler) ListProductsByCategoryID(c *gin.Context) {
 			CreatedByName:  createdByName,
 			UpdatedBy:      product.UpdatedBy,
 			UpdatedByName:  updatedByName,
+
+			IsGiftCard:           product.IsGiftCard,
+			GiftCardValidityDays: product.GiftCardValidityDays,
+
+			MinStock:        product.MinStock,
+			ReorderQuantity: product.ReorderQuantity,
+
+			TotalStock:   totalStock,
+			BranchStocks: branchStocks,
 		})
 	}
 
@@ -207,11 +267,13 @@ func (h *ProductHandler) ListProductsByCategoryID(c *gin.Context) {
 
 // GetProduct godoc
 // @Summary Get product by ID
-// @Description Get product details by ID
+// @Description Get product details by ID, with its stock at the current branch
 // @Tags products
 // @Accept json
 // @Produce json
 // @Param id path int true "Product ID"
+// @Param include_branches query bool false "Add the total stock and the stock at every branch"
+// @Param branch_id query int false "Branch whose stock is shown, for users without a branch"
 // @Success 200 {object} dto.ProductResponse
 // @Router /api/products/{id} [get]
 func (h *ProductHandler) GetProduct(c *gin.Context) {
@@ -227,11 +289,19 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		return
 	}
 
-	product, err := h.service.GetProduct(uint(id), tenantID.(uint))
+	includeBranches := c.Query("include_branches") == "true"
+	branchID, err := stockBranchID(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	product, err := h.service.GetProduct(uint(id), tenantID.(uint), branchID, includeBranches)
 	if err != nil {
 		utils.NotFound(c, err.Error())
 		return
 	}
+	totalStock, branchStocks := mapBranchStocksToDTO(product, includeBranches)
 
 	var createdByName, updatedByName *string
 	if product.Creator != nil {
@@ -252,7 +322,8 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		CategoryDetail: mapCategoryToDTO(product.CategoryDetail),
 		SKU:            product.SKU,
 		Price:          product.Price,
-		Stock:          product.Stock,
+		CostPrice:      product.CostPrice,
+		Stock:          product.BranchStock,
 		Image:          utils.GetFullImageURL(product.Image),
 		IsActive:       product.IsActive,
 		CreatedAt:      product.CreatedAt.Format("2006-01-02 15:04:05"),
@@ -261,6 +332,15 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 		CreatedByName:  createdByName,
 		UpdatedBy:      product.UpdatedBy,
 		UpdatedByName:  updatedByName,
+
+		IsGiftCard:           product.IsGiftCard,
+		GiftCardValidityDays: product.GiftCardValidityDays,
+
+		MinStock:        product.MinStock,
+		ReorderQuantity: product.ReorderQuantity,
+
+		TotalStock:   totalStock,
+		BranchStocks: branchStocks,
 	})
 }
 
@@ -279,7 +359,10 @@ func (h *ProductHandler) GetProduct(c *gin.Context) {
 // @Param price formData number true "Product price" (when using multipart/form-data)
 // @Param stock formData integer false "Product stock" (when using multipart/form-data)
 // @Param is_active formData boolean false "Is product active" (when using multipart/form-data)
+// @Param is_gift_card formData boolean false "Selling the product issues a gift card worth its price" (when using multipart/form-data)
+// @Param gift_card_validity_days formData integer false "Days issued gift cards stay valid, 0 = never expire" (when using multipart/form-data)
 // @Param image formData file false "Product image file (optional)" (when using multipart/form-data)
+// @Param branch_id query int false "Branch the stock is set at, required with stock for users without a branch"
 // @Success 200 {object} dto.ProductResponse
 // @Router /api/products [post]
 func (h *ProductHandler) CreateProduct(c *gin.Context) {
@@ -310,7 +393,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 
 		// Parse price
 		if priceStr := c.PostForm("price"); priceStr != "" {
-			price, err := strconv.ParseFloat(priceStr, 64)
+			price, err := money.Parse(priceStr)
 			if err != nil {
 				utils.BadRequest(c, "Invalid price format")
 				return
@@ -333,6 +416,19 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 			req.IsActive = isActiveStr == "true" || isActiveStr == "1"
 		}
 
+		// Parse gift card settings
+		if isGiftCardStr := c.PostForm("is_gift_card"); isGiftCardStr != "" {
+			req.IsGiftCard = isGiftCardStr == "true" || isGiftCardStr == "1"
+		}
+		if validityStr := c.PostForm("gift_card_validity_days"); validityStr != "" {
+			validity, err := strconv.Atoi(validityStr)
+			if err != nil || validity < 0 {
+				utils.BadRequest(c, "Invalid gift_card_validity_days format")
+				return
+			}
+			req.GiftCardValidityDays = validity
+		}
+
 		// Validate required fields
 		if req.Name == "" {
 			utils.BadRequest(c, "Name is required")
@@ -354,7 +450,17 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 	currentUserID := c.GetUint("user_id")
 	req.CreatedBy = &currentUserID
 
-	product, err := h.service.CreateProduct(tenantID.(uint), req)
+	branchID, err := stockBranchID(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if branchID == 0 && req.Stock != 0 && !req.IsGiftCard {
+		utils.BadRequest(c, "branch_id is required for users without a branch to set stock")
+		return
+	}
+
+	product, err := h.service.CreateProduct(tenantID.(uint), branchID, req)
 	if err != nil {
 		utils.BadRequest(c, err.Error())
 		return
@@ -369,7 +475,7 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 				return
 			}
 			// Update product with image URL
-			product, _ = h.service.UpdateProductImage(product.ID, tenantID.(uint), imageURL)
+			product, _ = h.service.UpdateProductImage(product.ID, tenantID.(uint), branchID, imageURL)
 		}
 	}
 
@@ -382,12 +488,19 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 		CategoryDetail: mapCategoryToDTO(product.CategoryDetail),
 		SKU:            product.SKU,
 		Price:          product.Price,
-		Stock:          product.Stock,
+		CostPrice:      product.CostPrice,
+		Stock:          product.BranchStock,
 		Image:          utils.GetFullImageURL(product.Image),
 		IsActive:       product.IsActive,
 		CreatedAt:      product.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:      product.UpdatedAt.Format("2006-01-02 15:04:05"),
 		CreatedBy:      product.CreatedBy,
+
+		IsGiftCard:           product.IsGiftCard,
+		GiftCardValidityDays: product.GiftCardValidityDays,
+
+		MinStock:        product.MinStock,
+		ReorderQuantity: product.ReorderQuantity,
 	})
 }
 
@@ -407,7 +520,10 @@ func (h *ProductHandler) CreateProduct(c *gin.Context) {
 // @Param price formData number false "Product price" (when using multipart/form-data)
 // @Param stock formData integer false "Product stock" (when using multipart/form-data)
 // @Param is_active formData boolean false "Is product active" (when using multipart/form-data)
+// @Param is_gift_card formData boolean false "Selling the product issues a gift card worth its price" (when using multipart/form-data)
+// @Param gift_card_validity_days formData integer false "Days issued gift cards stay valid, 0 = never expire" (when using multipart/form-data)
 // @Param image formData file false "Product image file (optional)" (when using multipart/form-data)
+// @Param branch_id query int false "Branch the stock is set at, required with stock for users without a branch"
 // @Success 200 {object} dto.ProductResponse
 // @Router /api/products/{id} [put]
 func (h *ProductHandler) UpdateProduct(c *gin.Context) {
@@ -448,7 +564,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 
 		// Parse price
 		if priceStr := c.PostForm("price"); priceStr != "" {
-			price, err := strconv.ParseFloat(priceStr, 64)
+			price, err := money.Parse(priceStr)
 			if err != nil {
 				utils.BadRequest(c, "Invalid price format")
 				return
@@ -463,7 +579,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 				utils.BadRequest(c, "Invalid stock format")
 				return
 			}
-			req.Stock = stock
+			req.Stock = &stock
 		}
 
 		// Parse is_active
@@ -471,6 +587,20 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 			isActive := isActiveStr == "true" || isActiveStr == "1"
 			req.IsActive = &isActive
 		}
+
+		// Parse gift card settings
+		if isGiftCardStr := c.PostForm("is_gift_card"); isGiftCardStr != "" {
+			isGiftCard := isGiftCardStr == "true" || isGiftCardStr == "1"
+			req.IsGiftCard = &isGiftCard
+		}
+		if validityStr := c.PostForm("gift_card_validity_days"); validityStr != "" {
+			validity, err := strconv.Atoi(validityStr)
+			if err != nil || validity < 0 {
+				utils.BadRequest(c, "Invalid gift_card_validity_days format")
+				return
+			}
+			req.GiftCardValidityDays = &validity
+		}
 	} else {
 		// Parse JSON
 		if err := c.ShouldBindJSON(&req); err != nil {
@@ -483,7 +613,17 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 	currentUserID := c.GetUint("user_id")
 	req.UpdatedBy = &currentUserID
 
-	product, err := h.service.UpdateProduct(uint(id), tenantID.(uint), req)
+	branchID, err := stockBranchID(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	if branchID == 0 && req.Stock != nil {
+		utils.BadRequest(c, "branch_id is required for users without a branch to set stock")
+		return
+	}
+
+	product, err := h.service.UpdateProduct(uint(id), tenantID.(uint), branchID, req)
 	if err != nil {
 		utils.BadRequest(c, err.Error())
 		return
@@ -505,7 +645,7 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 				return
 			}
 			// Update product with image URL
-			product, _ = h.service.UpdateProductImage(product.ID, tenantID.(uint), imageURL)
+			product, _ = h.service.UpdateProductImage(product.ID, tenantID.(uint), branchID, imageURL)
 		}
 	}
 
@@ -518,13 +658,20 @@ func (h *ProductHandler) UpdateProduct(c *gin.Context) {
 		CategoryDetail: mapCategoryToDTO(product.CategoryDetail),
 		SKU:            product.SKU,
 		Price:          product.Price,
-		Stock:          product.Stock,
+		CostPrice:      product.CostPrice,
+		Stock:          product.BranchStock,
 		Image:          utils.GetFullImageURL(product.Image),
 		IsActive:       product.IsActive,
 		CreatedAt:      product.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:      product.UpdatedAt.Format("2006-01-02 15:04:05"),
 		CreatedBy:      product.CreatedBy,
 		UpdatedBy:      product.UpdatedBy,
+
+		IsGiftCard:           product.IsGiftCard,
+		GiftCardValidityDays: product.GiftCardValidityDays,
+
+		MinStock:        product.MinStock,
+		ReorderQuantity: product.ReorderQuantity,
 	})
 }
 
@@ -609,7 +756,7 @@ func (h *ProductHandler) UploadProductImage(c *gin.Context) {
 	}
 
 	// Get product first to verify it exists and belongs to tenant
-	product, err := h.service.GetProduct(uint(id), tenantID.(uint))
+	product, err := h.service.GetProduct(uint(id), tenantID.(uint), c.GetUint("branch_id"), false)
 	if err != nil {
 		utils.NotFound(c, "Product not found")
 		return
@@ -667,7 +814,7 @@ func (h *ProductHandler) UploadProductImage(c *gin.Context) {
 
 	// Update product image URL
 	imageURL := fmt.Sprintf("/uploads/products/%s", filename)
-	updatedProduct, err := h.service.UpdateProductImage(uint(id), tenantID.(uint), imageURL)
+	updatedProduct, err := h.service.UpdateProductImage(uint(id), tenantID.(uint), c.GetUint("branch_id"), imageURL)
 	if err != nil {
 		// Delete uploaded file if database update fails
 		os.Remove(filePath)
@@ -684,12 +831,19 @@ func (h *ProductHandler) UploadProductImage(c *gin.Context) {
 		CategoryDetail: mapCategoryToDTO(updatedProduct.CategoryDetail),
 		SKU:            updatedProduct.SKU,
 		Price:          updatedProduct.Price,
-		Stock:          updatedProduct.Stock,
+		CostPrice:      updatedProduct.CostPrice,
+		Stock:          updatedProduct.BranchStock,
 		Image:          utils.GetFullImageURL(updatedProduct.Image),
 		IsActive:       updatedProduct.IsActive,
 		CreatedAt:      updatedProduct.CreatedAt.Format("2006-01-02 15:04:05"),
 		UpdatedAt:      updatedProduct.UpdatedAt.Format("2006-01-02 15:04:05"),
 		CreatedBy:      updatedProduct.CreatedBy,
+
+		IsGiftCard:           updatedProduct.IsGiftCard,
+		GiftCardValidityDays: updatedProduct.GiftCardValidityDays,
+
+		MinStock:        updatedProduct.MinStock,
+		ReorderQuantity: updatedProduct.ReorderQuantity,
 	})
 }
 
@@ -770,7 +924,7 @@ func (h *ProductHandler) DeleteProductImage(c *gin.Context) {
 	}
 
 	// Get product first
-	product, err := h.service.GetProduct(uint(id), tenantID.(uint))
+	product, err := h.service.GetProduct(uint(id), tenantID.(uint), c.GetUint("branch_id"), false)
 	if err != nil {
 		utils.NotFound(c, "Product not found")
 		return
@@ -784,7 +938,7 @@ func (h *ProductHandler) DeleteProductImage(c *gin.Context) {
 	}
 
 	// Update database
-	_, err = h.service.UpdateProductImage(uint(id), tenantID.(uint), "")
+	_, err = h.service.UpdateProductImage(uint(id), tenantID.(uint), c.GetUint("branch_id"), "")
 	if err != nil {
 		utils.InternalError(c, err.Error())
 		return
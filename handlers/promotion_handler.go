@@ -0,0 +1,245 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PromotionHandler struct {
+	BaseHandler
+	promotionService *services.PromotionService
+}
+
+func NewPromotionHandler(cfg *config.Config, promotionService *services.PromotionService) *PromotionHandler {
+	return &PromotionHandler{
+		BaseHandler:      BaseHandler{config: cfg},
+		promotionService: promotionService,
+	}
+}
+
+// CreatePromotion godoc
+// @Summary Create a promotion
+// @Description Create a percentage, fixed or buy-x-get-y promotion, optionally limited to a category/product, time window or coupon code
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param request body dto.CreatePromotionRequest true "Promotion request"
+// @Success 200 {object} dto.PromotionResponse
+// @Router /api/promotions [post]
+func (h *PromotionHandler) CreatePromotion(c *gin.Context) {
+	var req dto.CreatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.CreatedBy = &currentUserID
+
+	promo, err := h.promotionService.CreatePromotion(tenantID, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Promotion created successfully", buildPromotionResponse(promo))
+}
+
+// GetPromotion godoc
+// @Summary Get promotion by ID
+// @Tags promotions
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} dto.PromotionResponse
+// @Router /api/promotions/{id} [get]
+func (h *PromotionHandler) GetPromotion(c *gin.Context) {
+	promotionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid promotion ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	promo, err := h.promotionService.GetPromotion(uint(promotionID), tenantID)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Promotion retrieved successfully", buildPromotionResponse(promo))
+}
+
+// ListPromotions godoc
+// @Summary List promotions
+// @Description Get paginated promotions of the tenant
+// @Tags promotions
+// @Produce json
+// @Param search query string false "Search by name or coupon code"
+// @Param active_only query bool false "Show only active promotions"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.PaginationResponse
+// @Router /api/promotions [get]
+func (h *PromotionHandler) ListPromotions(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	activeOnly := c.Query("active_only") == "true"
+	search := c.Query("search")
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	promotions, total, err := h.promotionService.ListPromotions(tenantID, search, activeOnly, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.PromotionResponse, len(promotions))
+	for i := range promotions {
+		responses[i] = buildPromotionResponse(&promotions[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":        0,
+		"message":     "Promotions retrieved successfully",
+		"page":        pagination.Page,
+		"page_size":   pagination.PageSize,
+		"total_items": total,
+		"total_pages": (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		"data":        responses,
+	})
+}
+
+// UpdatePromotion godoc
+// @Summary Update promotion
+// @Tags promotions
+// @Accept json
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Param request body dto.UpdatePromotionRequest true "Promotion fields to update"
+// @Success 200 {object} dto.PromotionResponse
+// @Router /api/promotions/{id} [put]
+func (h *PromotionHandler) UpdatePromotion(c *gin.Context) {
+	promotionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid promotion ID")
+		return
+	}
+
+	var req dto.UpdatePromotionRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.UpdatedBy = &currentUserID
+
+	promo, err := h.promotionService.UpdatePromotion(uint(promotionID), tenantID, req)
+	if err != nil {
+		if err.Error() == "promotion not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Promotion updated successfully", buildPromotionResponse(promo))
+}
+
+// DeletePromotion godoc
+// @Summary Delete promotion
+// @Tags promotions
+// @Produce json
+// @Param id path int true "Promotion ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/promotions/{id} [delete]
+func (h *PromotionHandler) DeletePromotion(c *gin.Context) {
+	promotionID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid promotion ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	if err := h.promotionService.DeletePromotion(uint(promotionID), tenantID, &currentUserID); err != nil {
+		if err.Error() == "promotion not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithoutData(c, "Promotion deleted successfully")
+}
+
+func buildPromotionResponse(promo *models.Promotion) dto.PromotionResponse {
+	var createdByName, updatedByName *string
+	if promo.Creator != nil {
+		name := promo.Creator.FullName
+		createdByName = &name
+	}
+	if promo.Updater != nil {
+		name := promo.Updater.FullName
+		updatedByName = &name
+	}
+
+	var startDate, endDate *string
+	if promo.StartDate != nil {
+		formatted := promo.StartDate.Format("2006-01-02 15:04:05")
+		startDate = &formatted
+	}
+	if promo.EndDate != nil {
+		formatted := promo.EndDate.Format("2006-01-02 15:04:05")
+		endDate = &formatted
+	}
+
+	return dto.PromotionResponse{
+		ID:            promo.ID,
+		TenantID:      promo.TenantID,
+		BranchID:      promo.BranchID,
+		Name:          promo.Name,
+		Description:   promo.Description,
+		Type:          promo.Type,
+		DiscountValue: promo.DiscountValue,
+		MaxDiscount:   promo.MaxDiscount,
+		CategoryID:    promo.CategoryID,
+		ProductID:     promo.ProductID,
+		BuyQuantity:   promo.BuyQuantity,
+		GetQuantity:   promo.GetQuantity,
+		MinSpend:      promo.MinSpend,
+		StartDate:     startDate,
+		EndDate:       endDate,
+		StartTime:     promo.StartTime,
+		EndTime:       promo.EndTime,
+		CouponCode:    promo.CouponCode,
+		UsageLimit:    promo.UsageLimit,
+		UsageCount:    promo.UsageCount,
+		IsActive:      promo.IsActive,
+		CreatedAt:     promo.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     promo.UpdatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:     promo.CreatedBy,
+		CreatedByName: createdByName,
+		UpdatedBy:     promo.UpdatedBy,
+		UpdatedByName: updatedByName,
+	}
+}
@@ -0,0 +1,347 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PurchaseOrderHandler struct {
+	BaseHandler
+	purchaseOrderService *services.PurchaseOrderService
+}
+
+func NewPurchaseOrderHandler(cfg *config.Config, purchaseOrderService *services.PurchaseOrderService) *PurchaseOrderHandler {
+	return &PurchaseOrderHandler{
+		BaseHandler:          BaseHandler{config: cfg},
+		purchaseOrderService: purchaseOrderService,
+	}
+}
+
+// CreatePurchaseOrder godoc
+// @Summary Draft a purchase order
+// @Description Draft a purchase order with a supplier for a branch (the current branch unless branch_id is given), with the unit cost agreed for every product
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param request body dto.CreatePurchaseOrderRequest true "Purchase order"
+// @Success 200 {object} dto.PurchaseOrderResponse
+// @Router /api/purchase-orders [post]
+func (h *PurchaseOrderHandler) CreatePurchaseOrder(c *gin.Context) {
+	var req dto.CreatePurchaseOrderRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	currentUserID := c.GetUint("user_id")
+
+	order, err := h.purchaseOrderService.CreatePurchaseOrder(tenantID, branchID, req, &currentUserID)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Purchase order created successfully", buildPurchaseOrderResponse(order))
+}
+
+// ListPurchaseOrders godoc
+// @Summary List purchase orders
+// @Description Get paginated purchase orders of the tenant, newest first
+// @Tags purchasing
+// @Produce json
+// @Param branch_id query int false "Only orders received into this branch"
+// @Param supplier_id query int false "Only orders with this supplier"
+// @Param status query string false "draft, ordered, partially_received, received, closed or cancelled"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.PaginationResponse
+// @Router /api/purchase-orders [get]
+func (h *PurchaseOrderHandler) ListPurchaseOrders(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	var branchID, supplierID uint
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+	if supplierIDStr := c.Query("supplier_id"); supplierIDStr != "" {
+		parsed, err := strconv.ParseUint(supplierIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid supplier ID")
+			return
+		}
+		supplierID = uint(parsed)
+	}
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	orders, total, err := h.purchaseOrderService.ListPurchaseOrders(tenantID, branchID, supplierID, c.Query("status"), pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.PurchaseOrderResponse, len(orders))
+	for i := range orders {
+		responses[i] = buildPurchaseOrderResponse(&orders[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":        0,
+		"message":     "Purchase orders retrieved successfully",
+		"page":        pagination.Page,
+		"page_size":   pagination.PageSize,
+		"total_items": total,
+		"total_pages": (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		"data":        responses,
+	})
+}
+
+// GetPurchaseOrder godoc
+// @Summary Get purchase order
+// @Description Get a purchase order with its items and goods receipts
+// @Tags purchasing
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} dto.PurchaseOrderResponse
+// @Router /api/purchase-orders/{id} [get]
+func (h *PurchaseOrderHandler) GetPurchaseOrder(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid purchase order ID")
+		return
+	}
+
+	order, err := h.purchaseOrderService.GetPurchaseOrder(uint(orderID), c.GetUint("tenant_id"))
+	if err != nil {
+		if err.Error() == "purchase order not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Purchase order retrieved successfully", buildPurchaseOrderResponse(order))
+}
+
+// PlacePurchaseOrder godoc
+// @Summary Place a purchase order
+// @Description Mark a draft purchase order as ordered from the supplier so goods can be received against it
+// @Tags purchasing
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Success 200 {object} dto.PurchaseOrderResponse
+// @Router /api/purchase-orders/{id}/order [post]
+func (h *PurchaseOrderHandler) PlacePurchaseOrder(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid purchase order ID")
+		return
+	}
+
+	currentUserID := c.GetUint("user_id")
+
+	order, err := h.purchaseOrderService.PlaceOrder(uint(orderID), c.GetUint("tenant_id"), &currentUserID)
+	if err != nil {
+		h.respondPurchaseOrderError(c, err)
+		return
+	}
+
+	utils.Success(c, "Purchase order placed successfully", buildPurchaseOrderResponse(order))
+}
+
+// ReceiveGoods godoc
+// @Summary Receive goods
+// @Description Record a full or partial delivery against an ordered purchase order. Delivered units go into the stock of the order's branch and move the products' average cost.
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Param request body dto.CreateGoodsReceiptRequest true "Delivered quantities"
+// @Success 200 {object} dto.PurchaseOrderResponse
+// @Router /api/purchase-orders/{id}/receipts [post]
+func (h *PurchaseOrderHandler) ReceiveGoods(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid purchase order ID")
+		return
+	}
+
+	var req dto.CreateGoodsReceiptRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	currentUserID := c.GetUint("user_id")
+
+	order, err := h.purchaseOrderService.ReceiveGoods(uint(orderID), c.GetUint("tenant_id"), req, &currentUserID)
+	if err != nil {
+		h.respondPurchaseOrderError(c, err)
+		return
+	}
+
+	utils.Success(c, "Goods received successfully", buildPurchaseOrderResponse(order))
+}
+
+// ClosePurchaseOrder godoc
+// @Summary Close a purchase order
+// @Description Close a partially received purchase order whose remaining quantities will not be delivered
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Param request body dto.PurchaseOrderNotesRequest false "Notes"
+// @Success 200 {object} dto.PurchaseOrderResponse
+// @Router /api/purchase-orders/{id}/close [post]
+func (h *PurchaseOrderHandler) ClosePurchaseOrder(c *gin.Context) {
+	h.finishPurchaseOrder(c, h.purchaseOrderService.ClosePurchaseOrder, "Purchase order closed successfully")
+}
+
+// CancelPurchaseOrder godoc
+// @Summary Cancel a purchase order
+// @Description Cancel a draft or ordered purchase order nothing has been received on yet
+// @Tags purchasing
+// @Accept json
+// @Produce json
+// @Param id path int true "Purchase order ID"
+// @Param request body dto.PurchaseOrderNotesRequest false "Notes"
+// @Success 200 {object} dto.PurchaseOrderResponse
+// @Router /api/purchase-orders/{id}/cancel [post]
+func (h *PurchaseOrderHandler) CancelPurchaseOrder(c *gin.Context) {
+	h.finishPurchaseOrder(c, h.purchaseOrderService.CancelPurchaseOrder, "Purchase order cancelled successfully")
+}
+
+func (h *PurchaseOrderHandler) finishPurchaseOrder(c *gin.Context, finish func(uint, uint, dto.PurchaseOrderNotesRequest, *uint) (*models.PurchaseOrder, error), message string) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid purchase order ID")
+		return
+	}
+
+	// Notes are optional, so is the body
+	var req dto.PurchaseOrderNotesRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	currentUserID := c.GetUint("user_id")
+
+	order, err := finish(uint(orderID), c.GetUint("tenant_id"), req, &currentUserID)
+	if err != nil {
+		h.respondPurchaseOrderError(c, err)
+		return
+	}
+
+	utils.Success(c, message, buildPurchaseOrderResponse(order))
+}
+
+func (h *PurchaseOrderHandler) respondPurchaseOrderError(c *gin.Context, err error) {
+	if err.Error() == "purchase order not found" {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	utils.BadRequest(c, err.Error())
+}
+
+func buildPurchaseOrderResponse(order *models.PurchaseOrder) dto.PurchaseOrderResponse {
+	response := dto.PurchaseOrderResponse{
+		ID:          order.ID,
+		BranchID:    order.BranchID,
+		SupplierID:  order.SupplierID,
+		Status:      order.Status,
+		Notes:       order.Notes,
+		TotalAmount: order.TotalAmount,
+		OrderedBy:   order.OrderedBy,
+		ClosedBy:    order.ClosedBy,
+		ClosedNotes: order.ClosedNotes,
+		CreatedAt:   order.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:   order.CreatedBy,
+		Items:       make([]dto.PurchaseOrderItemResponse, len(order.Items)),
+	}
+	if order.Branch != nil {
+		response.BranchName = order.Branch.Name
+	}
+	if order.Supplier != nil {
+		response.SupplierName = order.Supplier.Name
+	}
+	if order.Creator != nil {
+		name := order.Creator.FullName
+		response.CreatedByName = &name
+	}
+	if order.ExpectedAt != nil {
+		expectedAt := order.ExpectedAt.Format("2006-01-02")
+		response.ExpectedAt = &expectedAt
+	}
+	if order.OrderedAt != nil {
+		orderedAt := order.OrderedAt.Format("2006-01-02 15:04:05")
+		response.OrderedAt = &orderedAt
+	}
+	if order.ClosedAt != nil {
+		closedAt := order.ClosedAt.Format("2006-01-02 15:04:05")
+		response.ClosedAt = &closedAt
+	}
+
+	for i, item := range order.Items {
+		response.Items[i] = dto.PurchaseOrderItemResponse{
+			ID:               item.ID,
+			ProductID:        item.ProductID,
+			ProductName:      item.Product.Name,
+			SKU:              item.Product.SKU,
+			Quantity:         item.Quantity,
+			UnitCost:         item.UnitCost,
+			Subtotal:         item.Subtotal,
+			ReceivedQuantity: item.ReceivedQuantity,
+		}
+	}
+
+	for _, receipt := range order.Receipts {
+		receiptResponse := dto.GoodsReceiptResponse{
+			ID:         receipt.ID,
+			BranchID:   receipt.BranchID,
+			Notes:      receipt.Notes,
+			ReceivedAt: receipt.ReceivedAt.Format("2006-01-02 15:04:05"),
+			ReceivedBy: receipt.ReceivedBy,
+			Items:      make([]dto.GoodsReceiptItemResponse, len(receipt.Items)),
+		}
+		if receipt.Receiver != nil {
+			name := receipt.Receiver.FullName
+			receiptResponse.ReceivedByName = &name
+		}
+		for i, item := range receipt.Items {
+			receiptResponse.Items[i] = dto.GoodsReceiptItemResponse{
+				ID:                  item.ID,
+				PurchaseOrderItemID: item.PurchaseOrderItemID,
+				ProductID:           item.ProductID,
+				Quantity:            item.Quantity,
+				UnitCost:            item.UnitCost,
+			}
+		}
+		response.Receipts = append(response.Receipts, receiptResponse)
+	}
+
+	return response
+}
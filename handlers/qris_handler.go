@@ -0,0 +1,299 @@
+package handlers
+
+import (
+	"encoding/base64"
+	"fmt"
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type QRISHandler struct {
+	BaseHandler
+	qrisService *services.QRISService
+}
+
+func NewQRISHandler(cfg *config.Config, qrisService *services.QRISService) *QRISHandler {
+	return &QRISHandler{
+		BaseHandler: BaseHandler{config: cfg},
+		qrisService: qrisService,
+	}
+}
+
+// parseQRISImageOptions reads the format and size query parameters shared by the QR endpoints
+func parseQRISImageOptions(c *gin.Context) (string, int, bool) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "png" {
+		utils.BadRequest(c, "format must be json or png")
+		return "", 0, false
+	}
+
+	size := 512
+	if sizeStr := c.Query("size"); sizeStr != "" {
+		parsed, err := strconv.Atoi(sizeStr)
+		if err != nil || parsed < 128 || parsed > 2048 {
+			utils.BadRequest(c, "size must be between 128 and 2048")
+			return "", 0, false
+		}
+		size = parsed
+	}
+	return format, size, true
+}
+
+// writeQRISCode sends a generated QR as a PNG image or as JSON with the payload and the image
+func writeQRISCode(c *gin.Context, code *services.QRISCode, format string, size int, message string) {
+	image, err := services.RenderQRISPNG(code.Payload, size)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	if format == "png" {
+		filename := fmt.Sprintf("qris-branch-%d", code.BranchID)
+		if code.OrderNumber != "" {
+			filename = fmt.Sprintf("qris-%s", code.OrderNumber)
+		}
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.png"`, filename))
+		c.Data(http.StatusOK, "image/png", image)
+		return
+	}
+
+	response := dto.QRISResponse{
+		Type:        code.Type,
+		Payload:     code.Payload,
+		Image:       "data:image/png;base64," + base64.StdEncoding.EncodeToString(image),
+		OrderNumber: code.OrderNumber,
+		BranchID:    code.BranchID,
+	}
+	if code.Type == services.QRISDynamic {
+		amount := code.Amount
+		orderID := code.OrderID
+		response.Amount = &amount
+		response.OrderID = &orderID
+	}
+	utils.Success(c, message, response)
+}
+
+// GetOrderQRIS godoc
+// @Summary Generate order QRIS
+// @Description Generate a dynamic QRIS code for the order's balance due, or part of it for split payments. format=png returns the image only.
+// @Tags qris
+// @Produce json
+// @Produce png
+// @Param id path int true "Order ID"
+// @Param amount query number false "Amount to collect, defaults to the balance due"
+// @Param format query string false "json (default) or png"
+// @Param size query int false "Image size in pixels (128-2048)" default(512)
+// @Success 200 {object} dto.QRISResponse
+// @Router /api/orders/{id}/qris [get]
+func (h *QRISHandler) GetOrderQRIS(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
+	}
+
+	var amount *money.Amount
+	if amountStr := c.Query("amount"); amountStr != "" {
+		parsed, err := money.Parse(amountStr)
+		if err != nil {
+			utils.BadRequest(c, "Invalid amount")
+			return
+		}
+		amount = &parsed
+	}
+
+	format, size, ok := parseQRISImageOptions(c)
+	if !ok {
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	code, err := h.qrisService.GenerateOrderQRIS(uint(orderID), tenantID, amount)
+	if err != nil {
+		if err.Error() == "order not found" {
+			utils.NotFound(c, "Order not found")
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	writeQRISCode(c, code, format, size, "QRIS generated successfully")
+}
+
+// GetStaticQRIS godoc
+// @Summary Generate static QRIS
+// @Description Generate the reusable QRIS code of a branch, where the customer enters the amount. format=png returns the image only.
+// @Tags qris
+// @Produce json
+// @Produce png
+// @Param branch_id query int false "Branch ID, defaults to the current branch"
+// @Param format query string false "json (default) or png"
+// @Param size query int false "Image size in pixels (128-2048)" default(512)
+// @Success 200 {object} dto.QRISResponse
+// @Router /api/qris/static [get]
+func (h *QRISHandler) GetStaticQRIS(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+
+	format, size, ok := parseQRISImageOptions(c)
+	if !ok {
+		return
+	}
+
+	code, err := h.qrisService.GenerateStaticQRIS(tenantID, branchID)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	writeQRISCode(c, code, format, size, "QRIS generated successfully")
+}
+
+// ParseQRIS godoc
+// @Summary Parse and validate a QRIS string
+// @Description Decode an EMVCo merchant-presented QR string, checking its structure, mandatory fields and CRC
+// @Tags qris
+// @Accept json
+// @Produce json
+// @Param request body dto.ParseQRISRequest true "QR string"
+// @Success 200 {object} dto.ParseQRISResponse
+// @Router /api/qris/parse [post]
+func (h *QRISHandler) ParseQRIS(c *gin.Context) {
+	var req dto.ParseQRISRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	payload, err := services.ParseQRIS(req.Payload)
+	if err != nil {
+		utils.BadRequest(c, "Invalid QRIS: "+err.Error())
+		return
+	}
+
+	utils.Success(c, "QRIS is valid", dto.ParseQRISResponse{
+		Type:         payload.Type,
+		MerchantName: payload.MerchantName,
+		MerchantCity: payload.MerchantCity,
+		PostalCode:   payload.PostalCode,
+		CategoryCode: payload.CategoryCode,
+		Currency:     payload.Currency,
+		CountryCode:  payload.CountryCode,
+		Amount:       payload.Amount,
+		NMID:         payload.NMID,
+		BillNumber:   payload.BillNumber,
+		Reference:    payload.Reference,
+		Terminal:     payload.Terminal,
+		CRC:          payload.CRC,
+		Fields:       buildQRISFieldResponses(payload.Fields),
+	})
+}
+
+// GetQRISMerchant godoc
+// @Summary Get QRIS merchant data
+// @Description Get the QRIS merchant data used by a branch (its own or the tenant wide one)
+// @Tags qris
+// @Produce json
+// @Param branch_id query int false "Branch ID, defaults to the current branch"
+// @Success 200 {object} dto.QRISMerchantResponse
+// @Router /api/qris/merchant [get]
+func (h *QRISHandler) GetQRISMerchant(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+
+	merchant, err := h.qrisService.GetMerchant(tenantID, branchID)
+	if err != nil {
+		if err.Error() == "QRIS merchant not configured" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "QRIS merchant retrieved successfully", buildQRISMerchantResponse(merchant))
+}
+
+// SaveQRISMerchant godoc
+// @Summary Save QRIS merchant data
+// @Description Create or update the QRIS merchant data of the tenant, or of a single branch when branch_id is set
+// @Tags qris
+// @Accept json
+// @Produce json
+// @Param request body dto.SaveQRISMerchantRequest true "QRIS merchant data"
+// @Success 200 {object} dto.QRISMerchantResponse
+// @Router /api/qris/merchant [put]
+func (h *QRISHandler) SaveQRISMerchant(c *gin.Context) {
+	var req dto.SaveQRISMerchantRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.UpdatedBy = &currentUserID
+
+	merchant, err := h.qrisService.SaveMerchant(tenantID, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "QRIS merchant saved successfully", buildQRISMerchantResponse(merchant))
+}
+
+func buildQRISFieldResponses(fields []services.QRISField) []dto.QRISFieldResponse {
+	responses := make([]dto.QRISFieldResponse, len(fields))
+	for i, field := range fields {
+		responses[i] = dto.QRISFieldResponse{ID: field.ID, Value: field.Value}
+		if len(field.Children) > 0 {
+			responses[i].Children = buildQRISFieldResponses(field.Children)
+		}
+	}
+	return responses
+}
+
+func buildQRISMerchantResponse(merchant *models.QRISMerchant) dto.QRISMerchantResponse {
+	return dto.QRISMerchantResponse{
+		ID:               merchant.ID,
+		TenantID:         merchant.TenantID,
+		BranchID:         merchant.BranchID,
+		MerchantName:     merchant.MerchantName,
+		MerchantCity:     merchant.MerchantCity,
+		PostalCode:       merchant.PostalCode,
+		CategoryCode:     merchant.CategoryCode,
+		AcquirerDomain:   merchant.AcquirerDomain,
+		MerchantPAN:      merchant.MerchantPAN,
+		MerchantID:       merchant.MerchantID,
+		NMID:             merchant.NMID,
+		MerchantCriteria: merchant.MerchantCriteria,
+		TerminalLabel:    merchant.TerminalLabel,
+	}
+}
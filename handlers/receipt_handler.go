@@ -0,0 +1,166 @@
+package handlers
+
+import (
+	"fmt"
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReceiptHandler struct {
+	BaseHandler
+	receiptService *services.ReceiptService
+}
+
+func NewReceiptHandler(cfg *config.Config, receiptService *services.ReceiptService) *ReceiptHandler {
+	return &ReceiptHandler{
+		BaseHandler:    BaseHandler{config: cfg},
+		receiptService: receiptService,
+	}
+}
+
+// GetOrderReceipt godoc
+// @Summary Render order receipt
+// @Description Render the receipt of an order with the branch receipt template. escpos returns raw printer bytes, pdf a single page as long as the receipt
+// @Tags receipts
+// @Produce plain
+// @Produce html
+// @Produce octet-stream
+// @Produce application/pdf
+// @Param id path int true "Order ID"
+// @Param format query string false "text (default), escpos, pdf or html"
+// @Param width query int false "Paper width in mm (58 or 80), defaults to the template width"
+// @Success 200 {string} string "Rendered receipt"
+// @Router /api/orders/{id}/receipt [get]
+func (h *ReceiptHandler) GetOrderReceipt(c *gin.Context) {
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid order ID")
+		return
+	}
+
+	format := c.DefaultQuery("format", "text")
+	if format != "text" && format != "escpos" && format != "pdf" && format != "html" {
+		utils.BadRequest(c, "format must be text, escpos, pdf or html")
+		return
+	}
+
+	var width int
+	if widthStr := c.Query("width"); widthStr != "" {
+		width, err = strconv.Atoi(widthStr)
+		if err != nil || (width != 58 && width != 80) {
+			utils.BadRequest(c, "width must be 58 or 80")
+			return
+		}
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	receipt, err := h.receiptService.BuildReceipt(uint(orderID), tenantID, width)
+	if err != nil {
+		if err.Error() == "order not found" {
+			utils.NotFound(c, "Order not found")
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+	receipt.LogoURL = utils.GetFullImageURL(receipt.LogoURL)
+
+	filename := fmt.Sprintf("receipt-%s", receipt.OrderNumber)
+	switch format {
+	case "escpos":
+		c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.bin"`, filename))
+		c.Data(http.StatusOK, "application/octet-stream", services.RenderReceiptESCPOS(receipt))
+	case "pdf":
+		c.Header("Content-Disposition", fmt.Sprintf(`inline; filename="%s.pdf"`, filename))
+		c.Data(http.StatusOK, "application/pdf", services.RenderReceiptPDF(receipt))
+	case "html":
+		html, err := services.RenderReceiptHTML(receipt)
+		if err != nil {
+			utils.InternalError(c, err.Error())
+			return
+		}
+		c.Data(http.StatusOK, "text/html; charset=utf-8", []byte(html))
+	default:
+		c.Data(http.StatusOK, "text/plain; charset=utf-8", []byte(services.RenderReceiptText(receipt)))
+	}
+}
+
+// GetReceiptTemplate godoc
+// @Summary Get receipt template
+// @Description Get the receipt template used by a branch (its own, the tenant wide one or the default)
+// @Tags receipts
+// @Produce json
+// @Param branch_id query int false "Branch ID, defaults to the current branch"
+// @Success 200 {object} dto.ReceiptTemplateResponse
+// @Router /api/receipt-template [get]
+func (h *ReceiptHandler) GetReceiptTemplate(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+
+	template, err := h.receiptService.GetTemplate(tenantID, branchID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Receipt template retrieved successfully", buildReceiptTemplateResponse(template))
+}
+
+// SaveReceiptTemplate godoc
+// @Summary Save receipt template
+// @Description Create or update the receipt template of the tenant, or of a single branch when branch_id is set
+// @Tags receipts
+// @Accept json
+// @Produce json
+// @Param request body dto.SaveReceiptTemplateRequest true "Receipt template"
+// @Success 200 {object} dto.ReceiptTemplateResponse
+// @Router /api/receipt-template [put]
+func (h *ReceiptHandler) SaveReceiptTemplate(c *gin.Context) {
+	var req dto.SaveReceiptTemplateRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.UpdatedBy = &currentUserID
+
+	template, err := h.receiptService.SaveTemplate(tenantID, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Receipt template saved successfully", buildReceiptTemplateResponse(template))
+}
+
+func buildReceiptTemplateResponse(template *models.ReceiptTemplate) dto.ReceiptTemplateResponse {
+	return dto.ReceiptTemplateResponse{
+		ID:           template.ID,
+		TenantID:     template.TenantID,
+		BranchID:     template.BranchID,
+		HeaderText:   template.HeaderText,
+		FooterText:   template.FooterText,
+		ShowLogo:     template.ShowLogo,
+		ShowTaxLines: template.ShowTaxLines,
+		ShowCashier:  template.ShowCashier,
+		PaperWidth:   template.PaperWidth,
+	}
+}
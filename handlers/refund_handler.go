@@ -0,0 +1,148 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RefundHandler struct {
+	BaseHandler
+	refundService *services.RefundService
+}
+
+func NewRefundHandler(cfg *config.Config, refundService *services.RefundService) *RefundHandler {
+	return &RefundHandler{
+		BaseHandler:   BaseHandler{config: cfg},
+		refundService: refundService,
+	}
+}
+
+// CreateRefund godoc
+// @Summary Refund a payment
+// @Description Fully or partially refund a completed payment, optionally for specific order items, and optionally put the items back into stock. Needs a supervisor's approval with their PIN. The order moves to partially_refunded or refunded. Payments collected by a payment provider are refunded there after the refund is saved as pending; if the provider refuses, the refund is kept as failed and the order is left as it was
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Param request body dto.CreateRefundRequest true "Refund request"
+// @Success 200 {object} dto.RefundResponse
+// @Router /api/payments/{id}/refunds [post]
+func (h *RefundHandler) CreateRefund(c *gin.Context) {
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid payment ID")
+		return
+	}
+
+	var req dto.CreateRefundRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.CreatedBy = &currentUserID
+
+	refund, err := h.refundService.CreateRefund(uint(paymentID), tenantID, req)
+	if err != nil {
+		if err.Error() == "payment not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		if isApprovalError(err) {
+			utils.Forbidden(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Refund created successfully", buildRefundResponse(refund))
+}
+
+// GetRefundsByPayment godoc
+// @Summary List refunds of a payment
+// @Tags payments
+// @Produce json
+// @Param id path int true "Payment ID"
+// @Success 200 {array} dto.RefundResponse
+// @Router /api/payments/{id}/refunds [get]
+func (h *RefundHandler) GetRefundsByPayment(c *gin.Context) {
+	paymentID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid payment ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	refunds, err := h.refundService.GetRefundsByPayment(uint(paymentID), tenantID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.RefundResponse, len(refunds))
+	for i := range refunds {
+		responses[i] = buildRefundResponse(&refunds[i])
+	}
+
+	utils.Success(c, "Refunds retrieved successfully", responses)
+}
+
+func buildRefundResponse(refund *models.Refund) dto.RefundResponse {
+	var approvedByName, createdByName *string
+	if refund.Approver != nil {
+		name := refund.Approver.FullName
+		approvedByName = &name
+	}
+	if refund.Creator != nil {
+		name := refund.Creator.FullName
+		createdByName = &name
+	}
+
+	var giftCardCode *string
+	if refund.GiftCard != nil {
+		giftCardCode = &refund.GiftCard.Code
+	}
+
+	items := make([]dto.RefundItemResponse, len(refund.Items))
+	for i, item := range refund.Items {
+		items[i] = dto.RefundItemResponse{
+			ID:          item.ID,
+			OrderItemID: item.OrderItemID,
+			ProductID:   item.ProductID,
+			ProductName: item.Product.Name,
+			Quantity:    item.Quantity,
+			Amount:      item.Amount,
+			Restocked:   item.Restocked,
+		}
+	}
+
+	return dto.RefundResponse{
+		ID:             refund.ID,
+		OrderID:        refund.OrderID,
+		PaymentID:      refund.PaymentID,
+		Amount:         refund.Amount,
+		RefundMethod:   refund.RefundMethod,
+		Reason:         refund.Reason,
+		Restock:        refund.Restock,
+		Status:         refund.Status,
+		FailureReason:  refund.FailureReason,
+		Items:          items,
+		GiftCardID:     refund.GiftCardID,
+		GiftCardCode:   giftCardCode,
+		ApprovedBy:     refund.ApprovedBy,
+		ApprovedByName: approvedByName,
+		CreatedAt:      refund.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:      refund.CreatedBy,
+		CreatedByName:  createdByName,
+	}
+}
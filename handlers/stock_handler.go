@@ -0,0 +1,196 @@
+package handlers
+
+import (
+	"errors"
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockHandler struct {
+	BaseHandler
+	stockService *services.StockService
+}
+
+func NewStockHandler(cfg *config.Config, stockService *services.StockService) *StockHandler {
+	return &StockHandler{
+		BaseHandler:  BaseHandler{config: cfg},
+		stockService: stockService,
+	}
+}
+
+// stockBranchID returns the branch whose stock the request reads or moves: the user's branch, or
+// for users without one (owners and tenant-wide admins) the branch_id query parameter. It is 0
+// when a user without a branch leaves branch_id out.
+func stockBranchID(c *gin.Context) (uint, error) {
+	if branchID := c.GetUint("branch_id"); branchID != 0 {
+		return branchID, nil
+	}
+	branchIDStr := c.Query("branch_id")
+	if branchIDStr == "" {
+		return 0, nil
+	}
+	parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+	if err != nil {
+		return 0, errors.New("invalid branch ID")
+	}
+	return uint(parsed), nil
+}
+
+// requiredStockBranchID is stockBranchID for requests that move stock, which cannot go without a branch
+func requiredStockBranchID(c *gin.Context) (uint, error) {
+	branchID, err := stockBranchID(c)
+	if err != nil {
+		return 0, err
+	}
+	if branchID == 0 {
+		return 0, errors.New("branch_id is required for users without a branch")
+	}
+	return branchID, nil
+}
+
+// AdjustStock godoc
+// @Summary Adjust product stock
+// @Description Correct a product's stock at the current branch by a quantity (adjustment), or set it to the quantity counted on the shelf (stocktake). The change is recorded in the stock movement ledger.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param branch_id query int false "Branch to adjust, required for users without a branch"
+// @Param request body dto.StockAdjustmentRequest true "Adjustment"
+// @Success 200 {object} dto.StockMovementResponse
+// @Router /api/products/{id}/stock-adjustments [post]
+func (h *StockHandler) AdjustStock(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid product ID")
+		return
+	}
+
+	var req dto.StockAdjustmentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	branchID, err := requiredStockBranchID(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+	currentUserID := c.GetUint("user_id")
+
+	movement, err := h.stockService.AdjustStock(uint(productID), tenantID, branchID, req, &currentUserID)
+	if err != nil {
+		if err.Error() == "product not found" || err.Error() == "branch not found or doesn't belong to this tenant" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Stock adjusted successfully", buildStockMovementResponse(movement))
+}
+
+// GetStockMovements godoc
+// @Summary Get product stock movements
+// @Description Get a product's stock with one page of the movements that explain it, newest first. Without branch_id the stock is the total over all branches.
+// @Tags stock
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param branch_id query int false "Only movements at this branch"
+// @Param type query string false "sale, refund, adjustment, receipt, transfer, stocktake or sync"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.StockMovementHistoryResponse
+// @Router /api/products/{id}/stock-movements [get]
+func (h *StockHandler) GetStockMovements(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid product ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	var branchID *uint
+	if branchParam := c.Query("branch_id"); branchParam != "" {
+		id, err := strconv.ParseUint(branchParam, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch_id")
+			return
+		}
+		bid := uint(id)
+		branchID = &bid
+	}
+
+	product, movements, total, err := h.stockService.ListMovements(uint(productID), tenantID, branchID, c.Query("type"), pagination.Page, pagination.PageSize)
+	if err != nil {
+		if err.Error() == "product not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.StockMovementResponse, len(movements))
+	for i := range movements {
+		responses[i] = buildStockMovementResponse(&movements[i])
+	}
+
+	stock := product.Stock
+	if branchID != nil {
+		stock = product.BranchStock
+	}
+
+	utils.Success(c, "Stock movements retrieved successfully", dto.StockMovementHistoryResponse{
+		ProductID:   product.ID,
+		ProductName: product.Name,
+		BranchID:    branchID,
+		Stock:       stock,
+		Page:        pagination.Page,
+		PageSize:    pagination.PageSize,
+		TotalItems:  total,
+		TotalPages:  (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		Movements:   responses,
+	})
+}
+
+func buildStockMovementResponse(movement *models.StockMovement) dto.StockMovementResponse {
+	var createdByName *string
+	if movement.Creator != nil {
+		name := movement.Creator.FullName
+		createdByName = &name
+	}
+
+	return dto.StockMovementResponse{
+		ID:            movement.ID,
+		BranchID:      movement.BranchID,
+		ProductID:     movement.ProductID,
+		Type:          movement.Type,
+		Quantity:      movement.Quantity,
+		BalanceAfter:  movement.BalanceAfter,
+		Reason:        movement.Reason,
+		ReferenceType: movement.ReferenceType,
+		ReferenceID:   movement.ReferenceID,
+		CreatedAt:     movement.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:     movement.CreatedBy,
+		CreatedByName: createdByName,
+	}
+}
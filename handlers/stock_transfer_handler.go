@@ -0,0 +1,358 @@
+package handlers
+
+import (
+	"errors"
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type StockTransferHandler struct {
+	BaseHandler
+	stockTransferService *services.StockTransferService
+}
+
+func NewStockTransferHandler(cfg *config.Config, stockTransferService *services.StockTransferService) *StockTransferHandler {
+	return &StockTransferHandler{
+		BaseHandler:          BaseHandler{config: cfg},
+		stockTransferService: stockTransferService,
+	}
+}
+
+// CreateStockTransfer godoc
+// @Summary Draft a stock transfer
+// @Description Draft a transfer of products from a branch (the current branch unless from_branch_id is given) to another branch of the tenant. Stock moves once it is sent.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateStockTransferRequest true "Transfer"
+// @Success 200 {object} dto.StockTransferResponse
+// @Router /api/stock-transfers [post]
+func (h *StockTransferHandler) CreateStockTransfer(c *gin.Context) {
+	var req dto.CreateStockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+	currentUserID := c.GetUint("user_id")
+
+	transfer, err := h.stockTransferService.CreateTransfer(tenantID, branchID, req, &currentUserID)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Stock transfer created successfully", buildStockTransferResponse(transfer))
+}
+
+// ListStockTransfers godoc
+// @Summary List stock transfers
+// @Description Get paginated stock transfers of the tenant, newest first
+// @Tags stock
+// @Produce json
+// @Param branch_id query int false "Only transfers from or to this branch"
+// @Param status query string false "draft, sent, received, closed or cancelled"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.PaginationResponse
+// @Router /api/stock-transfers [get]
+func (h *StockTransferHandler) ListStockTransfers(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	var branchID uint
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	transfers, total, err := h.stockTransferService.ListTransfers(tenantID, branchID, c.Query("status"), pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.StockTransferResponse, len(transfers))
+	for i := range transfers {
+		responses[i] = buildStockTransferResponse(&transfers[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":        0,
+		"message":     "Stock transfers retrieved successfully",
+		"page":        pagination.Page,
+		"page_size":   pagination.PageSize,
+		"total_items": total,
+		"total_pages": (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		"data":        responses,
+	})
+}
+
+// GetInTransitStock godoc
+// @Summary Get stock in transit
+// @Description Get the stock on sent transfers that has not been received yet, per product and route
+// @Tags stock
+// @Produce json
+// @Param branch_id query int false "Only transfers from or to this branch"
+// @Param product_id query int false "Only this product"
+// @Success 200 {array} dto.InTransitStockResponse
+// @Router /api/stock-transfers/in-transit [get]
+func (h *StockTransferHandler) GetInTransitStock(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	var branchID, productID uint
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+	if productIDStr := c.Query("product_id"); productIDStr != "" {
+		parsed, err := strconv.ParseUint(productIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid product ID")
+			return
+		}
+		productID = uint(parsed)
+	}
+
+	stock, err := h.stockTransferService.ListInTransit(tenantID, branchID, productID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Stock in transit retrieved successfully", stock)
+}
+
+// GetStockTransfer godoc
+// @Summary Get stock transfer
+// @Description Get a stock transfer with its items
+// @Tags stock
+// @Produce json
+// @Param id path int true "Stock transfer ID"
+// @Success 200 {object} dto.StockTransferResponse
+// @Router /api/stock-transfers/{id} [get]
+func (h *StockTransferHandler) GetStockTransfer(c *gin.Context) {
+	transferID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid stock transfer ID")
+		return
+	}
+
+	transfer, err := h.stockTransferService.GetTransfer(uint(transferID), c.GetUint("tenant_id"))
+	if err != nil {
+		if err.Error() == "stock transfer not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Stock transfer retrieved successfully", buildStockTransferResponse(transfer))
+}
+
+// SendStockTransfer godoc
+// @Summary Send a stock transfer
+// @Description Send a draft transfer: its items leave the source branch's stock and are in transit until received
+// @Tags stock
+// @Produce json
+// @Param id path int true "Stock transfer ID"
+// @Success 200 {object} dto.StockTransferResponse
+// @Router /api/stock-transfers/{id}/send [post]
+func (h *StockTransferHandler) SendStockTransfer(c *gin.Context) {
+	transferID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid stock transfer ID")
+		return
+	}
+
+	currentUserID := c.GetUint("user_id")
+
+	transfer, err := h.stockTransferService.SendTransfer(uint(transferID), c.GetUint("tenant_id"), &currentUserID)
+	if err != nil {
+		h.respondTransferError(c, err)
+		return
+	}
+
+	utils.Success(c, "Stock transfer sent successfully", buildStockTransferResponse(transfer))
+}
+
+// ReceiveStockTransfer godoc
+// @Summary Receive a stock transfer
+// @Description Receive a sent transfer with the quantity counted of every item. Counted units arrive in the destination branch's stock; counts that differ from what was sent are kept as discrepancies. Only users of the destination branch, owners and superadmins may receive.
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock transfer ID"
+// @Param request body dto.ReceiveStockTransferRequest true "Counts"
+// @Success 200 {object} dto.StockTransferResponse
+// @Router /api/stock-transfers/{id}/receive [post]
+func (h *StockTransferHandler) ReceiveStockTransfer(c *gin.Context) {
+	transferID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid stock transfer ID")
+		return
+	}
+
+	var req dto.ReceiveStockTransferRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	currentUserID := c.GetUint("user_id")
+
+	transfer, err := h.stockTransferService.ReceiveTransfer(uint(transferID), c.GetUint("tenant_id"), req, &currentUserID)
+	if err != nil {
+		h.respondTransferError(c, err)
+		return
+	}
+
+	utils.Success(c, "Stock transfer received successfully", buildStockTransferResponse(transfer))
+}
+
+// CloseStockTransfer godoc
+// @Summary Close a stock transfer
+// @Description Close a received transfer once its discrepancies have been looked at
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock transfer ID"
+// @Param request body dto.StockTransferNotesRequest false "Notes"
+// @Success 200 {object} dto.StockTransferResponse
+// @Router /api/stock-transfers/{id}/close [post]
+func (h *StockTransferHandler) CloseStockTransfer(c *gin.Context) {
+	h.finishStockTransfer(c, h.stockTransferService.CloseTransfer, "Stock transfer closed successfully")
+}
+
+// CancelStockTransfer godoc
+// @Summary Cancel a stock transfer
+// @Description Cancel a draft transfer before any stock has moved
+// @Tags stock
+// @Accept json
+// @Produce json
+// @Param id path int true "Stock transfer ID"
+// @Param request body dto.StockTransferNotesRequest false "Notes"
+// @Success 200 {object} dto.StockTransferResponse
+// @Router /api/stock-transfers/{id}/cancel [post]
+func (h *StockTransferHandler) CancelStockTransfer(c *gin.Context) {
+	h.finishStockTransfer(c, h.stockTransferService.CancelTransfer, "Stock transfer cancelled successfully")
+}
+
+func (h *StockTransferHandler) finishStockTransfer(c *gin.Context, finish func(uint, uint, dto.StockTransferNotesRequest, *uint) (*models.StockTransfer, error), message string) {
+	transferID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid stock transfer ID")
+		return
+	}
+
+	// Notes are optional, so is the body
+	var req dto.StockTransferNotesRequest
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+	}
+
+	currentUserID := c.GetUint("user_id")
+
+	transfer, err := finish(uint(transferID), c.GetUint("tenant_id"), req, &currentUserID)
+	if err != nil {
+		h.respondTransferError(c, err)
+		return
+	}
+
+	utils.Success(c, message, buildStockTransferResponse(transfer))
+}
+
+func (h *StockTransferHandler) respondTransferError(c *gin.Context, err error) {
+	if err.Error() == "stock transfer not found" {
+		utils.NotFound(c, err.Error())
+		return
+	}
+	if errors.Is(err, services.ErrStockTransferReceiver) {
+		utils.Forbidden(c, err.Error())
+		return
+	}
+	utils.BadRequest(c, err.Error())
+}
+
+func buildStockTransferResponse(transfer *models.StockTransfer) dto.StockTransferResponse {
+	response := dto.StockTransferResponse{
+		ID:            transfer.ID,
+		FromBranchID:  transfer.FromBranchID,
+		ToBranchID:    transfer.ToBranchID,
+		Status:        transfer.Status,
+		Notes:         transfer.Notes,
+		SentBy:        transfer.SentBy,
+		ReceivedBy:    transfer.ReceivedBy,
+		ReceivedNotes: transfer.ReceivedNotes,
+		ClosedBy:      transfer.ClosedBy,
+		ClosedNotes:   transfer.ClosedNotes,
+		CreatedAt:     transfer.CreatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:     transfer.CreatedBy,
+		Items:         make([]dto.StockTransferItemResponse, len(transfer.Items)),
+	}
+	if transfer.FromBranch != nil {
+		response.FromBranchName = transfer.FromBranch.Name
+	}
+	if transfer.ToBranch != nil {
+		response.ToBranchName = transfer.ToBranch.Name
+	}
+	if transfer.Creator != nil {
+		name := transfer.Creator.FullName
+		response.CreatedByName = &name
+	}
+	if transfer.SentAt != nil {
+		sentAt := transfer.SentAt.Format("2006-01-02 15:04:05")
+		response.SentAt = &sentAt
+	}
+	if transfer.ReceivedAt != nil {
+		receivedAt := transfer.ReceivedAt.Format("2006-01-02 15:04:05")
+		response.ReceivedAt = &receivedAt
+	}
+	if transfer.ClosedAt != nil {
+		closedAt := transfer.ClosedAt.Format("2006-01-02 15:04:05")
+		response.ClosedAt = &closedAt
+	}
+
+	for i, item := range transfer.Items {
+		response.Items[i] = dto.StockTransferItemResponse{
+			ID:               item.ID,
+			ProductID:        item.ProductID,
+			ProductName:      item.Product.Name,
+			SKU:              item.Product.SKU,
+			Quantity:         item.Quantity,
+			ReceivedQuantity: item.ReceivedQuantity,
+			Discrepancy:      item.Discrepancy,
+			DiscrepancyNote:  item.DiscrepancyNote,
+		}
+	}
+
+	return response
+}
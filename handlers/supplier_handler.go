@@ -0,0 +1,230 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SupplierHandler struct {
+	BaseHandler
+	supplierService *services.SupplierService
+}
+
+func NewSupplierHandler(cfg *config.Config, supplierService *services.SupplierService) *SupplierHandler {
+	return &SupplierHandler{
+		BaseHandler:     BaseHandler{config: cfg},
+		supplierService: supplierService,
+	}
+}
+
+// CreateSupplier godoc
+// @Summary Create a supplier
+// @Description Add a supplier to the tenant's directory. Names must be unique within the tenant.
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateSupplierRequest true "Supplier request"
+// @Success 200 {object} dto.SupplierResponse
+// @Router /api/suppliers [post]
+func (h *SupplierHandler) CreateSupplier(c *gin.Context) {
+	var req dto.CreateSupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.CreatedBy = &currentUserID
+
+	supplier, err := h.supplierService.CreateSupplier(tenantID, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Supplier created successfully", buildSupplierResponse(supplier))
+}
+
+// GetSupplier godoc
+// @Summary Get supplier
+// @Tags suppliers
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} dto.SupplierResponse
+// @Router /api/suppliers/{id} [get]
+func (h *SupplierHandler) GetSupplier(c *gin.Context) {
+	supplierID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid supplier ID")
+		return
+	}
+
+	supplier, err := h.supplierService.GetSupplier(uint(supplierID), c.GetUint("tenant_id"))
+	if err != nil {
+		if err.Error() == "supplier not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Supplier retrieved successfully", buildSupplierResponse(supplier))
+}
+
+// ListSuppliers godoc
+// @Summary List suppliers
+// @Description Get paginated suppliers of the tenant, sorted by name
+// @Tags suppliers
+// @Produce json
+// @Param search query string false "Search by name, contact, phone or email"
+// @Param active_only query bool false "Leave out inactive suppliers"
+// @Param page query int false "Page number" default(1)
+// @Param page_size query int false "Items per page" default(32)
+// @Success 200 {object} dto.PaginationResponse
+// @Router /api/suppliers [get]
+func (h *SupplierHandler) ListSuppliers(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	search := c.Query("search")
+	activeOnly := c.Query("active_only") == "true"
+
+	// Parse pagination parameters
+	var pagination dto.PaginationRequest
+	if err := c.ShouldBindQuery(&pagination); err != nil {
+		pagination = *dto.NewPaginationRequest(1, 32)
+	} else {
+		pagination = *dto.NewPaginationRequest(pagination.Page, pagination.PageSize)
+	}
+
+	suppliers, total, err := h.supplierService.ListSuppliers(tenantID, search, activeOnly, pagination.Page, pagination.PageSize)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.SupplierResponse, len(suppliers))
+	for i := range suppliers {
+		responses[i] = buildSupplierResponse(&suppliers[i])
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"code":        0,
+		"message":     "Suppliers retrieved successfully",
+		"page":        pagination.Page,
+		"page_size":   pagination.PageSize,
+		"total_items": total,
+		"total_pages": (int(total) + pagination.PageSize - 1) / pagination.PageSize,
+		"data":        responses,
+	})
+}
+
+// UpdateSupplier godoc
+// @Summary Update supplier
+// @Description Update a supplier's details. Set is_active to false to stop ordering from them.
+// @Tags suppliers
+// @Accept json
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Param request body dto.UpdateSupplierRequest true "Supplier fields to update"
+// @Success 200 {object} dto.SupplierResponse
+// @Router /api/suppliers/{id} [put]
+func (h *SupplierHandler) UpdateSupplier(c *gin.Context) {
+	supplierID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid supplier ID")
+		return
+	}
+
+	var req dto.UpdateSupplierRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.UpdatedBy = &currentUserID
+
+	supplier, err := h.supplierService.UpdateSupplier(uint(supplierID), tenantID, req)
+	if err != nil {
+		if err.Error() == "supplier not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Supplier updated successfully", buildSupplierResponse(supplier))
+}
+
+// DeleteSupplier godoc
+// @Summary Delete supplier
+// @Description Remove a supplier from the directory. Suppliers with open purchase orders cannot be deleted.
+// @Tags suppliers
+// @Produce json
+// @Param id path int true "Supplier ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/suppliers/{id} [delete]
+func (h *SupplierHandler) DeleteSupplier(c *gin.Context) {
+	supplierID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid supplier ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	if err := h.supplierService.DeleteSupplier(uint(supplierID), tenantID, &currentUserID); err != nil {
+		switch err.Error() {
+		case "supplier not found":
+			utils.NotFound(c, err.Error())
+		case "supplier has open purchase orders":
+			utils.BadRequest(c, err.Error())
+		default:
+			utils.InternalError(c, err.Error())
+		}
+		return
+	}
+
+	utils.SuccessWithoutData(c, "Supplier deleted successfully")
+}
+
+func buildSupplierResponse(supplier *models.Supplier) dto.SupplierResponse {
+	var createdByName, updatedByName *string
+	if supplier.Creator != nil {
+		name := supplier.Creator.FullName
+		createdByName = &name
+	}
+	if supplier.Updater != nil {
+		name := supplier.Updater.FullName
+		updatedByName = &name
+	}
+
+	return dto.SupplierResponse{
+		ID:            supplier.ID,
+		TenantID:      supplier.TenantID,
+		Name:          supplier.Name,
+		ContactName:   supplier.ContactName,
+		Phone:         supplier.Phone,
+		Email:         supplier.Email,
+		Address:       supplier.Address,
+		Notes:         supplier.Notes,
+		IsActive:      supplier.IsActive,
+		CreatedAt:     supplier.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     supplier.UpdatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:     supplier.CreatedBy,
+		CreatedByName: createdByName,
+		UpdatedBy:     supplier.UpdatedBy,
+		UpdatedByName: updatedByName,
+	}
+}
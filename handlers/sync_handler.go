@@ -86,8 +86,9 @@ func (h *SyncHandler) DownloadToClient(c *gin.Context) {
 	}
 
 	tenantID, _ := c.Get("tenant_id")
+	branchID, _ := c.Get("branch_id")
 
-	result, err := h.syncService.DownloadToClient(&req, tenantID.(uint))
+	result, err := h.syncService.DownloadToClient(&req, tenantID.(uint), branchID.(uint))
 	if err != nil {
 		utils.Error(c, http.StatusInternalServerError, 1, err.Error())
 		return
@@ -0,0 +1,221 @@
+package handlers
+
+import (
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TaxRuleHandler struct {
+	BaseHandler
+	taxService *services.TaxService
+}
+
+func NewTaxRuleHandler(cfg *config.Config, taxService *services.TaxService) *TaxRuleHandler {
+	return &TaxRuleHandler{
+		BaseHandler: BaseHandler{config: cfg},
+		taxService:  taxService,
+	}
+}
+
+// CreateTaxRule godoc
+// @Summary Create a tax or service charge rule
+// @Description Create a rule for the whole tenant or a single branch. Rules are applied by sequence; compound rules are charged on top of earlier charges and inclusive rules are extracted from prices
+// @Tags tax-rules
+// @Accept json
+// @Produce json
+// @Param request body dto.CreateTaxRuleRequest true "Tax rule request"
+// @Success 200 {object} dto.TaxRuleResponse
+// @Router /api/tax-rules [post]
+func (h *TaxRuleHandler) CreateTaxRule(c *gin.Context) {
+	var req dto.CreateTaxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.CreatedBy = &currentUserID
+
+	rule, err := h.taxService.CreateTaxRule(tenantID, req)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Tax rule created successfully", buildTaxRuleResponse(rule))
+}
+
+// GetTaxRule godoc
+// @Summary Get tax rule by ID
+// @Tags tax-rules
+// @Produce json
+// @Param id path int true "Tax rule ID"
+// @Success 200 {object} dto.TaxRuleResponse
+// @Router /api/tax-rules/{id} [get]
+func (h *TaxRuleHandler) GetTaxRule(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid tax rule ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+
+	rule, err := h.taxService.GetTaxRule(uint(ruleID), tenantID)
+	if err != nil {
+		utils.NotFound(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Tax rule retrieved successfully", buildTaxRuleResponse(rule))
+}
+
+// ListTaxRules godoc
+// @Summary List tax rules
+// @Description Get the tax and service charge rules of the tenant
+// @Tags tax-rules
+// @Produce json
+// @Param branch_id query int false "Only rules applying to this branch"
+// @Success 200 {array} dto.TaxRuleResponse
+// @Router /api/tax-rules [get]
+func (h *TaxRuleHandler) ListTaxRules(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	var branchID uint
+	if branchIDStr := c.Query("branch_id"); branchIDStr != "" {
+		parsed, err := strconv.ParseUint(branchIDStr, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid branch ID")
+			return
+		}
+		branchID = uint(parsed)
+	}
+
+	rules, err := h.taxService.ListTaxRules(tenantID, branchID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	responses := make([]dto.TaxRuleResponse, len(rules))
+	for i := range rules {
+		responses[i] = buildTaxRuleResponse(&rules[i])
+	}
+
+	utils.Success(c, "Tax rules retrieved successfully", responses)
+}
+
+// UpdateTaxRule godoc
+// @Summary Update tax rule
+// @Tags tax-rules
+// @Accept json
+// @Produce json
+// @Param id path int true "Tax rule ID"
+// @Param request body dto.UpdateTaxRuleRequest true "Tax rule fields to update"
+// @Success 200 {object} dto.TaxRuleResponse
+// @Router /api/tax-rules/{id} [put]
+func (h *TaxRuleHandler) UpdateTaxRule(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid tax rule ID")
+		return
+	}
+
+	var req dto.UpdateTaxRuleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+	req.UpdatedBy = &currentUserID
+
+	rule, err := h.taxService.UpdateTaxRule(uint(ruleID), tenantID, req)
+	if err != nil {
+		if err.Error() == "tax rule not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Tax rule updated successfully", buildTaxRuleResponse(rule))
+}
+
+// DeleteTaxRule godoc
+// @Summary Delete tax rule
+// @Tags tax-rules
+// @Produce json
+// @Param id path int true "Tax rule ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/tax-rules/{id} [delete]
+func (h *TaxRuleHandler) DeleteTaxRule(c *gin.Context) {
+	ruleID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		utils.BadRequest(c, "Invalid tax rule ID")
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	if err := h.taxService.DeleteTaxRule(uint(ruleID), tenantID, &currentUserID); err != nil {
+		if err.Error() == "tax rule not found" {
+			utils.NotFound(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.SuccessWithoutData(c, "Tax rule deleted successfully")
+}
+
+func buildTaxRuleResponse(rule *models.TaxRule) dto.TaxRuleResponse {
+	var createdByName, updatedByName *string
+	if rule.Creator != nil {
+		name := rule.Creator.FullName
+		createdByName = &name
+	}
+	if rule.Updater != nil {
+		name := rule.Updater.FullName
+		updatedByName = &name
+	}
+
+	exemptions := make([]dto.TaxRuleExemptionResponse, len(rule.Exemptions))
+	for i, exemption := range rule.Exemptions {
+		exemptions[i] = dto.TaxRuleExemptionResponse{CategoryID: exemption.CategoryID}
+		if exemption.Category != nil {
+			exemptions[i].CategoryName = exemption.Category.Name
+		}
+	}
+
+	return dto.TaxRuleResponse{
+		ID:            rule.ID,
+		TenantID:      rule.TenantID,
+		BranchID:      rule.BranchID,
+		Name:          rule.Name,
+		Type:          rule.Type,
+		Rate:          rule.Rate,
+		IsInclusive:   rule.IsInclusive,
+		Sequence:      rule.Sequence,
+		IsCompound:    rule.IsCompound,
+		Exemptions:    exemptions,
+		IsActive:      rule.IsActive,
+		CreatedAt:     rule.CreatedAt.Format("2006-01-02 15:04:05"),
+		UpdatedAt:     rule.UpdatedAt.Format("2006-01-02 15:04:05"),
+		CreatedBy:     rule.CreatedBy,
+		CreatedByName: createdByName,
+		UpdatedBy:     rule.UpdatedBy,
+		UpdatedByName: updatedByName,
+	}
+}
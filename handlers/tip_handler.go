@@ -0,0 +1,146 @@
+package handlers
+
+import (
+	"errors"
+	"myposcore/config"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/services"
+	"myposcore/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TipHandler struct {
+	BaseHandler
+	tipService *services.TipService
+}
+
+func NewTipHandler(cfg *config.Config, tipService *services.TipService) *TipHandler {
+	return &TipHandler{
+		BaseHandler: BaseHandler{config: cfg},
+		tipService:  tipService,
+	}
+}
+
+// GetTipPresets godoc
+// @Summary Get tip presets
+// @Description Get the tip suggestions offered at payment, fixed amounts or percentages of the order total
+// @Tags tips
+// @Produce json
+// @Success 200 {array} dto.TipPresetResponse
+// @Router /api/tips/presets [get]
+func (h *TipHandler) GetTipPresets(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+
+	presets, err := h.tipService.GetPresets(tenantID)
+	if err != nil {
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Tip presets retrieved successfully", buildTipPresetResponses(presets))
+}
+
+// SaveTipPresets godoc
+// @Summary Save tip presets
+// @Description Replace the tenant's tip presets; they are offered in the given order
+// @Tags tips
+// @Accept json
+// @Produce json
+// @Param request body dto.SaveTipPresetsRequest true "Tip presets"
+// @Success 200 {array} dto.TipPresetResponse
+// @Router /api/tips/presets [put]
+func (h *TipHandler) SaveTipPresets(c *gin.Context) {
+	var req dto.SaveTipPresetsRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	tenantID := c.GetUint("tenant_id")
+	currentUserID := c.GetUint("user_id")
+
+	presets, err := h.tipService.SavePresets(tenantID, req, &currentUserID)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	utils.Success(c, "Tip presets saved successfully", buildTipPresetResponses(presets))
+}
+
+// GetTipReport godoc
+// @Summary Get tip report
+// @Description Get the tips owed to each staff member, per cash shift, so they can be paid out. Tips are not sales revenue and stay with the staff member when a sale is refunded.
+// @Tags tips
+// @Produce json
+// @Param date_from query string false "From date (YYYY-MM-DD), in the branch timezone"
+// @Param date_to query string false "To date (YYYY-MM-DD), inclusive"
+// @Param user_id query int false "Staff member tipped"
+// @Param shift_id query int false "Cash shift"
+// @Param payment_method query string false "Comma separated payment methods"
+// @Success 200 {object} dto.TipReportResponse
+// @Router /api/tips/report [get]
+func (h *TipHandler) GetTipReport(c *gin.Context) {
+	tenantID := c.GetUint("tenant_id")
+	branchID := c.GetUint("branch_id")
+
+	filter, err := parseListFilter(c)
+	if err != nil {
+		utils.BadRequest(c, err.Error())
+		return
+	}
+
+	var shiftID *uint
+	if shiftParam := c.Query("shift_id"); shiftParam != "" {
+		id, err := strconv.ParseUint(shiftParam, 10, 32)
+		if err != nil {
+			utils.BadRequest(c, "Invalid shift_id")
+			return
+		}
+		sid := uint(id)
+		shiftID = &sid
+	}
+
+	staff, err := h.tipService.GetReport(tenantID, branchID, filter, shiftID)
+	if err != nil {
+		if errors.Is(err, services.ErrInvalidListFilter) {
+			utils.BadRequest(c, err.Error())
+			return
+		}
+		utils.InternalError(c, err.Error())
+		return
+	}
+
+	response := dto.TipReportResponse{
+		DateFrom:    filter.DateFrom,
+		DateTo:      filter.DateTo,
+		ShiftID:     shiftID,
+		Staff:       staff,
+		GeneratedAt: time.Now().Format("2006-01-02 15:04:05"),
+	}
+	for _, member := range staff {
+		response.CashTips += member.CashTips
+		response.NonCashTips += member.NonCashTips
+		response.TotalTips += member.TotalTips
+	}
+
+	utils.Success(c, "Tip report generated successfully", response)
+}
+
+func buildTipPresetResponses(presets []models.TipPreset) []dto.TipPresetResponse {
+	responses := make([]dto.TipPresetResponse, len(presets))
+	for i, preset := range presets {
+		responses[i] = dto.TipPresetResponse{
+			ID:        preset.ID,
+			Type:      preset.Type,
+			Amount:    preset.Amount,
+			Percent:   preset.Percent,
+			SortOrder: preset.SortOrder,
+		}
+	}
+	return responses
+}
@@ -0,0 +1,103 @@
+package middleware
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"io"
+	"myposcore/services"
+	"myposcore/utils"
+	"net/http"
+
+	"github.com/gin-gonic/gin"
+)
+
+// maxIdempotencyKeyLength matches the size of idempotency_keys.key
+const maxIdempotencyKeyLength = 255
+
+// responseRecorder keeps a copy of the response body while it is written to the client
+type responseRecorder struct {
+	gin.ResponseWriter
+	body bytes.Buffer
+}
+
+func (w *responseRecorder) Write(data []byte) (int, error) {
+	w.body.Write(data)
+	return w.ResponseWriter.Write(data)
+}
+
+func (w *responseRecorder) WriteString(s string) (int, error) {
+	w.body.WriteString(s)
+	return w.ResponseWriter.WriteString(s)
+}
+
+// IdempotencyMiddleware makes a write endpoint safe to retry. When the request carries an
+// Idempotency-Key header, the first response is stored per tenant, user and key and replayed for
+// retries; reusing the key with a different body returns 409. Requests without the header run as usual.
+func IdempotencyMiddleware(idempotencyService *services.IdempotencyService) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		key := c.GetHeader("Idempotency-Key")
+		if key == "" {
+			c.Next()
+			return
+		}
+		if len(key) > maxIdempotencyKeyLength {
+			utils.BadRequest(c, "Idempotency-Key must be at most 255 characters")
+			c.Abort()
+			return
+		}
+
+		body, err := io.ReadAll(c.Request.Body)
+		if err != nil {
+			utils.BadRequest(c, "Failed to read request body")
+			c.Abort()
+			return
+		}
+		c.Request.Body = io.NopCloser(bytes.NewReader(body))
+
+		hash := sha256.New()
+		hash.Write([]byte(c.Request.Method + " " + c.Request.URL.Path + "\n"))
+		hash.Write(body)
+		requestHash := hex.EncodeToString(hash.Sum(nil))
+
+		record, isNew, err := idempotencyService.Begin(c.GetUint("tenant_id"), c.GetUint("user_id"), key, c.Request.Method, c.Request.URL.Path, requestHash)
+		if err != nil {
+			if errors.Is(err, services.ErrIdempotencyKeyReused) || errors.Is(err, services.ErrIdempotencyKeyInProgress) {
+				utils.Conflict(c, err.Error())
+			} else {
+				utils.InternalError(c, err.Error())
+			}
+			c.Abort()
+			return
+		}
+
+		// Replay the stored response
+		if !isNew {
+			c.Header("Idempotent-Replayed", "true")
+			c.Data(record.ResponseStatus, record.ContentType, []byte(record.ResponseBody))
+			c.Abort()
+			return
+		}
+
+		// Free the key if the handler panics, otherwise retries would be rejected until it expires
+		defer func() {
+			if r := recover(); r != nil {
+				_ = idempotencyService.Release(record.ID)
+				panic(r)
+			}
+		}()
+
+		recorder := &responseRecorder{ResponseWriter: c.Writer}
+		c.Writer = recorder
+		c.Next()
+
+		// Server errors are not stored so the client can retry with the same key
+		status := recorder.Status()
+		if status >= http.StatusInternalServerError {
+			_ = idempotencyService.Release(record.ID)
+			return
+		}
+		_ = idempotencyService.Complete(record.ID, status, recorder.Header().Get("Content-Type"), recorder.body.Bytes())
+	}
+}
@@ -0,0 +1,20 @@
+-- Migration: Branch timezone and list filter indexes
+-- Description: Branches get an IANA timezone so date filters on order and payment listings follow
+--              the branch's business day, and indexes back the new list filters
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Add timezone to branches
+ALTER TABLE branches ADD COLUMN IF NOT EXISTS timezone VARCHAR(50) DEFAULT 'Asia/Jakarta';
+UPDATE branches SET timezone = 'Asia/Jakarta' WHERE timezone IS NULL OR timezone = '';
+
+-- Step 2: Indexes for filtered listings
+CREATE INDEX IF NOT EXISTS idx_orders_tenant_created_at ON orders(tenant_id, created_at);
+CREATE INDEX IF NOT EXISTS idx_orders_order_number_prefix ON orders(order_number varchar_pattern_ops);
+CREATE INDEX IF NOT EXISTS idx_payments_created_at ON payments(created_at);
+
+-- Rollback instructions:
+-- DROP INDEX IF EXISTS idx_payments_created_at;
+-- DROP INDEX IF EXISTS idx_orders_order_number_prefix;
+-- DROP INDEX IF EXISTS idx_orders_tenant_created_at;
+-- ALTER TABLE branches DROP COLUMN IF EXISTS timezone;
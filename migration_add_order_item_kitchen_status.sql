@@ -0,0 +1,12 @@
+-- Migration: Kitchen status of order items
+-- Description: Kitchen displays bump single items (pending -> preparing -> ready) besides whole
+--              orders; each bump is streamed to the branch's screens as order.item_bumped
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Add kitchen_status to order_items
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS kitchen_status VARCHAR(20) DEFAULT 'pending';
+UPDATE order_items SET kitchen_status = 'pending' WHERE kitchen_status IS NULL;
+
+-- Rollback instructions:
+-- ALTER TABLE order_items DROP COLUMN IF EXISTS kitchen_status;
@@ -0,0 +1,28 @@
+-- Migration: Supervisor approved manual discounts
+-- Description: Orders can carry a manual discount granted by the cashier with a supervisor's PIN
+--              approval. The resolved amount is part of discount_amount. Approvals of voids,
+--              cancellations of paid orders, manual discounts and drawer openings are written to
+--              audit_trails with entity_type 'supervisor_approval'
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Add manual discount columns to orders
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS manual_discount_type VARCHAR(20);
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS manual_discount_value DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS manual_discount_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS manual_discount_reason TEXT;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS manual_discount_approved_by INTEGER REFERENCES users(id) ON DELETE SET NULL;
+
+CREATE INDEX IF NOT EXISTS idx_orders_manual_discount_approved_by ON orders(manual_discount_approved_by);
+
+-- Step 2: Index to look up approvals in the audit trail
+CREATE INDEX IF NOT EXISTS idx_audit_trails_supervisor_approval ON audit_trails(tenant_id, created_at) WHERE entity_type = 'supervisor_approval';
+
+-- Rollback instructions:
+-- DROP INDEX IF EXISTS idx_audit_trails_supervisor_approval;
+-- DROP INDEX IF EXISTS idx_orders_manual_discount_approved_by;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS manual_discount_approved_by;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS manual_discount_reason;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS manual_discount_amount;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS manual_discount_value;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS manual_discount_type;
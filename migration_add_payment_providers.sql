@@ -0,0 +1,30 @@
+-- Migration: Payment providers
+-- Description: Asynchronous card/e-wallet payments collected through a payment provider
+--              (pending -> provider callback -> completed or failed) and provider refund references
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Provider fields on payments
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider VARCHAR(50);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider_reference VARCHAR(100);
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider_action_url TEXT;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider_qr_string TEXT;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS provider_expires_at TIMESTAMP;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS failure_reason TEXT;
+
+CREATE INDEX IF NOT EXISTS idx_payments_provider ON payments(provider);
+CREATE INDEX IF NOT EXISTS idx_payments_provider_reference ON payments(provider_reference);
+
+-- Step 2: Provider reference on refunds
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS provider_reference VARCHAR(100);
+
+-- Rollback instructions:
+-- ALTER TABLE refunds DROP COLUMN IF EXISTS provider_reference;
+-- DROP INDEX IF EXISTS idx_payments_provider_reference;
+-- DROP INDEX IF EXISTS idx_payments_provider;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS failure_reason;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS provider_expires_at;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS provider_qr_string;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS provider_action_url;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS provider_reference;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS provider;
@@ -0,0 +1,11 @@
+-- Migration: Pending and failed provider refunds
+-- Description: Refunds of provider payments are saved as pending before the provider is called, then
+--              completed or marked failed with the provider's reason.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Add failure_reason to refunds
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS failure_reason TEXT;
+
+-- Rollback instructions:
+-- ALTER TABLE refunds DROP COLUMN IF EXISTS failure_reason;
@@ -0,0 +1,40 @@
+-- Migration: Split tenders and partial payments
+-- Description: An order can now be paid by several payments. payments.amount holds the amount
+--              applied to the order, the cash handed over and the change are stored separately,
+--              and orders track the paid amount so the balance due can be shown
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Add columns
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS tendered_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS change_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS paid_amount DECIMAL(15,2) DEFAULT 0;
+
+-- Step 2: Backfill existing payments (one payment per order, amount included the change)
+UPDATE payments SET tendered_amount = amount WHERE tendered_amount = 0;
+
+UPDATE payments p
+SET change_amount = p.amount - o.total_amount,
+    amount = o.total_amount
+FROM orders o
+WHERE o.id = p.order_id
+  AND p.amount > o.total_amount
+  AND p.change_amount = 0;
+
+-- Step 3: Backfill paid amount of orders
+UPDATE orders o
+SET paid_amount = sub.paid
+FROM (
+    SELECT order_id, SUM(amount) AS paid
+    FROM payments
+    WHERE status IN ('completed', 'partially_refunded', 'refunded')
+      AND deleted_at IS NULL
+    GROUP BY order_id
+) sub
+WHERE sub.order_id = o.id;
+
+-- Rollback instructions:
+-- UPDATE payments SET amount = tendered_amount WHERE tendered_amount > amount;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS paid_amount;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS change_amount;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS tendered_amount;
@@ -0,0 +1,21 @@
+-- Migration: Tenant currency and cash rounding
+-- Description: Each tenant's currency, its minor unit precision and how cash totals are rounded to
+--              the smallest denomination; payments keep the cash rounding they wrote off
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Currency settings of each tenant; existing tenants keep IDR with 2 decimals and no cash rounding
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS currency_code VARCHAR(3) NOT NULL DEFAULT 'IDR';
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS currency_precision INTEGER NOT NULL DEFAULT 2;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS cash_rounding_increment DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE tenants ADD COLUMN IF NOT EXISTS cash_rounding_mode VARCHAR(20) NOT NULL DEFAULT 'half_up';
+
+-- Step 2: Cash rounding written off by a payment; amount + rounding_amount settles the order
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS rounding_amount DECIMAL(15,2) DEFAULT 0;
+
+-- Rollback instructions:
+-- ALTER TABLE payments DROP COLUMN IF EXISTS rounding_amount;
+-- ALTER TABLE tenants DROP COLUMN IF EXISTS cash_rounding_mode;
+-- ALTER TABLE tenants DROP COLUMN IF EXISTS cash_rounding_increment;
+-- ALTER TABLE tenants DROP COLUMN IF EXISTS currency_precision;
+-- ALTER TABLE tenants DROP COLUMN IF EXISTS currency_code;
@@ -0,0 +1,14 @@
+-- Migration: Purchase costs at full scale
+-- Description: Keep the weighted average cost of products, and the unit cost order items keep from
+--              it, at the 4 decimal places money amounts carry, so averaging over many goods
+--              receipts does not drift by rounding to the currency's minor unit.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Widen the cost columns
+ALTER TABLE products ALTER COLUMN cost_price TYPE DECIMAL(15,4);
+ALTER TABLE order_items ALTER COLUMN unit_cost TYPE DECIMAL(15,4);
+
+-- Rollback instructions:
+-- ALTER TABLE order_items ALTER COLUMN unit_cost TYPE DECIMAL(15,2);
+-- ALTER TABLE products ALTER COLUMN cost_price TYPE DECIMAL(15,2);
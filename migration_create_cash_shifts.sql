@@ -0,0 +1,73 @@
+-- Migration: Cash shifts
+-- Description: Cashier shifts on a branch cash drawer with opening float, pay-ins/pay-outs and
+--              close-out counts; cash payments and refunds are tied to the open shift
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create cash_shifts table
+CREATE TABLE IF NOT EXISTS cash_shifts (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id),
+    status VARCHAR(20) NOT NULL,
+    opening_float DECIMAL(15,2) NOT NULL,
+    opening_notes TEXT,
+    opened_at TIMESTAMP NOT NULL,
+    closed_at TIMESTAMP,
+    closed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    closing_notes TEXT,
+    denominations JSONB DEFAULT '[]',
+    cash_sales DECIMAL(15,2) DEFAULT 0,
+    cash_refunds DECIMAL(15,2) DEFAULT 0,
+    pay_ins DECIMAL(15,2) DEFAULT 0,
+    pay_outs DECIMAL(15,2) DEFAULT 0,
+    expected_cash DECIMAL(15,2) DEFAULT 0,
+    counted_cash DECIMAL(15,2) DEFAULT 0,
+    variance DECIMAL(15,2) DEFAULT 0,
+    z_number INTEGER DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_shifts_tenant_id ON cash_shifts(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_cash_shifts_branch_id ON cash_shifts(branch_id);
+CREATE INDEX IF NOT EXISTS idx_cash_shifts_user_id ON cash_shifts(user_id);
+CREATE INDEX IF NOT EXISTS idx_cash_shifts_status ON cash_shifts(status);
+CREATE INDEX IF NOT EXISTS idx_cash_shifts_closed_by ON cash_shifts(closed_by);
+
+-- A cashier has at most one open shift per branch
+CREATE UNIQUE INDEX IF NOT EXISTS idx_cash_shift_open ON cash_shifts(branch_id, user_id) WHERE status = 'open';
+
+-- Step 2: Create cash_movements table (pay-ins and pay-outs)
+CREATE TABLE IF NOT EXISTS cash_movements (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    shift_id INTEGER NOT NULL REFERENCES cash_shifts(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    reason TEXT NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_cash_movements_tenant_id ON cash_movements(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_cash_movements_branch_id ON cash_movements(branch_id);
+CREATE INDEX IF NOT EXISTS idx_cash_movements_shift_id ON cash_movements(shift_id);
+CREATE INDEX IF NOT EXISTS idx_cash_movements_created_by ON cash_movements(created_by);
+
+-- Step 3: Tie payments and refunds to the shift they were taken in
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS shift_id INTEGER;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS shift_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_payments_shift_id ON payments(shift_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_shift_id ON refunds(shift_id);
+
+-- Rollback instructions:
+-- DROP INDEX IF EXISTS idx_refunds_shift_id;
+-- DROP INDEX IF EXISTS idx_payments_shift_id;
+-- ALTER TABLE refunds DROP COLUMN IF EXISTS shift_id;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS shift_id;
+-- DROP TABLE IF EXISTS cash_movements;
+-- DROP TABLE IF EXISTS cash_shifts;
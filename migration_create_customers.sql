@@ -0,0 +1,39 @@
+-- Migration: Customer directory
+-- Description: Tenant scoped customers (name, phone, email, birthday, notes, tags) and an optional
+--              customer_id on orders for lifetime spend and visit history
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create customers table
+CREATE TABLE IF NOT EXISTS customers (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    phone VARCHAR(30),
+    email VARCHAR(255),
+    birthday DATE,
+    notes TEXT,
+    tags JSONB NOT NULL DEFAULT '[]',
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_customers_tenant_id ON customers(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_customers_name ON customers(name);
+CREATE INDEX IF NOT EXISTS idx_customers_phone ON customers(phone);
+CREATE INDEX IF NOT EXISTS idx_customers_email ON customers(email);
+CREATE INDEX IF NOT EXISTS idx_customers_tags ON customers USING GIN (tags);
+CREATE INDEX IF NOT EXISTS idx_customers_deleted_at ON customers(deleted_at);
+
+-- Step 2: Link orders to customers
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS customer_id INTEGER REFERENCES customers(id) ON DELETE SET NULL;
+CREATE INDEX IF NOT EXISTS idx_orders_customer_id ON orders(customer_id);
+
+-- Rollback instructions:
+-- DROP INDEX IF EXISTS idx_orders_customer_id;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS customer_id;
+-- DROP TABLE IF EXISTS customers;
@@ -0,0 +1,86 @@
+-- Migration: Gift cards and store credit
+-- Description: Gift cards sold as order items and store credit issued from refunds, tendered with the
+--              gift_card payment method. Every balance movement is kept in an append-only ledger.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create gift_cards table
+CREATE TABLE IF NOT EXISTS gift_cards (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    code VARCHAR(32) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    customer_id INTEGER REFERENCES customers(id) ON DELETE SET NULL,
+    initial_balance DECIMAL(15,2) NOT NULL,
+    balance DECIMAL(15,2) NOT NULL,
+    expires_at TIMESTAMP,
+    status VARCHAR(20) NOT NULL DEFAULT 'active',
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    order_item_id INTEGER REFERENCES order_items(id) ON DELETE SET NULL,
+    refund_id INTEGER REFERENCES refunds(id) ON DELETE SET NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_gift_card_code ON gift_cards(tenant_id, code);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_branch_id ON gift_cards(branch_id);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_type ON gift_cards(type);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_customer_id ON gift_cards(customer_id);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_expires_at ON gift_cards(expires_at);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_status ON gift_cards(status);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_order_id ON gift_cards(order_id);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_order_item_id ON gift_cards(order_item_id);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_refund_id ON gift_cards(refund_id);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_created_by ON gift_cards(created_by);
+CREATE INDEX IF NOT EXISTS idx_gift_cards_updated_by ON gift_cards(updated_by);
+
+-- Step 2: Create gift_card_transactions ledger (amount positive = credit, negative = debit)
+CREATE TABLE IF NOT EXISTS gift_card_transactions (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    gift_card_id INTEGER NOT NULL REFERENCES gift_cards(id) ON DELETE CASCADE,
+    branch_id INTEGER REFERENCES branches(id) ON DELETE SET NULL,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    payment_id INTEGER REFERENCES payments(id) ON DELETE SET NULL,
+    refund_id INTEGER REFERENCES refunds(id) ON DELETE SET NULL,
+    type VARCHAR(20) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    balance_after DECIMAL(15,2) NOT NULL,
+    description TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_tenant_id ON gift_card_transactions(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_gift_card_id ON gift_card_transactions(gift_card_id);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_branch_id ON gift_card_transactions(branch_id);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_order_id ON gift_card_transactions(order_id);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_payment_id ON gift_card_transactions(payment_id);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_refund_id ON gift_card_transactions(refund_id);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_type ON gift_card_transactions(type);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_created_by ON gift_card_transactions(created_by);
+CREATE INDEX IF NOT EXISTS idx_gift_card_transactions_created_at ON gift_card_transactions(created_at);
+
+-- Step 3: Products sold as gift cards
+ALTER TABLE products ADD COLUMN IF NOT EXISTS is_gift_card BOOLEAN DEFAULT false;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS gift_card_validity_days INTEGER DEFAULT 0;
+
+-- Step 4: Card redeemed by a gift_card payment and card credited by a gift_card or store_credit refund
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS gift_card_id INTEGER;
+ALTER TABLE refunds ADD COLUMN IF NOT EXISTS gift_card_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_payments_gift_card_id ON payments(gift_card_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_gift_card_id ON refunds(gift_card_id);
+
+-- Rollback instructions:
+-- DROP INDEX IF EXISTS idx_refunds_gift_card_id;
+-- DROP INDEX IF EXISTS idx_payments_gift_card_id;
+-- ALTER TABLE refunds DROP COLUMN IF EXISTS gift_card_id;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS gift_card_id;
+-- ALTER TABLE products DROP COLUMN IF EXISTS gift_card_validity_days;
+-- ALTER TABLE products DROP COLUMN IF EXISTS is_gift_card;
+-- DROP TABLE IF EXISTS gift_card_transactions;
+-- DROP TABLE IF EXISTS gift_cards;
@@ -0,0 +1,30 @@
+-- Migration: Idempotency keys
+-- Description: Responses of order, payment, refund and sync upload writes sent with an
+--              Idempotency-Key header are stored per tenant, user and key and replayed on retry.
+--              Keys expire after 24 hours and are purged by the server
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create idempotency_keys table
+CREATE TABLE IF NOT EXISTS idempotency_keys (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL REFERENCES users(id) ON DELETE CASCADE,
+    key VARCHAR(255) NOT NULL,
+    method VARCHAR(10) NOT NULL,
+    path VARCHAR(255) NOT NULL,
+    request_hash VARCHAR(64) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    response_status INTEGER,
+    response_body TEXT,
+    content_type VARCHAR(100),
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    expires_at TIMESTAMP NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_idempotency_key ON idempotency_keys(tenant_id, user_id, key);
+CREATE INDEX IF NOT EXISTS idx_idempotency_keys_expires_at ON idempotency_keys(expires_at);
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS idempotency_keys;
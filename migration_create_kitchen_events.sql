@@ -0,0 +1,24 @@
+-- Migration: Kitchen display events
+-- Description: Order changes are written to kitchen_events in the same transaction as the change
+--              and streamed to kitchen screens over SSE. The id is the SSE event id used to resume
+--              after a reconnect; events older than 24 hours are purged by the server
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create kitchen_events table
+CREATE TABLE IF NOT EXISTS kitchen_events (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    type VARCHAR(40) NOT NULL,
+    data JSONB NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_kitchen_event_branch ON kitchen_events(tenant_id, branch_id, id);
+CREATE INDEX IF NOT EXISTS idx_kitchen_events_order_id ON kitchen_events(order_id);
+CREATE INDEX IF NOT EXISTS idx_kitchen_events_created_at ON kitchen_events(created_at);
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS kitchen_events;
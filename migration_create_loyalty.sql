@@ -0,0 +1,84 @@
+-- Migration: Loyalty points program
+-- Description: Per tenant earn/redeem rules with category multipliers and tiers, an append-only
+--              points ledger linked to orders, payments and refunds, and cached balances on customers
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create loyalty_programs table
+CREATE TABLE IF NOT EXISTS loyalty_programs (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    is_active BOOLEAN NOT NULL DEFAULT FALSE,
+    points_per_unit DECIMAL(15,4) DEFAULT 0,
+    redeem_value DECIMAL(15,2) DEFAULT 0,
+    min_redeem_points INTEGER DEFAULT 0,
+    expiry_days INTEGER DEFAULT 0,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_programs_tenant_id ON loyalty_programs(tenant_id);
+
+-- Step 2: Create loyalty_category_multipliers table
+CREATE TABLE IF NOT EXISTS loyalty_category_multipliers (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    multiplier DECIMAL(8,2) NOT NULL
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_category_multiplier ON loyalty_category_multipliers(tenant_id, category_id);
+
+-- Step 3: Create loyalty_tiers table
+CREATE TABLE IF NOT EXISTS loyalty_tiers (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(50) NOT NULL,
+    min_points INTEGER NOT NULL DEFAULT 0,
+    earn_multiplier DECIMAL(8,2) NOT NULL DEFAULT 1
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_loyalty_tier ON loyalty_tiers(tenant_id, name);
+
+-- Step 4: Create loyalty_transactions ledger (append-only)
+CREATE TABLE IF NOT EXISTS loyalty_transactions (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    customer_id INTEGER NOT NULL REFERENCES customers(id) ON DELETE CASCADE,
+    branch_id INTEGER REFERENCES branches(id) ON DELETE SET NULL,
+    order_id INTEGER REFERENCES orders(id) ON DELETE SET NULL,
+    payment_id INTEGER REFERENCES payments(id) ON DELETE SET NULL,
+    refund_id INTEGER REFERENCES refunds(id) ON DELETE SET NULL,
+    type VARCHAR(20) NOT NULL,
+    points INTEGER NOT NULL,
+    balance_after INTEGER NOT NULL,
+    expires_at TIMESTAMP,
+    description TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_tenant_id ON loyalty_transactions(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_customer_id ON loyalty_transactions(customer_id);
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_order_id ON loyalty_transactions(order_id);
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_payment_id ON loyalty_transactions(payment_id);
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_refund_id ON loyalty_transactions(refund_id);
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_type ON loyalty_transactions(type);
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_expires_at ON loyalty_transactions(expires_at);
+CREATE INDEX IF NOT EXISTS idx_loyalty_transactions_created_at ON loyalty_transactions(created_at);
+
+-- Step 5: Cached balance and tier on customers
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS loyalty_points INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS lifetime_points INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE customers ADD COLUMN IF NOT EXISTS loyalty_tier VARCHAR(50);
+
+-- Rollback instructions:
+-- ALTER TABLE customers DROP COLUMN IF EXISTS loyalty_tier;
+-- ALTER TABLE customers DROP COLUMN IF EXISTS lifetime_points;
+-- ALTER TABLE customers DROP COLUMN IF EXISTS loyalty_points;
+-- DROP TABLE IF EXISTS loyalty_transactions;
+-- DROP TABLE IF EXISTS loyalty_tiers;
+-- DROP TABLE IF EXISTS loyalty_category_multipliers;
+-- DROP TABLE IF EXISTS loyalty_programs;
@@ -0,0 +1,61 @@
+-- Migration: Gap-free per-branch order numbering
+-- Description: Order numbers are issued from a per tenant/branch/day counter inside the order
+--              transaction, formatted per tenant or branch, and offline clients can reserve blocks
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create order_number_formats table
+CREATE TABLE IF NOT EXISTS order_number_formats (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER REFERENCES branches(id) ON DELETE CASCADE,
+    prefix VARCHAR(20) DEFAULT 'ORD',
+    branch_code VARCHAR(20),
+    date_format VARCHAR(20) DEFAULT '20060102',
+    padding INTEGER DEFAULT 4,
+    separator VARCHAR(5) DEFAULT '-',
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_number_format ON order_number_formats(tenant_id, branch_id);
+CREATE INDEX IF NOT EXISTS idx_order_number_formats_deleted_at ON order_number_formats(deleted_at);
+
+-- Step 2: Create order_number_sequences table (last counter per branch per day)
+CREATE TABLE IF NOT EXISTS order_number_sequences (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    sequence_date VARCHAR(10) NOT NULL,
+    last_number INTEGER NOT NULL DEFAULT 0,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_number_sequence ON order_number_sequences(tenant_id, branch_id, sequence_date);
+
+-- Step 3: Create order_number_reservations table (blocks handed to offline clients)
+CREATE TABLE IF NOT EXISTS order_number_reservations (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    client_id VARCHAR(100) NOT NULL,
+    sequence_date VARCHAR(10) NOT NULL,
+    start_number INTEGER NOT NULL,
+    end_number INTEGER NOT NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_number_reservations_tenant_id ON order_number_reservations(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_order_number_reservations_branch_id ON order_number_reservations(branch_id);
+CREATE INDEX IF NOT EXISTS idx_order_number_reservations_client_id ON order_number_reservations(client_id);
+CREATE INDEX IF NOT EXISTS idx_order_number_reservations_sequence_date ON order_number_reservations(sequence_date);
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS order_number_reservations;
+-- DROP TABLE IF EXISTS order_number_sequences;
+-- DROP TABLE IF EXISTS order_number_formats;
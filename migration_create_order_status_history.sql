@@ -0,0 +1,27 @@
+-- Migration: Create order_status_history table
+-- Description: Records every order status transition (pending -> confirmed -> preparing -> ready -> completed, cancelled, voided)
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create order_status_history table
+CREATE TABLE IF NOT EXISTS order_status_history (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    from_status VARCHAR(20),
+    to_status VARCHAR(20) NOT NULL,
+    reason TEXT,
+    changed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+-- Step 2: Create indexes for performance
+CREATE INDEX IF NOT EXISTS idx_order_status_history_tenant_id ON order_status_history(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_order_status_history_branch_id ON order_status_history(branch_id);
+CREATE INDEX IF NOT EXISTS idx_order_status_history_order_id ON order_status_history(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_status_history_to_status ON order_status_history(to_status);
+CREATE INDEX IF NOT EXISTS idx_order_status_history_changed_by ON order_status_history(changed_by);
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS order_status_history;
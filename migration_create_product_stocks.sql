@@ -0,0 +1,31 @@
+-- Migration: Stock per branch
+-- Description: Keep the stock of every product at every branch. Sales, refunds, adjustments and sync
+--              move the stock of the branch they happen at; products.stock stays the total.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create product_stocks table
+CREATE TABLE IF NOT EXISTS product_stocks (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL DEFAULT 0,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_product_stock_branch ON product_stocks(branch_id, product_id);
+CREATE INDEX IF NOT EXISTS idx_product_stocks_tenant_id ON product_stocks(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_product_stocks_branch_id ON product_stocks(branch_id);
+CREATE INDEX IF NOT EXISTS idx_product_stocks_product_id ON product_stocks(product_id);
+
+-- Step 2: Book the current stock of every product at the branch holding its ledger balance, which
+--         is the tenant's first branch for balances opened by migration_create_stock_movements.sql
+INSERT INTO product_stocks (tenant_id, branch_id, product_id, quantity, updated_at)
+SELECT m.tenant_id, m.branch_id, m.product_id, SUM(m.quantity), CURRENT_TIMESTAMP
+FROM stock_movements m
+GROUP BY m.tenant_id, m.branch_id, m.product_id
+ON CONFLICT (branch_id, product_id) DO NOTHING;
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS product_stocks;
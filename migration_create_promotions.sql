@@ -0,0 +1,84 @@
+-- Migration: Create promotions and order discount breakdown
+-- Description: Adds tenant scoped promotions (percentage, fixed, buy_x_get_y, coupons) and stores
+--              gross/discount/net amounts per order and per order item
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create promotions table
+CREATE TABLE IF NOT EXISTS promotions (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER REFERENCES branches(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    description TEXT,
+    type VARCHAR(20) NOT NULL,
+    discount_value DECIMAL(15,2) DEFAULT 0,
+    max_discount DECIMAL(15,2) DEFAULT 0,
+    category_id INTEGER REFERENCES categories(id) ON DELETE SET NULL,
+    product_id INTEGER REFERENCES products(id) ON DELETE SET NULL,
+    buy_quantity INTEGER DEFAULT 0,
+    get_quantity INTEGER DEFAULT 0,
+    min_spend DECIMAL(15,2) DEFAULT 0,
+    start_date TIMESTAMP,
+    end_date TIMESTAMP,
+    start_time VARCHAR(5),
+    end_time VARCHAR(5),
+    coupon_code VARCHAR(50),
+    usage_limit INTEGER DEFAULT 0,
+    usage_count INTEGER DEFAULT 0,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_promotions_tenant_id ON promotions(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_promotions_branch_id ON promotions(branch_id);
+CREATE INDEX IF NOT EXISTS idx_promotions_type ON promotions(type);
+CREATE INDEX IF NOT EXISTS idx_promotions_coupon_code ON promotions(coupon_code);
+CREATE INDEX IF NOT EXISTS idx_promotions_is_active ON promotions(is_active);
+CREATE INDEX IF NOT EXISTS idx_promotions_deleted_at ON promotions(deleted_at);
+
+-- Step 2: Create order_promotions table (discount granted by each promotion)
+CREATE TABLE IF NOT EXISTS order_promotions (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    promotion_id INTEGER NOT NULL REFERENCES promotions(id),
+    promotion_name VARCHAR(255),
+    coupon_code VARCHAR(50),
+    discount_amount DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_promotions_tenant_id ON order_promotions(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_order_promotions_order_id ON order_promotions(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_promotions_promotion_id ON order_promotions(promotion_id);
+
+-- Step 3: Add discount breakdown to orders and order items
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS gross_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS coupon_code VARCHAR(50);
+CREATE INDEX IF NOT EXISTS idx_orders_coupon_code ON orders(coupon_code);
+
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS discount_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS net_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS promotion_id INTEGER;
+CREATE INDEX IF NOT EXISTS idx_order_items_promotion_id ON order_items(promotion_id);
+
+-- Step 4: Backfill existing orders (no discounts were possible before)
+UPDATE orders SET gross_amount = total_amount WHERE gross_amount = 0;
+UPDATE order_items SET net_amount = subtotal WHERE net_amount = 0;
+
+-- Rollback instructions:
+-- ALTER TABLE order_items DROP COLUMN IF EXISTS promotion_id;
+-- ALTER TABLE order_items DROP COLUMN IF EXISTS net_amount;
+-- ALTER TABLE order_items DROP COLUMN IF EXISTS discount_amount;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS coupon_code;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS discount_amount;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS gross_amount;
+-- DROP TABLE IF EXISTS order_promotions;
+-- DROP TABLE IF EXISTS promotions;
@@ -0,0 +1,119 @@
+-- Migration: Suppliers, purchase orders and goods receipts
+-- Description: Supplier directory and purchase orders with lines at an agreed unit cost:
+--              draft -> ordered -> partially_received / received (or closed short, cancelled).
+--              Goods receipts add the delivered stock to the receiving branch and move the products'
+--              average cost, which order items keep as the unit cost at the time of sale.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create suppliers table
+CREATE TABLE IF NOT EXISTS suppliers (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    name VARCHAR(255) NOT NULL,
+    contact_name VARCHAR(255),
+    phone VARCHAR(30),
+    email VARCHAR(255),
+    address TEXT,
+    notes TEXT,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_suppliers_tenant_id ON suppliers(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_suppliers_name ON suppliers(name);
+CREATE INDEX IF NOT EXISTS idx_suppliers_created_by ON suppliers(created_by);
+CREATE INDEX IF NOT EXISTS idx_suppliers_updated_by ON suppliers(updated_by);
+CREATE INDEX IF NOT EXISTS idx_suppliers_deleted_by ON suppliers(deleted_by);
+CREATE INDEX IF NOT EXISTS idx_suppliers_deleted_at ON suppliers(deleted_at);
+
+-- Step 2: Create purchase_orders table
+CREATE TABLE IF NOT EXISTS purchase_orders (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    supplier_id INTEGER NOT NULL REFERENCES suppliers(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    notes TEXT,
+    expected_at DATE,
+    total_amount DECIMAL(15,2) NOT NULL DEFAULT 0,
+    ordered_at TIMESTAMP,
+    ordered_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    closed_at TIMESTAMP,
+    closed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    closed_notes TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_tenant_id ON purchase_orders(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_branch_id ON purchase_orders(branch_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_supplier_id ON purchase_orders(supplier_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_status ON purchase_orders(status);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_ordered_by ON purchase_orders(ordered_by);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_closed_by ON purchase_orders(closed_by);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_created_by ON purchase_orders(created_by);
+CREATE INDEX IF NOT EXISTS idx_purchase_orders_updated_by ON purchase_orders(updated_by);
+
+-- Step 3: Create purchase_order_items table
+CREATE TABLE IF NOT EXISTS purchase_order_items (
+    id SERIAL PRIMARY KEY,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL,
+    unit_cost DECIMAL(15,2) NOT NULL,
+    subtotal DECIMAL(15,2) NOT NULL,
+    received_quantity INTEGER NOT NULL DEFAULT 0
+);
+
+CREATE INDEX IF NOT EXISTS idx_purchase_order_items_purchase_order_id ON purchase_order_items(purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_purchase_order_items_product_id ON purchase_order_items(product_id);
+
+-- Step 4: Create goods_receipts and goods_receipt_items tables
+CREATE TABLE IF NOT EXISTS goods_receipts (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    purchase_order_id INTEGER NOT NULL REFERENCES purchase_orders(id) ON DELETE CASCADE,
+    notes TEXT,
+    received_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    received_at TIMESTAMP NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_tenant_id ON goods_receipts(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_branch_id ON goods_receipts(branch_id);
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_purchase_order_id ON goods_receipts(purchase_order_id);
+CREATE INDEX IF NOT EXISTS idx_goods_receipts_received_by ON goods_receipts(received_by);
+
+CREATE TABLE IF NOT EXISTS goods_receipt_items (
+    id SERIAL PRIMARY KEY,
+    goods_receipt_id INTEGER NOT NULL REFERENCES goods_receipts(id) ON DELETE CASCADE,
+    purchase_order_item_id INTEGER NOT NULL REFERENCES purchase_order_items(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL,
+    unit_cost DECIMAL(15,2) NOT NULL
+);
+
+CREATE INDEX IF NOT EXISTS idx_goods_receipt_items_goods_receipt_id ON goods_receipt_items(goods_receipt_id);
+CREATE INDEX IF NOT EXISTS idx_goods_receipt_items_purchase_order_item_id ON goods_receipt_items(purchase_order_item_id);
+CREATE INDEX IF NOT EXISTS idx_goods_receipt_items_product_id ON goods_receipt_items(product_id);
+
+-- Step 5: Purchase costs on products and sold order items
+ALTER TABLE products ADD COLUMN IF NOT EXISTS cost_price DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE order_items ADD COLUMN IF NOT EXISTS unit_cost DECIMAL(15,2) DEFAULT 0;
+
+-- Rollback instructions:
+-- ALTER TABLE order_items DROP COLUMN IF EXISTS unit_cost;
+-- ALTER TABLE products DROP COLUMN IF EXISTS cost_price;
+-- DROP TABLE IF EXISTS goods_receipt_items;
+-- DROP TABLE IF EXISTS goods_receipts;
+-- DROP TABLE IF EXISTS purchase_order_items;
+-- DROP TABLE IF EXISTS purchase_orders;
+-- DROP TABLE IF EXISTS suppliers;
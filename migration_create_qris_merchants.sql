@@ -0,0 +1,33 @@
+-- Migration: QRIS merchants
+-- Description: Per tenant (or per branch) merchant data encoded in the EMVCo QRIS codes
+--              generated by GET /api/orders/:id/qris and GET /api/qris/static
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create qris_merchants table
+CREATE TABLE IF NOT EXISTS qris_merchants (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER REFERENCES branches(id) ON DELETE CASCADE,
+    merchant_name VARCHAR(25) NOT NULL,
+    merchant_city VARCHAR(15) NOT NULL,
+    postal_code VARCHAR(10),
+    category_code VARCHAR(4) NOT NULL,
+    acquirer_domain VARCHAR(32) NOT NULL,
+    merchant_pan VARCHAR(19) NOT NULL,
+    merchant_id VARCHAR(15) NOT NULL,
+    nmid VARCHAR(15) NOT NULL,
+    merchant_criteria VARCHAR(3) NOT NULL,
+    terminal_label VARCHAR(25),
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_qris_merchant ON qris_merchants(tenant_id, branch_id);
+CREATE INDEX IF NOT EXISTS idx_qris_merchants_deleted_at ON qris_merchants(deleted_at);
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS qris_merchants;
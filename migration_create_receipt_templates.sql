@@ -0,0 +1,29 @@
+-- Migration: Receipt templates
+-- Description: Per tenant (or per branch) receipt templates used by GET /api/orders/:id/receipt
+--              to render text, ESC/POS, PDF and HTML receipts
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create receipt_templates table
+CREATE TABLE IF NOT EXISTS receipt_templates (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER REFERENCES branches(id) ON DELETE CASCADE,
+    header_text TEXT,
+    footer_text TEXT,
+    show_logo BOOLEAN NOT NULL DEFAULT TRUE,
+    show_tax_lines BOOLEAN NOT NULL DEFAULT TRUE,
+    show_cashier BOOLEAN NOT NULL DEFAULT TRUE,
+    paper_width INTEGER DEFAULT 80,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_receipt_template ON receipt_templates(tenant_id, branch_id);
+CREATE INDEX IF NOT EXISTS idx_receipt_templates_deleted_at ON receipt_templates(deleted_at);
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS receipt_templates;
@@ -0,0 +1,61 @@
+-- Migration: Create refunds
+-- Description: Adds full/partial refunds against completed payments, optionally per order item
+--              with restock, and tracks the refunded amount on payments and orders
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create refunds table
+CREATE TABLE IF NOT EXISTS refunds (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    payment_id INTEGER NOT NULL REFERENCES payments(id) ON DELETE CASCADE,
+    amount DECIMAL(15,2) NOT NULL,
+    refund_method VARCHAR(50) NOT NULL,
+    reason TEXT NOT NULL,
+    restock BOOLEAN DEFAULT FALSE,
+    status VARCHAR(20) DEFAULT 'completed',
+    approved_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refunds_tenant_id ON refunds(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_branch_id ON refunds(branch_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_order_id ON refunds(order_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_payment_id ON refunds(payment_id);
+CREATE INDEX IF NOT EXISTS idx_refunds_status ON refunds(status);
+CREATE INDEX IF NOT EXISTS idx_refunds_approved_by ON refunds(approved_by);
+CREATE INDEX IF NOT EXISTS idx_refunds_created_at ON refunds(created_at);
+CREATE INDEX IF NOT EXISTS idx_refunds_deleted_at ON refunds(deleted_at);
+
+-- Step 2: Create refund_items table (returned quantities per order item)
+CREATE TABLE IF NOT EXISTS refund_items (
+    id SERIAL PRIMARY KEY,
+    refund_id INTEGER NOT NULL REFERENCES refunds(id) ON DELETE CASCADE,
+    order_item_id INTEGER NOT NULL REFERENCES order_items(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id),
+    quantity INTEGER NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    restocked BOOLEAN DEFAULT FALSE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_refund_items_refund_id ON refund_items(refund_id);
+CREATE INDEX IF NOT EXISTS idx_refund_items_order_item_id ON refund_items(order_item_id);
+CREATE INDEX IF NOT EXISTS idx_refund_items_product_id ON refund_items(product_id);
+
+-- Step 3: Track refunded amounts
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS refunded_amount DECIMAL(15,2) DEFAULT 0;
+
+-- Rollback instructions:
+-- ALTER TABLE orders DROP COLUMN IF EXISTS refunded_amount;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS refunded_amount;
+-- DROP TABLE IF EXISTS refund_items;
+-- DROP TABLE IF EXISTS refunds;
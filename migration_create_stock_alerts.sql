@@ -0,0 +1,43 @@
+-- Migration: Reorder points and stock alerts
+-- Description: Minimum stock and reorder quantity per product, overridable per branch, and the
+--              low-stock alerts raised when a branch's stock falls to them and out-of-stock alerts
+--              raised when it runs out.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Reorder point of products (0 = no low-stock alerts) and branch overrides (NULL = the product's)
+ALTER TABLE products ADD COLUMN IF NOT EXISTS min_stock INTEGER DEFAULT 0;
+ALTER TABLE products ADD COLUMN IF NOT EXISTS reorder_quantity INTEGER DEFAULT 0;
+ALTER TABLE product_stocks ADD COLUMN IF NOT EXISTS min_stock INTEGER;
+ALTER TABLE product_stocks ADD COLUMN IF NOT EXISTS reorder_quantity INTEGER;
+
+-- Step 2: Create stock_alerts table
+CREATE TABLE IF NOT EXISTS stock_alerts (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    status VARCHAR(20) NOT NULL,
+    quantity INTEGER NOT NULL,
+    min_stock INTEGER NOT NULL,
+    raised_at TIMESTAMP NOT NULL,
+    resolved_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_tenant_id ON stock_alerts(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_branch_id ON stock_alerts(branch_id);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_product_id ON stock_alerts(product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_type ON stock_alerts(type);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_status ON stock_alerts(status);
+CREATE INDEX IF NOT EXISTS idx_stock_alerts_raised_at ON stock_alerts(raised_at);
+
+-- Step 3: A product has at most one open alert per branch
+CREATE UNIQUE INDEX IF NOT EXISTS idx_stock_alerts_open ON stock_alerts(branch_id, product_id) WHERE status = 'open';
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS stock_alerts;
+-- ALTER TABLE product_stocks DROP COLUMN IF EXISTS reorder_quantity;
+-- ALTER TABLE product_stocks DROP COLUMN IF EXISTS min_stock;
+-- ALTER TABLE products DROP COLUMN IF EXISTS reorder_quantity;
+-- ALTER TABLE products DROP COLUMN IF EXISTS min_stock;
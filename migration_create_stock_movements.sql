@@ -0,0 +1,43 @@
+-- Migration: Stock movements
+-- Description: Append-only ledger of every stock change (sale, refund, adjustment, receipt, transfer,
+--              stocktake, sync). products.stock becomes the running balance of the ledger.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create stock_movements table (quantity positive = in, negative = out)
+CREATE TABLE IF NOT EXISTS stock_movements (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    quantity INTEGER NOT NULL,
+    balance_after INTEGER NOT NULL,
+    reason TEXT,
+    reference_type VARCHAR(50),
+    reference_id INTEGER,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_movements_tenant_id ON stock_movements(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_branch_id ON stock_movements(branch_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_product_id ON stock_movements(product_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_type ON stock_movements(type);
+CREATE INDEX IF NOT EXISTS idx_stock_movement_reference ON stock_movements(reference_type, reference_id);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_created_by ON stock_movements(created_by);
+CREATE INDEX IF NOT EXISTS idx_stock_movements_created_at ON stock_movements(created_at);
+
+-- Step 2: Open the ledger with the current stock of every product, booked at the tenant's first branch
+INSERT INTO stock_movements (tenant_id, branch_id, product_id, type, quantity, balance_after, reason, reference_type, reference_id, created_at)
+SELECT p.tenant_id,
+       (SELECT MIN(b.id) FROM branches b WHERE b.tenant_id = p.tenant_id),
+       p.id, 'adjustment', p.stock, p.stock, 'opening balance', 'product', p.id, CURRENT_TIMESTAMP
+FROM products p
+WHERE p.stock <> 0
+  AND p.deleted_at IS NULL
+  AND EXISTS (SELECT 1 FROM branches b WHERE b.tenant_id = p.tenant_id)
+  AND NOT EXISTS (SELECT 1 FROM stock_movements m WHERE m.product_id = p.id);
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS stock_movements;
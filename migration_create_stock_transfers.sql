@@ -0,0 +1,53 @@
+-- Migration: Stock transfers between branches
+-- Description: Transfer documents moving stock from one branch of a tenant to another:
+--              draft -> sent (stock leaves the source) -> received (counted stock arrives) -> closed.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create stock_transfers table
+CREATE TABLE IF NOT EXISTS stock_transfers (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    from_branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    to_branch_id INTEGER NOT NULL REFERENCES branches(id) ON DELETE CASCADE,
+    status VARCHAR(20) NOT NULL,
+    notes TEXT,
+    sent_at TIMESTAMP,
+    sent_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    received_at TIMESTAMP,
+    received_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    received_notes TEXT,
+    closed_at TIMESTAMP,
+    closed_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    closed_notes TEXT,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_tenant_id ON stock_transfers(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_from_branch_id ON stock_transfers(from_branch_id);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_to_branch_id ON stock_transfers(to_branch_id);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_status ON stock_transfers(status);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_sent_by ON stock_transfers(sent_by);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_received_by ON stock_transfers(received_by);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_closed_by ON stock_transfers(closed_by);
+CREATE INDEX IF NOT EXISTS idx_stock_transfers_created_by ON stock_transfers(created_by);
+
+-- Step 2: Create stock_transfer_items table (discrepancy = received_quantity - quantity)
+CREATE TABLE IF NOT EXISTS stock_transfer_items (
+    id SERIAL PRIMARY KEY,
+    stock_transfer_id INTEGER NOT NULL REFERENCES stock_transfers(id) ON DELETE CASCADE,
+    product_id INTEGER NOT NULL REFERENCES products(id) ON DELETE CASCADE,
+    quantity INTEGER NOT NULL,
+    received_quantity INTEGER,
+    discrepancy INTEGER DEFAULT 0,
+    discrepancy_note TEXT
+);
+
+CREATE INDEX IF NOT EXISTS idx_stock_transfer_items_stock_transfer_id ON stock_transfer_items(stock_transfer_id);
+CREATE INDEX IF NOT EXISTS idx_stock_transfer_items_product_id ON stock_transfer_items(product_id);
+
+-- Rollback instructions:
+-- DROP TABLE IF EXISTS stock_transfer_items;
+-- DROP TABLE IF EXISTS stock_transfers;
@@ -0,0 +1,77 @@
+-- Migration: Create tax and service charge rules
+-- Description: Adds per-tenant (optionally per-branch) tax/service charge rules with category
+--              exemptions, and stores the subtotal/service charge/tax breakdown per order
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create tax_rules table
+CREATE TABLE IF NOT EXISTS tax_rules (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    branch_id INTEGER REFERENCES branches(id) ON DELETE CASCADE,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    rate DECIMAL(7,4) NOT NULL,
+    is_inclusive BOOLEAN DEFAULT FALSE,
+    sequence INTEGER DEFAULT 0,
+    is_compound BOOLEAN DEFAULT FALSE,
+    is_active BOOLEAN DEFAULT TRUE,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    updated_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    deleted_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    deleted_at TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tax_rules_tenant_id ON tax_rules(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_tax_rules_branch_id ON tax_rules(branch_id);
+CREATE INDEX IF NOT EXISTS idx_tax_rules_type ON tax_rules(type);
+CREATE INDEX IF NOT EXISTS idx_tax_rules_sequence ON tax_rules(sequence);
+CREATE INDEX IF NOT EXISTS idx_tax_rules_is_active ON tax_rules(is_active);
+CREATE INDEX IF NOT EXISTS idx_tax_rules_deleted_at ON tax_rules(deleted_at);
+
+-- Step 2: Create tax_rule_exemptions table (categories not charged by a rule)
+CREATE TABLE IF NOT EXISTS tax_rule_exemptions (
+    id SERIAL PRIMARY KEY,
+    tax_rule_id INTEGER NOT NULL REFERENCES tax_rules(id) ON DELETE CASCADE,
+    category_id INTEGER NOT NULL REFERENCES categories(id) ON DELETE CASCADE,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE UNIQUE INDEX IF NOT EXISTS idx_tax_rule_exemption ON tax_rule_exemptions(tax_rule_id, category_id);
+
+-- Step 3: Create order_taxes table (charge lines computed for each order)
+CREATE TABLE IF NOT EXISTS order_taxes (
+    id SERIAL PRIMARY KEY,
+    order_id INTEGER NOT NULL REFERENCES orders(id) ON DELETE CASCADE,
+    tax_rule_id INTEGER REFERENCES tax_rules(id) ON DELETE SET NULL,
+    name VARCHAR(100) NOT NULL,
+    type VARCHAR(20) NOT NULL,
+    rate DECIMAL(7,4) NOT NULL,
+    is_inclusive BOOLEAN DEFAULT FALSE,
+    taxable_amount DECIMAL(15,2) NOT NULL,
+    amount DECIMAL(15,2) NOT NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_order_taxes_order_id ON order_taxes(order_id);
+CREATE INDEX IF NOT EXISTS idx_order_taxes_tax_rule_id ON order_taxes(tax_rule_id);
+
+-- Step 4: Add tax breakdown to orders
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS subtotal_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS service_charge_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS tax_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE orders ADD COLUMN IF NOT EXISTS inclusive_tax_amount DECIMAL(15,2) DEFAULT 0;
+
+-- Step 5: Backfill existing orders (no charges were applied before)
+UPDATE orders SET subtotal_amount = total_amount WHERE subtotal_amount = 0;
+
+-- Rollback instructions:
+-- ALTER TABLE orders DROP COLUMN IF EXISTS inclusive_tax_amount;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS tax_amount;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS service_charge_amount;
+-- ALTER TABLE orders DROP COLUMN IF EXISTS subtotal_amount;
+-- DROP TABLE IF EXISTS order_taxes;
+-- DROP TABLE IF EXISTS tax_rule_exemptions;
+-- DROP TABLE IF EXISTS tax_rules;
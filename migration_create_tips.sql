@@ -0,0 +1,37 @@
+-- Migration: Tips
+-- Description: Tip presets per tenant and tips captured on payments, kept apart from the amount applied
+--              to the order and attributed to a staff member for payouts
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Create tip_presets table (fixed amounts or percentages of the order total)
+CREATE TABLE IF NOT EXISTS tip_presets (
+    id SERIAL PRIMARY KEY,
+    tenant_id INTEGER NOT NULL REFERENCES tenants(id) ON DELETE CASCADE,
+    type VARCHAR(20) NOT NULL,
+    amount DECIMAL(15,2) DEFAULT 0,
+    percent DECIMAL(7,4) DEFAULT 0,
+    sort_order INTEGER DEFAULT 0,
+    created_by INTEGER REFERENCES users(id) ON DELETE SET NULL,
+    created_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP,
+    updated_at TIMESTAMP NOT NULL DEFAULT CURRENT_TIMESTAMP
+);
+
+CREATE INDEX IF NOT EXISTS idx_tip_presets_tenant_id ON tip_presets(tenant_id);
+CREATE INDEX IF NOT EXISTS idx_tip_presets_created_by ON tip_presets(created_by);
+
+-- Step 2: Tips on payments and the staff member they are paid out to
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS tip_amount DECIMAL(15,2) DEFAULT 0;
+ALTER TABLE payments ADD COLUMN IF NOT EXISTS tip_user_id INTEGER;
+
+CREATE INDEX IF NOT EXISTS idx_payments_tip_user_id ON payments(tip_user_id);
+
+-- Step 3: Cash tips stay in the drawer, so closed shifts keep them with the expected cash
+ALTER TABLE cash_shifts ADD COLUMN IF NOT EXISTS cash_tips DECIMAL(15,2) DEFAULT 0;
+
+-- Rollback instructions:
+-- ALTER TABLE cash_shifts DROP COLUMN IF EXISTS cash_tips;
+-- DROP INDEX IF EXISTS idx_payments_tip_user_id;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS tip_user_id;
+-- ALTER TABLE payments DROP COLUMN IF EXISTS tip_amount;
+-- DROP TABLE IF EXISTS tip_presets;
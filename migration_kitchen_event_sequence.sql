@@ -0,0 +1,27 @@
+-- Migration: Kitchen event sequence
+-- Description: Kitchen events get their stream position once committed, so screens receive them in
+--              commit order and a slow transaction cannot commit an event below ids already streamed.
+--              The sequence replaces the id as the SSE event id; existing events keep their id.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Add sequence to kitchen_events
+ALTER TABLE kitchen_events ADD COLUMN IF NOT EXISTS sequence BIGINT;
+UPDATE kitchen_events SET sequence = id WHERE sequence IS NULL;
+
+-- Step 2: Stream by sequence and find events still to be sequenced
+DROP INDEX IF EXISTS idx_kitchen_event_branch;
+CREATE INDEX IF NOT EXISTS idx_kitchen_event_stream ON kitchen_events(tenant_id, branch_id, sequence);
+CREATE INDEX IF NOT EXISTS idx_kitchen_event_unsequenced ON kitchen_events(id) WHERE sequence IS NULL;
+
+-- Step 3: Continue the sequence after the existing events
+INSERT INTO configs (key, value, created_at, updated_at)
+SELECT 'kitchen_event_sequence', COALESCE(MAX(sequence), 0)::TEXT, CURRENT_TIMESTAMP, CURRENT_TIMESTAMP FROM kitchen_events
+ON CONFLICT (key) DO NOTHING;
+
+-- Rollback instructions:
+-- DELETE FROM configs WHERE key = 'kitchen_event_sequence';
+-- DROP INDEX IF EXISTS idx_kitchen_event_unsequenced;
+-- DROP INDEX IF EXISTS idx_kitchen_event_stream;
+-- CREATE INDEX IF NOT EXISTS idx_kitchen_event_branch ON kitchen_events(tenant_id, branch_id, id);
+-- ALTER TABLE kitchen_events DROP COLUMN IF EXISTS sequence;
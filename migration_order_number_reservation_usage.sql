@@ -0,0 +1,18 @@
+-- Migration: Order number reservation usage
+-- Description: Order numbers sent by offline clients must come from a block the client reserved for
+--              the branch. Reservations keep the numbers' prefix and counter width so later format
+--              changes don't affect them, and count the numbers taken by synced orders. Reservations
+--              made before keep an empty prefix and are checked against the branch's current format;
+--              their usage counts from this migration on.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Add number prefix, padding and usage to order_number_reservations
+ALTER TABLE order_number_reservations ADD COLUMN IF NOT EXISTS number_prefix VARCHAR(50);
+ALTER TABLE order_number_reservations ADD COLUMN IF NOT EXISTS padding INTEGER NOT NULL DEFAULT 0;
+ALTER TABLE order_number_reservations ADD COLUMN IF NOT EXISTS used_count INTEGER NOT NULL DEFAULT 0;
+
+-- Rollback instructions:
+-- ALTER TABLE order_number_reservations DROP COLUMN IF EXISTS used_count;
+-- ALTER TABLE order_number_reservations DROP COLUMN IF EXISTS padding;
+-- ALTER TABLE order_number_reservations DROP COLUMN IF EXISTS number_prefix;
@@ -0,0 +1,18 @@
+-- Migration: Tenant scoped order numbers
+-- Description: Order numbers only need to be unique within a tenant, as every tenant formats its own
+--              numbers. Formats without a daily date part keep one running counter per branch (stored
+--              under an empty sequence_date), and a tenant has at most one tenant wide format.
+-- Author: System
+-- Date: 2026-10-18
+
+-- Step 1: Replace the global order number index with a per tenant one
+DROP INDEX IF EXISTS idx_orders_order_number;
+CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_tenant_order_number ON orders(tenant_id, order_number);
+
+-- Step 2: One tenant wide format per tenant (branch_id NULL is not caught by idx_order_number_format)
+CREATE UNIQUE INDEX IF NOT EXISTS idx_order_number_format_tenant_wide ON order_number_formats(tenant_id) WHERE branch_id IS NULL;
+
+-- Rollback instructions:
+-- DROP INDEX IF EXISTS idx_order_number_format_tenant_wide;
+-- DROP INDEX IF EXISTS idx_orders_tenant_order_number;
+-- CREATE UNIQUE INDEX IF NOT EXISTS idx_orders_order_number ON orders(order_number);
@@ -19,6 +19,7 @@ type Branch struct {
 	Email       string `gorm:"type:varchar(255)" json:"email"`
 	Phone       string `gorm:"type:varchar(50)" json:"phone"`
 	Image       string `gorm:"type:varchar(500)" json:"image"`
+	Timezone    string `gorm:"size:50;default:'Asia/Jakarta'" json:"timezone"` // IANA name, used for business days and date filters
 	IsActive    bool   `gorm:"default:true" json:"is_active"`
 
 	// Audit tracking
@@ -31,7 +32,7 @@ type Branch struct {
 	ClientID       string     `gorm:"size:255;index" json:"client_id,omitempty"`
 	LocalTimestamp *time.Time `json:"local_timestamp,omitempty"`
 	Version        int        `gorm:"default:1" json:"version"`
-	ConflictData   *string     `gorm:"type:jsonb" json:"conflict_data,omitempty"`
+	ConflictData   *string    `gorm:"type:jsonb" json:"conflict_data,omitempty"`
 
 	// Relations
 	Tenant  *Tenant `gorm:"foreignKey:TenantID" json:"tenant,omitempty"`
@@ -0,0 +1,67 @@
+package models
+
+import (
+	"myposcore/money"
+	"time"
+)
+
+// CashShift - A cashier's session on a branch cash drawer, from the opening float to the close-out count
+type CashShift struct {
+	ID           uint         `gorm:"primarykey" json:"id"`
+	TenantID     uint         `gorm:"not null;index" json:"tenant_id"`
+	BranchID     uint         `gorm:"not null;index;uniqueIndex:idx_cash_shift_open,where:status = 'open'" json:"branch_id"`
+	UserID       uint         `gorm:"not null;index;uniqueIndex:idx_cash_shift_open,where:status = 'open'" json:"user_id"` // Cashier owning the drawer
+	Status       string       `gorm:"size:20;not null;index" json:"status"`                                                // open, closed
+	OpeningFloat money.Amount `gorm:"type:decimal(15,2);not null" json:"opening_float"`
+	OpeningNotes string       `gorm:"type:text" json:"opening_notes"`
+	OpenedAt     time.Time    `gorm:"not null" json:"opened_at"`
+
+	// Close-out, filled in when the shift is closed
+	ClosedAt      *time.Time   `json:"closed_at"`
+	ClosedBy      *uint        `gorm:"index" json:"closed_by"`
+	ClosingNotes  string       `gorm:"type:text" json:"closing_notes"`
+	Denominations string       `gorm:"type:jsonb;default:'[]'" json:"denominations"` // Counted notes and coins, [{"value":..,"count":..}]
+	CashSales     money.Amount `gorm:"type:decimal(15,2);default:0" json:"cash_sales"`
+	CashRefunds   money.Amount `gorm:"type:decimal(15,2);default:0" json:"cash_refunds"`
+	CashTips      money.Amount `gorm:"type:decimal(15,2);default:0" json:"cash_tips"` // Tips left in the drawer until paid out
+	PayIns        money.Amount `gorm:"type:decimal(15,2);default:0" json:"pay_ins"`
+	PayOuts       money.Amount `gorm:"type:decimal(15,2);default:0" json:"pay_outs"`
+	ExpectedCash  money.Amount `gorm:"type:decimal(15,2);default:0" json:"expected_cash"` // Float + sales + tips - refunds + pay-ins - pay-outs
+	CountedCash   money.Amount `gorm:"type:decimal(15,2);default:0" json:"counted_cash"`
+	Variance      money.Amount `gorm:"type:decimal(15,2);default:0" json:"variance"` // Counted - expected; negative = short
+	ZNumber       int          `gorm:"default:0" json:"z_number"`                    // Sequence of Z reports per branch
+
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Tenant Tenant `gorm:"foreignKey:TenantID" json:"-"`
+	Branch Branch `gorm:"foreignKey:BranchID" json:"-"`
+	User   User   `gorm:"foreignKey:UserID" json:"-"`
+	Closer *User  `gorm:"foreignKey:ClosedBy;constraint:-" json:"closer,omitempty"`
+}
+
+func (CashShift) TableName() string {
+	return "cash_shifts"
+}
+
+// CashMovement - Cash put into (pay-in) or taken out of (pay-out) the drawer outside a sale
+type CashMovement struct {
+	ID        uint         `gorm:"primarykey" json:"id"`
+	TenantID  uint         `gorm:"not null;index" json:"tenant_id"`
+	BranchID  uint         `gorm:"not null;index" json:"branch_id"`
+	ShiftID   uint         `gorm:"not null;index" json:"shift_id"`
+	Type      string       `gorm:"size:20;not null" json:"type"` // pay_in, pay_out
+	Amount    money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Reason    string       `gorm:"type:text;not null" json:"reason"`
+	CreatedBy *uint        `gorm:"index" json:"created_by"`
+	CreatedAt time.Time    `json:"created_at"`
+
+	// Relations
+	Shift   CashShift `gorm:"foreignKey:ShiftID" json:"-"`
+	Creator *User     `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+}
+
+func (CashMovement) TableName() string {
+	return "cash_movements"
+}
@@ -0,0 +1,40 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Customer - Tenant scoped customer directory entry that orders can be linked to
+type Customer struct {
+	ID       uint       `gorm:"primarykey" json:"id"`
+	TenantID uint       `gorm:"not null;index" json:"tenant_id"`
+	Name     string     `gorm:"size:255;not null;index" json:"name"`
+	Phone    string     `gorm:"size:30;index" json:"phone"`
+	Email    string     `gorm:"size:255;index" json:"email"`
+	Birthday *time.Time `gorm:"type:date" json:"birthday"`
+	Notes    string     `gorm:"type:text" json:"notes"`
+	Tags     string     `gorm:"type:jsonb;not null;default:'[]'" json:"tags"` // JSON array of strings
+
+	// Loyalty balance, kept in step with the loyalty_transactions ledger
+	LoyaltyPoints  int    `gorm:"not null;default:0" json:"loyalty_points"`
+	LifetimePoints int    `gorm:"not null;default:0" json:"lifetime_points"` // Earned minus reversed earnings, decides the tier
+	LoyaltyTier    string `gorm:"size:50" json:"loyalty_tier"`
+
+	CreatedBy *uint          `gorm:"index" json:"created_by"`
+	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
+	DeletedBy *uint          `gorm:"index" json:"deleted_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Tenant  Tenant `gorm:"foreignKey:TenantID" json:"-"`
+	Creator *User  `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater *User  `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+}
+
+func (Customer) TableName() string {
+	return "customers"
+}
@@ -0,0 +1,63 @@
+package models
+
+import (
+	"myposcore/money"
+	"time"
+)
+
+// GiftCard - Prepaid balance that can be tendered as payment: a gift card sold on an order or store
+// credit issued from a refund. The balance only changes through GiftCardTransaction entries.
+type GiftCard struct {
+	ID             uint         `gorm:"primarykey" json:"id"`
+	TenantID       uint         `gorm:"not null;uniqueIndex:idx_gift_card_code" json:"tenant_id"`
+	BranchID       uint         `gorm:"not null;index" json:"branch_id"` // Branch that issued the card
+	Code           string       `gorm:"size:32;not null;uniqueIndex:idx_gift_card_code" json:"code"`
+	Type           string       `gorm:"size:20;not null;index" json:"type"` // gift_card, store_credit
+	CustomerID     *uint        `gorm:"index" json:"customer_id"`           // Holder, when known
+	InitialBalance money.Amount `gorm:"type:decimal(15,2);not null" json:"initial_balance"`
+	Balance        money.Amount `gorm:"type:decimal(15,2);not null" json:"balance"`
+	ExpiresAt      *time.Time   `gorm:"index" json:"expires_at"`                               // nil = never
+	Status         string       `gorm:"size:20;not null;default:'active';index" json:"status"` // active, voided
+	OrderID        *uint        `gorm:"index" json:"order_id"`                                 // Order that sold the card
+	OrderItemID    *uint        `gorm:"index" json:"order_item_id"`
+	RefundID       *uint        `gorm:"index" json:"refund_id"` // Refund that issued the store credit
+
+	CreatedBy *uint     `gorm:"index" json:"created_by"`
+	UpdatedBy *uint     `gorm:"index" json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Customer *Customer `gorm:"foreignKey:CustomerID;constraint:OnDelete:SET NULL" json:"customer,omitempty"`
+	Creator  *User     `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+}
+
+func (GiftCard) TableName() string {
+	return "gift_cards"
+}
+
+// GiftCardTransaction - Append-only ledger entry of value put on or taken off a gift card.
+// Entries are never updated; reversals are new entries of the opposite sign.
+type GiftCardTransaction struct {
+	ID           uint         `gorm:"primarykey" json:"id"`
+	TenantID     uint         `gorm:"not null;index" json:"tenant_id"`
+	GiftCardID   uint         `gorm:"not null;index" json:"gift_card_id"`
+	BranchID     *uint        `gorm:"index" json:"branch_id"`
+	OrderID      *uint        `gorm:"index" json:"order_id"`
+	PaymentID    *uint        `gorm:"index" json:"payment_id"`
+	RefundID     *uint        `gorm:"index" json:"refund_id"`
+	Type         string       `gorm:"size:20;not null;index" json:"type"`        // issue, redeem, reverse_redeem, void
+	Amount       money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"` // Positive credits, negative debits
+	BalanceAfter money.Amount `gorm:"type:decimal(15,2);not null" json:"balance_after"`
+	Description  string       `gorm:"type:text" json:"description"`
+	CreatedBy    *uint        `gorm:"index" json:"created_by"`
+	CreatedAt    time.Time    `gorm:"index" json:"created_at"`
+
+	// Relations
+	GiftCard GiftCard `gorm:"foreignKey:GiftCardID" json:"-"`
+	Creator  *User    `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+}
+
+func (GiftCardTransaction) TableName() string {
+	return "gift_card_transactions"
+}
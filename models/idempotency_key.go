@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// IdempotencyKey - Outcome of a write request sent with an Idempotency-Key header, replayed when
+// a terminal retries the same request
+type IdempotencyKey struct {
+	ID             uint      `gorm:"primarykey" json:"id"`
+	TenantID       uint      `gorm:"not null;uniqueIndex:idx_idempotency_key" json:"tenant_id"`
+	UserID         uint      `gorm:"not null;uniqueIndex:idx_idempotency_key" json:"user_id"`
+	Key            string    `gorm:"size:255;not null;uniqueIndex:idx_idempotency_key" json:"key"`
+	Method         string    `gorm:"size:10;not null" json:"method"`
+	Path           string    `gorm:"size:255;not null" json:"path"`
+	RequestHash    string    `gorm:"size:64;not null" json:"request_hash"` // SHA-256 of method, path and body
+	Status         string    `gorm:"size:20;not null" json:"status"`       // processing, completed
+	ResponseStatus int       `json:"response_status"`                      // HTTP status of the stored response
+	ResponseBody   string    `gorm:"type:text" json:"response_body"`
+	ContentType    string    `gorm:"size:100" json:"content_type"`
+	CreatedAt      time.Time `json:"created_at"`
+	UpdatedAt      time.Time `json:"updated_at"`
+	ExpiresAt      time.Time `gorm:"not null;index" json:"expires_at"`
+}
+
+func (IdempotencyKey) TableName() string {
+	return "idempotency_keys"
+}
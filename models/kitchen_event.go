@@ -0,0 +1,21 @@
+package models
+
+import "time"
+
+// KitchenEvent - Order change pushed to the kitchen display screens of a branch. The Sequence is
+// given once the event committed, so it follows commit order, and doubles as the SSE event id so
+// reconnecting screens can resume where they left off.
+type KitchenEvent struct {
+	ID        uint      `gorm:"primarykey;index:idx_kitchen_event_unsequenced,where:sequence IS NULL" json:"id"`
+	TenantID  uint      `gorm:"not null;index:idx_kitchen_event_stream,priority:1" json:"tenant_id"`
+	BranchID  uint      `gorm:"not null;index:idx_kitchen_event_stream,priority:2" json:"branch_id"`
+	OrderID   uint      `gorm:"not null;index" json:"order_id"`
+	Type      string    `gorm:"size:40;not null" json:"type"` // order.created, order.item_added, order.item_updated, order.item_removed, order.status_changed, order.cancelled, order.item_bumped
+	Data      string    `gorm:"type:jsonb;not null" json:"data"`
+	Sequence  *uint     `gorm:"index:idx_kitchen_event_stream,priority:3" json:"sequence"` // Position in the stream, nil until sequenced
+	CreatedAt time.Time `gorm:"index" json:"created_at"`
+}
+
+func (KitchenEvent) TableName() string {
+	return "kitchen_events"
+}
@@ -0,0 +1,81 @@
+package models
+
+import (
+	"myposcore/money"
+	"time"
+)
+
+// LoyaltyProgram - Earn and redeem rules of a tenant's loyalty program
+type LoyaltyProgram struct {
+	ID              uint         `gorm:"primarykey" json:"id"`
+	TenantID        uint         `gorm:"not null;uniqueIndex" json:"tenant_id"`
+	IsActive        bool         `gorm:"not null" json:"is_active"`
+	PointsPerUnit   float64      `gorm:"type:decimal(15,4);default:0" json:"points_per_unit"` // Points earned per currency unit spent
+	RedeemValue     money.Amount `gorm:"type:decimal(15,2);default:0" json:"redeem_value"`    // Currency value of one point when redeemed
+	MinRedeemPoints int          `gorm:"default:0" json:"min_redeem_points"`                  // Smallest redemption allowed
+	ExpiryDays      int          `gorm:"default:0" json:"expiry_days"`                        // 0 = points never expire
+
+	CreatedBy *uint     `gorm:"index" json:"created_by"`
+	UpdatedBy *uint     `gorm:"index" json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Tenant Tenant `gorm:"foreignKey:TenantID" json:"-"`
+}
+
+func (LoyaltyProgram) TableName() string {
+	return "loyalty_programs"
+}
+
+// LoyaltyCategoryMultiplier - Earn rate multiplier for items of a category, e.g. 2x points on coffee
+type LoyaltyCategoryMultiplier struct {
+	ID         uint    `gorm:"primarykey" json:"id"`
+	TenantID   uint    `gorm:"not null;uniqueIndex:idx_loyalty_category_multiplier" json:"tenant_id"`
+	CategoryID uint    `gorm:"not null;uniqueIndex:idx_loyalty_category_multiplier" json:"category_id"`
+	Multiplier float64 `gorm:"type:decimal(8,2);not null" json:"multiplier"`
+}
+
+func (LoyaltyCategoryMultiplier) TableName() string {
+	return "loyalty_category_multipliers"
+}
+
+// LoyaltyTier - Member level reached with lifetime points; higher tiers earn faster
+type LoyaltyTier struct {
+	ID             uint    `gorm:"primarykey" json:"id"`
+	TenantID       uint    `gorm:"not null;uniqueIndex:idx_loyalty_tier" json:"tenant_id"`
+	Name           string  `gorm:"size:50;not null;uniqueIndex:idx_loyalty_tier" json:"name"`
+	MinPoints      int     `gorm:"not null;default:0" json:"min_points"` // Lifetime points needed
+	EarnMultiplier float64 `gorm:"type:decimal(8,2);not null;default:1" json:"earn_multiplier"`
+}
+
+func (LoyaltyTier) TableName() string {
+	return "loyalty_tiers"
+}
+
+// LoyaltyTransaction - Append-only ledger entry of points earned or spent by a customer.
+// Entries are never updated; reversals are new entries of the opposite sign.
+type LoyaltyTransaction struct {
+	ID           uint       `gorm:"primarykey" json:"id"`
+	TenantID     uint       `gorm:"not null;index" json:"tenant_id"`
+	CustomerID   uint       `gorm:"not null;index" json:"customer_id"`
+	BranchID     *uint      `gorm:"index" json:"branch_id"`
+	OrderID      *uint      `gorm:"index" json:"order_id"`
+	PaymentID    *uint      `gorm:"index" json:"payment_id"`
+	RefundID     *uint      `gorm:"index" json:"refund_id"`
+	Type         string     `gorm:"size:20;not null;index" json:"type"` // earn, redeem, reverse_earn, reverse_redeem, expire
+	Points       int        `gorm:"not null" json:"points"`             // Positive credits, negative debits
+	BalanceAfter int        `gorm:"not null" json:"balance_after"`
+	ExpiresAt    *time.Time `gorm:"index" json:"expires_at"` // Credits only, nil = never
+	Description  string     `gorm:"type:text" json:"description"`
+	CreatedBy    *uint      `gorm:"index" json:"created_by"`
+	CreatedAt    time.Time  `gorm:"index" json:"created_at"`
+
+	// Relations
+	Customer Customer `gorm:"foreignKey:CustomerID" json:"-"`
+	Creator  *User    `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+}
+
+func (LoyaltyTransaction) TableName() string {
+	return "loyalty_transactions"
+}
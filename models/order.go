@@ -1,27 +1,46 @@
 package models
 
 import (
+	"myposcore/money"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type Order struct {
-	ID          uint    `gorm:"primarykey" json:"id"`
-	TenantID    uint    `gorm:"not null;index" json:"tenant_id"`
-	BranchID    uint    `gorm:"not null;index" json:"branch_id"`
-	UserID      uint    `gorm:"not null;index" json:"user_id"`
-	OrderNumber string  `gorm:"size:50;uniqueIndex;not null" json:"order_number"`
-	TotalAmount float64 `gorm:"type:decimal(15,2);not null" json:"total_amount"`
-	Status      string  `gorm:"size:20;default:'pending';index" json:"status"` // pending, confirmed, completed, cancelled
-	Notes       string  `gorm:"type:text" json:"notes"`
+	ID                  uint         `gorm:"primarykey" json:"id"`
+	TenantID            uint         `gorm:"not null;index;uniqueIndex:idx_orders_tenant_order_number" json:"tenant_id"`
+	BranchID            uint         `gorm:"not null;index" json:"branch_id"`
+	UserID              uint         `gorm:"not null;index" json:"user_id"`
+	CustomerID          *uint        `gorm:"index" json:"customer_id"` // Optional customer from the directory
+	OrderNumber         string       `gorm:"size:50;not null;uniqueIndex:idx_orders_tenant_order_number" json:"order_number"`
+	GrossAmount         money.Amount `gorm:"type:decimal(15,2);default:0" json:"gross_amount"`          // Sum of item subtotals before discount
+	DiscountAmount      money.Amount `gorm:"type:decimal(15,2);default:0" json:"discount_amount"`       // Item + order level discounts
+	SubtotalAmount      money.Amount `gorm:"type:decimal(15,2);default:0" json:"subtotal_amount"`       // Gross - discount
+	ServiceChargeAmount money.Amount `gorm:"type:decimal(15,2);default:0" json:"service_charge_amount"` // Exclusive service charges
+	TaxAmount           money.Amount `gorm:"type:decimal(15,2);default:0" json:"tax_amount"`            // Exclusive taxes
+	InclusiveTaxAmount  money.Amount `gorm:"type:decimal(15,2);default:0" json:"inclusive_tax_amount"`  // Taxes contained in prices
+	TotalAmount         money.Amount `gorm:"type:decimal(15,2);not null" json:"total_amount"`           // Grand total
+	PaidAmount          money.Amount `gorm:"type:decimal(15,2);default:0" json:"paid_amount"`           // Sum of payments applied
+	RefundedAmount      money.Amount `gorm:"type:decimal(15,2);default:0" json:"refunded_amount"`       // Sum of refunds
+	CouponCode          string       `gorm:"size:50;index" json:"coupon_code"`
+
+	// Manual discount granted by the cashier with a supervisor's approval, on top of promotions
+	ManualDiscountType       string       `gorm:"size:20" json:"manual_discount_type"`                        // amount, percentage; empty = none
+	ManualDiscountValue      money.Amount `gorm:"type:decimal(15,2);default:0" json:"manual_discount_value"`  // Fixed amount or percentage
+	ManualDiscountAmount     money.Amount `gorm:"type:decimal(15,2);default:0" json:"manual_discount_amount"` // Resolved amount, part of DiscountAmount
+	ManualDiscountReason     string       `gorm:"type:text" json:"manual_discount_reason"`
+	ManualDiscountApprovedBy *uint        `gorm:"index" json:"manual_discount_approved_by"`
+
+	Status string `gorm:"size:20;default:'pending';index" json:"status"` // pending, confirmed, preparing, ready, completed, cancelled, voided, partially_refunded, refunded
+	Notes  string `gorm:"type:text" json:"notes"`
 
 	// Offline sync fields
 	SyncStatus     string     `gorm:"size:20;default:'synced';index" json:"sync_status"` // pending, synced, conflict, failed
 	ClientID       string     `gorm:"size:100;index" json:"client_id"`
 	LocalTimestamp *time.Time `json:"local_timestamp"`
 	Version        int        `gorm:"default:1" json:"version"`
-	ConflictData   *string     `gorm:"type:jsonb" json:"conflict_data,omitempty"`
+	ConflictData   *string    `gorm:"type:jsonb" json:"conflict_data,omitempty"`
 
 	CreatedBy *uint          `gorm:"index" json:"created_by"`
 	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
@@ -31,23 +50,37 @@ type Order struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Tenant     Tenant      `gorm:"foreignKey:TenantID" json:"-"`
-	Branch     Branch      `gorm:"foreignKey:BranchID" json:"-"`
-	User       User        `gorm:"foreignKey:UserID" json:"-"`
-	Creator    *User       `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
-	Updater    *User       `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
-	Deleter    *User       `gorm:"foreignKey:DeletedBy;constraint:-" json:"deleter,omitempty"`
-	OrderItems []OrderItem `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
-	Payments   []Payment   `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
+	Tenant        Tenant               `gorm:"foreignKey:TenantID" json:"-"`
+	Branch        Branch               `gorm:"foreignKey:BranchID" json:"-"`
+	User          User                 `gorm:"foreignKey:UserID" json:"-"`
+	Customer      *Customer            `gorm:"foreignKey:CustomerID;constraint:OnDelete:SET NULL" json:"customer,omitempty"`
+	Creator       *User                `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater       *User                `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+	Deleter       *User                `gorm:"foreignKey:DeletedBy;constraint:-" json:"deleter,omitempty"`
+	OrderItems    []OrderItem          `gorm:"foreignKey:OrderID" json:"order_items,omitempty"`
+	Payments      []Payment            `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
+	StatusHistory []OrderStatusHistory `gorm:"foreignKey:OrderID" json:"status_history,omitempty"`
+	Promotions    []OrderPromotion     `gorm:"foreignKey:OrderID" json:"promotions,omitempty"`
+	Taxes         []OrderTax           `gorm:"foreignKey:OrderID" json:"taxes,omitempty"`
+	Refunds       []Refund             `gorm:"foreignKey:OrderID" json:"refunds,omitempty"`
 }
 
 type OrderItem struct {
-	ID        uint    `gorm:"primarykey" json:"id"`
-	OrderID   uint    `gorm:"not null;index" json:"order_id"`
-	ProductID uint    `gorm:"not null;index" json:"product_id"`
-	Quantity  int     `gorm:"not null" json:"quantity"`
-	Price     float64 `gorm:"type:decimal(15,2);not null" json:"price"`
-	Subtotal  float64 `gorm:"type:decimal(15,2);not null" json:"subtotal"`
+	ID             uint         `gorm:"primarykey" json:"id"`
+	OrderID        uint         `gorm:"not null;index" json:"order_id"`
+	ProductID      uint         `gorm:"not null;index" json:"product_id"`
+	Quantity       int          `gorm:"not null" json:"quantity"`
+	Price          money.Amount `gorm:"type:decimal(15,2);not null" json:"price"`
+	Subtotal       money.Amount `gorm:"type:decimal(15,2);not null" json:"subtotal"` // Gross: price * quantity
+	DiscountAmount money.Amount `gorm:"type:decimal(15,2);default:0" json:"discount_amount"`
+	NetAmount      money.Amount `gorm:"type:decimal(15,2);default:0" json:"net_amount"` // Subtotal - discount
+	PromotionID    *uint        `gorm:"index" json:"promotion_id"`                      // Item level promotion, if any
+
+	// Purchase cost of one unit when it was sold, so margins use real costs
+	UnitCost money.Amount `gorm:"type:decimal(15,4);default:0" json:"unit_cost"`
+
+	// Progress of the item in the kitchen, bumped per item by the kitchen display
+	KitchenStatus string `gorm:"size:20;default:'pending'" json:"kitchen_status"` // pending, preparing, ready
 
 	// Offline sync fields
 	SyncStatus     string     `gorm:"size:20;default:'synced';index" json:"sync_status"`
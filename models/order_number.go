@@ -0,0 +1,74 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// OrderNumberFormat - How order numbers of a tenant (or a single branch) are built,
+// e.g. ORD-JKT01-20261018-0001
+type OrderNumberFormat struct {
+	ID         uint   `gorm:"primarykey" json:"id"`
+	TenantID   uint   `gorm:"not null;uniqueIndex:idx_order_number_format;uniqueIndex:idx_order_number_format_tenant_wide,where:branch_id IS NULL" json:"tenant_id"`
+	BranchID   *uint  `gorm:"uniqueIndex:idx_order_number_format" json:"branch_id"` // nil = all branches without a format of their own
+	Prefix     string `gorm:"size:20;default:'ORD'" json:"prefix"`
+	BranchCode string `gorm:"size:20" json:"branch_code"`                    // Empty = zero-padded branch ID
+	DateFormat string `gorm:"size:20;default:'20060102'" json:"date_format"` // Go layout, empty = no date part; without a day the counter never resets
+	Padding    int    `gorm:"default:4" json:"padding"`                      // Counter width
+	Separator  string `gorm:"size:5;default:'-'" json:"separator"`
+
+	CreatedBy *uint          `gorm:"index" json:"created_by"`
+	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Tenant  Tenant `gorm:"foreignKey:TenantID" json:"-"`
+	Creator *User  `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater *User  `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+}
+
+func (OrderNumberFormat) TableName() string {
+	return "order_number_formats"
+}
+
+// OrderNumberSequence - Last counter issued for a branch on a given day, or overall when the
+// branch's format has no daily date part
+type OrderNumberSequence struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	TenantID     uint      `gorm:"not null;uniqueIndex:idx_order_number_sequence" json:"tenant_id"`
+	BranchID     uint      `gorm:"not null;uniqueIndex:idx_order_number_sequence" json:"branch_id"`
+	SequenceDate string    `gorm:"size:10;not null;uniqueIndex:idx_order_number_sequence" json:"sequence_date"` // YYYY-MM-DD, empty = never resets
+	LastNumber   int       `gorm:"not null;default:0" json:"last_number"`
+	CreatedAt    time.Time `json:"created_at"`
+	UpdatedAt    time.Time `json:"updated_at"`
+}
+
+func (OrderNumberSequence) TableName() string {
+	return "order_number_sequences"
+}
+
+// OrderNumberReservation - Block of counters handed to an offline client
+type OrderNumberReservation struct {
+	ID           uint      `gorm:"primarykey" json:"id"`
+	TenantID     uint      `gorm:"not null;index" json:"tenant_id"`
+	BranchID     uint      `gorm:"not null;index" json:"branch_id"`
+	ClientID     string    `gorm:"size:100;not null;index" json:"client_id"`
+	SequenceDate string    `gorm:"size:10;not null;index" json:"sequence_date"` // YYYY-MM-DD
+	StartNumber  int       `gorm:"not null" json:"start_number"`
+	EndNumber    int       `gorm:"not null" json:"end_number"`
+	NumberPrefix string    `gorm:"size:50" json:"number_prefix"` // Order numbers as reserved, without the counter
+	Padding      int       `gorm:"not null;default:0" json:"padding"`
+	UsedCount    int       `gorm:"not null;default:0" json:"used_count"` // Numbers taken by synced orders
+	CreatedBy    *uint     `gorm:"index" json:"created_by"`
+	CreatedAt    time.Time `json:"created_at"`
+
+	// Relations
+	Creator *User `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+}
+
+func (OrderNumberReservation) TableName() string {
+	return "order_number_reservations"
+}
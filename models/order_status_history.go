@@ -0,0 +1,24 @@
+package models
+
+import "time"
+
+// OrderStatusHistory - Append-only log of every status transition on an order
+type OrderStatusHistory struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	TenantID   uint      `gorm:"not null;index" json:"tenant_id"`
+	BranchID   uint      `gorm:"not null;index" json:"branch_id"`
+	OrderID    uint      `gorm:"not null;index" json:"order_id"`
+	FromStatus string    `gorm:"size:20" json:"from_status"`
+	ToStatus   string    `gorm:"size:20;not null;index" json:"to_status"`
+	Reason     string    `gorm:"type:text" json:"reason"`
+	ChangedBy  *uint     `gorm:"index" json:"changed_by"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Relations
+	Order   Order `gorm:"foreignKey:OrderID" json:"-"`
+	Changer *User `gorm:"foreignKey:ChangedBy;constraint:-" json:"changer,omitempty"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_history"
+}
@@ -1,27 +1,47 @@
 package models
 
 import (
+	"myposcore/money"
 	"time"
 
 	"gorm.io/gorm"
 )
 
 type Payment struct {
-	ID            uint    `gorm:"primarykey" json:"id"`
-	TenantID      uint    `gorm:"not null;index" json:"tenant_id"`
-	BranchID      uint    `gorm:"not null;index" json:"branch_id"`
-	OrderID       uint    `gorm:"not null;index" json:"order_id"`
-	Amount        float64 `gorm:"type:decimal(15,2);not null" json:"amount"`
-	PaymentMethod string  `gorm:"size:50;not null" json:"payment_method"`        // cash, card, transfer, qris
-	Status        string  `gorm:"size:20;default:'pending';index" json:"status"` // pending, completed, failed
-	Notes         string  `gorm:"type:text" json:"notes"`
+	ID             uint         `gorm:"primarykey" json:"id"`
+	TenantID       uint         `gorm:"not null;index" json:"tenant_id"`
+	BranchID       uint         `gorm:"not null;index" json:"branch_id"`
+	OrderID        uint         `gorm:"not null;index" json:"order_id"`
+	Amount         money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"`           // Applied to the order
+	TenderedAmount money.Amount `gorm:"type:decimal(15,2);default:0" json:"tendered_amount"` // Handed over by the customer
+	ChangeAmount   money.Amount `gorm:"type:decimal(15,2);default:0" json:"change_amount"`   // Cash returned
+	RoundingAmount money.Amount `gorm:"type:decimal(15,2);default:0" json:"rounding_amount"` // Cash rounding written off; Amount + rounding settles the order
+	PaymentMethod  string       `gorm:"size:50;not null" json:"payment_method"`              // cash, card, transfer, qris, ewallet, loyalty_points, gift_card
+	Status         string       `gorm:"size:20;default:'pending';index" json:"status"`       // pending, completed, failed, partially_refunded, refunded
+	RefundedAmount money.Amount `gorm:"type:decimal(15,2);default:0" json:"refunded_amount"`
+	Notes          string       `gorm:"type:text" json:"notes"`
+	ShiftID        *uint        `gorm:"index" json:"shift_id"` // Cash shift of the cashier taking the payment
+
+	// Gratuity collected with the payment; kept out of Amount so it never counts as sales revenue
+	TipAmount money.Amount `gorm:"type:decimal(15,2);default:0" json:"tip_amount"`
+	TipUserID *uint        `gorm:"index" json:"tip_user_id"` // Staff member the tip is paid out to
+
+	GiftCardID *uint `gorm:"index" json:"gift_card_id"` // Card redeemed by a gift_card payment
+
+	// Asynchronous collection through a payment provider; empty Provider = settled immediately
+	Provider          string     `gorm:"size:50;index" json:"provider"`
+	ProviderReference string     `gorm:"size:100;index" json:"provider_reference"`
+	ProviderActionURL string     `gorm:"type:text" json:"provider_action_url"` // Page the customer pays on, if any
+	ProviderQRString  string     `gorm:"type:text" json:"provider_qr_string"`  // QR payload to display, if any
+	ProviderExpiresAt *time.Time `json:"provider_expires_at"`
+	FailureReason     string     `gorm:"type:text" json:"failure_reason"`
 
 	// Offline sync fields
 	SyncStatus     string     `gorm:"size:20;default:'synced';index" json:"sync_status"`
 	ClientID       string     `gorm:"size:100;index" json:"client_id"`
 	LocalTimestamp *time.Time `json:"local_timestamp"`
 	Version        int        `gorm:"default:1" json:"version"`
-	ConflictData   *string     `gorm:"type:jsonb" json:"conflict_data,omitempty"`
+	ConflictData   *string    `gorm:"type:jsonb" json:"conflict_data,omitempty"`
 
 	CreatedBy *uint          `gorm:"index" json:"created_by"`
 	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
@@ -31,8 +51,10 @@ type Payment struct {
 	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relations
-	Order   Order `gorm:"foreignKey:OrderID" json:"order,omitempty"`
-	Creator *User `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
-	Updater *User `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
-	Deleter *User `gorm:"foreignKey:DeletedBy;constraint:-" json:"deleter,omitempty"`
+	Order   Order    `gorm:"foreignKey:OrderID" json:"order,omitempty"`
+	Refunds []Refund `gorm:"foreignKey:PaymentID" json:"refunds,omitempty"`
+	TipUser *User    `gorm:"foreignKey:TipUserID;constraint:-" json:"tip_user,omitempty"`
+	Creator *User    `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater *User    `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+	Deleter *User    `gorm:"foreignKey:DeletedBy;constraint:-" json:"deleter,omitempty"`
 }
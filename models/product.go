@@ -1,6 +1,7 @@
 package models
 
 import (
+	"myposcore/money"
 	"time"
 
 	"gorm.io/gorm"
@@ -14,8 +15,8 @@ type Product struct {
 	Category    string         `gorm:"size:100;index" json:"category"` // Legacy field, will be deprecated
 	CategoryID  *uint          `gorm:"index" json:"category_id"`
 	SKU         string         `gorm:"size:100;index" json:"sku"`
-	Price       float64        `gorm:"type:decimal(10,2);not null" json:"price"`
-	Stock       int            `gorm:"default:0" json:"stock"`
+	Price       money.Amount   `gorm:"type:decimal(10,2);not null" json:"price"`
+	Stock       int            `gorm:"default:0" json:"stock"` // Total over all branches, only changed through the stock ledger
 	Image       string         `gorm:"type:varchar(500)" json:"image"`
 	IsActive    bool           `gorm:"default:true" json:"is_active"`
 	CreatedBy   *uint          `gorm:"index" json:"created_by"`
@@ -25,6 +26,23 @@ type Product struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Gift card products issue a card worth the price for every unit sold
+	IsGiftCard           bool `gorm:"default:false" json:"is_gift_card"`
+	GiftCardValidityDays int  `gorm:"default:0" json:"gift_card_validity_days"` // 0 = cards never expire
+
+	// Stock per branch; BranchStock is filled in for the branch the product is loaded for
+	BranchStock  int            `gorm:"-" json:"branch_stock"`
+	BranchStocks []ProductStock `gorm:"foreignKey:ProductID" json:"branch_stocks,omitempty"`
+
+	// Weighted average purchase cost of the stock, moved by goods receipts. Kept at the full scale of
+	// money.Amount so repeated receipts do not drift it by rounding.
+	CostPrice money.Amount `gorm:"type:decimal(15,4);default:0" json:"cost_price"`
+
+	// Reorder point: at or below MinStock the product raises low-stock alerts, 0 = none. Running out
+	// raises an out-of-stock alert either way. Branches can set their own on ProductStock.
+	MinStock        int `gorm:"default:0" json:"min_stock"`
+	ReorderQuantity int `gorm:"default:0" json:"reorder_quantity"` // Smallest quantity worth ordering
+
 	// Offline Sync Fields
 	SyncStatus     string     `gorm:"size:20;default:'synced';index" json:"sync_status"`
 	ClientID       string     `gorm:"size:255;index" json:"client_id,omitempty"`
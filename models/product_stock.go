@@ -0,0 +1,25 @@
+package models
+
+import "time"
+
+// ProductStock - Stock of a product held by one branch, the running balance of the branch's
+// stock movements. Product.Stock is the sum over all branches.
+type ProductStock struct {
+	ID        uint      `gorm:"primarykey" json:"id"`
+	TenantID  uint      `gorm:"not null;index" json:"tenant_id"`
+	BranchID  uint      `gorm:"not null;uniqueIndex:idx_product_stock_branch;index" json:"branch_id"`
+	ProductID uint      `gorm:"not null;uniqueIndex:idx_product_stock_branch;index" json:"product_id"`
+	Quantity  int       `gorm:"not null;default:0" json:"quantity"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// The branch's own reorder point, nil = the product's
+	MinStock        *int `json:"min_stock"`
+	ReorderQuantity *int `json:"reorder_quantity"`
+
+	// Relations
+	Branch *Branch `gorm:"foreignKey:BranchID" json:"branch,omitempty"`
+}
+
+func (ProductStock) TableName() string {
+	return "product_stocks"
+}
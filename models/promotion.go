@@ -0,0 +1,82 @@
+package models
+
+import (
+	"myposcore/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Promotion - Tenant scoped discount rule evaluated when an order is created
+type Promotion struct {
+	ID          uint   `gorm:"primarykey" json:"id"`
+	TenantID    uint   `gorm:"not null;index" json:"tenant_id"`
+	BranchID    *uint  `gorm:"index" json:"branch_id"` // nil = all branches
+	Name        string `gorm:"size:255;not null" json:"name"`
+	Description string `gorm:"type:text" json:"description"`
+	Type        string `gorm:"size:20;not null;index" json:"type"` // percentage, fixed, buy_x_get_y
+
+	// Discount value: percent for percentage, currency amount for fixed (per unit when item scoped)
+	DiscountValue money.Amount `gorm:"type:decimal(15,2);default:0" json:"discount_value"`
+	MaxDiscount   money.Amount `gorm:"type:decimal(15,2);default:0" json:"max_discount"` // 0 = no cap
+
+	// Scope: both nil = whole order, otherwise only matching items are discounted
+	CategoryID *uint `gorm:"index" json:"category_id"`
+	ProductID  *uint `gorm:"index" json:"product_id"`
+
+	// Buy X get Y (cheapest units of the matching line are free)
+	BuyQuantity int `gorm:"default:0" json:"buy_quantity"`
+	GetQuantity int `gorm:"default:0" json:"get_quantity"`
+
+	// Conditions
+	MinSpend  money.Amount `gorm:"type:decimal(15,2);default:0" json:"min_spend"`
+	StartDate *time.Time   `gorm:"index" json:"start_date"`
+	EndDate   *time.Time   `gorm:"index" json:"end_date"`
+	StartTime string       `gorm:"size:5" json:"start_time"` // HH:MM daily window, empty = all day
+	EndTime   string       `gorm:"size:5" json:"end_time"`
+
+	// Coupon: empty code = applied automatically
+	CouponCode string `gorm:"size:50;index" json:"coupon_code"`
+	UsageLimit int    `gorm:"default:0" json:"usage_limit"` // 0 = unlimited
+	UsageCount int    `gorm:"default:0" json:"usage_count"`
+
+	IsActive  bool           `gorm:"default:true;index" json:"is_active"`
+	CreatedBy *uint          `gorm:"index" json:"created_by"`
+	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
+	DeletedBy *uint          `gorm:"index" json:"deleted_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Tenant   Tenant    `gorm:"foreignKey:TenantID" json:"-"`
+	Category *Category `gorm:"foreignKey:CategoryID;constraint:-" json:"category,omitempty"`
+	Product  *Product  `gorm:"foreignKey:ProductID;constraint:-" json:"product,omitempty"`
+	Creator  *User     `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater  *User     `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+	Deleter  *User     `gorm:"foreignKey:DeletedBy;constraint:-" json:"deleter,omitempty"`
+}
+
+func (Promotion) TableName() string {
+	return "promotions"
+}
+
+// OrderPromotion - Discount granted to an order by a single promotion
+type OrderPromotion struct {
+	ID             uint         `gorm:"primarykey" json:"id"`
+	TenantID       uint         `gorm:"not null;index" json:"tenant_id"`
+	OrderID        uint         `gorm:"not null;index" json:"order_id"`
+	PromotionID    uint         `gorm:"not null;index" json:"promotion_id"`
+	PromotionName  string       `gorm:"size:255" json:"promotion_name"`
+	CouponCode     string       `gorm:"size:50;index" json:"coupon_code"`
+	DiscountAmount money.Amount `gorm:"type:decimal(15,2);not null" json:"discount_amount"`
+	CreatedAt      time.Time    `json:"created_at"`
+
+	// Relations
+	Order     Order     `gorm:"foreignKey:OrderID" json:"-"`
+	Promotion Promotion `gorm:"foreignKey:PromotionID" json:"-"`
+}
+
+func (OrderPromotion) TableName() string {
+	return "order_promotions"
+}
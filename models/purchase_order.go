@@ -0,0 +1,93 @@
+package models
+
+import (
+	"myposcore/money"
+	"time"
+)
+
+// PurchaseOrder - Products ordered from a supplier for one branch. Goods receipts against the order
+// put the delivered quantities into the branch's stock.
+type PurchaseOrder struct {
+	ID          uint         `gorm:"primarykey" json:"id"`
+	TenantID    uint         `gorm:"not null;index" json:"tenant_id"`
+	BranchID    uint         `gorm:"not null;index" json:"branch_id"` // Receiving branch
+	SupplierID  uint         `gorm:"not null;index" json:"supplier_id"`
+	Status      string       `gorm:"size:20;not null;index" json:"status"` // draft, ordered, partially_received, received, closed, cancelled
+	Notes       string       `gorm:"type:text" json:"notes"`
+	ExpectedAt  *time.Time   `gorm:"type:date" json:"expected_at"`
+	TotalAmount money.Amount `gorm:"type:decimal(15,2);not null;default:0" json:"total_amount"` // Sum of quantity * unit cost
+
+	// Filled in as the order moves on
+	OrderedAt   *time.Time `json:"ordered_at"`
+	OrderedBy   *uint      `gorm:"index" json:"ordered_by"`
+	ClosedAt    *time.Time `json:"closed_at"` // Received in full, closed short or cancelled
+	ClosedBy    *uint      `gorm:"index" json:"closed_by"`
+	ClosedNotes string     `gorm:"type:text" json:"closed_notes"`
+
+	CreatedBy *uint     `gorm:"index" json:"created_by"`
+	UpdatedBy *uint     `gorm:"index" json:"updated_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	Branch   *Branch             `gorm:"foreignKey:BranchID" json:"branch,omitempty"`
+	Supplier *Supplier           `gorm:"foreignKey:SupplierID" json:"supplier,omitempty"`
+	Items    []PurchaseOrderItem `gorm:"foreignKey:PurchaseOrderID" json:"items,omitempty"`
+	Receipts []GoodsReceipt      `gorm:"foreignKey:PurchaseOrderID" json:"receipts,omitempty"`
+	Creator  *User               `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+}
+
+func (PurchaseOrder) TableName() string {
+	return "purchase_orders"
+}
+
+// PurchaseOrderItem - A product on a purchase order at the agreed unit cost
+type PurchaseOrderItem struct {
+	ID               uint         `gorm:"primarykey" json:"id"`
+	PurchaseOrderID  uint         `gorm:"not null;index" json:"purchase_order_id"`
+	ProductID        uint         `gorm:"not null;index" json:"product_id"`
+	Quantity         int          `gorm:"not null" json:"quantity"`
+	UnitCost         money.Amount `gorm:"type:decimal(15,2);not null" json:"unit_cost"`
+	Subtotal         money.Amount `gorm:"type:decimal(15,2);not null" json:"subtotal"` // Quantity * unit cost
+	ReceivedQuantity int          `gorm:"not null;default:0" json:"received_quantity"` // Over all goods receipts
+
+	// Relations
+	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (PurchaseOrderItem) TableName() string {
+	return "purchase_order_items"
+}
+
+// GoodsReceipt - One delivery against a purchase order, received into the order's branch
+type GoodsReceipt struct {
+	ID              uint      `gorm:"primarykey" json:"id"`
+	TenantID        uint      `gorm:"not null;index" json:"tenant_id"`
+	BranchID        uint      `gorm:"not null;index" json:"branch_id"`
+	PurchaseOrderID uint      `gorm:"not null;index" json:"purchase_order_id"`
+	Notes           string    `gorm:"type:text" json:"notes"`
+	ReceivedBy      *uint     `gorm:"index" json:"received_by"`
+	ReceivedAt      time.Time `gorm:"not null" json:"received_at"`
+
+	// Relations
+	Items    []GoodsReceiptItem `gorm:"foreignKey:GoodsReceiptID" json:"items,omitempty"`
+	Receiver *User              `gorm:"foreignKey:ReceivedBy;constraint:-" json:"receiver,omitempty"`
+}
+
+func (GoodsReceipt) TableName() string {
+	return "goods_receipts"
+}
+
+// GoodsReceiptItem - Units of a purchase order item delivered, at the cost actually paid for them
+type GoodsReceiptItem struct {
+	ID                  uint         `gorm:"primarykey" json:"id"`
+	GoodsReceiptID      uint         `gorm:"not null;index" json:"goods_receipt_id"`
+	PurchaseOrderItemID uint         `gorm:"not null;index" json:"purchase_order_item_id"`
+	ProductID           uint         `gorm:"not null;index" json:"product_id"`
+	Quantity            int          `gorm:"not null" json:"quantity"`
+	UnitCost            money.Amount `gorm:"type:decimal(15,2);not null" json:"unit_cost"`
+}
+
+func (GoodsReceiptItem) TableName() string {
+	return "goods_receipt_items"
+}
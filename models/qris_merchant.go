@@ -0,0 +1,39 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// QRISMerchant - Merchant data a tenant (or a single branch) prints in its QRIS codes
+type QRISMerchant struct {
+	ID               uint   `gorm:"primarykey" json:"id"`
+	TenantID         uint   `gorm:"not null;uniqueIndex:idx_qris_merchant" json:"tenant_id"`
+	BranchID         *uint  `gorm:"uniqueIndex:idx_qris_merchant" json:"branch_id"` // nil = all branches without merchant data of their own
+	MerchantName     string `gorm:"size:25;not null" json:"merchant_name"`
+	MerchantCity     string `gorm:"size:15;not null" json:"merchant_city"`
+	PostalCode       string `gorm:"size:10" json:"postal_code"`
+	CategoryCode     string `gorm:"size:4;not null" json:"category_code"`     // ISO 18245 merchant category code
+	AcquirerDomain   string `gorm:"size:32;not null" json:"acquirer_domain"`  // Reverse domain of the acquirer, e.g. ID.CO.BANKNAME.WWW
+	MerchantPAN      string `gorm:"size:19;not null" json:"merchant_pan"`     // Merchant PAN issued by the acquirer
+	MerchantID       string `gorm:"size:15;not null" json:"merchant_id"`      // Merchant ID at the acquirer
+	NMID             string `gorm:"size:15;not null" json:"nmid"`             // National merchant ID issued by QRIS
+	MerchantCriteria string `gorm:"size:3;not null" json:"merchant_criteria"` // UMI, UKE, UME, UBE or URE
+	TerminalLabel    string `gorm:"size:25" json:"terminal_label"`
+
+	CreatedBy *uint          `gorm:"index" json:"created_by"`
+	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Tenant  Tenant `gorm:"foreignKey:TenantID" json:"-"`
+	Creator *User  `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater *User  `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+}
+
+func (QRISMerchant) TableName() string {
+	return "qris_merchants"
+}
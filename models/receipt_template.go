@@ -0,0 +1,35 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// ReceiptTemplate - What a printed receipt of a tenant (or a single branch) shows
+type ReceiptTemplate struct {
+	ID           uint   `gorm:"primarykey" json:"id"`
+	TenantID     uint   `gorm:"not null;uniqueIndex:idx_receipt_template" json:"tenant_id"`
+	BranchID     *uint  `gorm:"uniqueIndex:idx_receipt_template" json:"branch_id"` // nil = all branches without a template of their own
+	HeaderText   string `gorm:"type:text" json:"header_text"`                      // Printed under the tenant name, one line per line break
+	FooterText   string `gorm:"type:text" json:"footer_text"`
+	ShowLogo     bool   `gorm:"not null" json:"show_logo"`
+	ShowTaxLines bool   `gorm:"not null" json:"show_tax_lines"` // false = one line per tax type
+	ShowCashier  bool   `gorm:"not null" json:"show_cashier"`
+	PaperWidth   int    `gorm:"default:80" json:"paper_width"` // 58 or 80 (mm)
+
+	CreatedBy *uint          `gorm:"index" json:"created_by"`
+	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Tenant  Tenant `gorm:"foreignKey:TenantID" json:"-"`
+	Creator *User  `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater *User  `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+}
+
+func (ReceiptTemplate) TableName() string {
+	return "receipt_templates"
+}
@@ -0,0 +1,71 @@
+package models
+
+import (
+	"myposcore/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Refund - Full or partial reversal of a completed payment
+type Refund struct {
+	ID           uint         `gorm:"primarykey" json:"id"`
+	TenantID     uint         `gorm:"not null;index" json:"tenant_id"`
+	BranchID     uint         `gorm:"not null;index" json:"branch_id"`
+	OrderID      uint         `gorm:"not null;index" json:"order_id"`
+	PaymentID    uint         `gorm:"not null;index" json:"payment_id"`
+	Amount       money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"`
+	RefundMethod string       `gorm:"size:50;not null" json:"refund_method"` // cash, card, transfer, qris, loyalty_points, gift_card, store_credit
+	Reason       string       `gorm:"type:text;not null" json:"reason"`
+	Restock      bool         `gorm:"default:false" json:"restock"`
+	Status       string       `gorm:"size:20;default:'completed';index" json:"status"` // pending, completed, failed
+	ApprovedBy   *uint        `gorm:"index" json:"approved_by"`
+	ShiftID      *uint        `gorm:"index" json:"shift_id"` // Cash shift the refund was paid out of
+
+	ProviderReference string `gorm:"size:100" json:"provider_reference"` // Refund reference at the payment provider
+	FailureReason     string `gorm:"type:text" json:"failure_reason"`    // Why the provider refused the refund
+
+	GiftCardID *uint `gorm:"index" json:"gift_card_id"` // Card credited by a gift_card or store_credit refund
+
+	CreatedBy *uint          `gorm:"index" json:"created_by"`
+	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
+	DeletedBy *uint          `gorm:"index" json:"deleted_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Order    Order        `gorm:"foreignKey:OrderID" json:"-"`
+	Payment  Payment      `gorm:"foreignKey:PaymentID" json:"-"`
+	Items    []RefundItem `gorm:"foreignKey:RefundID" json:"items,omitempty"`
+	GiftCard *GiftCard    `gorm:"foreignKey:GiftCardID;constraint:-" json:"gift_card,omitempty"`
+	Approver *User        `gorm:"foreignKey:ApprovedBy;constraint:-" json:"approver,omitempty"`
+	Creator  *User        `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater  *User        `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+	Deleter  *User        `gorm:"foreignKey:DeletedBy;constraint:-" json:"deleter,omitempty"`
+}
+
+func (Refund) TableName() string {
+	return "refunds"
+}
+
+// RefundItem - Quantity of an order item returned as part of a refund
+type RefundItem struct {
+	ID          uint         `gorm:"primarykey" json:"id"`
+	RefundID    uint         `gorm:"not null;index" json:"refund_id"`
+	OrderItemID uint         `gorm:"not null;index" json:"order_item_id"`
+	ProductID   uint         `gorm:"not null;index" json:"product_id"`
+	Quantity    int          `gorm:"not null" json:"quantity"`
+	Amount      money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"`
+	Restocked   bool         `gorm:"default:false" json:"restocked"`
+	CreatedAt   time.Time    `json:"created_at"`
+
+	// Relations
+	Refund    Refund    `gorm:"foreignKey:RefundID" json:"-"`
+	OrderItem OrderItem `gorm:"foreignKey:OrderItemID" json:"-"`
+	Product   Product   `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (RefundItem) TableName() string {
+	return "refund_items"
+}
@@ -0,0 +1,26 @@
+package models
+
+import "time"
+
+// StockAlert - Raised when a product's stock at a branch falls to its reorder point or runs out,
+// resolved once the stock is back above it. A product has at most one open alert per branch.
+type StockAlert struct {
+	ID         uint       `gorm:"primarykey" json:"id"`
+	TenantID   uint       `gorm:"not null;index" json:"tenant_id"`
+	BranchID   uint       `gorm:"not null;index" json:"branch_id"`
+	ProductID  uint       `gorm:"not null;index" json:"product_id"`
+	Type       string     `gorm:"size:20;not null;index" json:"type"`   // low_stock, out_of_stock
+	Status     string     `gorm:"size:20;not null;index" json:"status"` // open, resolved
+	Quantity   int        `gorm:"not null" json:"quantity"`             // Stock at the branch when raised
+	MinStock   int        `gorm:"not null" json:"min_stock"`            // Reorder point when raised
+	RaisedAt   time.Time  `gorm:"not null;index" json:"raised_at"`
+	ResolvedAt *time.Time `json:"resolved_at"`
+
+	// Relations
+	Branch  *Branch `gorm:"foreignKey:BranchID" json:"branch,omitempty"`
+	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (StockAlert) TableName() string {
+	return "stock_alerts"
+}
@@ -0,0 +1,28 @@
+package models
+
+import "time"
+
+// StockMovement - Append-only ledger entry of a change to a product's stock. Product.Stock is the
+// running balance of these entries; corrections are new entries, never edits.
+type StockMovement struct {
+	ID            uint      `gorm:"primarykey" json:"id"`
+	TenantID      uint      `gorm:"not null;index" json:"tenant_id"`
+	BranchID      uint      `gorm:"not null;index" json:"branch_id"`
+	ProductID     uint      `gorm:"not null;index" json:"product_id"`
+	Type          string    `gorm:"size:20;not null;index" json:"type"` // sale, refund, adjustment, receipt, transfer, stocktake, sync
+	Quantity      int       `gorm:"not null" json:"quantity"`           // Positive adds stock, negative removes it
+	BalanceAfter  int       `gorm:"not null" json:"balance_after"`      // Stock of the product at the branch
+	Reason        string    `gorm:"type:text" json:"reason"`
+	ReferenceType string    `gorm:"size:50;index:idx_stock_movement_reference" json:"reference_type"` // Document that caused it: order, refund, product
+	ReferenceID   *uint     `gorm:"index:idx_stock_movement_reference" json:"reference_id"`
+	CreatedBy     *uint     `gorm:"index" json:"created_by"`
+	CreatedAt     time.Time `gorm:"index" json:"created_at"`
+
+	// Relations
+	Product Product `gorm:"foreignKey:ProductID" json:"-"`
+	Creator *User   `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+}
+
+func (StockMovement) TableName() string {
+	return "stock_movements"
+}
@@ -0,0 +1,59 @@
+package models
+
+import "time"
+
+// StockTransfer - Stock moved from one branch of a tenant to another. Stock leaves the source branch
+// when the transfer is sent and arrives at the destination, as counted there, when it is received.
+type StockTransfer struct {
+	ID           uint   `gorm:"primarykey" json:"id"`
+	TenantID     uint   `gorm:"not null;index" json:"tenant_id"`
+	FromBranchID uint   `gorm:"not null;index" json:"from_branch_id"`
+	ToBranchID   uint   `gorm:"not null;index" json:"to_branch_id"`
+	Status       string `gorm:"size:20;not null;index" json:"status"` // draft, sent, received, closed, cancelled
+	Notes        string `gorm:"type:text" json:"notes"`
+
+	// Filled in as the transfer moves on
+	SentAt        *time.Time `json:"sent_at"`
+	SentBy        *uint      `gorm:"index" json:"sent_by"`
+	ReceivedAt    *time.Time `json:"received_at"`
+	ReceivedBy    *uint      `gorm:"index" json:"received_by"`
+	ReceivedNotes string     `gorm:"type:text" json:"received_notes"`
+	ClosedAt      *time.Time `json:"closed_at"`
+	ClosedBy      *uint      `gorm:"index" json:"closed_by"`
+	ClosedNotes   string     `gorm:"type:text" json:"closed_notes"`
+
+	CreatedBy *uint     `gorm:"index" json:"created_by"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	// Relations
+	FromBranch *Branch             `gorm:"foreignKey:FromBranchID" json:"from_branch,omitempty"`
+	ToBranch   *Branch             `gorm:"foreignKey:ToBranchID" json:"to_branch,omitempty"`
+	Items      []StockTransferItem `gorm:"foreignKey:StockTransferID" json:"items,omitempty"`
+	Creator    *User               `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Sender     *User               `gorm:"foreignKey:SentBy;constraint:-" json:"sender,omitempty"`
+	Receiver   *User               `gorm:"foreignKey:ReceivedBy;constraint:-" json:"receiver,omitempty"`
+	Closer     *User               `gorm:"foreignKey:ClosedBy;constraint:-" json:"closer,omitempty"`
+}
+
+func (StockTransfer) TableName() string {
+	return "stock_transfers"
+}
+
+// StockTransferItem - A product on a transfer with the quantity sent and the quantity counted on arrival
+type StockTransferItem struct {
+	ID               uint   `gorm:"primarykey" json:"id"`
+	StockTransferID  uint   `gorm:"not null;index" json:"stock_transfer_id"`
+	ProductID        uint   `gorm:"not null;index" json:"product_id"`
+	Quantity         int    `gorm:"not null" json:"quantity"`     // Units sent
+	ReceivedQuantity *int   `json:"received_quantity"`            // Units counted at the destination
+	Discrepancy      int    `gorm:"default:0" json:"discrepancy"` // Received - sent; negative = short
+	DiscrepancyNote  string `gorm:"type:text" json:"discrepancy_note"`
+
+	// Relations
+	Product Product `gorm:"foreignKey:ProductID" json:"product,omitempty"`
+}
+
+func (StockTransferItem) TableName() string {
+	return "stock_transfer_items"
+}
@@ -0,0 +1,36 @@
+package models
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Supplier - Tenant scoped directory entry of a supplier that purchase orders are placed with
+type Supplier struct {
+	ID          uint   `gorm:"primarykey" json:"id"`
+	TenantID    uint   `gorm:"not null;index" json:"tenant_id"`
+	Name        string `gorm:"size:255;not null;index" json:"name"`
+	ContactName string `gorm:"size:255" json:"contact_name"`
+	Phone       string `gorm:"size:30" json:"phone"`
+	Email       string `gorm:"size:255" json:"email"`
+	Address     string `gorm:"type:text" json:"address"`
+	Notes       string `gorm:"type:text" json:"notes"`
+	IsActive    bool   `gorm:"default:true" json:"is_active"`
+
+	CreatedBy *uint          `gorm:"index" json:"created_by"`
+	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
+	DeletedBy *uint          `gorm:"index" json:"deleted_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Tenant  Tenant `gorm:"foreignKey:TenantID" json:"-"`
+	Creator *User  `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater *User  `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+}
+
+func (Supplier) TableName() string {
+	return "suppliers"
+}
@@ -0,0 +1,77 @@
+package models
+
+import (
+	"myposcore/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// TaxRule - Tax or service charge applied to orders of a tenant (or a single branch)
+type TaxRule struct {
+	ID       uint   `gorm:"primarykey" json:"id"`
+	TenantID uint   `gorm:"not null;index" json:"tenant_id"`
+	BranchID *uint  `gorm:"index" json:"branch_id"`             // nil = all branches without rules of their own
+	Name     string `gorm:"size:100;not null" json:"name"`      // e.g. PB1, PPN, Service Charge
+	Type     string `gorm:"size:20;not null;index" json:"type"` // tax, service_charge
+
+	Rate        float64 `gorm:"type:decimal(7,4);not null" json:"rate"` // Percent, e.g. 10 for 10%
+	IsInclusive bool    `gorm:"default:false" json:"is_inclusive"`      // Already included in product prices
+	Sequence    int     `gorm:"default:0;index" json:"sequence"`        // Evaluation order, lowest first
+	IsCompound  bool    `gorm:"default:false" json:"is_compound"`       // Base includes charges with a lower sequence
+	IsActive    bool    `gorm:"default:true;index" json:"is_active"`
+
+	CreatedBy *uint          `gorm:"index" json:"created_by"`
+	UpdatedBy *uint          `gorm:"index" json:"updated_by"`
+	DeletedBy *uint          `gorm:"index" json:"deleted_by"`
+	CreatedAt time.Time      `json:"created_at"`
+	UpdatedAt time.Time      `json:"updated_at"`
+	DeletedAt gorm.DeletedAt `gorm:"index" json:"-"`
+
+	// Relations
+	Tenant     Tenant             `gorm:"foreignKey:TenantID" json:"-"`
+	Exemptions []TaxRuleExemption `gorm:"foreignKey:TaxRuleID" json:"exemptions,omitempty"`
+	Creator    *User              `gorm:"foreignKey:CreatedBy;constraint:-" json:"creator,omitempty"`
+	Updater    *User              `gorm:"foreignKey:UpdatedBy;constraint:-" json:"updater,omitempty"`
+	Deleter    *User              `gorm:"foreignKey:DeletedBy;constraint:-" json:"deleter,omitempty"`
+}
+
+func (TaxRule) TableName() string {
+	return "tax_rules"
+}
+
+// TaxRuleExemption - Category whose items are not charged by a tax rule
+type TaxRuleExemption struct {
+	ID         uint      `gorm:"primarykey" json:"id"`
+	TaxRuleID  uint      `gorm:"not null;uniqueIndex:idx_tax_rule_exemption" json:"tax_rule_id"`
+	CategoryID uint      `gorm:"not null;uniqueIndex:idx_tax_rule_exemption" json:"category_id"`
+	CreatedAt  time.Time `json:"created_at"`
+
+	// Relations
+	Category *Category `gorm:"foreignKey:CategoryID;constraint:-" json:"category,omitempty"`
+}
+
+func (TaxRuleExemption) TableName() string {
+	return "tax_rule_exemptions"
+}
+
+// OrderTax - Tax or service charge line computed for an order
+type OrderTax struct {
+	ID            uint         `gorm:"primarykey" json:"id"`
+	OrderID       uint         `gorm:"not null;index" json:"order_id"`
+	TaxRuleID     *uint        `gorm:"index" json:"tax_rule_id"`
+	Name          string       `gorm:"size:100;not null" json:"name"`
+	Type          string       `gorm:"size:20;not null" json:"type"` // tax, service_charge
+	Rate          float64      `gorm:"type:decimal(7,4);not null" json:"rate"`
+	IsInclusive   bool         `gorm:"default:false" json:"is_inclusive"`
+	TaxableAmount money.Amount `gorm:"type:decimal(15,2);not null" json:"taxable_amount"`
+	Amount        money.Amount `gorm:"type:decimal(15,2);not null" json:"amount"`
+	CreatedAt     time.Time    `json:"created_at"`
+
+	// Relations
+	Order Order `gorm:"foreignKey:OrderID" json:"-"`
+}
+
+func (OrderTax) TableName() string {
+	return "order_taxes"
+}
@@ -1,6 +1,7 @@
 package models
 
 import (
+	"myposcore/money"
 	"time"
 
 	"gorm.io/gorm"
@@ -23,6 +24,12 @@ type Tenant struct {
 	UpdatedAt   time.Time      `json:"updated_at"`
 	DeletedAt   gorm.DeletedAt `gorm:"index" json:"-"`
 
+	// Currency amounts are rounded in; cash totals are also rounded to the smallest denomination
+	CurrencyCode          string       `gorm:"size:3;not null;default:'IDR'" json:"currency_code"`
+	CurrencyPrecision     int          `gorm:"not null;default:2" json:"currency_precision"`                 // Minor unit decimal places
+	CashRoundingIncrement money.Amount `gorm:"type:decimal(15,2);default:0" json:"cash_rounding_increment"`  // e.g. 100 or 500 IDR; 0 = none
+	CashRoundingMode      string       `gorm:"size:20;not null;default:'half_up'" json:"cash_rounding_mode"` // half_up, half_even, down, up
+
 	// Audit tracking
 	CreatedBy *uint `gorm:"index" json:"created_by,omitempty"`
 	UpdatedBy *uint `gorm:"index" json:"updated_by,omitempty"`
@@ -0,0 +1,23 @@
+package models
+
+import (
+	"myposcore/money"
+	"time"
+)
+
+// TipPreset - A tip suggested to the customer at payment: a fixed amount or a percentage of the order total
+type TipPreset struct {
+	ID        uint         `gorm:"primarykey" json:"id"`
+	TenantID  uint         `gorm:"not null;index" json:"tenant_id"`
+	Type      string       `gorm:"size:20;not null" json:"type"`               // fixed, percent
+	Amount    money.Amount `gorm:"type:decimal(15,2);default:0" json:"amount"` // Fixed tip
+	Percent   float64      `gorm:"type:decimal(7,4);default:0" json:"percent"` // Percent of the order total, e.g. 10 for 10%
+	SortOrder int          `gorm:"default:0" json:"sort_order"`
+	CreatedBy *uint        `gorm:"index" json:"created_by"`
+	CreatedAt time.Time    `json:"created_at"`
+	UpdatedAt time.Time    `json:"updated_at"`
+}
+
+func (TipPreset) TableName() string {
+	return "tip_presets"
+}
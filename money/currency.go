@@ -0,0 +1,55 @@
+package money
+
+// Currency is how a tenant's amounts are rounded: to the minor unit of the currency and, for cash,
+// to the smallest denomination in circulation
+type Currency struct {
+	Code          string       // ISO 4217, e.g. IDR
+	Precision     int          // Minor unit decimal places
+	CashIncrement Amount       // Cash totals are rounded to a multiple of this; 0 = minor unit
+	CashRounding  RoundingMode // How cash totals are rounded to the increment
+}
+
+// DefaultCurrency is used for tenants that have not configured a currency
+var DefaultCurrency = Currency{Code: "IDR", Precision: 2, CashRounding: RoundHalfUp}
+
+// Round rounds an amount to the minor unit, half up
+func (c Currency) Round(a Amount) Amount {
+	return a.Round(c.Precision, RoundHalfUp)
+}
+
+// RoundCash rounds an amount to what can be paid in cash, e.g. to the nearest 100 or 500 IDR
+func (c Currency) RoundCash(a Amount) Amount {
+	if c.CashIncrement <= 0 {
+		return c.Round(a)
+	}
+	mode := c.CashRounding
+	if !mode.Valid() {
+		mode = RoundHalfUp
+	}
+	return a.RoundToIncrement(c.CashIncrement, mode)
+}
+
+// Percent returns rate percent of an amount, rounded to the minor unit. Rates carry up to Scale
+// decimal places, as stored by the tax and promotion tables.
+func (c Currency) Percent(a Amount, rate float64) Amount {
+	return a.MulFrac(int64(FromFloat(rate)), 100*unit, c.Precision, RoundHalfUp)
+}
+
+// Share returns the part/whole share of an amount, rounded to the minor unit
+func (c Currency) Share(a, part, whole Amount) Amount {
+	if whole == 0 {
+		return 0
+	}
+	return a.MulFrac(int64(part), int64(whole), c.Precision, RoundHalfUp)
+}
+
+// ExcludePercent returns the part of a price including rate percent that is not the rate, e.g. the
+// net amount of a tax inclusive price
+func (c Currency) ExcludePercent(a Amount, rate float64) Amount {
+	return a.MulFrac(100*unit, 100*unit+int64(FromFloat(rate)), c.Precision, RoundHalfUp)
+}
+
+// Format writes an amount with the currency's decimal places
+func (c Currency) Format(a Amount) string {
+	return a.StringFixed(c.Precision)
+}
@@ -0,0 +1,257 @@
+package money
+
+import (
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"strings"
+)
+
+// Scale is the number of decimal places an Amount carries internally. It leaves room below the
+// minor unit of every supported currency so intermediate results are exact before rounding.
+const Scale = 4
+
+const unit = 10000 // 10^Scale
+
+// Amount is an exact decimal amount of money, held as an integer number of 1/10000 units.
+// Amounts add, subtract and compare with the ordinary operators; multiplication and division go
+// through the methods below so the result is rounded explicitly.
+type Amount int64
+
+// RoundingMode decides which way an amount between two representable values goes
+type RoundingMode string
+
+const (
+	RoundHalfUp   RoundingMode = "half_up"   // nearest, ties away from zero
+	RoundHalfEven RoundingMode = "half_even" // nearest, ties to the even neighbour (banker's rounding)
+	RoundDown     RoundingMode = "down"      // towards zero
+	RoundUp       RoundingMode = "up"        // away from zero
+)
+
+// Valid reports whether m is a known rounding mode
+func (m RoundingMode) Valid() bool {
+	switch m {
+	case RoundHalfUp, RoundHalfEven, RoundDown, RoundUp:
+		return true
+	}
+	return false
+}
+
+// Zero is the zero amount
+const Zero Amount = 0
+
+// New returns a whole number of major units
+func New(units int64) Amount {
+	return Amount(units * unit)
+}
+
+// FromFloat converts a float, rounding half away from zero at Scale. Only for values that did not
+// come from money arithmetic, such as configuration.
+func FromFloat(f float64) Amount {
+	return Amount(math.Round(f * unit))
+}
+
+// Parse reads a decimal string such as "15000", "-12.5" or "1e3". Digits beyond Scale are
+// rounded half away from zero.
+func Parse(s string) (Amount, error) {
+	s = strings.TrimSpace(s)
+	if s == "" {
+		return 0, errors.New("money: empty amount")
+	}
+	r, ok := new(big.Rat).SetString(s)
+	if !ok {
+		return 0, fmt.Errorf("money: invalid amount %q", s)
+	}
+	scaled := divRound(new(big.Int).Mul(r.Num(), big.NewInt(unit)), r.Denom(), RoundHalfUp)
+	if !scaled.IsInt64() {
+		return 0, fmt.Errorf("money: amount %q out of range", s)
+	}
+	return Amount(scaled.Int64()), nil
+}
+
+// Float64 returns the amount as a float, for ratios and display only
+func (a Amount) Float64() float64 {
+	return float64(a) / unit
+}
+
+// Mul multiplies the amount by a quantity
+func (a Amount) Mul(quantity int) Amount {
+	return a * Amount(quantity)
+}
+
+// MulFrac returns a * num / den rounded to precision decimal places with mode
+func (a Amount) MulFrac(num, den int64, precision int, mode RoundingMode) Amount {
+	if den == 0 {
+		panic("money: division by zero")
+	}
+	n := new(big.Int).Mul(big.NewInt(int64(a)), big.NewInt(num))
+	d := new(big.Int).Mul(big.NewInt(den), big.NewInt(step(precision)))
+	q := divRound(n, d, mode)
+	return Amount(q.Int64() * step(precision))
+}
+
+// Round rounds the amount to precision decimal places
+func (a Amount) Round(precision int, mode RoundingMode) Amount {
+	return a.MulFrac(1, 1, precision, mode)
+}
+
+// RoundToIncrement rounds the amount to a multiple of increment, e.g. the smallest coin in
+// circulation. A zero or negative increment leaves the amount unchanged.
+func (a Amount) RoundToIncrement(increment Amount, mode RoundingMode) Amount {
+	if increment <= 0 {
+		return a
+	}
+	q := divRound(big.NewInt(int64(a)), big.NewInt(int64(increment)), mode)
+	return Amount(q.Int64()) * increment
+}
+
+// Min returns the smaller amount
+func Min(a, b Amount) Amount {
+	if a < b {
+		return a
+	}
+	return b
+}
+
+// Max returns the larger amount
+func Max(a, b Amount) Amount {
+	if a > b {
+		return a
+	}
+	return b
+}
+
+// String formats the amount without trailing zeros, e.g. "15000" or "12.5"
+func (a Amount) String() string {
+	s := a.StringFixed(Scale)
+	if strings.Contains(s, ".") {
+		s = strings.TrimRight(s, "0")
+		s = strings.TrimSuffix(s, ".")
+	}
+	return s
+}
+
+// StringFixed formats the amount with exactly precision decimal places, rounding half up
+func (a Amount) StringFixed(precision int) string {
+	rounded := a.Round(precision, RoundHalfUp)
+	sign := ""
+	v := int64(rounded)
+	if v < 0 {
+		sign = "-"
+		v = -v
+	}
+	whole, frac := v/unit, v%unit
+	if precision <= 0 {
+		return fmt.Sprintf("%s%d", sign, whole)
+	}
+	digits := fmt.Sprintf("%0*d", Scale, frac)[:precision]
+	return fmt.Sprintf("%s%d.%s", sign, whole, digits)
+}
+
+// MarshalJSON writes the amount as a JSON number
+func (a Amount) MarshalJSON() ([]byte, error) {
+	return []byte(a.String()), nil
+}
+
+// UnmarshalJSON reads a JSON number or numeric string without going through float64
+func (a *Amount) UnmarshalJSON(data []byte) error {
+	s := strings.Trim(string(data), `"`)
+	if s == "null" || s == "" {
+		return nil
+	}
+	parsed, err := Parse(s)
+	if err != nil {
+		return err
+	}
+	*a = parsed
+	return nil
+}
+
+// Value stores the amount as a decimal string, which numeric columns take without loss
+func (a Amount) Value() (driver.Value, error) {
+	return a.String(), nil
+}
+
+// Scan reads numeric, text, integer and float columns
+func (a *Amount) Scan(src interface{}) error {
+	switch v := src.(type) {
+	case nil:
+		*a = 0
+		return nil
+	case int64:
+		*a = New(v)
+		return nil
+	case float64:
+		*a = FromFloat(v)
+		return nil
+	case []byte:
+		parsed, err := Parse(string(v))
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	case string:
+		parsed, err := Parse(v)
+		if err != nil {
+			return err
+		}
+		*a = parsed
+		return nil
+	}
+	return fmt.Errorf("money: cannot scan %T into Amount", src)
+}
+
+// step is the internal value of one unit at precision decimal places
+func step(precision int) int64 {
+	if precision < 0 {
+		precision = 0
+	}
+	if precision > Scale {
+		precision = Scale
+	}
+	s := int64(1)
+	for i := precision; i < Scale; i++ {
+		s *= 10
+	}
+	return s
+}
+
+// divRound divides n by d and rounds the quotient with mode
+func divRound(n, d *big.Int, mode RoundingMode) *big.Int {
+	if d.Sign() < 0 {
+		n = new(big.Int).Neg(n)
+		d = new(big.Int).Neg(d)
+	}
+	q, r := new(big.Int).QuoRem(n, d, new(big.Int))
+	if r.Sign() == 0 {
+		return q
+	}
+
+	away := false
+	switch mode {
+	case RoundDown:
+	case RoundUp:
+		away = true
+	default:
+		// Compare twice the remainder with the divisor to find which neighbour is nearer
+		twice := new(big.Int).Abs(r)
+		twice.Lsh(twice, 1)
+		switch twice.Cmp(d) {
+		case 1:
+			away = true
+		case 0:
+			away = mode != RoundHalfEven || q.Bit(0) == 1
+		}
+	}
+	if away {
+		if n.Sign() < 0 {
+			q.Sub(q, big.NewInt(1))
+		} else {
+			q.Add(q, big.NewInt(1))
+		}
+	}
+	return q
+}
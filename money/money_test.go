@@ -0,0 +1,301 @@
+package money
+
+import (
+	"math/big"
+	"testing"
+)
+
+func TestParse(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: "15000", want: New(15000)},
+		{in: "-12.5", want: -125000},
+		{in: "1e3", want: New(1000)},
+		{in: " 7.25 ", want: 72500},
+		{in: "0.0001", want: 1},
+		{in: "0.00005", want: 1},   // half away from zero
+		{in: "0.000049", want: 0},  // below half
+		{in: "-0.00005", want: -1}, // half away from zero
+		{in: "-0.000049", want: 0},
+		{in: "", wantErr: true},
+		{in: "   ", wantErr: true},
+		{in: "abc", wantErr: true},
+		{in: "1.2.3", wantErr: true},
+		{in: "12,50", wantErr: true},
+		{in: "99999999999999999999", wantErr: true}, // beyond int64 at Scale
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			got, err := Parse(tt.in)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("Parse(%q) = %d, want an error", tt.in, got)
+				}
+				return
+			}
+			if err != nil {
+				t.Fatalf("Parse(%q): %v", tt.in, err)
+			}
+			if got != tt.want {
+				t.Fatalf("Parse(%q) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestUnmarshalJSON(t *testing.T) {
+	tests := []struct {
+		in      string
+		want    Amount
+		wantErr bool
+	}{
+		{in: `12.5`, want: 125000},
+		{in: `"12.5"`, want: 125000},
+		{in: `null`, want: 0},
+		{in: `"x"`, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.in, func(t *testing.T) {
+			var got Amount
+			err := got.UnmarshalJSON([]byte(tt.in))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("UnmarshalJSON(%s) error = %v, want error %v", tt.in, err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Fatalf("UnmarshalJSON(%s) = %d, want %d", tt.in, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDivRound(t *testing.T) {
+	tests := []struct {
+		name string
+		n, d int64
+		mode RoundingMode
+		want int64
+	}{
+		{"exact", 6, 2, RoundHalfEven, 3},
+		{"half even down to even", 5, 2, RoundHalfEven, 2},
+		{"half even up to even", 7, 2, RoundHalfEven, 4},
+		{"half even negative down to even", -5, 2, RoundHalfEven, -2},
+		{"half even negative up to even", -7, 2, RoundHalfEven, -4},
+		{"half even negative divisor", 5, -2, RoundHalfEven, -2},
+		{"half even above half", 8, 3, RoundHalfEven, 3},
+		{"half even below half", 7, 3, RoundHalfEven, 2},
+		{"half up tie", 5, 2, RoundHalfUp, 3},
+		{"half up negative tie", -5, 2, RoundHalfUp, -3},
+		{"half up below half", 7, 3, RoundHalfUp, 2},
+		{"down", 19, 10, RoundDown, 1},
+		{"down negative", -19, 10, RoundDown, -1},
+		{"up", 11, 10, RoundUp, 2},
+		{"up negative", -11, 10, RoundUp, -2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := divRound(big.NewInt(tt.n), big.NewInt(tt.d), tt.mode)
+			if got.Int64() != tt.want {
+				t.Fatalf("divRound(%d, %d, %s) = %s, want %d", tt.n, tt.d, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRound(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        Amount
+		precision int
+		mode      RoundingMode
+		want      Amount
+	}{
+		{"half up tie", 10050, 2, RoundHalfUp, 10100},
+		{"half up below tie", 10049, 2, RoundHalfUp, 10000},
+		{"half up negative tie", -10050, 2, RoundHalfUp, -10100},
+		{"half even tie to even below", 10050, 2, RoundHalfEven, 10000},
+		{"half even tie to even above", 10150, 2, RoundHalfEven, 10200},
+		{"half even above tie", 10051, 2, RoundHalfEven, 10100},
+		{"half even negative tie", -10050, 2, RoundHalfEven, -10000},
+		{"half even negative tie above", -10150, 2, RoundHalfEven, -10200},
+		{"down", 10190, 2, RoundDown, 10100},
+		{"down negative", -10190, 2, RoundDown, -10100},
+		{"up", 10110, 2, RoundUp, 10200},
+		{"up negative", -10110, 2, RoundUp, -10200},
+		{"whole units half even", 25000, 0, RoundHalfEven, 20000},
+		{"whole units half even odd", 35000, 0, RoundHalfEven, 40000},
+		{"whole units half even negative", -25000, 0, RoundHalfEven, -20000},
+		{"already rounded", 12300, 2, RoundUp, 12300},
+		{"precision beyond scale", 12345, 6, RoundHalfUp, 12345},
+		{"negative precision as whole units", 15000, -1, RoundHalfUp, 20000},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.Round(tt.precision, tt.mode); got != tt.want {
+				t.Fatalf("%d.Round(%d, %s) = %d, want %d", tt.in, tt.precision, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRoundToIncrement(t *testing.T) {
+	tests := []struct {
+		name      string
+		in        Amount
+		increment Amount
+		mode      RoundingMode
+		want      Amount
+	}{
+		{"half up tie", New(1250), New(500), RoundHalfUp, New(1500)},
+		{"half up below tie", New(1249), New(500), RoundHalfUp, New(1000)},
+		{"half up negative tie", New(-1250), New(500), RoundHalfUp, New(-1500)},
+		{"half even tie to even below", New(1250), New(500), RoundHalfEven, New(1000)},
+		{"half even tie to even above", New(1750), New(500), RoundHalfEven, New(2000)},
+		{"down", New(1499), New(500), RoundDown, New(1000)},
+		{"down negative", New(-1499), New(500), RoundDown, New(-1000)},
+		{"up", New(1001), New(500), RoundUp, New(1500)},
+		{"up negative", New(-1001), New(500), RoundUp, New(-1500)},
+		{"fractional increment", 12340, 500, RoundHalfUp, 12500},
+		{"multiple unchanged", New(1500), New(500), RoundUp, New(1500)},
+		{"zero increment unchanged", 12345, 0, RoundHalfUp, 12345},
+		{"negative increment unchanged", 12345, -100, RoundHalfUp, 12345},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.in.RoundToIncrement(tt.increment, tt.mode); got != tt.want {
+				t.Fatalf("%s.RoundToIncrement(%s, %s) = %s, want %s", tt.in, tt.increment, tt.mode, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPercent(t *testing.T) {
+	idr := DefaultCurrency
+	tests := []struct {
+		name string
+		in   Amount
+		rate float64
+		want Amount
+	}{
+		{"whole", New(100), 11, New(11)},
+		{"tie rounds up", 100500, 10, 10100},   // 1.005 -> 1.01
+		{"below minor unit", New(1), 0.125, 0}, // 0.00125 -> 0.00
+		{"fractional rate", New(1000), 12.3456, 1234600},
+		{"negative", New(-100), 11, New(-11)},
+		{"negative tie", -100500, 10, -10100},
+		{"zero rate", New(100), 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idr.Percent(tt.in, tt.rate); got != tt.want {
+				t.Fatalf("Percent(%s, %v) = %s, want %s", tt.in, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestExcludePercent(t *testing.T) {
+	idr := DefaultCurrency
+	tests := []struct {
+		name string
+		in   Amount
+		rate float64
+		want Amount
+	}{
+		{"exact", New(111), 11, New(100)},
+		{"rounded", New(100), 10, 909100}, // 90.9090... -> 90.91
+		{"negative", New(-100), 10, -909100},
+		{"zero rate", New(100), 0, New(100)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := idr.ExcludePercent(tt.in, tt.rate)
+			if got != tt.want {
+				t.Fatalf("ExcludePercent(%s, %v) = %s, want %s", tt.in, tt.rate, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestShare(t *testing.T) {
+	idr := DefaultCurrency
+	tests := []struct {
+		name            string
+		in, part, whole Amount
+		want            Amount
+	}{
+		{"third", New(100), New(1), New(3), 333300},
+		{"two thirds", New(100), New(2), New(3), 666700},
+		{"all", New(100), New(3), New(3), New(100)},
+		{"zero whole", New(100), New(1), 0, 0},
+		{"negative", New(-100), New(1), New(3), -333300},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := idr.Share(tt.in, tt.part, tt.whole); got != tt.want {
+				t.Fatalf("Share(%s, %s, %s) = %s, want %s", tt.in, tt.part, tt.whole, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestCurrencyPrecision(t *testing.T) {
+	tests := []struct {
+		name       string
+		currency   Currency
+		in         Amount
+		wantRound  Amount
+		wantCash   Amount
+		wantFormat string
+	}{
+		{"no minor unit", Currency{Code: "JPY", Precision: 0}, 12345000, 12350000, 12350000, "1235"},
+		{"two decimals", Currency{Code: "USD", Precision: 2}, 123450, 123500, 123500, "12.35"},
+		{"three decimals", Currency{Code: "KWD", Precision: 3}, 12345, 12350, 12350, "1.235"},
+		{"negative", Currency{Code: "USD", Precision: 2}, -123450, -123500, -123500, "-12.35"},
+		{"cash to 500", Currency{Code: "IDR", Precision: 2, CashIncrement: New(500), CashRounding: RoundHalfUp}, New(1250), New(1250), New(1500), "1250.00"},
+		{"cash down", Currency{Code: "IDR", Precision: 2, CashIncrement: New(100), CashRounding: RoundDown}, New(1299), New(1299), New(1200), "1299.00"},
+		{"cash with unknown mode rounds half up", Currency{Code: "IDR", Precision: 2, CashIncrement: New(100), CashRounding: "nearest"}, New(1250), New(1250), New(1300), "1250.00"},
+		{"cash to 0.05", Currency{Code: "CHF", Precision: 2, CashIncrement: 500, CashRounding: RoundHalfEven}, 10250, 10300, 10000, "1.03"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := tt.currency.Round(tt.in); got != tt.wantRound {
+				t.Errorf("Round(%s) = %s, want %s", tt.in, got, tt.wantRound)
+			}
+			if got := tt.currency.RoundCash(tt.in); got != tt.wantCash {
+				t.Errorf("RoundCash(%s) = %s, want %s", tt.in, got, tt.wantCash)
+			}
+			if got := tt.currency.Format(tt.in); got != tt.wantFormat {
+				t.Errorf("Format(%s) = %q, want %q", tt.in, got, tt.wantFormat)
+			}
+		})
+	}
+}
+
+func TestString(t *testing.T) {
+	tests := []struct {
+		in        Amount
+		want      string
+		precision int
+		wantFixed string
+	}{
+		{New(15000), "15000", 2, "15000.00"},
+		{125000, "12.5", 2, "12.50"},
+		{-5000, "-0.5", 2, "-0.50"},
+		{1, "0.0001", 2, "0.00"},
+		{-10050, "-1.005", 2, "-1.01"},
+		{0, "0", 0, "0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.want, func(t *testing.T) {
+			if got := tt.in.String(); got != tt.want {
+				t.Errorf("String() = %q, want %q", got, tt.want)
+			}
+			if got := tt.in.StringFixed(tt.precision); got != tt.wantFixed {
+				t.Errorf("StringFixed(%d) = %q, want %q", tt.precision, got, tt.wantFixed)
+			}
+		})
+	}
+}
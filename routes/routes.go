@@ -1,11 +1,13 @@
 package routes
 
 import (
+	"log"
 	"myposcore/config"
 	"myposcore/database"
 	"myposcore/handlers"
 	"myposcore/middleware"
 	"myposcore/services"
+	"time"
 
 	"github.com/gin-gonic/gin"
 )
@@ -15,15 +17,52 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 	auditTrailService := services.NewAuditTrailService(database.DB)
 
 	// Initialize services with audit trail dependency
-	orderService := services.NewOrderService(database.DB, auditTrailService)
-	paymentService := services.NewPaymentService(database.DB, auditTrailService)
+	promotionService := services.NewPromotionService(database.DB, auditTrailService)
+	taxService := services.NewTaxService(database.DB, auditTrailService)
+	orderNumberService := services.NewOrderNumberService(database.DB, auditTrailService)
+	approvalService := services.NewApprovalService(database.DB, services.NewPINService(), auditTrailService)
+	orderService := services.NewOrderService(database.DB, auditTrailService, promotionService, taxService, orderNumberService, approvalService)
+	paymentProviders := services.NewPaymentProviderRegistry()
+	// The mock provider settles payments on request, so it only exists when explicitly enabled
+	var mockPaymentProvider *services.MockPaymentProvider
+	if cfg.MockPaymentEnabled {
+		mockPaymentProvider = services.NewMockPaymentProvider(cfg.MockPaymentSecret)
+		if err := paymentProviders.Register(mockPaymentProvider); err != nil {
+			log.Fatal("Failed to register payment provider:", err)
+		}
+	}
+	if err := paymentProviders.AssignMethods(cfg.PaymentProviderMethods); err != nil {
+		log.Fatal("Invalid PAYMENT_PROVIDER_METHODS:", err)
+	}
+	paymentService := services.NewPaymentService(database.DB, auditTrailService, paymentProviders)
+	refundService := services.NewRefundService(database.DB, auditTrailService, paymentProviders, approvalService)
+	receiptService := services.NewReceiptService(database.DB, auditTrailService)
+	qrisService := services.NewQRISService(database.DB, auditTrailService)
+	currencyService := services.NewCurrencyService(database.DB, auditTrailService)
+	tipService := services.NewTipService(database.DB, auditTrailService)
+	kitchenService := services.NewKitchenService(database.DB, orderService)
+	kitchenService.StartEventPurger(time.Hour)
+	cashDrawerService := services.NewCashDrawerService(database.DB, auditTrailService, approvalService)
+	cashShiftService := services.NewCashShiftService(database.DB, auditTrailService)
+	idempotencyService := services.NewIdempotencyService(database.DB)
+	idempotencyService.StartPurger(time.Hour)
+	customerService := services.NewCustomerService(database.DB, auditTrailService)
+	loyaltyService := services.NewLoyaltyService(database.DB, auditTrailService)
+	loyaltyService.StartExpiryJob(time.Hour)
+	giftCardService := services.NewGiftCardService(database.DB, auditTrailService)
 	faqService := services.NewFAQService(database.DB, auditTrailService)
 	categoryService := services.NewCategoryService(database.DB, auditTrailService)
 	userService := services.NewUserService(auditTrailService)
 	productService := services.NewProductService(auditTrailService)
+	stockService := services.NewStockService(database.DB, auditTrailService)
+	stockTransferService := services.NewStockTransferService(database.DB, auditTrailService)
+	supplierService := services.NewSupplierService(database.DB, auditTrailService)
+	purchaseOrderService := services.NewPurchaseOrderService(database.DB, auditTrailService)
+	inventoryService := services.NewInventoryService(database.DB, auditTrailService)
+	inventoryService.StartAlertEvaluator(time.Minute)
 	configService := services.NewConfigService(database.DB)
 	branchService := services.NewSuperAdminBranchService()
-	syncService := services.NewSyncService(database.DB)
+	syncService := services.NewSyncService(database.DB, orderNumberService, taxService, approvalService)
 
 	// Initialize handlers
 	healthHandler := handlers.NewHealthHandler(cfg)
@@ -35,8 +74,28 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 	adminChangePINHandler := handlers.NewAdminChangePINHandler(cfg, auditTrailService)
 	pinHandler := handlers.NewPINHandler(cfg, auditTrailService)
 	productHandler := handlers.NewProductHandler(cfg, productService)
+	stockHandler := handlers.NewStockHandler(cfg, stockService)
+	stockTransferHandler := handlers.NewStockTransferHandler(cfg, stockTransferService)
+	supplierHandler := handlers.NewSupplierHandler(cfg, supplierService)
+	purchaseOrderHandler := handlers.NewPurchaseOrderHandler(cfg, purchaseOrderService)
+	inventoryHandler := handlers.NewInventoryHandler(cfg, inventoryService)
 	orderHandler := handlers.NewOrderHandler(cfg, orderService)
 	paymentHandler := handlers.NewPaymentHandler(cfg, paymentService)
+	paymentProviderHandler := handlers.NewPaymentProviderHandler(cfg, paymentService, mockPaymentProvider)
+	refundHandler := handlers.NewRefundHandler(cfg, refundService)
+	promotionHandler := handlers.NewPromotionHandler(cfg, promotionService)
+	taxRuleHandler := handlers.NewTaxRuleHandler(cfg, taxService)
+	orderNumberHandler := handlers.NewOrderNumberHandler(cfg, orderNumberService)
+	receiptHandler := handlers.NewReceiptHandler(cfg, receiptService)
+	qrisHandler := handlers.NewQRISHandler(cfg, qrisService)
+	currencyHandler := handlers.NewCurrencyHandler(cfg, currencyService)
+	tipHandler := handlers.NewTipHandler(cfg, tipService)
+	kitchenHandler := handlers.NewKitchenHandler(cfg, kitchenService, orderService)
+	cashDrawerHandler := handlers.NewCashDrawerHandler(cfg, cashDrawerService)
+	cashShiftHandler := handlers.NewCashShiftHandler(cfg, cashShiftService)
+	customerHandler := handlers.NewCustomerHandler(cfg, customerService)
+	loyaltyHandler := handlers.NewLoyaltyHandler(cfg, loyaltyService, customerService)
+	giftCardHandler := handlers.NewGiftCardHandler(cfg, giftCardService)
 	tncHandler := handlers.NewTnCHandler(configService)
 	faqHandler := handlers.NewFAQHandler(faqService)
 	categoryHandler := handlers.NewCategoryHandler(categoryService)
@@ -48,6 +107,9 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 	tenantHandler := handlers.NewTenantHandler(cfg)
 	syncHandler := handlers.NewSyncHandler(syncService)
 
+	// Retried writes with the same Idempotency-Key header get the original response
+	idempotent := middleware.IdempotencyMiddleware(idempotencyService)
+
 	// Health check
 	router.GET("/health", healthHandler.Handle)
 
@@ -73,6 +135,9 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 			// Config routes (public)
 			public.POST("/config/set", configHandler.SetConfig)
 			public.GET("/config/get/:key", configHandler.GetConfig)
+
+			// Payment provider callbacks (public, verified by the provider's signature)
+			public.POST("/payments/webhooks/:provider", paymentProviderHandler.HandleWebhook)
 		}
 
 		// Protected routes
@@ -126,18 +191,139 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 			protected.DELETE("/products/:id", productHandler.DeleteProduct)
 			protected.POST("/products/:id/photo", productHandler.UploadProductImage)
 			protected.DELETE("/products/:id/photo", productHandler.DeleteProductImage)
+			protected.GET("/products/:id/stock-movements", stockHandler.GetStockMovements)
+			protected.POST("/products/:id/stock-adjustments", stockHandler.AdjustStock)
+			protected.PUT("/products/:id/reorder-point", inventoryHandler.SetReorderPoint)
+
+			// Inventory routes
+			protected.GET("/inventory/alerts", inventoryHandler.ListStockAlerts)
+			protected.GET("/inventory/reorder-suggestions", inventoryHandler.GetReorderSuggestions)
+
+			// Stock transfer routes
+			protected.POST("/stock-transfers", stockTransferHandler.CreateStockTransfer)
+			protected.GET("/stock-transfers", stockTransferHandler.ListStockTransfers)
+			protected.GET("/stock-transfers/in-transit", stockTransferHandler.GetInTransitStock)
+			protected.GET("/stock-transfers/:id", stockTransferHandler.GetStockTransfer)
+			protected.POST("/stock-transfers/:id/send", stockTransferHandler.SendStockTransfer)
+			protected.POST("/stock-transfers/:id/receive", stockTransferHandler.ReceiveStockTransfer)
+			protected.POST("/stock-transfers/:id/close", stockTransferHandler.CloseStockTransfer)
+			protected.POST("/stock-transfers/:id/cancel", stockTransferHandler.CancelStockTransfer)
+
+			// Supplier routes
+			protected.GET("/suppliers", supplierHandler.ListSuppliers)
+			protected.GET("/suppliers/:id", supplierHandler.GetSupplier)
+			protected.POST("/suppliers", supplierHandler.CreateSupplier)
+			protected.PUT("/suppliers/:id", supplierHandler.UpdateSupplier)
+			protected.DELETE("/suppliers/:id", supplierHandler.DeleteSupplier)
+
+			// Purchase order routes
+			protected.POST("/purchase-orders", purchaseOrderHandler.CreatePurchaseOrder)
+			protected.GET("/purchase-orders", purchaseOrderHandler.ListPurchaseOrders)
+			protected.GET("/purchase-orders/:id", purchaseOrderHandler.GetPurchaseOrder)
+			protected.POST("/purchase-orders/:id/order", purchaseOrderHandler.PlacePurchaseOrder)
+			protected.POST("/purchase-orders/:id/receipts", purchaseOrderHandler.ReceiveGoods)
+			protected.POST("/purchase-orders/:id/close", purchaseOrderHandler.ClosePurchaseOrder)
+			protected.POST("/purchase-orders/:id/cancel", purchaseOrderHandler.CancelPurchaseOrder)
 
 			// Order routes
-			protected.POST("/orders", orderHandler.CreateOrder)
+			protected.POST("/orders", idempotent, orderHandler.CreateOrder)
 			protected.GET("/orders", orderHandler.ListOrders)
 			protected.GET("/orders/:id", orderHandler.GetOrder)
 			protected.GET("/orders/:id/payments", paymentHandler.GetPaymentsByOrder)
+			protected.PUT("/orders/:id/status", orderHandler.UpdateOrderStatus)
+			protected.GET("/orders/:id/status-history", orderHandler.GetOrderStatusHistory)
+			protected.POST("/orders/:id/items", idempotent, orderHandler.AddOrderItem)
+			protected.PUT("/orders/:id/items/:item_id", orderHandler.UpdateOrderItem)
+			protected.DELETE("/orders/:id/items/:item_id", orderHandler.RemoveOrderItem)
+			protected.PUT("/orders/:id/discount", orderHandler.ApplyManualDiscount)
+			protected.GET("/orders/:id/receipt", receiptHandler.GetOrderReceipt)
+			protected.GET("/orders/:id/qris", qrisHandler.GetOrderQRIS)
+
+			// Kitchen display routes
+			protected.GET("/kitchen/stream", kitchenHandler.StreamKitchenEvents)
+			protected.GET("/kitchen/orders", kitchenHandler.GetKitchenOrders)
+			protected.POST("/kitchen/orders/:id/bump", kitchenHandler.BumpKitchenOrder)
+			protected.POST("/kitchen/orders/:id/items/:item_id/bump", kitchenHandler.BumpKitchenOrderItem)
+
+			// Cash drawer routes
+			protected.POST("/cash-drawer/open", cashDrawerHandler.OpenCashDrawer)
+
+			// Cash shift routes
+			protected.POST("/cash-shifts", cashShiftHandler.OpenCashShift)
+			protected.GET("/cash-shifts", cashShiftHandler.ListCashShifts)
+			protected.GET("/cash-shifts/current", cashShiftHandler.GetCurrentCashShift)
+			protected.GET("/cash-shifts/:id", cashShiftHandler.GetCashShift)
+			protected.GET("/cash-shifts/:id/report", cashShiftHandler.GetCashShiftReport)
+			protected.POST("/cash-shifts/:id/movements", cashShiftHandler.CreateCashMovement)
+			protected.POST("/cash-shifts/:id/close", cashShiftHandler.CloseCashShift)
+
+			// Receipt template routes
+			protected.GET("/receipt-template", receiptHandler.GetReceiptTemplate)
+			protected.PUT("/receipt-template", receiptHandler.SaveReceiptTemplate)
+
+			// Currency routes
+			protected.GET("/currency", currencyHandler.GetCurrencySettings)
+			protected.PUT("/currency", currencyHandler.SaveCurrencySettings)
+
+			// Tip routes
+			protected.GET("/tips/presets", tipHandler.GetTipPresets)
+			protected.PUT("/tips/presets", tipHandler.SaveTipPresets)
+			protected.GET("/tips/report", tipHandler.GetTipReport)
+
+			// QRIS routes
+			protected.GET("/qris/static", qrisHandler.GetStaticQRIS)
+			protected.POST("/qris/parse", qrisHandler.ParseQRIS)
+			protected.GET("/qris/merchant", qrisHandler.GetQRISMerchant)
+			protected.PUT("/qris/merchant", qrisHandler.SaveQRISMerchant)
+
+			// Customer routes
+			protected.GET("/customers", customerHandler.ListCustomers)
+			protected.GET("/customers/:id", customerHandler.GetCustomer)
+			protected.POST("/customers", customerHandler.CreateCustomer)
+			protected.PUT("/customers/:id", customerHandler.UpdateCustomer)
+			protected.DELETE("/customers/:id", customerHandler.DeleteCustomer)
+			protected.GET("/customers/:id/loyalty", loyaltyHandler.GetCustomerLoyalty)
+
+			// Loyalty routes
+			protected.GET("/loyalty/program", loyaltyHandler.GetLoyaltyProgram)
+			protected.PUT("/loyalty/program", loyaltyHandler.SaveLoyaltyProgram)
+			protected.GET("/loyalty/members", loyaltyHandler.LookupLoyaltyMember)
+
+			// Gift card routes
+			protected.GET("/gift-cards", giftCardHandler.ListGiftCards)
+			protected.GET("/gift-cards/balance", giftCardHandler.GetGiftCardBalance)
+			protected.GET("/gift-cards/:id", giftCardHandler.GetGiftCard)
+
+			// Promotion routes
+			protected.GET("/promotions", promotionHandler.ListPromotions)
+			protected.GET("/promotions/:id", promotionHandler.GetPromotion)
+			protected.POST("/promotions", promotionHandler.CreatePromotion)
+			protected.PUT("/promotions/:id", promotionHandler.UpdatePromotion)
+			protected.DELETE("/promotions/:id", promotionHandler.DeletePromotion)
+
+			// Order numbering routes
+			protected.GET("/order-numbers/format", orderNumberHandler.GetOrderNumberFormat)
+			protected.PUT("/order-numbers/format", orderNumberHandler.SaveOrderNumberFormat)
+			protected.POST("/order-numbers/reservations", orderNumberHandler.ReserveOrderNumbers)
+
+			// Tax & service charge routes
+			protected.GET("/tax-rules", taxRuleHandler.ListTaxRules)
+			protected.GET("/tax-rules/:id", taxRuleHandler.GetTaxRule)
+			protected.POST("/tax-rules", taxRuleHandler.CreateTaxRule)
+			protected.PUT("/tax-rules/:id", taxRuleHandler.UpdateTaxRule)
+			protected.DELETE("/tax-rules/:id", taxRuleHandler.DeleteTaxRule)
 
 			// Payment routes
-			protected.POST("/payments", paymentHandler.CreatePayment)
+			protected.POST("/payments", idempotent, paymentHandler.CreatePayment)
 			protected.GET("/payments", paymentHandler.ListPayments)
 			protected.GET("/payments/:id", paymentHandler.GetPayment)
 			protected.GET("/payments/performance", paymentHandler.GetPaymentPerformance)
+			protected.POST("/payments/:id/refresh-status", paymentHandler.RefreshPaymentStatus)
+			if mockPaymentProvider != nil {
+				protected.POST("/payments/providers/mock/simulate", paymentProviderHandler.SimulateMockPayment)
+			}
+			protected.POST("/payments/:id/refunds", idempotent, refundHandler.CreateRefund)
+			protected.GET("/payments/:id/refunds", refundHandler.GetRefundsByPayment)
 
 			// User routes
 			protected.GET("/users", userHandler.ListUsers)
@@ -170,12 +356,12 @@ func SetupRoutes(router *gin.Engine, cfg *config.Config) {
 			// Sync routes (offline mode support)
 			sync := protected.Group("/sync")
 			{
-				sync.POST("/upload", syncHandler.UploadFromClient)           // Upload data dari mobile
-				sync.POST("/download", syncHandler.DownloadToClient)         // Download master data
-				sync.GET("/status", syncHandler.GetSyncStatus)               // Check sync status
-				sync.GET("/logs", syncHandler.GetSyncLogs)                   // Get sync history
-				sync.POST("/conflicts/resolve", syncHandler.ResolveConflict) // Manual resolve conflict
-				sync.GET("/time", syncHandler.GetServerTime)                 // Get server time
+				sync.POST("/upload", idempotent, syncHandler.UploadFromClient) // Upload data dari mobile
+				sync.POST("/download", syncHandler.DownloadToClient)           // Download master data
+				sync.GET("/status", syncHandler.GetSyncStatus)                 // Check sync status
+				sync.GET("/logs", syncHandler.GetSyncLogs)                     // Get sync history
+				sync.POST("/conflicts/resolve", syncHandler.ResolveConflict)   // Manual resolve conflict
+				sync.GET("/time", syncHandler.GetServerTime)                   // Get server time
 			}
 		}
 	}
@@ -82,25 +82,33 @@ func (s *AdminChangePINService) AdminChangePIN(adminID uint, req dto.AdminChange
 	return nil
 }
 
-// validateRoleHierarchy checks if admin role has permission to change target user's PIN
-func (s *AdminChangePINService) validateRoleHierarchy(adminRole, targetRole string) error {
-	// Role hierarchy: superadmin > owner > admin > staff
-	roleHierarchy := map[string]int{
-		"superadmin": 4,
-		"owner":      3,
-		"admin":      2,
-		"staff":      1,
-	}
+// roleLevels ranks the user roles: superadmin > owner > admin > staff
+var roleLevels = map[string]int{
+	"superadmin": 4,
+	"owner":      3,
+	"admin":      2,
+	"staff":      1,
+}
 
-	adminLevel, adminExists := roleHierarchy[adminRole]
-	targetLevel, targetExists := roleHierarchy[targetRole]
+// roleOutranks reports whether role is strictly higher than other in the role hierarchy
+func roleOutranks(role, other string) (bool, error) {
+	level, exists := roleLevels[role]
+	otherLevel, otherExists := roleLevels[other]
+	if !exists || !otherExists {
+		return false, errors.New("invalid role")
+	}
+	return level > otherLevel, nil
+}
 
-	if !adminExists || !targetExists {
-		return errors.New("invalid role")
+// validateRoleHierarchy checks if admin role has permission to change target user's PIN
+func (s *AdminChangePINService) validateRoleHierarchy(adminRole, targetRole string) error {
+	outranks, err := roleOutranks(adminRole, targetRole)
+	if err != nil {
+		return err
 	}
 
-	// Admin must have higher or equal role level
-	if adminLevel <= targetLevel {
+	// Admin must have a higher role level
+	if !outranks {
 		return errors.New("insufficient permission: can only change PIN for lower role users")
 	}
 
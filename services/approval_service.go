@@ -0,0 +1,130 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Actions that need a supervisor's approval
+const (
+	ApprovalVoidItem        = "void_item"
+	ApprovalCancelPaidOrder = "cancel_paid_order"
+	ApprovalManualDiscount  = "manual_discount"
+	ApprovalOpenCashDrawer  = "open_cash_drawer"
+	ApprovalRefund          = "refund"
+)
+
+var (
+	// ErrApprovalRequired is returned when an action needs a supervisor approval that was not given
+	ErrApprovalRequired = errors.New("supervisor approval required")
+	// ErrApprovalDenied is returned when the given approval is not valid
+	ErrApprovalDenied = errors.New("supervisor approval denied")
+)
+
+// selfApprovingRoles may approve their own actions, as nobody in the tenant outranks them
+var selfApprovingRoles = map[string]bool{
+	"superadmin": true,
+	"owner":      true,
+}
+
+type ApprovalService struct {
+	db                *gorm.DB
+	pinService        *PINService
+	auditTrailService *AuditTrailService
+}
+
+func NewApprovalService(db *gorm.DB, pinService *PINService, auditTrailService *AuditTrailService) *ApprovalService {
+	return &ApprovalService{
+		db:                db,
+		pinService:        pinService,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// ApprovedAction is a verified supervisor approval, ready to be recorded once the action succeeded
+type ApprovedAction struct {
+	Action     string
+	Requester  models.User
+	Approver   models.User
+	ApprovedAt time.Time
+}
+
+// Verify checks the approver's PIN and that the approver outranks the requesting user
+// (superadmin > owner > admin > staff). Owners and superadmins may approve their own actions.
+func (s *ApprovalService) Verify(tenantID uint, requestedBy *uint, action string, approval *dto.SupervisorApproval) (*ApprovedAction, error) {
+	if approval == nil || approval.ApproverID == 0 || approval.PIN == "" {
+		return nil, fmt.Errorf("%w for %s", ErrApprovalRequired, action)
+	}
+	if requestedBy == nil {
+		return nil, fmt.Errorf("%w: requesting user unknown", ErrApprovalDenied)
+	}
+
+	var requester models.User
+	if err := s.db.Where("id = ?", *requestedBy).First(&requester).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: requesting user not found", ErrApprovalDenied)
+		}
+		return nil, err
+	}
+
+	var approver models.User
+	if err := s.db.Where("id = ? AND is_active = ?", approval.ApproverID, true).First(&approver).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%w: approver not found", ErrApprovalDenied)
+		}
+		return nil, err
+	}
+	if approver.Role != "superadmin" && approver.TenantID != tenantID {
+		return nil, fmt.Errorf("%w: approver not found", ErrApprovalDenied)
+	}
+
+	if approver.ID == requester.ID {
+		if !selfApprovingRoles[approver.Role] {
+			return nil, fmt.Errorf("%w: another user must approve", ErrApprovalDenied)
+		}
+	} else {
+		outranks, err := roleOutranks(approver.Role, requester.Role)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s", ErrApprovalDenied, err.Error())
+		}
+		if !outranks {
+			return nil, fmt.Errorf("%w: approver must have a higher role than %s", ErrApprovalDenied, requester.Role)
+		}
+	}
+
+	if err := s.pinService.VerifyPIN(approver.ID, approval.PIN); err != nil {
+		return nil, fmt.Errorf("%w: %s", ErrApprovalDenied, err.Error())
+	}
+
+	return &ApprovedAction{
+		Action:     action,
+		Requester:  requester,
+		Approver:   approver,
+		ApprovedAt: time.Now(),
+	}, nil
+}
+
+// Record writes the dedicated audit entry of an approved action, naming both the requester and the approver
+func (s *ApprovalService) Record(tenantID uint, branchID *uint, approved *ApprovedAction, entityType string, entityID uint, details map[string]interface{}) {
+	changes := map[string]interface{}{
+		"entity_type": entityType,
+		"entity_id":   entityID,
+		"requested_by": map[string]interface{}{
+			"id":   approved.Requester.ID,
+			"name": approved.Requester.FullName,
+			"role": approved.Requester.Role,
+		},
+		"approved_by": map[string]interface{}{
+			"id":   approved.Approver.ID,
+			"name": approved.Approver.FullName,
+			"role": approved.Approver.Role,
+		},
+		"details": details,
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, branchID, approved.Requester.ID, "supervisor_approval", entityID, approved.Action, changes, "", "")
+}
@@ -0,0 +1,36 @@
+package services
+
+import (
+	"myposcore/dto"
+
+	"gorm.io/gorm"
+)
+
+type CashDrawerService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+	approvalService   *ApprovalService
+}
+
+func NewCashDrawerService(db *gorm.DB, auditTrailService *AuditTrailService, approvalService *ApprovalService) *CashDrawerService {
+	return &CashDrawerService{
+		db:                db,
+		auditTrailService: auditTrailService,
+		approvalService:   approvalService,
+	}
+}
+
+// OpenDrawer authorises opening the cash drawer of a branch without a sale. The terminal only
+// kicks the drawer once this succeeded.
+func (s *CashDrawerService) OpenDrawer(tenantID, branchID uint, req dto.OpenCashDrawerRequest, requestedBy *uint) (*ApprovedAction, error) {
+	approved, err := s.approvalService.Verify(tenantID, requestedBy, ApprovalOpenCashDrawer, req.Approval)
+	if err != nil {
+		return nil, err
+	}
+
+	s.approvalService.Record(tenantID, &branchID, approved, "cash_drawer", branchID, map[string]interface{}{
+		"reason": req.Reason,
+	})
+
+	return approved, nil
+}
@@ -0,0 +1,412 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type CashShiftService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewCashShiftService(db *gorm.DB, auditTrailService *AuditTrailService) *CashShiftService {
+	return &CashShiftService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// ErrNoOpenCashShift is returned when cash is taken in or paid out without an open shift
+var ErrNoOpenCashShift = errors.New("no open cash shift: open a shift before handling cash")
+
+// CashShiftReport is the X (open shift) or Z (closed shift) summary of a shift
+type CashShiftReport struct {
+	ReportType    string
+	Shift         models.CashShift
+	OrderCount    int
+	Payments      []dto.CashShiftMethodTotal
+	TotalPayments money.Amount
+	Refunds       []dto.CashShiftMethodTotal
+	TotalRefunds  money.Amount
+	Movements     []models.CashMovement
+	CashSales     money.Amount
+	CashRefunds   money.Amount
+	CashTips      money.Amount
+	TotalTips     money.Amount
+	Tips          []dto.TipStaffTotal
+	PayIns        money.Amount
+	PayOuts       money.Amount
+	ExpectedCash  money.Amount
+}
+
+// openCashShift returns the shift a cashier has open on a branch, or nil. The shift is share
+// locked so it cannot be closed while the caller's transaction adds to it.
+func openCashShift(tx *gorm.DB, tenantID, branchID uint, userID *uint) (*models.CashShift, error) {
+	if userID == nil {
+		return nil, nil
+	}
+	var shift models.CashShift
+	err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+		Where("tenant_id = ? AND branch_id = ? AND user_id = ? AND status = ?", tenantID, branchID, *userID, "open").
+		First(&shift).Error
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// OpenShift starts a cashier's shift on a branch with the cash put in the drawer as float
+func (s *CashShiftService) OpenShift(tenantID, branchID, userID uint, req dto.OpenCashShiftRequest) (*models.CashShift, error) {
+	var existing int64
+	if err := s.db.Model(&models.CashShift{}).
+		Where("tenant_id = ? AND branch_id = ? AND user_id = ? AND status = ?", tenantID, branchID, userID, "open").
+		Count(&existing).Error; err != nil {
+		return nil, err
+	}
+	if existing > 0 {
+		return nil, errors.New("cash shift already open")
+	}
+	cur, err := tenantCurrency(s.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	shift := &models.CashShift{
+		TenantID:      tenantID,
+		BranchID:      branchID,
+		UserID:        userID,
+		Status:        "open",
+		OpeningFloat:  cur.Round(req.OpeningFloat),
+		OpeningNotes:  req.Notes,
+		OpenedAt:      time.Now(),
+		Denominations: "[]",
+	}
+	if err := s.db.Create(shift).Error; err != nil {
+		return nil, err
+	}
+
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, &branchID, userID, "cash_shift", shift.ID, "open", map[string]interface{}{
+		"opening_float": shift.OpeningFloat,
+		"notes":         shift.OpeningNotes,
+	}, "", "")
+
+	return s.GetShift(shift.ID, tenantID)
+}
+
+// GetShift gets a shift of the tenant
+func (s *CashShiftService) GetShift(shiftID, tenantID uint) (*models.CashShift, error) {
+	var shift models.CashShift
+	if err := s.db.Preload("User").Preload("Closer").
+		Where("id = ? AND tenant_id = ?", shiftID, tenantID).First(&shift).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("cash shift not found")
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// GetCurrentShift gets the shift the user has open on a branch
+func (s *CashShiftService) GetCurrentShift(tenantID, branchID, userID uint) (*models.CashShift, error) {
+	var shift models.CashShift
+	if err := s.db.Preload("User").
+		Where("tenant_id = ? AND branch_id = ? AND user_id = ? AND status = ?", tenantID, branchID, userID, "open").
+		First(&shift).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("no open cash shift")
+		}
+		return nil, err
+	}
+	return &shift, nil
+}
+
+// ListShifts lists the tenant's shifts, newest first. Zero branchID/userID and an empty status match all.
+func (s *CashShiftService) ListShifts(tenantID, branchID, userID uint, status string, page, pageSize int) ([]models.CashShift, int64, error) {
+	var shifts []models.CashShift
+	var total int64
+
+	query := s.db.Model(&models.CashShift{}).Where("tenant_id = ?", tenantID)
+	if branchID != 0 {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if userID != 0 {
+		query = query.Where("user_id = ?", userID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("User").Preload("Closer").Order("opened_at DESC").
+		Offset(offset).Limit(pageSize).Find(&shifts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return shifts, total, nil
+}
+
+// AddMovement records a pay-in or pay-out on an open shift
+func (s *CashShiftService) AddMovement(shiftID, tenantID uint, req dto.CreateCashMovementRequest, createdBy *uint) (*models.CashMovement, error) {
+	var movement *models.CashMovement
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var shift models.CashShift
+		if err := tx.Clauses(clause.Locking{Strength: "SHARE"}).
+			Where("id = ? AND tenant_id = ?", shiftID, tenantID).First(&shift).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("cash shift not found")
+			}
+			return err
+		}
+		if shift.Status != "open" {
+			return errors.New("cash shift is closed")
+		}
+		cur, err := tenantCurrency(tx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		movement = &models.CashMovement{
+			TenantID:  tenantID,
+			BranchID:  shift.BranchID,
+			ShiftID:   shift.ID,
+			Type:      req.Type,
+			Amount:    cur.Round(req.Amount),
+			Reason:    req.Reason,
+			CreatedBy: createdBy,
+		}
+		return tx.Create(movement).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var userID uint
+	if createdBy != nil {
+		userID = *createdBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, &movement.BranchID, userID, "cash_movement", movement.ID, "create", map[string]interface{}{
+		"shift_id": movement.ShiftID,
+		"type":     movement.Type,
+		"amount":   movement.Amount,
+		"reason":   movement.Reason,
+	}, "", "")
+
+	if err := s.db.Preload("Creator").First(movement, movement.ID).Error; err != nil {
+		return nil, err
+	}
+	return movement, nil
+}
+
+// CloseShift counts the drawer out: the counted denominations are compared with the cash the drawer
+// should hold and the variance is kept with the shift, which gets the branch's next Z number
+func (s *CashShiftService) CloseShift(shiftID, tenantID uint, req dto.CloseCashShiftRequest, closedBy *uint) (*models.CashShift, error) {
+	var counted money.Amount
+	for _, denomination := range req.Denominations {
+		counted += denomination.Value.Mul(denomination.Count)
+	}
+	denominationsJSON, err := json.Marshal(req.Denominations)
+	if err != nil {
+		return nil, err
+	}
+
+	var shift models.CashShift
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", shiftID, tenantID).First(&shift).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("cash shift not found")
+			}
+			return err
+		}
+		if shift.Status != "open" {
+			return errors.New("cash shift already closed")
+		}
+
+		report, err := buildCashShiftReport(tx, &shift)
+		if err != nil {
+			return err
+		}
+
+		// Z numbers run per branch; lock the branch so two closes cannot take the same number
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&models.Branch{}, shift.BranchID).Error; err != nil {
+			return err
+		}
+		var lastZ int
+		if err := tx.Model(&models.CashShift{}).Select("COALESCE(MAX(z_number), 0)").
+			Where("branch_id = ?", shift.BranchID).Scan(&lastZ).Error; err != nil {
+			return err
+		}
+
+		now := time.Now()
+		shift.Status = "closed"
+		shift.ClosedAt = &now
+		shift.ClosedBy = closedBy
+		shift.ClosingNotes = req.Notes
+		shift.Denominations = string(denominationsJSON)
+		shift.CashSales = report.CashSales
+		shift.CashRefunds = report.CashRefunds
+		shift.CashTips = report.CashTips
+		shift.PayIns = report.PayIns
+		shift.PayOuts = report.PayOuts
+		shift.ExpectedCash = report.ExpectedCash
+		shift.CountedCash = counted
+		shift.Variance = counted - report.ExpectedCash
+		shift.ZNumber = lastZ + 1
+		return tx.Save(&shift).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var userID uint
+	if closedBy != nil {
+		userID = *closedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, &shift.BranchID, userID, "cash_shift", shift.ID, "close", map[string]interface{}{
+		"expected_cash": shift.ExpectedCash,
+		"counted_cash":  shift.CountedCash,
+		"variance":      shift.Variance,
+		"z_number":      shift.ZNumber,
+		"notes":         shift.ClosingNotes,
+	}, "", "")
+
+	return s.GetShift(shift.ID, tenantID)
+}
+
+// GetReport builds the X report of an open shift or the Z report of a closed one
+func (s *CashShiftService) GetReport(shiftID, tenantID uint) (*CashShiftReport, error) {
+	shift, err := s.GetShift(shiftID, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return buildCashShiftReport(s.db, shift)
+}
+
+// buildCashShiftReport totals the payments, tips, refunds and pay-ins/outs of a shift. Closed shifts
+// report the cash figures frozen at close.
+func buildCashShiftReport(tx *gorm.DB, shift *models.CashShift) (*CashShiftReport, error) {
+	report := &CashShiftReport{ReportType: "X", Shift: *shift}
+	if shift.Status == "closed" {
+		report.ReportType = "Z"
+	}
+
+	type methodTotal struct {
+		Method string
+		Count  int
+		Amount money.Amount
+	}
+
+	var payments []methodTotal
+	if err := tx.Model(&models.Payment{}).
+		Select("payment_method AS method, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("shift_id = ? AND status IN ?", shift.ID, paidPaymentStatuses).
+		Group("payment_method").Order("payment_method").Scan(&payments).Error; err != nil {
+		return nil, err
+	}
+	report.Payments = make([]dto.CashShiftMethodTotal, len(payments))
+	for i, p := range payments {
+		report.Payments[i] = dto.CashShiftMethodTotal{Method: p.Method, Count: p.Count, Amount: p.Amount}
+		report.TotalPayments += p.Amount
+		if p.Method == "cash" {
+			report.CashSales = p.Amount
+		}
+	}
+
+	var orderCount int64
+	if err := tx.Model(&models.Payment{}).Distinct("order_id").
+		Where("shift_id = ? AND status IN ?", shift.ID, paidPaymentStatuses).
+		Count(&orderCount).Error; err != nil {
+		return nil, err
+	}
+	report.OrderCount = int(orderCount)
+
+	var refunds []methodTotal
+	if err := tx.Model(&models.Refund{}).
+		Select("refund_method AS method, COUNT(*) AS count, COALESCE(SUM(amount), 0) AS amount").
+		Where("shift_id = ?", shift.ID).
+		Group("refund_method").Order("refund_method").Scan(&refunds).Error; err != nil {
+		return nil, err
+	}
+	report.Refunds = make([]dto.CashShiftMethodTotal, len(refunds))
+	for i, r := range refunds {
+		report.Refunds[i] = dto.CashShiftMethodTotal{Method: r.Method, Count: r.Count, Amount: r.Amount}
+		report.TotalRefunds += r.Amount
+		if r.Method == "cash" {
+			report.CashRefunds = r.Amount
+		}
+	}
+
+	tips, err := summarizeTips(tx.Model(&models.Payment{}).Where("payments.shift_id = ?", shift.ID))
+	if err != nil {
+		return nil, err
+	}
+	report.Tips = make([]dto.TipStaffTotal, len(tips))
+	for i, t := range tips {
+		t.Shifts = nil
+		report.Tips[i] = t
+		report.TotalTips += t.TotalTips
+		report.CashTips += t.CashTips
+	}
+
+	if err := tx.Preload("Creator").Where("shift_id = ?", shift.ID).
+		Order("created_at ASC").Find(&report.Movements).Error; err != nil {
+		return nil, err
+	}
+	for _, movement := range report.Movements {
+		switch movement.Type {
+		case "pay_in":
+			report.PayIns += movement.Amount
+		case "pay_out":
+			report.PayOuts += movement.Amount
+		}
+	}
+
+	if shift.Status == "closed" {
+		report.CashSales = shift.CashSales
+		report.CashRefunds = shift.CashRefunds
+		report.CashTips = shift.CashTips
+		report.PayIns = shift.PayIns
+		report.PayOuts = shift.PayOuts
+		report.ExpectedCash = shift.ExpectedCash
+		return report, nil
+	}
+
+	report.ExpectedCash = shift.OpeningFloat + report.CashSales + report.CashTips - report.CashRefunds + report.PayIns - report.PayOuts
+	return report, nil
+}
+
+// DecodeCashDenominations returns the counted denominations stored on a shift
+func DecodeCashDenominations(shift *models.CashShift) []dto.CashDenominationCount {
+	denominations := []dto.CashDenominationCount{}
+	if shift.Denominations != "" {
+		_ = json.Unmarshal([]byte(shift.Denominations), &denominations)
+	}
+	return denominations
+}
+
+// requireCashShift returns the open shift cash must be taken in or paid out of
+func requireCashShift(tx *gorm.DB, tenantID, branchID uint, userID *uint) (*models.CashShift, error) {
+	shift, err := openCashShift(tx, tenantID, branchID, userID)
+	if err != nil {
+		return nil, err
+	}
+	if shift == nil {
+		return nil, ErrNoOpenCashShift
+	}
+	return shift, nil
+}
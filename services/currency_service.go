@@ -0,0 +1,105 @@
+package services
+
+import (
+	"errors"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type CurrencyService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewCurrencyService(db *gorm.DB, auditTrailService *AuditTrailService) *CurrencyService {
+	return &CurrencyService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// tenantCurrency returns the currency the tenant's amounts are rounded in
+func tenantCurrency(tx *gorm.DB, tenantID uint) (money.Currency, error) {
+	var tenant models.Tenant
+	if err := tx.Select("id, currency_code, currency_precision, cash_rounding_increment, cash_rounding_mode").
+		First(&tenant, tenantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return money.Currency{}, errors.New("tenant not found")
+		}
+		return money.Currency{}, err
+	}
+	return currencyOf(&tenant), nil
+}
+
+// currencyOf builds the rounding rules from the tenant's settings, falling back to the default
+// currency for tenants created before they existed
+func currencyOf(tenant *models.Tenant) money.Currency {
+	if tenant.CurrencyCode == "" {
+		return money.DefaultCurrency
+	}
+	return money.Currency{
+		Code:          tenant.CurrencyCode,
+		Precision:     tenant.CurrencyPrecision,
+		CashIncrement: tenant.CashRoundingIncrement,
+		CashRounding:  money.RoundingMode(tenant.CashRoundingMode),
+	}
+}
+
+// GetSettings returns the tenant's currency and cash rounding settings
+func (s *CurrencyService) GetSettings(tenantID uint) (*models.Tenant, error) {
+	var tenant models.Tenant
+	if err := s.db.First(&tenant, tenantID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tenant not found")
+		}
+		return nil, err
+	}
+	return &tenant, nil
+}
+
+// SaveSettings changes the tenant's currency and cash rounding. Existing amounts are not converted.
+func (s *CurrencyService) SaveSettings(tenantID uint, req dto.SaveCurrencySettingsRequest, updatedBy *uint) (*models.Tenant, error) {
+	precision := *req.CurrencyPrecision
+	if req.CashRoundingIncrement < 0 || req.CashRoundingIncrement.Round(precision, money.RoundDown) != req.CashRoundingIncrement {
+		return nil, errors.New("cash_rounding_increment must be a positive multiple of the currency's minor unit")
+	}
+	mode := req.CashRoundingMode
+	if mode == "" {
+		mode = string(money.RoundHalfUp)
+	}
+
+	tenant, err := s.GetSettings(tenantID)
+	if err != nil {
+		return nil, err
+	}
+	old := currencyOf(tenant)
+
+	updates := map[string]interface{}{
+		"currency_code":           strings.ToUpper(req.CurrencyCode),
+		"currency_precision":      precision,
+		"cash_rounding_increment": req.CashRoundingIncrement,
+		"cash_rounding_mode":      mode,
+		"updated_by":              updatedBy,
+	}
+	if err := s.db.Model(tenant).Updates(updates).Error; err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{
+		"currency_code":           map[string]interface{}{"old": old.Code, "new": updates["currency_code"]},
+		"currency_precision":      map[string]interface{}{"old": old.Precision, "new": precision},
+		"cash_rounding_increment": map[string]interface{}{"old": old.CashIncrement, "new": req.CashRoundingIncrement},
+		"cash_rounding_mode":      map[string]interface{}{"old": old.CashRounding, "new": mode},
+	}
+	var auditUserID uint
+	if updatedBy != nil {
+		auditUserID = *updatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "tenant_currency", tenantID, "update", changes, "", "")
+
+	return s.GetSettings(tenantID)
+}
@@ -0,0 +1,338 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CustomerService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewCustomerService(db *gorm.DB, auditTrailService *AuditTrailService) *CustomerService {
+	return &CustomerService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// customerStatsExcludedStatuses are order statuses that never count as a visit or as spend
+var customerStatsExcludedStatuses = []string{"cancelled", "voided"}
+
+// normalizeCustomerTags trims, lowercases and de-duplicates tags, keeping their order
+func normalizeCustomerTags(tags []string) []string {
+	normalized := make([]string, 0, len(tags))
+	seen := make(map[string]bool, len(tags))
+	for _, tag := range tags {
+		tag = strings.ToLower(strings.TrimSpace(tag))
+		if tag == "" || seen[tag] {
+			continue
+		}
+		seen[tag] = true
+		normalized = append(normalized, tag)
+	}
+	return normalized
+}
+
+func encodeCustomerTags(tags []string) string {
+	data, _ := json.Marshal(normalizeCustomerTags(tags))
+	return string(data)
+}
+
+// DecodeCustomerTags returns the tags stored on a customer
+func DecodeCustomerTags(customer *models.Customer) []string {
+	tags := []string{}
+	if customer.Tags != "" {
+		_ = json.Unmarshal([]byte(customer.Tags), &tags)
+	}
+	return tags
+}
+
+func parseCustomerBirthday(value string) (*time.Time, error) {
+	if value == "" {
+		return nil, nil
+	}
+	birthday, err := time.Parse("2006-01-02", value)
+	if err != nil {
+		return nil, errors.New("birthday must use YYYY-MM-DD format")
+	}
+	if birthday.After(time.Now()) {
+		return nil, errors.New("birthday cannot be in the future")
+	}
+	return &birthday, nil
+}
+
+func (s *CustomerService) CreateCustomer(tenantID uint, req dto.CreateCustomerRequest) (*models.Customer, error) {
+	birthday, err := parseCustomerBirthday(req.Birthday)
+	if err != nil {
+		return nil, err
+	}
+
+	customer := models.Customer{
+		TenantID:  tenantID,
+		Name:      strings.TrimSpace(req.Name),
+		Phone:     strings.TrimSpace(req.Phone),
+		Email:     strings.ToLower(strings.TrimSpace(req.Email)),
+		Birthday:  birthday,
+		Notes:     req.Notes,
+		Tags:      encodeCustomerTags(req.Tags),
+		CreatedBy: req.CreatedBy,
+	}
+	if customer.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := s.checkContactUnique(tenantID, customer.Phone, customer.Email, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&customer).Error; err != nil {
+		return nil, err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"name":  customer.Name,
+		"phone": customer.Phone,
+		"email": customer.Email,
+		"tags":  customer.Tags,
+	}
+	var auditUserID uint
+	if req.CreatedBy != nil {
+		auditUserID = *req.CreatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "customer", customer.ID, "create", changes, "", "")
+
+	return s.GetCustomer(customer.ID, tenantID)
+}
+
+func (s *CustomerService) GetCustomer(id, tenantID uint) (*models.Customer, error) {
+	var customer models.Customer
+	if err := s.db.Preload("Creator").Preload("Updater").
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("customer not found")
+		}
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// ListCustomers searches the directory by name, phone or email. tag limits the result to customers carrying it.
+func (s *CustomerService) ListCustomers(tenantID uint, search, tag string, page, pageSize int) ([]models.Customer, int64, error) {
+	var customers []models.Customer
+	var total int64
+
+	query := s.db.Model(&models.Customer{}).Where("tenant_id = ?", tenantID)
+
+	if search != "" {
+		searchPattern := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR phone ILIKE ? OR email ILIKE ?", searchPattern, searchPattern, searchPattern)
+	}
+	if tag = strings.ToLower(strings.TrimSpace(tag)); tag != "" {
+		tagFilter, _ := json.Marshal([]string{tag})
+		query = query.Where("tags @> ?::jsonb", string(tagFilter))
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Creator").Preload("Updater").
+		Order("name ASC, id ASC").
+		Limit(pageSize).
+		Offset(offset).
+		Find(&customers).Error; err != nil {
+		return nil, 0, err
+	}
+	return customers, total, nil
+}
+
+func (s *CustomerService) UpdateCustomer(id, tenantID uint, req dto.UpdateCustomerRequest) (*models.Customer, error) {
+	customer, err := s.GetCustomer(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name is required")
+		}
+		changes["name"] = map[string]interface{}{"old": customer.Name, "new": name}
+		customer.Name = name
+	}
+	if req.Phone != nil {
+		phone := strings.TrimSpace(*req.Phone)
+		changes["phone"] = map[string]interface{}{"old": customer.Phone, "new": phone}
+		customer.Phone = phone
+	}
+	if req.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*req.Email))
+		changes["email"] = map[string]interface{}{"old": customer.Email, "new": email}
+		customer.Email = email
+	}
+	if req.Birthday != nil {
+		birthday, err := parseCustomerBirthday(*req.Birthday)
+		if err != nil {
+			return nil, err
+		}
+		changes["birthday"] = map[string]interface{}{"old": customer.Birthday, "new": birthday}
+		customer.Birthday = birthday
+	}
+	if req.Notes != nil {
+		customer.Notes = *req.Notes
+		changes["notes"] = *req.Notes
+	}
+	if req.Tags != nil {
+		tags := encodeCustomerTags(req.Tags)
+		changes["tags"] = map[string]interface{}{"old": customer.Tags, "new": tags}
+		customer.Tags = tags
+	}
+	if err := s.checkContactUnique(tenantID, customer.Phone, customer.Email, customer.ID); err != nil {
+		return nil, err
+	}
+
+	customer.UpdatedBy = req.UpdatedBy
+	if err := s.db.Model(customer).Select("name", "phone", "email", "birthday", "notes", "tags", "updated_by").
+		Updates(customer).Error; err != nil {
+		return nil, err
+	}
+
+	var auditUserID uint
+	if req.UpdatedBy != nil {
+		auditUserID = *req.UpdatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "customer", customer.ID, "update", changes, "", "")
+
+	return s.GetCustomer(customer.ID, tenantID)
+}
+
+// DeleteCustomer soft deletes the customer. Linked orders keep their customer_id for reporting.
+func (s *CustomerService) DeleteCustomer(id, tenantID uint, deletedBy *uint) error {
+	customer, err := s.GetCustomer(id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if deletedBy != nil {
+		if err := s.db.Model(customer).Update("deleted_by", deletedBy).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.Delete(customer).Error; err != nil {
+		return err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"name":  customer.Name,
+		"phone": customer.Phone,
+	}
+	var auditUserID uint
+	if deletedBy != nil {
+		auditUserID = *deletedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "customer", customer.ID, "delete", changes, "", "")
+
+	return nil
+}
+
+// GetCustomerStats returns the lifetime spend (net of refunds), visit count and last visit of a customer
+func (s *CustomerService) GetCustomerStats(customerID, tenantID uint) (*dto.CustomerStats, error) {
+	var row struct {
+		LifetimeSpend money.Amount
+		VisitCount    int64
+		LastVisit     *time.Time
+	}
+	if err := s.db.Model(&models.Order{}).
+		Select("COALESCE(SUM(total_amount - refunded_amount), 0) AS lifetime_spend, COUNT(*) AS visit_count, MAX(created_at) AS last_visit").
+		Where("tenant_id = ? AND customer_id = ? AND status NOT IN ?", tenantID, customerID, customerStatsExcludedStatuses).
+		Scan(&row).Error; err != nil {
+		return nil, err
+	}
+
+	stats := &dto.CustomerStats{
+		LifetimeSpend: row.LifetimeSpend,
+		VisitCount:    row.VisitCount,
+	}
+	if row.LastVisit != nil {
+		formatted := row.LastVisit.Format("2006-01-02 15:04:05")
+		stats.LastVisit = &formatted
+	}
+	return stats, nil
+}
+
+// ListCustomerOrders returns the customer's orders across all branches, newest first
+func (s *CustomerService) ListCustomerOrders(customerID, tenantID uint, page, pageSize int) ([]models.Order, int64, error) {
+	var orders []models.Order
+	var total int64
+
+	query := s.db.Model(&models.Order{}).Where("tenant_id = ? AND customer_id = ?", tenantID, customerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Creator").Preload("Updater").Preload("OrderItems.Product").Preload("Promotions").Preload("Taxes").
+		Order("created_at DESC, id DESC").
+		Limit(pageSize).
+		Offset(offset).
+		Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+	return orders, total, nil
+}
+
+// checkContactUnique keeps phone numbers and emails unique within the tenant's directory
+func (s *CustomerService) checkContactUnique(tenantID uint, phone, email string, excludeID uint) error {
+	if phone != "" {
+		var count int64
+		if err := s.db.Model(&models.Customer{}).
+			Where("tenant_id = ? AND phone = ? AND id != ?", tenantID, phone, excludeID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("customer with this phone already exists")
+		}
+	}
+	if email != "" {
+		var count int64
+		if err := s.db.Model(&models.Customer{}).
+			Where("tenant_id = ? AND email = ? AND id != ?", tenantID, email, excludeID).
+			Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("customer with this email already exists")
+		}
+	}
+	return nil
+}
+
+// validateOrderCustomer checks that an order's customer belongs to the tenant
+func validateOrderCustomer(tx *gorm.DB, tenantID uint, customerID *uint) error {
+	if customerID == nil {
+		return nil
+	}
+	var count int64
+	if err := tx.Model(&models.Customer{}).Where("id = ? AND tenant_id = ?", *customerID, tenantID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("customer not found")
+	}
+	return nil
+}
@@ -0,0 +1,474 @@
+package services
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"myposcore/models"
+	"myposcore/money"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// GiftCardPaymentMethod is the payment method that redeems a gift card or store credit
+const GiftCardPaymentMethod = "gift_card"
+
+// StoreCreditRefundMethod refunds onto a new store credit card instead of paying money out
+const StoreCreditRefundMethod = "store_credit"
+
+// Gift card types
+const (
+	GiftCardTypeGiftCard    = "gift_card"
+	GiftCardTypeStoreCredit = "store_credit"
+)
+
+// Gift card ledger entry types
+const (
+	GiftCardIssue         = "issue"
+	GiftCardRedeem        = "redeem"
+	GiftCardReverseRedeem = "reverse_redeem"
+	GiftCardVoid          = "void"
+)
+
+// giftCardCodeAlphabet leaves out characters that are easily misread, like 0/O and 1/I. Its 32
+// characters divide 256, so every character is equally likely.
+const giftCardCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+const giftCardCodeLength = 16
+
+type GiftCardService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewGiftCardService(db *gorm.DB, auditTrailService *AuditTrailService) *GiftCardService {
+	return &GiftCardService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// GiftCardStatus returns the status a card can be used with: active, expired or voided
+func GiftCardStatus(card *models.GiftCard, now time.Time) string {
+	if card.Status == "active" && card.ExpiresAt != nil && !now.Before(*card.ExpiresAt) {
+		return "expired"
+	}
+	return card.Status
+}
+
+// normalizeGiftCardCode strips the separators printed on cards and typed by cashiers
+func normalizeGiftCardCode(code string) string {
+	return strings.ToUpper(strings.NewReplacer("-", "", " ", "").Replace(strings.TrimSpace(code)))
+}
+
+func generateGiftCardCode() (string, error) {
+	buf := make([]byte, giftCardCodeLength)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	for i, b := range buf {
+		buf[i] = giftCardCodeAlphabet[int(b)%len(giftCardCodeAlphabet)]
+	}
+	return string(buf), nil
+}
+
+// findGiftCard looks a card of the tenant up by its code
+func findGiftCard(tx *gorm.DB, tenantID uint, code string) (*models.GiftCard, error) {
+	code = normalizeGiftCardCode(code)
+	if code == "" {
+		return nil, errors.New("gift card code is required")
+	}
+	var card models.GiftCard
+	if err := tx.Where("tenant_id = ? AND code = ?", tenantID, code).First(&card).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("gift card not found")
+		}
+		return nil, err
+	}
+	return &card, nil
+}
+
+// issueGiftCard creates a card under a new random code and credits its initial balance through
+// the ledger. entry describes the issue; its amount is the card's initial balance.
+func issueGiftCard(tx *gorm.DB, card *models.GiftCard, entry models.GiftCardTransaction) error {
+	for attempt := 1; card.Code == ""; attempt++ {
+		code, err := generateGiftCardCode()
+		if err != nil {
+			return err
+		}
+		var taken int64
+		if err := tx.Model(&models.GiftCard{}).Where("tenant_id = ? AND code = ?", card.TenantID, code).Count(&taken).Error; err != nil {
+			return err
+		}
+		if taken == 0 {
+			card.Code = code
+		} else if attempt >= 5 {
+			return errors.New("could not generate a unique gift card code")
+		}
+	}
+
+	card.Balance = 0
+	card.Status = "active"
+	if err := tx.Create(card).Error; err != nil {
+		return err
+	}
+
+	entry.TenantID = card.TenantID
+	entry.GiftCardID = card.ID
+	entry.Type = GiftCardIssue
+	entry.Amount = card.InitialBalance
+	if err := appendGiftCardEntry(tx, &entry); err != nil {
+		return err
+	}
+	card.Balance = entry.BalanceAfter
+	return nil
+}
+
+// appendGiftCardEntry adds a ledger entry and moves the card's balance with it. Redemptions need an
+// active, unexpired card and may not overdraw it; voids close the card.
+func appendGiftCardEntry(tx *gorm.DB, entry *models.GiftCardTransaction) error {
+	var card models.GiftCard
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", entry.GiftCardID, entry.TenantID).
+		First(&card).Error; err != nil {
+		return err
+	}
+
+	balance := card.Balance + entry.Amount
+	if entry.Type == GiftCardRedeem {
+		if status := GiftCardStatus(&card, time.Now()); status != "active" {
+			return fmt.Errorf("gift card is %s", status)
+		}
+		if balance < 0 {
+			cur, err := tenantCurrency(tx, card.TenantID)
+			if err != nil {
+				return err
+			}
+			return fmt.Errorf("insufficient gift card balance: balance is %s", cur.Format(card.Balance))
+		}
+	}
+
+	entry.BalanceAfter = balance
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"balance":    balance,
+		"updated_by": entry.CreatedBy,
+	}
+	if entry.Type == GiftCardVoid {
+		updates["status"] = "voided"
+	}
+	return tx.Model(&models.GiftCard{}).Where("id = ?", card.ID).Updates(updates).Error
+}
+
+// sumGiftCardEntries adds up the amounts of the ledger entries matching the condition
+func sumGiftCardEntries(tx *gorm.DB, query string, args ...interface{}) (money.Amount, error) {
+	var total money.Amount
+	err := tx.Model(&models.GiftCardTransaction{}).Select("COALESCE(SUM(amount), 0)").Where(query, args...).Scan(&total).Error
+	return total, err
+}
+
+// redeemGiftCard debits the card paying for a gift_card payment
+func redeemGiftCard(tx *gorm.DB, order *models.Order, payment *models.Payment, createdBy *uint) error {
+	return appendGiftCardEntry(tx, &models.GiftCardTransaction{
+		TenantID:    order.TenantID,
+		GiftCardID:  *payment.GiftCardID,
+		BranchID:    &order.BranchID,
+		OrderID:     &order.ID,
+		PaymentID:   &payment.ID,
+		Type:        GiftCardRedeem,
+		Amount:      -payment.Amount,
+		Description: fmt.Sprintf("redeemed for order %s", order.OrderNumber),
+		CreatedBy:   createdBy,
+	})
+}
+
+// giftCardUnitValue is what was paid for one unit of a gift card line: the line's net amount split
+// evenly over its units, with the rounding remainder on the last unit so the cards add up to the line
+func giftCardUnitValue(cur money.Currency, item *models.OrderItem, unit int) money.Amount {
+	paidUpTo := func(units int) money.Amount {
+		return item.NetAmount.MulFrac(int64(units), int64(item.Quantity), cur.Precision, money.RoundDown)
+	}
+	if unit == item.Quantity-1 {
+		return item.NetAmount - paidUpTo(unit)
+	}
+	return paidUpTo(unit+1) - paidUpTo(unit)
+}
+
+// issueOrderGiftCards issues a card worth what was paid for it for every unit of the gift card
+// products on a completed order. Cards already issued for an item are not issued again.
+func issueOrderGiftCards(tx *gorm.DB, order *models.Order, changedBy *uint) error {
+	cur, err := tenantCurrency(tx, order.TenantID)
+	if err != nil {
+		return err
+	}
+
+	var items []models.OrderItem
+	if err := tx.Preload("Product").
+		Joins("JOIN products ON products.id = order_items.product_id").
+		Where("order_items.order_id = ? AND products.is_gift_card = ?", order.ID, true).
+		Find(&items).Error; err != nil {
+		return err
+	}
+
+	for _, item := range items {
+		var issued int64
+		if err := tx.Model(&models.GiftCard{}).Where("order_item_id = ?", item.ID).Count(&issued).Error; err != nil {
+			return err
+		}
+		var expiresAt *time.Time
+		if item.Product.GiftCardValidityDays > 0 {
+			t := time.Now().AddDate(0, 0, item.Product.GiftCardValidityDays)
+			expiresAt = &t
+		}
+		for i := int(issued); i < item.Quantity; i++ {
+			itemID := item.ID
+			card := &models.GiftCard{
+				TenantID:       order.TenantID,
+				BranchID:       order.BranchID,
+				Type:           GiftCardTypeGiftCard,
+				CustomerID:     order.CustomerID,
+				InitialBalance: giftCardUnitValue(cur, &item, i),
+				ExpiresAt:      expiresAt,
+				OrderID:        &order.ID,
+				OrderItemID:    &itemID,
+				CreatedBy:      changedBy,
+			}
+			if err := issueGiftCard(tx, card, models.GiftCardTransaction{
+				BranchID:    &order.BranchID,
+				OrderID:     &order.ID,
+				Description: fmt.Sprintf("sold on order %s", order.OrderNumber),
+				CreatedBy:   changedBy,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// voidOrderGiftCards voids cards sold on an order that were never used: those of one order item when
+// orderItemID is given, limited to quantity when it is positive, or all of them. Cards that were
+// redeemed cannot be taken back, so their sale cannot be reversed.
+func voidOrderGiftCards(tx *gorm.DB, order *models.Order, orderItemID *uint, quantity int, refundID *uint, changedBy *uint) error {
+	query := tx.Where("order_id = ? AND type = ? AND status = ?", order.ID, GiftCardTypeGiftCard, "active")
+	if orderItemID != nil {
+		query = query.Where("order_item_id = ?", *orderItemID)
+	}
+	var cards []models.GiftCard
+	if err := query.Order("id ASC").Find(&cards).Error; err != nil {
+		return err
+	}
+
+	voided := 0
+	for _, card := range cards {
+		if quantity > 0 && voided == quantity {
+			break
+		}
+		if card.Balance != card.InitialBalance {
+			continue
+		}
+		if err := appendGiftCardEntry(tx, &models.GiftCardTransaction{
+			TenantID:    order.TenantID,
+			GiftCardID:  card.ID,
+			BranchID:    &order.BranchID,
+			OrderID:     &order.ID,
+			RefundID:    refundID,
+			Type:        GiftCardVoid,
+			Amount:      -card.Balance,
+			Description: fmt.Sprintf("sale reversed on order %s", order.OrderNumber),
+			CreatedBy:   changedBy,
+		}); err != nil {
+			return err
+		}
+		voided++
+	}
+
+	unused := len(cards)
+	if quantity > 0 && quantity < unused {
+		unused = quantity
+	}
+	if voided < unused {
+		return errors.New("gift cards sold on this order have already been used")
+	}
+	return nil
+}
+
+// returnGiftCardRedemptions credits back what the gift card payments of an order took off the
+// cards, less what refunds and earlier returns already gave back
+func returnGiftCardRedemptions(tx *gorm.DB, order *models.Order, changedBy *uint) error {
+	var payments []models.Payment
+	if err := tx.Where("order_id = ? AND payment_method = ? AND status IN ? AND gift_card_id IS NOT NULL", order.ID, GiftCardPaymentMethod, paidPaymentStatuses).
+		Find(&payments).Error; err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		returned, err := sumGiftCardEntries(tx, "payment_id = ? AND type = ? AND refund_id IS NULL", payment.ID, GiftCardReverseRedeem)
+		if err != nil {
+			return err
+		}
+		outstanding := payment.Amount - payment.RefundedAmount - returned
+		if outstanding <= 0 {
+			continue
+		}
+		paymentID := payment.ID
+		if err := appendGiftCardEntry(tx, &models.GiftCardTransaction{
+			TenantID:    order.TenantID,
+			GiftCardID:  *payment.GiftCardID,
+			BranchID:    &order.BranchID,
+			OrderID:     &order.ID,
+			PaymentID:   &paymentID,
+			Type:        GiftCardReverseRedeem,
+			Amount:      outstanding,
+			Description: fmt.Sprintf("returned redemption of order %s", order.OrderNumber),
+			CreatedBy:   changedBy,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// creditRefundGiftCard puts a gift_card refund back on the card that paid, or issues a store_credit
+// refund as a new store credit card for the order's customer
+func creditRefundGiftCard(tx *gorm.DB, order *models.Order, payment *models.Payment, refund *models.Refund, createdBy *uint) error {
+	entry := models.GiftCardTransaction{
+		TenantID:  order.TenantID,
+		BranchID:  &order.BranchID,
+		OrderID:   &order.ID,
+		PaymentID: &payment.ID,
+		RefundID:  &refund.ID,
+		CreatedBy: createdBy,
+	}
+
+	switch refund.RefundMethod {
+	case GiftCardPaymentMethod:
+		if payment.GiftCardID == nil {
+			return errors.New("payment has no gift card to refund to")
+		}
+		var card models.GiftCard
+		if err := tx.First(&card, *payment.GiftCardID).Error; err != nil {
+			return err
+		}
+		if card.Status == "voided" {
+			return errors.New("gift card is voided, refund as store credit instead")
+		}
+		entry.GiftCardID = card.ID
+		entry.Type = GiftCardReverseRedeem
+		entry.Amount = refund.Amount
+		entry.Description = fmt.Sprintf("refunded on order %s", order.OrderNumber)
+		if err := appendGiftCardEntry(tx, &entry); err != nil {
+			return err
+		}
+		refund.GiftCardID = &card.ID
+	case StoreCreditRefundMethod:
+		card := &models.GiftCard{
+			TenantID:       order.TenantID,
+			BranchID:       order.BranchID,
+			Type:           GiftCardTypeStoreCredit,
+			CustomerID:     order.CustomerID,
+			InitialBalance: refund.Amount,
+			RefundID:       &refund.ID,
+			CreatedBy:      createdBy,
+		}
+		entry.Description = fmt.Sprintf("store credit for refund on order %s", order.OrderNumber)
+		if err := issueGiftCard(tx, card, entry); err != nil {
+			return err
+		}
+		refund.GiftCardID = &card.ID
+	default:
+		return nil
+	}
+
+	return tx.Model(refund).Update("gift_card_id", refund.GiftCardID).Error
+}
+
+// recordGiftCardStatusChange issues the gift cards sold on an order when it completes, and voids
+// them and returns gift card payments when it is cancelled or voided
+func recordGiftCardStatusChange(tx *gorm.DB, order *models.Order, toStatus string, changedBy *uint) error {
+	switch toStatus {
+	case "completed":
+		return issueOrderGiftCards(tx, order, changedBy)
+	case "cancelled", "voided":
+		if err := voidOrderGiftCards(tx, order, nil, 0, nil, changedBy); err != nil {
+			return err
+		}
+		return returnGiftCardRedemptions(tx, order, changedBy)
+	}
+	return nil
+}
+
+// GetBalance looks a card up by its code for a balance inquiry
+func (s *GiftCardService) GetBalance(tenantID uint, code string) (*models.GiftCard, error) {
+	return findGiftCard(s.db, tenantID, code)
+}
+
+// GetGiftCard gets a card of the tenant
+func (s *GiftCardService) GetGiftCard(giftCardID, tenantID uint) (*models.GiftCard, error) {
+	var card models.GiftCard
+	if err := s.db.Preload("Customer").Where("id = ? AND tenant_id = ?", giftCardID, tenantID).First(&card).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("gift card not found")
+		}
+		return nil, err
+	}
+	return &card, nil
+}
+
+// ListGiftCards lists the tenant's cards, newest first. Empty cardType/status and a nil customerID match all.
+func (s *GiftCardService) ListGiftCards(tenantID uint, cardType, status string, customerID *uint, page, pageSize int) ([]models.GiftCard, int64, error) {
+	var cards []models.GiftCard
+	var total int64
+
+	query := s.db.Model(&models.GiftCard{}).Where("tenant_id = ?", tenantID)
+	if cardType != "" {
+		query = query.Where("type = ?", cardType)
+	}
+	switch status {
+	case "":
+	case "expired":
+		query = query.Where("status = ? AND expires_at <= ?", "active", time.Now())
+	case "active":
+		query = query.Where("status = ? AND (expires_at IS NULL OR expires_at > ?)", "active", time.Now())
+	default:
+		query = query.Where("status = ?", status)
+	}
+	if customerID != nil {
+		query = query.Where("customer_id = ?", *customerID)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Customer").Order("created_at DESC, id DESC").
+		Offset(offset).Limit(pageSize).Find(&cards).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return cards, total, nil
+}
+
+// ListTransactions returns one page of a card's ledger, newest first
+func (s *GiftCardService) ListTransactions(giftCardID, tenantID uint, page, pageSize int) ([]models.GiftCardTransaction, int64, error) {
+	var transactions []models.GiftCardTransaction
+	var total int64
+
+	query := s.db.Model(&models.GiftCardTransaction{}).Where("gift_card_id = ? AND tenant_id = ?", giftCardID, tenantID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&transactions).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return transactions, total, nil
+}
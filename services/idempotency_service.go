@@ -0,0 +1,127 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"myposcore/models"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// idempotencyKeyTTL is how long a stored response can be replayed
+const idempotencyKeyTTL = 24 * time.Hour
+
+// idempotencyProcessingLease is how long a request holds its key while running. A key still
+// processing after that was left behind by a request that crashed, and a retry may take it over.
+// It is well above the longest a request waits on a payment provider.
+const idempotencyProcessingLease = 2 * time.Minute
+
+var (
+	// ErrIdempotencyKeyReused is returned when a key is sent again with a different request
+	ErrIdempotencyKeyReused = errors.New("idempotency key was already used with a different request")
+	// ErrIdempotencyKeyInProgress is returned while the first request with a key is still running
+	ErrIdempotencyKeyInProgress = errors.New("a request with this idempotency key is still being processed")
+)
+
+type IdempotencyService struct {
+	db *gorm.DB
+}
+
+func NewIdempotencyService(db *gorm.DB) *IdempotencyService {
+	return &IdempotencyService{db: db}
+}
+
+// Begin claims a key for a request. It returns the stored record and false when the request was
+// already completed and its response should be replayed, or a new record and true when the request
+// should run. A key left processing past its lease is handed to the new request.
+func (s *IdempotencyService) Begin(tenantID, userID uint, key, method, path, requestHash string) (*models.IdempotencyKey, bool, error) {
+	now := time.Now()
+	record := &models.IdempotencyKey{
+		TenantID:    tenantID,
+		UserID:      userID,
+		Key:         key,
+		Method:      method,
+		Path:        path,
+		RequestHash: requestHash,
+		Status:      "processing",
+		ExpiresAt:   now.Add(idempotencyKeyTTL),
+	}
+
+	// An expired key may be used again
+	if err := s.db.Where("tenant_id = ? AND user_id = ? AND key = ? AND expires_at <= ?", tenantID, userID, key, now).
+		Delete(&models.IdempotencyKey{}).Error; err != nil {
+		return nil, false, err
+	}
+
+	res := s.db.Raw(`INSERT INTO idempotency_keys (tenant_id, user_id, key, method, path, request_hash, status, response_status, response_body, content_type, created_at, updated_at, expires_at)
+		VALUES (?, ?, ?, ?, ?, ?, ?, 0, '', '', ?, ?, ?)
+		ON CONFLICT (tenant_id, user_id, key) DO NOTHING
+		RETURNING id`, tenantID, userID, key, method, path, requestHash, record.Status, now, now, record.ExpiresAt).
+		Scan(&record.ID)
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected > 0 {
+		return record, true, nil
+	}
+
+	var existing models.IdempotencyKey
+	if err := s.db.Where("tenant_id = ? AND user_id = ? AND key = ?", tenantID, userID, key).First(&existing).Error; err != nil {
+		return nil, false, err
+	}
+	if existing.RequestHash != requestHash {
+		return nil, false, ErrIdempotencyKeyReused
+	}
+	if existing.Status == "completed" {
+		return &existing, false, nil
+	}
+
+	// Take over a key whose request stopped without completing or releasing it
+	res = s.db.Model(&models.IdempotencyKey{}).
+		Where("id = ? AND status = ? AND updated_at <= ?", existing.ID, "processing", now.Add(-idempotencyProcessingLease)).
+		Updates(map[string]interface{}{"updated_at": now, "expires_at": record.ExpiresAt})
+	if res.Error != nil {
+		return nil, false, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, false, ErrIdempotencyKeyInProgress
+	}
+	existing.UpdatedAt = now
+	existing.ExpiresAt = record.ExpiresAt
+	return &existing, true, nil
+}
+
+// Complete stores the response of a request so retries get the same answer
+func (s *IdempotencyService) Complete(id uint, status int, contentType string, body []byte) error {
+	return s.db.Model(&models.IdempotencyKey{}).Where("id = ?", id).Updates(map[string]interface{}{
+		"status":          "completed",
+		"response_status": status,
+		"content_type":    contentType,
+		"response_body":   string(body),
+	}).Error
+}
+
+// Release forgets a key whose request failed on the server side, so the client can retry it
+func (s *IdempotencyService) Release(id uint) error {
+	return s.db.Delete(&models.IdempotencyKey{}, id).Error
+}
+
+// PurgeExpired deletes keys past their expiry
+func (s *IdempotencyService) PurgeExpired() (int64, error) {
+	res := s.db.Where("expires_at <= ?", time.Now()).Delete(&models.IdempotencyKey{})
+	return res.RowsAffected, res.Error
+}
+
+// StartPurger deletes expired keys every interval in the background
+func (s *IdempotencyService) StartPurger(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.PurgeExpired(); err != nil {
+				log.Printf("Failed to purge idempotency keys: %v", err)
+			}
+		}
+	}()
+}
@@ -0,0 +1,434 @@
+package services
+
+import (
+	"errors"
+	"log"
+	"math"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"sort"
+	"sync"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stock alert types and statuses
+const (
+	StockAlertLow      = "low_stock"
+	StockAlertOut      = "out_of_stock"
+	StockAlertOpen     = "open"
+	StockAlertResolved = "resolved"
+)
+
+// stockAlertOverlap is how far before the previous evaluation each evaluation looks for stock
+// movements, so movements of transactions that committed late are not missed
+const stockAlertOverlap = time.Minute
+
+// stockAlertWatermarkKey is the config key keeping when stock alerts were last evaluated, so a
+// restart carries on from there instead of looking at all stock again
+const stockAlertWatermarkKey = "stock_alerts_evaluated_at"
+
+type InventoryService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+	configService     *ConfigService
+
+	mu sync.Mutex
+}
+
+func NewInventoryService(db *gorm.DB, auditTrailService *AuditTrailService) *InventoryService {
+	return &InventoryService{
+		db:                db,
+		auditTrailService: auditTrailService,
+		configService:     NewConfigService(db),
+	}
+}
+
+// evaluateStockAlert compares the stock of a product at a branch with its reorder point and raises,
+// replaces or resolves the open alert to match. Running out raises an out-of-stock alert whatever
+// the reorder point; low-stock alerts need one. It reports whether an alert was raised.
+func evaluateStockAlert(tx *gorm.DB, branchID, productID uint, now time.Time) (bool, error) {
+	var stock models.ProductStock
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("branch_id = ? AND product_id = ?", branchID, productID).First(&stock).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, err
+	}
+
+	// Deleted and inactive products are not watched any more, and gift cards are not stocked
+	var product models.Product
+	if err := tx.Select("id, min_stock, is_active, is_gift_card").First(&product, productID).Error; err != nil &&
+		!errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	minStock := product.MinStock
+	if stock.MinStock != nil {
+		minStock = *stock.MinStock
+	}
+
+	alertType := ""
+	if product.ID != 0 && product.IsActive && !product.IsGiftCard {
+		if stock.Quantity <= 0 {
+			alertType = StockAlertOut
+		} else if minStock > 0 && stock.Quantity <= minStock {
+			alertType = StockAlertLow
+		}
+	}
+
+	var open models.StockAlert
+	err := tx.Where("branch_id = ? AND product_id = ? AND status = ?", branchID, productID, StockAlertOpen).First(&open).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return false, err
+	}
+	if open.ID != 0 {
+		if open.Type == alertType {
+			return false, nil
+		}
+		if err := tx.Model(&open).Updates(map[string]interface{}{
+			"status":      StockAlertResolved,
+			"resolved_at": now,
+		}).Error; err != nil {
+			return false, err
+		}
+	}
+	if alertType == "" {
+		return false, nil
+	}
+
+	return true, tx.Create(&models.StockAlert{
+		TenantID:  stock.TenantID,
+		BranchID:  branchID,
+		ProductID: productID,
+		Type:      alertType,
+		Status:    StockAlertOpen,
+		Quantity:  stock.Quantity,
+		MinStock:  minStock,
+		RaisedAt:  now,
+	}).Error
+}
+
+// evaluateStockAlerts evaluates the alerts of a product at each of the branches, every one in its
+// own transaction
+func (s *InventoryService) evaluateStockAlerts(productID uint, branchIDs []uint, now time.Time) (int, error) {
+	raised := 0
+	for _, branchID := range branchIDs {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			alerted, err := evaluateStockAlert(tx, branchID, productID, now)
+			if alerted {
+				raised++
+			}
+			return err
+		})
+		if err != nil {
+			return raised, err
+		}
+	}
+	return raised, nil
+}
+
+// EvaluateAlerts raises and resolves stock alerts for the products whose stock moved since the
+// previous evaluation; the first evaluation ever looks at all stock. The time of the previous
+// evaluation is kept in the configs table, so it survives restarts. It returns the alerts raised.
+func (s *InventoryService) EvaluateAlerts() (int, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	started := time.Now()
+	var lastEvaluated time.Time
+	if value, err := s.configService.GetConfig(stockAlertWatermarkKey); err == nil {
+		if lastEvaluated, err = time.Parse(time.RFC3339Nano, value); err != nil {
+			return 0, err
+		}
+	} else if err.Error() != "config key not found" {
+		return 0, err
+	}
+
+	var keys []struct {
+		BranchID  uint
+		ProductID uint
+	}
+	query := s.db.Model(&models.ProductStock{}).Select("branch_id, product_id")
+	if !lastEvaluated.IsZero() {
+		query = s.db.Model(&models.StockMovement{}).Distinct("branch_id", "product_id").
+			Where("created_at >= ?", lastEvaluated.Add(-stockAlertOverlap))
+	}
+	if err := query.Scan(&keys).Error; err != nil {
+		return 0, err
+	}
+
+	raised := 0
+	for _, key := range keys {
+		alerted, err := s.evaluateStockAlerts(key.ProductID, []uint{key.BranchID}, started)
+		raised += alerted
+		if err != nil {
+			return raised, err
+		}
+	}
+	return raised, s.configService.SetConfig(stockAlertWatermarkKey, started.UTC().Format(time.RFC3339Nano))
+}
+
+// StartAlertEvaluator evaluates stock alerts every interval in the background
+func (s *InventoryService) StartAlertEvaluator(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.EvaluateAlerts(); err != nil {
+				log.Printf("Failed to evaluate stock alerts: %v", err)
+			}
+		}
+	}()
+}
+
+// SetReorderPoint sets a product's reorder point, or with a branch the branch's own one, and
+// evaluates the product's alerts against it right away
+func (s *InventoryService) SetReorderPoint(productID, tenantID uint, req dto.ReorderPointRequest, updatedBy *uint) (*dto.ReorderPointResponse, error) {
+	var product models.Product
+	var stock *models.ProductStock
+	changes := map[string]interface{}{}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("product not found")
+			}
+			return err
+		}
+
+		if req.BranchID == nil {
+			var minStock, reorderQuantity int
+			if req.MinStock != nil {
+				minStock = *req.MinStock
+			}
+			if req.ReorderQuantity != nil {
+				reorderQuantity = *req.ReorderQuantity
+			}
+			changes["min_stock"] = map[string]interface{}{"old": product.MinStock, "new": minStock}
+			changes["reorder_quantity"] = map[string]interface{}{"old": product.ReorderQuantity, "new": reorderQuantity}
+			product.MinStock = minStock
+			product.ReorderQuantity = reorderQuantity
+			return tx.Model(&product).Updates(map[string]interface{}{
+				"min_stock":        product.MinStock,
+				"reorder_quantity": product.ReorderQuantity,
+				"updated_by":       updatedBy,
+			}).Error
+		}
+
+		if _, err := tenantBranch(tx, tenantID, *req.BranchID); err != nil {
+			return err
+		}
+		var err error
+		stock, err = lockBranchStock(tx, tenantID, *req.BranchID, productID)
+		if err != nil {
+			return err
+		}
+		changes["branch_min_stock"] = map[string]interface{}{"old": stock.MinStock, "new": req.MinStock}
+		changes["branch_reorder_quantity"] = map[string]interface{}{"old": stock.ReorderQuantity, "new": req.ReorderQuantity}
+		stock.MinStock = req.MinStock
+		stock.ReorderQuantity = req.ReorderQuantity
+		return tx.Model(stock).Updates(map[string]interface{}{
+			"min_stock":        stock.MinStock,
+			"reorder_quantity": stock.ReorderQuantity,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var auditUserID uint
+	if updatedBy != nil {
+		auditUserID = *updatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, req.BranchID, auditUserID, "product", product.ID, "reorder_point", changes, "", "")
+
+	// A new reorder point can raise or resolve alerts without any stock moving
+	var branchIDs []uint
+	if req.BranchID != nil {
+		branchIDs = []uint{*req.BranchID}
+	} else if err := s.db.Model(&models.ProductStock{}).Where("product_id = ?", product.ID).
+		Pluck("branch_id", &branchIDs).Error; err != nil {
+		return nil, err
+	}
+	if _, err := s.evaluateStockAlerts(product.ID, branchIDs, time.Now()); err != nil {
+		return nil, err
+	}
+
+	response := &dto.ReorderPointResponse{
+		ProductID:                product.ID,
+		MinStock:                 product.MinStock,
+		ReorderQuantity:          product.ReorderQuantity,
+		EffectiveMinStock:        product.MinStock,
+		EffectiveReorderQuantity: product.ReorderQuantity,
+	}
+	if stock != nil {
+		response.BranchID = &stock.BranchID
+		response.BranchMinStock = stock.MinStock
+		response.BranchReorderQuantity = stock.ReorderQuantity
+		if stock.MinStock != nil {
+			response.EffectiveMinStock = *stock.MinStock
+		}
+		if stock.ReorderQuantity != nil {
+			response.EffectiveReorderQuantity = *stock.ReorderQuantity
+		}
+	}
+	return response, nil
+}
+
+// ListAlerts lists the tenant's stock alerts, newest first. Zero branchID and empty alertType or
+// status match all.
+func (s *InventoryService) ListAlerts(tenantID, branchID uint, alertType, status string, page, pageSize int) ([]models.StockAlert, int64, error) {
+	var alerts []models.StockAlert
+	var total int64
+
+	query := s.db.Model(&models.StockAlert{}).Where("tenant_id = ?", tenantID)
+	if branchID != 0 {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if alertType != "" {
+		query = query.Where("type = ?", alertType)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Branch").
+		Preload("Product", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Order("raised_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&alerts).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return alerts, total, nil
+}
+
+// ReorderSuggestions suggests what to order for a branch from its sales over the last days. A
+// product is suggested when its stock and the quantity still on order will not cover its reorder
+// point plus the sales expected over the cover days; the suggestion makes up the difference, but
+// is at least the product's reorder quantity. Products selling fastest relative to their stock
+// come first.
+func (s *InventoryService) ReorderSuggestions(tenantID, branchID uint, days, coverDays int) (*dto.ReorderSuggestionsResponse, error) {
+	if _, err := tenantBranch(s.db, tenantID, branchID); err != nil {
+		return nil, err
+	}
+	cur, err := tenantCurrency(s.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	type productQuantity struct {
+		ProductID uint
+		Quantity  int
+	}
+
+	// Sales net of refunds and of orders put back, including sales made offline
+	var sales []productQuantity
+	if err := s.db.Model(&models.StockMovement{}).Select("product_id, -SUM(quantity) AS quantity").
+		Where("tenant_id = ? AND branch_id = ? AND created_at >= ?", tenantID, branchID, time.Now().AddDate(0, 0, -days)).
+		Where("type IN ? OR (type = ? AND reference_type = ?)", []string{StockMovementSale, StockMovementRefund}, StockMovementSync, "order").
+		Group("product_id").Scan(&sales).Error; err != nil {
+		return nil, err
+	}
+	sold := make(map[uint]int, len(sales))
+	soldIDs := make([]uint, 0, len(sales))
+	for _, sale := range sales {
+		if sale.Quantity > 0 {
+			sold[sale.ProductID] = sale.Quantity
+			soldIDs = append(soldIDs, sale.ProductID)
+		}
+	}
+
+	var ordered []productQuantity
+	if err := s.db.Table("purchase_order_items AS i").
+		Select("i.product_id, SUM(i.quantity - i.received_quantity) AS quantity").
+		Joins("JOIN purchase_orders o ON o.id = i.purchase_order_id").
+		Where("o.tenant_id = ? AND o.branch_id = ? AND o.status IN ?", tenantID, branchID,
+			[]string{PurchaseOrderOrdered, PurchaseOrderPartiallyReceived}).
+		Group("i.product_id").Scan(&ordered).Error; err != nil {
+		return nil, err
+	}
+	onOrder := make(map[uint]int, len(ordered))
+	for _, item := range ordered {
+		onOrder[item.ProductID] = item.Quantity
+	}
+
+	var products []struct {
+		ProductID       uint
+		ProductName     string
+		SKU             string
+		Stock           int
+		MinStock        int
+		ReorderQuantity int
+		CostPrice       money.Amount
+	}
+	if err := s.db.Table("products AS p").
+		Select("p.id AS product_id, p.name AS product_name, p.sku, COALESCE(ps.quantity, 0) AS stock, "+
+			"COALESCE(ps.min_stock, p.min_stock) AS min_stock, "+
+			"COALESCE(ps.reorder_quantity, p.reorder_quantity) AS reorder_quantity, p.cost_price").
+		Joins("LEFT JOIN product_stocks ps ON ps.product_id = p.id AND ps.branch_id = ?", branchID).
+		Where("p.tenant_id = ? AND p.is_active = ? AND p.is_gift_card = ? AND p.deleted_at IS NULL", tenantID, true, false).
+		Where("COALESCE(ps.min_stock, p.min_stock) > 0 OR p.id IN ?", soldIDs).
+		Order("p.name ASC, p.id ASC").Scan(&products).Error; err != nil {
+		return nil, err
+	}
+
+	response := &dto.ReorderSuggestionsResponse{
+		BranchID:  branchID,
+		Days:      days,
+		CoverDays: coverDays,
+		Items:     []dto.ReorderSuggestion{},
+	}
+	for _, product := range products {
+		dailySales := float64(sold[product.ProductID]) / float64(days)
+		available := product.Stock + onOrder[product.ProductID]
+		quantity := product.MinStock + int(math.Ceil(dailySales*float64(coverDays))) - available
+		// At or below the reorder point is always worth an order, even without recent sales
+		if quantity < 1 && product.MinStock > 0 && available <= product.MinStock {
+			quantity = 1
+		}
+		if quantity < 1 {
+			continue
+		}
+		if quantity < product.ReorderQuantity {
+			quantity = product.ReorderQuantity
+		}
+
+		suggestion := dto.ReorderSuggestion{
+			ProductID:         product.ProductID,
+			ProductName:       product.ProductName,
+			SKU:               product.SKU,
+			Stock:             product.Stock,
+			OnOrder:           onOrder[product.ProductID],
+			MinStock:          product.MinStock,
+			ReorderQuantity:   product.ReorderQuantity,
+			SoldQuantity:      sold[product.ProductID],
+			AverageDailySales: math.Round(dailySales*100) / 100,
+			SuggestedQuantity: quantity,
+			UnitCost:          product.CostPrice,
+			EstimatedCost:     cur.Round(product.CostPrice.Mul(quantity)),
+		}
+		if dailySales > 0 {
+			cover := math.Round(math.Max(float64(product.Stock), 0)/dailySales*10) / 10
+			suggestion.DaysOfCover = &cover
+		}
+		response.Items = append(response.Items, suggestion)
+	}
+
+	// Without sales there is no rate to run out at, so those come last
+	sort.SliceStable(response.Items, func(i, j int) bool {
+		a, b := response.Items[i].DaysOfCover, response.Items[j].DaysOfCover
+		if a == nil || b == nil {
+			return a != nil && b == nil
+		}
+		return *a < *b
+	})
+
+	return response, nil
+}
@@ -0,0 +1,316 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"myposcore/models"
+	"slices"
+	"strconv"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Kitchen event types
+const (
+	KitchenEventOrderCreated  = "order.created"
+	KitchenEventItemAdded     = "order.item_added"
+	KitchenEventItemUpdated   = "order.item_updated"
+	KitchenEventItemRemoved   = "order.item_removed"
+	KitchenEventStatusChanged = "order.status_changed"
+	KitchenEventCancelled     = "order.cancelled"
+	KitchenEventItemBumped    = "order.item_bumped"
+)
+
+// Kitchen statuses of a single order item
+const (
+	KitchenItemPending   = "pending"
+	KitchenItemPreparing = "preparing"
+	KitchenItemReady     = "ready"
+)
+
+const (
+	// kitchenEventRetention is how long events are kept for reconnecting screens to replay
+	kitchenEventRetention = 24 * time.Hour
+	// kitchenEventSequenceKey is the config key holding the last sequence given to a kitchen event
+	kitchenEventSequenceKey = "kitchen_event_sequence"
+	// kitchenEventSequenceBatch is the most events sequenced in one go
+	kitchenEventSequenceBatch = 500
+)
+
+// KitchenOrderStatuses are the statuses of orders shown on a kitchen display
+var KitchenOrderStatuses = []string{"pending", "confirmed", "preparing", "ready"}
+
+// kitchenBumpStatuses is the next status of an order when the kitchen bumps it
+var kitchenBumpStatuses = map[string]string{
+	"pending":   "confirmed",
+	"confirmed": "preparing",
+	"preparing": "ready",
+}
+
+// kitchenItemBumpStatuses is the next kitchen status of an item when the kitchen bumps it
+var kitchenItemBumpStatuses = map[string]string{
+	KitchenItemPending:   KitchenItemPreparing,
+	KitchenItemPreparing: KitchenItemReady,
+}
+
+type KitchenService struct {
+	db           *gorm.DB
+	orderService *OrderService
+}
+
+func NewKitchenService(db *gorm.DB, orderService *OrderService) *KitchenService {
+	return &KitchenService{
+		db:           db,
+		orderService: orderService,
+	}
+}
+
+// recordKitchenEvent writes a kitchen event inside the caller's transaction, so screens only see
+// changes that were committed
+func recordKitchenEvent(tx *gorm.DB, order *models.Order, eventType string, data map[string]interface{}) error {
+	payload := map[string]interface{}{
+		"order_id":     order.ID,
+		"order_number": order.OrderNumber,
+		"status":       order.Status,
+		"notes":        order.Notes,
+	}
+	for key, value := range data {
+		payload[key] = value
+	}
+	encoded, err := json.Marshal(payload)
+	if err != nil {
+		return err
+	}
+
+	return tx.Create(&models.KitchenEvent{
+		TenantID: order.TenantID,
+		BranchID: order.BranchID,
+		OrderID:  order.ID,
+		Type:     eventType,
+		Data:     string(encoded),
+	}).Error
+}
+
+// kitchenItem describes an order item the way the kitchen needs it
+func kitchenItem(item *models.OrderItem, productName string) map[string]interface{} {
+	return map[string]interface{}{
+		"order_item_id":  item.ID,
+		"product_id":     item.ProductID,
+		"product_name":   productName,
+		"quantity":       item.Quantity,
+		"kitchen_status": item.KitchenStatus,
+	}
+}
+
+// kitchenOrderItems loads the items of an order with their product names
+func kitchenOrderItems(tx *gorm.DB, orderID uint) ([]map[string]interface{}, error) {
+	var items []models.OrderItem
+	if err := tx.Preload("Product").Where("order_id = ?", orderID).Order("id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+	result := make([]map[string]interface{}, len(items))
+	for i := range items {
+		result[i] = kitchenItem(&items[i], items[i].Product.Name)
+	}
+	return result, nil
+}
+
+// recordKitchenStatusEvent emits the kitchen event matching a status change; an empty fromStatus
+// means the order was just created
+func recordKitchenStatusEvent(tx *gorm.DB, order *models.Order, fromStatus, toStatus, reason string) error {
+	if fromStatus == "" {
+		items, err := kitchenOrderItems(tx, order.ID)
+		if err != nil {
+			return err
+		}
+		return recordKitchenEvent(tx, order, KitchenEventOrderCreated, map[string]interface{}{
+			"status": toStatus,
+			"items":  items,
+		})
+	}
+
+	eventType := KitchenEventStatusChanged
+	if toStatus == "cancelled" || toStatus == "voided" {
+		eventType = KitchenEventCancelled
+	}
+	return recordKitchenEvent(tx, order, eventType, map[string]interface{}{
+		"status":      toStatus,
+		"from_status": fromStatus,
+		"to_status":   toStatus,
+		"reason":      reason,
+	})
+}
+
+// sequenceEvents gives the committed events that have no sequence yet the next ones, in id order.
+// Ids are taken when an event is inserted, so a slow transaction can commit events below ids already
+// streamed; sequences are only given after the commit instead. Sequencing runs one at a time, holding
+// the counter locked until it commits, so sequences become visible in order and a screen that read up
+// to a sequence never misses a lower one.
+func (s *KitchenService) sequenceEvents() error {
+	var waiting []uint
+	if err := s.db.Model(&models.KitchenEvent{}).Where("sequence IS NULL").Limit(1).Pluck("id", &waiting).Error; err != nil {
+		return err
+	}
+	if len(waiting) == 0 {
+		return nil
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		counter := models.Config{Key: kitchenEventSequenceKey, Value: "0"}
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&counter).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("key = ?", kitchenEventSequenceKey).First(&counter).Error; err != nil {
+			return err
+		}
+		last, err := strconv.ParseUint(counter.Value, 10, 64)
+		if err != nil {
+			return err
+		}
+
+		var ids []uint
+		if err := tx.Model(&models.KitchenEvent{}).Where("sequence IS NULL").Order("id ASC").
+			Limit(kitchenEventSequenceBatch).Pluck("id", &ids).Error; err != nil {
+			return err
+		}
+		for _, id := range ids {
+			last++
+			if err := tx.Model(&models.KitchenEvent{}).Where("id = ?", id).Update("sequence", last).Error; err != nil {
+				return err
+			}
+		}
+		return tx.Model(&counter).Update("value", strconv.FormatUint(last, 10)).Error
+	})
+}
+
+// NextEvents returns up to limit events of a branch after the sequence, oldest first. Events that
+// committed since the previous call are sequenced first.
+func (s *KitchenService) NextEvents(tenantID, branchID, afterSequence uint, limit int) ([]models.KitchenEvent, error) {
+	if err := s.sequenceEvents(); err != nil {
+		return nil, err
+	}
+
+	var events []models.KitchenEvent
+	if err := s.db.Where("tenant_id = ? AND branch_id = ? AND sequence > ?", tenantID, branchID, afterSequence).
+		Order("sequence ASC").
+		Limit(limit).
+		Find(&events).Error; err != nil {
+		return nil, err
+	}
+	return events, nil
+}
+
+// LatestEventSequence returns the sequence of the newest committed event of a branch, or 0 when there is none
+func (s *KitchenService) LatestEventSequence(tenantID, branchID uint) (uint, error) {
+	if err := s.sequenceEvents(); err != nil {
+		return 0, err
+	}
+
+	var latest uint
+	if err := s.db.Model(&models.KitchenEvent{}).
+		Where("tenant_id = ? AND branch_id = ?", tenantID, branchID).
+		Select("COALESCE(MAX(sequence), 0)").
+		Scan(&latest).Error; err != nil {
+		return 0, err
+	}
+	return latest, nil
+}
+
+// BumpOrder moves an order to its next kitchen status, or to status when given
+func (s *KitchenService) BumpOrder(orderID, tenantID uint, status string, bumpedBy *uint) (*models.Order, error) {
+	order, err := s.orderService.GetOrder(orderID, tenantID)
+	if err != nil {
+		return nil, errors.New("order not found")
+	}
+
+	if status == "" {
+		next, ok := kitchenBumpStatuses[order.Status]
+		if !ok {
+			return nil, fmt.Errorf("cannot bump %s order", order.Status)
+		}
+		status = next
+	}
+
+	return s.orderService.UpdateOrderStatus(orderID, tenantID, status, "kitchen bump", nil, bumpedBy)
+}
+
+// BumpOrderItem moves one item of an order to its next kitchen status (pending -> preparing -> ready),
+// or to status when given, so stations can mark dishes ready one by one
+func (s *KitchenService) BumpOrderItem(orderID, itemID, tenantID uint, status string, bumpedBy *uint) (*models.OrderItem, error) {
+	if status != "" && status != KitchenItemPending && status != KitchenItemPreparing && status != KitchenItemReady {
+		return nil, fmt.Errorf("invalid kitchen status: %s", status)
+	}
+
+	var item models.OrderItem
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", orderID, tenantID).
+			First(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("order not found")
+			}
+			return err
+		}
+		if !slices.Contains(KitchenOrderStatuses, order.Status) {
+			return fmt.Errorf("cannot bump items of %s order", order.Status)
+		}
+
+		if err := tx.Preload("Product").Where("id = ? AND order_id = ?", itemID, order.ID).First(&item).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("order item not found")
+			}
+			return err
+		}
+
+		fromStatus := item.KitchenStatus
+		if status == "" {
+			next, ok := kitchenItemBumpStatuses[fromStatus]
+			if !ok {
+				return fmt.Errorf("cannot bump %s item", fromStatus)
+			}
+			status = next
+		}
+		if status == fromStatus {
+			return fmt.Errorf("item is already %s", status)
+		}
+
+		if err := tx.Model(&item).Update("kitchen_status", status).Error; err != nil {
+			return err
+		}
+		item.KitchenStatus = status
+
+		return recordKitchenEvent(tx, &order, KitchenEventItemBumped, map[string]interface{}{
+			"item":        kitchenItem(&item, item.Product.Name),
+			"from_status": fromStatus,
+			"bumped_by":   bumpedBy,
+		})
+	})
+	if err != nil {
+		return nil, err
+	}
+	return &item, nil
+}
+
+// PurgeEvents deletes events older than the retention window
+func (s *KitchenService) PurgeEvents() (int64, error) {
+	res := s.db.Where("created_at < ?", time.Now().Add(-kitchenEventRetention)).Delete(&models.KitchenEvent{})
+	return res.RowsAffected, res.Error
+}
+
+// StartEventPurger purges old kitchen events every interval in the background
+func (s *KitchenService) StartEventPurger(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.PurgeEvents(); err != nil {
+				log.Printf("Failed to purge kitchen events: %v", err)
+			}
+		}
+	}()
+}
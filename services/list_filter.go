@@ -0,0 +1,142 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// defaultBranchTimezone is used for branches without a timezone and for tenant wide listings
+const defaultBranchTimezone = "Asia/Jakarta"
+
+// ErrInvalidListFilter is returned when list query parameters cannot be applied
+var ErrInvalidListFilter = errors.New("invalid filter")
+
+// listFilterFields maps the shared list filter onto the columns of one listing
+type listFilterFields struct {
+	CreatedAt     string
+	Status        string
+	PaymentMethod string // full condition taking the list of methods
+	UserID        string
+	CustomerID    string
+	Amount        string
+	OrderNumber   string
+	Sorts         map[string]string // sort_by value => column
+	DefaultSort   string
+}
+
+// branchLocation returns the timezone of a branch, falling back to the default one
+func branchLocation(db *gorm.DB, tenantID, branchID uint) *time.Location {
+	timezone := defaultBranchTimezone
+	if branchID > 0 {
+		var branch models.Branch
+		if err := db.Select("timezone").Where("id = ? AND tenant_id = ?", branchID, tenantID).First(&branch).Error; err == nil && branch.Timezone != "" {
+			timezone = branch.Timezone
+		}
+	}
+	loc, err := time.LoadLocation(timezone)
+	if err != nil {
+		return time.UTC
+	}
+	return loc
+}
+
+var filterDateTimeLayouts = []string{"2006-01-02 15:04:05", "2006-01-02T15:04:05", "2006-01-02 15:04", "2006-01-02T15:04"}
+
+// parseFilterTime parses a date filter in loc. dateOnly reports a bare date so callers can cover the whole day.
+func parseFilterTime(value string, loc *time.Location) (t time.Time, dateOnly bool, err error) {
+	if t, err = time.Parse(time.RFC3339, value); err == nil {
+		return t, false, nil
+	}
+	if t, err = time.ParseInLocation("2006-01-02", value, loc); err == nil {
+		return t, true, nil
+	}
+	for _, layout := range filterDateTimeLayouts {
+		if t, err = time.ParseInLocation(layout, value, loc); err == nil {
+			return t, false, nil
+		}
+	}
+	return time.Time{}, false, fmt.Errorf("%w: date %q must be YYYY-MM-DD or YYYY-MM-DD HH:MM", ErrInvalidListFilter, value)
+}
+
+// applyListFilter adds the filter conditions to query. Date ranges are read in the timezone of the
+// branch, so "yesterday" means the branch's business day rather than the server's.
+func applyListFilter(db, query *gorm.DB, tenantID, branchID uint, filter *dto.ListFilter, fields listFilterFields) (*gorm.DB, error) {
+	if filter.DateFrom != "" || filter.DateTo != "" {
+		loc := branchLocation(db, tenantID, branchID)
+		if filter.DateFrom != "" {
+			from, _, err := parseFilterTime(filter.DateFrom, loc)
+			if err != nil {
+				return nil, err
+			}
+			query = query.Where(fields.CreatedAt+" >= ?", from)
+		}
+		if filter.DateTo != "" {
+			to, dateOnly, err := parseFilterTime(filter.DateTo, loc)
+			if err != nil {
+				return nil, err
+			}
+			if dateOnly {
+				query = query.Where(fields.CreatedAt+" < ?", to.AddDate(0, 0, 1))
+			} else {
+				query = query.Where(fields.CreatedAt+" <= ?", to)
+			}
+		}
+	}
+
+	if len(filter.Statuses) > 0 {
+		query = query.Where(fields.Status+" IN ?", filter.Statuses)
+	}
+	if len(filter.PaymentMethods) > 0 {
+		query = query.Where(fields.PaymentMethod, filter.PaymentMethods)
+	}
+	if filter.UserID != nil {
+		query = query.Where(fields.UserID+" = ?", *filter.UserID)
+	}
+	if filter.CustomerID != nil {
+		query = query.Where(fields.CustomerID+" = ?", *filter.CustomerID)
+	}
+	if filter.MinAmount != nil {
+		query = query.Where(fields.Amount+" >= ?", *filter.MinAmount)
+	}
+	if filter.MaxAmount != nil {
+		query = query.Where(fields.Amount+" <= ?", *filter.MaxAmount)
+	}
+	if filter.OrderNumber != "" {
+		prefix := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.OrderNumber)
+		query = query.Where(fields.OrderNumber+" LIKE ?", prefix+"%")
+	}
+
+	return query, nil
+}
+
+// listFilterOrder returns the ORDER BY clause for the filter, only allowing whitelisted sort fields
+func listFilterOrder(filter *dto.ListFilter, fields listFilterFields) (string, error) {
+	sortBy := filter.SortBy
+	if sortBy == "" {
+		sortBy = fields.DefaultSort
+	}
+	column, ok := fields.Sorts[sortBy]
+	if !ok {
+		allowed := make([]string, 0, len(fields.Sorts))
+		for name := range fields.Sorts {
+			allowed = append(allowed, name)
+		}
+		sort.Strings(allowed)
+		return "", fmt.Errorf("%w: sort_by must be one of: %s", ErrInvalidListFilter, strings.Join(allowed, ", "))
+	}
+
+	dir := "DESC"
+	if filter.SortDir == "asc" {
+		dir = "ASC"
+	}
+	// Tie-break on the primary key so pages stay stable
+	idColumn := strings.SplitN(fields.CreatedAt, ".", 2)[0] + ".id"
+	return fmt.Sprintf("%s %s, %s %s", column, dir, idColumn, dir), nil
+}
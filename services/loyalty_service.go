@@ -0,0 +1,679 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"math"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// LoyaltyPaymentMethod is the payment method that redeems a member's points
+const LoyaltyPaymentMethod = "loyalty_points"
+
+// Loyalty ledger entry types
+const (
+	LoyaltyEarn          = "earn"
+	LoyaltyRedeem        = "redeem"
+	LoyaltyReverseEarn   = "reverse_earn"
+	LoyaltyReverseRedeem = "reverse_redeem"
+	LoyaltyExpire        = "expire"
+)
+
+type LoyaltyService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewLoyaltyService(db *gorm.DB, auditTrailService *AuditTrailService) *LoyaltyService {
+	return &LoyaltyService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// LoyaltyProgramConfig is a tenant's program with its category multipliers and tiers
+type LoyaltyProgramConfig struct {
+	Program             models.LoyaltyProgram
+	CategoryMultipliers []models.LoyaltyCategoryMultiplier
+	Tiers               []models.LoyaltyTier // Lowest first
+}
+
+// GetProgram returns the tenant's loyalty rules. Tenants without a program get an inactive one.
+func (s *LoyaltyService) GetProgram(tenantID uint) (*LoyaltyProgramConfig, error) {
+	config := &LoyaltyProgramConfig{Program: models.LoyaltyProgram{TenantID: tenantID}}
+	if err := s.db.Where("tenant_id = ?", tenantID).First(&config.Program).Error; err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	if err := s.db.Where("tenant_id = ?", tenantID).Order("category_id ASC").Find(&config.CategoryMultipliers).Error; err != nil {
+		return nil, err
+	}
+	if err := s.db.Where("tenant_id = ?", tenantID).Order("min_points ASC, id ASC").Find(&config.Tiers).Error; err != nil {
+		return nil, err
+	}
+	return config, nil
+}
+
+// SaveProgram replaces the tenant's loyalty rules. Existing balances are kept; tiers of members are
+// re-evaluated the next time their lifetime points change.
+func (s *LoyaltyService) SaveProgram(tenantID uint, req dto.SaveLoyaltyProgramRequest, updatedBy *uint) (*LoyaltyProgramConfig, error) {
+	if req.IsActive && req.PointsPerUnit <= 0 {
+		return nil, errors.New("points_per_unit must be greater than zero for an active program")
+	}
+	if req.IsActive && req.RedeemValue <= 0 {
+		return nil, errors.New("redeem_value must be greater than zero for an active program")
+	}
+
+	categoryIDs := make([]uint, 0, len(req.CategoryMultipliers))
+	seenCategories := make(map[uint]bool, len(req.CategoryMultipliers))
+	for _, m := range req.CategoryMultipliers {
+		if seenCategories[m.CategoryID] {
+			return nil, fmt.Errorf("category ID %d has more than one multiplier", m.CategoryID)
+		}
+		seenCategories[m.CategoryID] = true
+		categoryIDs = append(categoryIDs, m.CategoryID)
+	}
+	if len(categoryIDs) > 0 {
+		var count int64
+		if err := s.db.Model(&models.Category{}).Where("id IN ? AND tenant_id = ?", categoryIDs, tenantID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if int(count) != len(categoryIDs) {
+			return nil, errors.New("some categories not found")
+		}
+	}
+	seenTiers := make(map[string]bool, len(req.Tiers))
+	for _, t := range req.Tiers {
+		name := strings.ToLower(strings.TrimSpace(t.Name))
+		if seenTiers[name] {
+			return nil, fmt.Errorf("tier %q is defined more than once", t.Name)
+		}
+		seenTiers[name] = true
+	}
+
+	old, err := s.GetProgram(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		program := models.LoyaltyProgram{
+			TenantID:        tenantID,
+			IsActive:        req.IsActive,
+			PointsPerUnit:   req.PointsPerUnit,
+			RedeemValue:     req.RedeemValue,
+			MinRedeemPoints: req.MinRedeemPoints,
+			ExpiryDays:      req.ExpiryDays,
+			CreatedBy:       updatedBy,
+			UpdatedBy:       updatedBy,
+		}
+		if err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "tenant_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"is_active", "points_per_unit", "redeem_value", "min_redeem_points", "expiry_days", "updated_by", "updated_at"}),
+		}).Create(&program).Error; err != nil {
+			return err
+		}
+
+		if err := tx.Where("tenant_id = ?", tenantID).Delete(&models.LoyaltyCategoryMultiplier{}).Error; err != nil {
+			return err
+		}
+		if len(req.CategoryMultipliers) > 0 {
+			multipliers := make([]models.LoyaltyCategoryMultiplier, len(req.CategoryMultipliers))
+			for i, m := range req.CategoryMultipliers {
+				multipliers[i] = models.LoyaltyCategoryMultiplier{TenantID: tenantID, CategoryID: m.CategoryID, Multiplier: m.Multiplier}
+			}
+			if err := tx.Create(&multipliers).Error; err != nil {
+				return err
+			}
+		}
+
+		if err := tx.Where("tenant_id = ?", tenantID).Delete(&models.LoyaltyTier{}).Error; err != nil {
+			return err
+		}
+		if len(req.Tiers) > 0 {
+			tiers := make([]models.LoyaltyTier, len(req.Tiers))
+			for i, t := range req.Tiers {
+				tiers[i] = models.LoyaltyTier{TenantID: tenantID, Name: strings.TrimSpace(t.Name), MinPoints: t.MinPoints, EarnMultiplier: t.EarnMultiplier}
+			}
+			if err := tx.Create(&tiers).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{
+		"is_active":            map[string]interface{}{"old": old.Program.IsActive, "new": req.IsActive},
+		"points_per_unit":      map[string]interface{}{"old": old.Program.PointsPerUnit, "new": req.PointsPerUnit},
+		"redeem_value":         map[string]interface{}{"old": old.Program.RedeemValue, "new": req.RedeemValue},
+		"min_redeem_points":    map[string]interface{}{"old": old.Program.MinRedeemPoints, "new": req.MinRedeemPoints},
+		"expiry_days":          map[string]interface{}{"old": old.Program.ExpiryDays, "new": req.ExpiryDays},
+		"category_multipliers": req.CategoryMultipliers,
+		"tiers":                req.Tiers,
+	}
+	var auditUserID uint
+	if updatedBy != nil {
+		auditUserID = *updatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "loyalty_program", tenantID, "update", changes, "", "")
+
+	return s.GetProgram(tenantID)
+}
+
+// FindMemberByPhone returns the customer registered with the phone number
+func (s *LoyaltyService) FindMemberByPhone(tenantID uint, phone string) (*models.Customer, error) {
+	customerID, err := findCustomerByPhone(s.db, tenantID, phone)
+	if err != nil {
+		return nil, err
+	}
+	var customer models.Customer
+	if err := s.db.Where("id = ?", customerID).First(&customer).Error; err != nil {
+		return nil, err
+	}
+	return &customer, nil
+}
+
+// ListTransactions returns a page of the customer's ledger, newest first
+func (s *LoyaltyService) ListTransactions(customerID, tenantID uint, page, pageSize int) ([]models.LoyaltyTransaction, int64, error) {
+	var transactions []models.LoyaltyTransaction
+	var total int64
+
+	query := s.db.Model(&models.LoyaltyTransaction{}).Where("tenant_id = ? AND customer_id = ?", tenantID, customerID)
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Order("created_at DESC, id DESC").
+		Limit(pageSize).
+		Offset(offset).
+		Find(&transactions).Error; err != nil {
+		return nil, 0, err
+	}
+	return transactions, total, nil
+}
+
+// loyaltyCredit is what is left of a credit entry while a customer's ledger is replayed
+type loyaltyCredit struct {
+	entry     models.LoyaltyTransaction
+	remaining int
+}
+
+// useLoyaltyCredits takes points from the credits that match, in the order given, and returns the
+// points that were not covered
+func useLoyaltyCredits(credits []*loyaltyCredit, points int, match func(*loyaltyCredit) bool) int {
+	for _, credit := range credits {
+		if points == 0 {
+			break
+		}
+		if credit.remaining == 0 || !match(credit) {
+			continue
+		}
+		used := credit.remaining
+		if used > points {
+			used = points
+		}
+		credit.remaining -= used
+		points -= used
+	}
+	return points
+}
+
+// expiredLoyaltyPoints replays a ledger, oldest entry first, and returns the points of credits
+// expired by now that no debit used up. Debits use up the credits expiring soonest first; a reversed
+// earn takes back the earn of its own order first, and an expiry only uses up credits that expire.
+func expiredLoyaltyPoints(entries []models.LoyaltyTransaction, now time.Time) int {
+	var credits []*loyaltyCredit
+	for _, entry := range entries {
+		if entry.Points > 0 {
+			credits = append(credits, &loyaltyCredit{entry: entry, remaining: entry.Points})
+			sort.SliceStable(credits, func(i, j int) bool {
+				a, b := credits[i].entry.ExpiresAt, credits[j].entry.ExpiresAt
+				return a != nil && (b == nil || a.Before(*b))
+			})
+			continue
+		}
+
+		points := -entry.Points
+		switch entry.Type {
+		case LoyaltyExpire:
+			useLoyaltyCredits(credits, points, func(credit *loyaltyCredit) bool {
+				return credit.entry.ExpiresAt != nil
+			})
+		case LoyaltyReverseEarn:
+			points = useLoyaltyCredits(credits, points, func(credit *loyaltyCredit) bool {
+				return credit.entry.Type == LoyaltyEarn && entry.OrderID != nil && credit.entry.OrderID != nil &&
+					*credit.entry.OrderID == *entry.OrderID
+			})
+			useLoyaltyCredits(credits, points, func(*loyaltyCredit) bool { return true })
+		default:
+			useLoyaltyCredits(credits, points, func(*loyaltyCredit) bool { return true })
+		}
+	}
+
+	expired := 0
+	for _, credit := range credits {
+		if credit.entry.ExpiresAt != nil && !credit.entry.ExpiresAt.After(now) {
+			expired += credit.remaining
+		}
+	}
+	return expired
+}
+
+// ExpirePoints writes expire entries for credits past their expiry date. Debits use up the credits
+// expiring soonest first, so what expires is whatever of the expired credits has not been spent yet.
+func (s *LoyaltyService) ExpirePoints(now time.Time) (int, error) {
+	var customerIDs []uint
+	if err := s.db.Model(&models.LoyaltyTransaction{}).
+		Joins("JOIN customers ON customers.id = loyalty_transactions.customer_id").
+		Where("loyalty_transactions.points > 0 AND loyalty_transactions.expires_at <= ? AND customers.loyalty_points > 0", now).
+		Distinct().
+		Pluck("loyalty_transactions.customer_id", &customerIDs).Error; err != nil {
+		return 0, err
+	}
+
+	expired := 0
+	for _, customerID := range customerIDs {
+		err := s.db.Transaction(func(tx *gorm.DB) error {
+			var customer models.Customer
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&customer, customerID).Error; err != nil {
+				return err
+			}
+			var entries []models.LoyaltyTransaction
+			if err := tx.Where("customer_id = ?", customerID).Order("id ASC").Find(&entries).Error; err != nil {
+				return err
+			}
+			points := expiredLoyaltyPoints(entries, now)
+			// Never more than the balance, which a reversal of spent points may have overdrawn
+			if points > customer.LoyaltyPoints {
+				points = customer.LoyaltyPoints
+			}
+			if points <= 0 {
+				return nil
+			}
+			expired++
+			return appendLoyaltyEntry(tx, &models.LoyaltyTransaction{
+				TenantID:    customer.TenantID,
+				CustomerID:  customer.ID,
+				Type:        LoyaltyExpire,
+				Points:      -points,
+				Description: "points expired",
+			})
+		})
+		if err != nil {
+			return expired, err
+		}
+	}
+	return expired, nil
+}
+
+// StartExpiryJob expires points in the background at the given interval
+func (s *LoyaltyService) StartExpiryJob(interval time.Duration) {
+	go func() {
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+		for range ticker.C {
+			if _, err := s.ExpirePoints(time.Now()); err != nil {
+				log.Printf("loyalty: failed to expire points: %v", err)
+			}
+		}
+	}()
+}
+
+// findCustomerByPhone resolves a loyalty member by phone number
+func findCustomerByPhone(tx *gorm.DB, tenantID uint, phone string) (uint, error) {
+	phone = strings.TrimSpace(phone)
+	if phone == "" {
+		return 0, errors.New("phone is required")
+	}
+	var customer models.Customer
+	if err := tx.Select("id").Where("tenant_id = ? AND phone = ?", tenantID, phone).First(&customer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("loyalty member not found")
+		}
+		return 0, err
+	}
+	return customer.ID, nil
+}
+
+// resolveOrderCustomer returns the customer an order is linked to, given either its ID or a member phone
+func resolveOrderCustomer(tx *gorm.DB, tenantID uint, customerID *uint, memberPhone string) (*uint, error) {
+	if customerID == nil && strings.TrimSpace(memberPhone) != "" {
+		id, err := findCustomerByPhone(tx, tenantID, memberPhone)
+		if err != nil {
+			return nil, err
+		}
+		return &id, nil
+	}
+	if err := validateOrderCustomer(tx, tenantID, customerID); err != nil {
+		return nil, err
+	}
+	return customerID, nil
+}
+
+// activeLoyaltyProgram returns the tenant's program, or nil when there is none or it is switched off
+func activeLoyaltyProgram(tx *gorm.DB, tenantID uint) (*models.LoyaltyProgram, error) {
+	var program models.LoyaltyProgram
+	if err := tx.Where("tenant_id = ?", tenantID).First(&program).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if !program.IsActive {
+		return nil, nil
+	}
+	return &program, nil
+}
+
+// loyaltyCreditExpiry returns when points credited now expire under the program
+func loyaltyCreditExpiry(program *models.LoyaltyProgram) *time.Time {
+	if program == nil || program.ExpiryDays <= 0 {
+		return nil
+	}
+	expiresAt := time.Now().AddDate(0, 0, program.ExpiryDays)
+	return &expiresAt
+}
+
+// appendLoyaltyEntry adds a ledger entry and moves the customer's balance, lifetime points and tier
+// with it. Redemptions may not overdraw the balance; reversals may, when the points were already spent.
+func appendLoyaltyEntry(tx *gorm.DB, entry *models.LoyaltyTransaction) error {
+	var customer models.Customer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", entry.CustomerID, entry.TenantID).
+		First(&customer).Error; err != nil {
+		return err
+	}
+
+	balance := customer.LoyaltyPoints + entry.Points
+	if entry.Type == LoyaltyRedeem && balance < 0 {
+		return fmt.Errorf("insufficient loyalty points: balance is %d", customer.LoyaltyPoints)
+	}
+	lifetime := customer.LifetimePoints
+	if entry.Type == LoyaltyEarn || entry.Type == LoyaltyReverseEarn {
+		lifetime += entry.Points
+	}
+
+	entry.BalanceAfter = balance
+	if err := tx.Create(entry).Error; err != nil {
+		return err
+	}
+
+	updates := map[string]interface{}{
+		"loyalty_points":  balance,
+		"lifetime_points": lifetime,
+	}
+	if lifetime != customer.LifetimePoints {
+		var tier models.LoyaltyTier
+		err := tx.Where("tenant_id = ? AND min_points <= ?", customer.TenantID, lifetime).
+			Order("min_points DESC").First(&tier).Error
+		if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+		updates["loyalty_tier"] = tier.Name
+	}
+	return tx.Model(&models.Customer{}).Where("id = ?", customer.ID).Updates(updates).Error
+}
+
+// sumLoyaltyPoints adds up the points of the ledger entries matching the condition
+func sumLoyaltyPoints(tx *gorm.DB, query string, args ...interface{}) (int, error) {
+	var total int
+	err := tx.Model(&models.LoyaltyTransaction{}).Select("COALESCE(SUM(points), 0)").Where(query, args...).Scan(&total).Error
+	return total, err
+}
+
+// sumOrderPayments adds up what was paid on an order, either with points only or without them
+func sumOrderPayments(tx *gorm.DB, orderID uint, loyalty bool) (money.Amount, error) {
+	op := "<>"
+	if loyalty {
+		op = "="
+	}
+	var total money.Amount
+	err := tx.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND status IN ? AND payment_method "+op+" ?", orderID, paidPaymentStatuses, LoyaltyPaymentMethod).
+		Scan(&total).Error
+	return total, err
+}
+
+// loyaltyPointsForAmount returns how many points pay for amount, rounding up
+func loyaltyPointsForAmount(program *models.LoyaltyProgram, amount money.Amount) int {
+	return int((amount + program.RedeemValue - 1) / program.RedeemValue)
+}
+
+// redeemLoyaltyPoints debits the points paying for a loyalty_points payment
+func redeemLoyaltyPoints(tx *gorm.DB, order *models.Order, payment *models.Payment, createdBy *uint) error {
+	if order.CustomerID == nil {
+		return errors.New("order has no loyalty member")
+	}
+	program, err := activeLoyaltyProgram(tx, order.TenantID)
+	if err != nil {
+		return err
+	}
+	if program == nil {
+		return errors.New("loyalty program is not active")
+	}
+
+	points := loyaltyPointsForAmount(program, payment.Amount)
+	if points < program.MinRedeemPoints {
+		return fmt.Errorf("at least %d points must be redeemed", program.MinRedeemPoints)
+	}
+	return appendLoyaltyEntry(tx, &models.LoyaltyTransaction{
+		TenantID:    order.TenantID,
+		CustomerID:  *order.CustomerID,
+		BranchID:    &order.BranchID,
+		OrderID:     &order.ID,
+		PaymentID:   &payment.ID,
+		Type:        LoyaltyRedeem,
+		Points:      -points,
+		Description: fmt.Sprintf("redeemed for order %s", order.OrderNumber),
+		CreatedBy:   createdBy,
+	})
+}
+
+// earnOrderLoyaltyPoints credits the member for a completed order. Each item earns its net amount
+// times the category multiplier, the member's tier multiplier applies on top, and the share of the
+// order paid with points earns nothing. An order earns once.
+func earnOrderLoyaltyPoints(tx *gorm.DB, order *models.Order, changedBy *uint) error {
+	if order.CustomerID == nil || order.TotalAmount <= 0 {
+		return nil
+	}
+	program, err := activeLoyaltyProgram(tx, order.TenantID)
+	if err != nil || program == nil {
+		return err
+	}
+
+	var earned int64
+	if err := tx.Model(&models.LoyaltyTransaction{}).
+		Where("order_id = ? AND type = ?", order.ID, LoyaltyEarn).
+		Count(&earned).Error; err != nil {
+		return err
+	}
+	if earned > 0 {
+		return nil
+	}
+
+	var items []models.OrderItem
+	if err := tx.Preload("Product").Where("order_id = ?", order.ID).Find(&items).Error; err != nil {
+		return err
+	}
+	var multipliers []models.LoyaltyCategoryMultiplier
+	if err := tx.Where("tenant_id = ?", order.TenantID).Find(&multipliers).Error; err != nil {
+		return err
+	}
+	categoryMultiplier := make(map[uint]float64, len(multipliers))
+	for _, m := range multipliers {
+		categoryMultiplier[m.CategoryID] = m.Multiplier
+	}
+
+	var base float64
+	for _, item := range items {
+		multiplier := 1.0
+		if item.Product.CategoryID != nil {
+			if m, ok := categoryMultiplier[*item.Product.CategoryID]; ok {
+				multiplier = m
+			}
+		}
+		base += item.NetAmount.Float64() * multiplier
+	}
+
+	var customer models.Customer
+	if err := tx.Select("id", "loyalty_tier").First(&customer, *order.CustomerID).Error; err != nil {
+		return err
+	}
+	tierMultiplier := 1.0
+	if customer.LoyaltyTier != "" {
+		var tier models.LoyaltyTier
+		if err := tx.Where("tenant_id = ? AND name = ?", order.TenantID, customer.LoyaltyTier).First(&tier).Error; err == nil {
+			tierMultiplier = tier.EarnMultiplier
+		}
+	}
+
+	loyaltyPaid, err := sumOrderPayments(tx, order.ID, true)
+	if err != nil {
+		return err
+	}
+	share := 1 - loyaltyPaid.Float64()/order.TotalAmount.Float64()
+	if share <= 0 {
+		return nil
+	}
+
+	points := int(math.Floor(base*program.PointsPerUnit*tierMultiplier*share + 1e-9))
+	if points <= 0 {
+		return nil
+	}
+	return appendLoyaltyEntry(tx, &models.LoyaltyTransaction{
+		TenantID:    order.TenantID,
+		CustomerID:  *order.CustomerID,
+		BranchID:    &order.BranchID,
+		OrderID:     &order.ID,
+		Type:        LoyaltyEarn,
+		Points:      points,
+		ExpiresAt:   loyaltyCreditExpiry(program),
+		Description: fmt.Sprintf("earned on order %s", order.OrderNumber),
+		CreatedBy:   changedBy,
+	})
+}
+
+// reverseOrderLoyalty takes back the points an order earned and returns the points it redeemed, in
+// proportion to what has been refunded. full reverses everything, for cancelled and voided orders.
+// Earlier reversals are taken into account, so it can run after every refund.
+func reverseOrderLoyalty(tx *gorm.DB, order *models.Order, refundID *uint, full bool, changedBy *uint) error {
+	if order.CustomerID == nil {
+		return nil
+	}
+
+	// Points earned on the order, reversed by the share of the non-points payments refunded
+	earned, err := sumLoyaltyPoints(tx, "order_id = ? AND type = ?", order.ID, LoyaltyEarn)
+	if err != nil {
+		return err
+	}
+	if earned > 0 {
+		reversed, err := sumLoyaltyPoints(tx, "order_id = ? AND type = ?", order.ID, LoyaltyReverseEarn)
+		if err != nil {
+			return err
+		}
+		target := earned
+		if !full {
+			paid, err := sumOrderPayments(tx, order.ID, false)
+			if err != nil {
+				return err
+			}
+			var refunded money.Amount
+			if err := tx.Table("refunds").Select("COALESCE(SUM(refunds.amount), 0)").
+				Joins("JOIN payments ON payments.id = refunds.payment_id").
+				Where("refunds.order_id = ? AND refunds.status = ? AND refunds.deleted_at IS NULL AND payments.payment_method <> ?", order.ID, "completed", LoyaltyPaymentMethod).
+				Scan(&refunded).Error; err != nil {
+				return err
+			}
+			target = 0
+			if paid > 0 {
+				target = int(math.Round(float64(earned) * math.Min(refunded.Float64()/paid.Float64(), 1)))
+			}
+		}
+		if delta := target + reversed; delta > 0 {
+			if err := appendLoyaltyEntry(tx, &models.LoyaltyTransaction{
+				TenantID:    order.TenantID,
+				CustomerID:  *order.CustomerID,
+				BranchID:    &order.BranchID,
+				OrderID:     &order.ID,
+				RefundID:    refundID,
+				Type:        LoyaltyReverseEarn,
+				Points:      -delta,
+				Description: fmt.Sprintf("reversed earning of order %s", order.OrderNumber),
+				CreatedBy:   changedBy,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+
+	// Points redeemed by each loyalty payment, returned by the share of the payment refunded
+	var payments []models.Payment
+	if err := tx.Where("order_id = ? AND payment_method = ? AND status IN ?", order.ID, LoyaltyPaymentMethod, paidPaymentStatuses).
+		Find(&payments).Error; err != nil {
+		return err
+	}
+	if len(payments) == 0 {
+		return nil
+	}
+	program, err := activeLoyaltyProgram(tx, order.TenantID)
+	if err != nil {
+		return err
+	}
+	for _, payment := range payments {
+		redeemed, err := sumLoyaltyPoints(tx, "payment_id = ? AND type = ?", payment.ID, LoyaltyRedeem)
+		if err != nil {
+			return err
+		}
+		returned, err := sumLoyaltyPoints(tx, "payment_id = ? AND type = ?", payment.ID, LoyaltyReverseRedeem)
+		if err != nil {
+			return err
+		}
+		target := -redeemed
+		if !full && payment.Amount > 0 {
+			target = int(math.Round(float64(-redeemed) * math.Min(payment.RefundedAmount.Float64()/payment.Amount.Float64(), 1)))
+		}
+		if delta := target - returned; delta > 0 {
+			paymentID := payment.ID
+			if err := appendLoyaltyEntry(tx, &models.LoyaltyTransaction{
+				TenantID:    order.TenantID,
+				CustomerID:  *order.CustomerID,
+				BranchID:    &order.BranchID,
+				OrderID:     &order.ID,
+				PaymentID:   &paymentID,
+				RefundID:    refundID,
+				Type:        LoyaltyReverseRedeem,
+				Points:      delta,
+				ExpiresAt:   loyaltyCreditExpiry(program),
+				Description: fmt.Sprintf("returned redemption of order %s", order.OrderNumber),
+				CreatedBy:   changedBy,
+			}); err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+// recordLoyaltyStatusChange earns points when an order completes and reverses them when it is
+// cancelled or voided
+func recordLoyaltyStatusChange(tx *gorm.DB, order *models.Order, toStatus string, changedBy *uint) error {
+	switch toStatus {
+	case "completed":
+		return earnOrderLoyaltyPoints(tx, order, changedBy)
+	case "cancelled", "voided":
+		return reverseOrderLoyalty(tx, order, nil, true, changedBy)
+	}
+	return nil
+}
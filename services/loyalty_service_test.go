@@ -0,0 +1,145 @@
+package services
+
+import (
+	"myposcore/models"
+	"testing"
+	"time"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newLoyaltyTestDB opens an in-memory database with the tables a loyalty ledger touches
+func newLoyaltyTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Skipf("sqlite unavailable: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Tenant{}, &models.Customer{}, &models.LoyaltyTier{}, &models.LoyaltyTransaction{}, &models.AuditTrail{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestExpirePointsIgnoresReversalsOfUnexpiredEarnings(t *testing.T) {
+	db := newLoyaltyTestDB(t)
+
+	tenant := models.Tenant{Name: "Tenant"}
+	if err := db.Create(&tenant).Error; err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	customer := models.Customer{TenantID: tenant.ID, Name: "Member", Phone: "0811"}
+	if err := db.Create(&customer).Error; err != nil {
+		t.Fatalf("create customer: %v", err)
+	}
+
+	now := time.Now()
+	expired := now.Add(-time.Hour)
+	later := now.AddDate(0, 0, 30)
+	firstOrder, secondOrder, thirdOrder := uint(1), uint(2), uint(3)
+	ledger := []models.LoyaltyTransaction{
+		// 100 points earned and already expired by an earlier run
+		{Type: LoyaltyEarn, OrderID: &firstOrder, Points: 100, ExpiresAt: &expired},
+		{Type: LoyaltyExpire, Points: -100},
+		// 100 points earned that expired since
+		{Type: LoyaltyEarn, OrderID: &secondOrder, Points: 100, ExpiresAt: &expired},
+		// 50 points earned later and taken back by a refund before they expire
+		{Type: LoyaltyEarn, OrderID: &thirdOrder, Points: 50, ExpiresAt: &later},
+		{Type: LoyaltyReverseEarn, OrderID: &thirdOrder, Points: -50},
+	}
+	for i := range ledger {
+		ledger[i].TenantID = tenant.ID
+		ledger[i].CustomerID = customer.ID
+		if err := appendLoyaltyEntry(db, &ledger[i]); err != nil {
+			t.Fatalf("append %s: %v", ledger[i].Type, err)
+		}
+	}
+
+	service := NewLoyaltyService(db, NewAuditTrailService(db))
+	count, err := service.ExpirePoints(now)
+	if err != nil {
+		t.Fatalf("expire points: %v", err)
+	}
+	if count != 1 {
+		t.Fatalf("customers expired = %d, want 1", count)
+	}
+
+	var entry models.LoyaltyTransaction
+	if err := db.Where("customer_id = ? AND type = ?", customer.ID, LoyaltyExpire).Order("id DESC").First(&entry).Error; err != nil {
+		t.Fatalf("load expire entry: %v", err)
+	}
+	if entry.Points != -100 {
+		t.Fatalf("expired points = %d, want -100", entry.Points)
+	}
+	if err := db.First(&customer, customer.ID).Error; err != nil {
+		t.Fatalf("reload customer: %v", err)
+	}
+	if customer.LoyaltyPoints != 0 {
+		t.Fatalf("balance = %d, want 0", customer.LoyaltyPoints)
+	}
+
+	// Nothing is left to expire on the next run
+	if count, err := service.ExpirePoints(now); err != nil || count != 0 {
+		t.Fatalf("second run = %d, %v, want 0, nil", count, err)
+	}
+}
+
+func TestExpiredLoyaltyPointsSpendsSoonestExpiringFirst(t *testing.T) {
+	now := time.Now()
+	expired := now.Add(-time.Hour)
+	later := now.AddDate(0, 0, 30)
+	orderID := uint(1)
+
+	tests := []struct {
+		name    string
+		entries []models.LoyaltyTransaction
+		want    int
+	}{
+		{
+			name: "redemption uses the expired credit first",
+			entries: []models.LoyaltyTransaction{
+				{Type: LoyaltyEarn, Points: 50, ExpiresAt: &later},
+				{Type: LoyaltyEarn, Points: 100, ExpiresAt: &expired},
+				{Type: LoyaltyRedeem, Points: -30},
+			},
+			want: 70,
+		},
+		{
+			name: "reversed earn takes back its own order",
+			entries: []models.LoyaltyTransaction{
+				{Type: LoyaltyEarn, Points: 100, ExpiresAt: &expired},
+				{Type: LoyaltyEarn, OrderID: &orderID, Points: 50, ExpiresAt: &later},
+				{Type: LoyaltyReverseEarn, OrderID: &orderID, Points: -50},
+			},
+			want: 100,
+		},
+		{
+			name: "points that never expire are spent last",
+			entries: []models.LoyaltyTransaction{
+				{Type: LoyaltyReverseRedeem, Points: 40},
+				{Type: LoyaltyEarn, Points: 100, ExpiresAt: &expired},
+				{Type: LoyaltyRedeem, Points: -60},
+			},
+			want: 40,
+		},
+		{
+			name: "earlier expiry is not counted twice",
+			entries: []models.LoyaltyTransaction{
+				{Type: LoyaltyEarn, Points: 100, ExpiresAt: &expired},
+				{Type: LoyaltyExpire, Points: -100},
+			},
+			want: 0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := expiredLoyaltyPoints(tt.entries, now); got != tt.want {
+				t.Fatalf("expiredLoyaltyPoints = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,170 @@
+package services
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"myposcore/money"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// MockSignatureHeader carries the HMAC-SHA256 of the callback body, hex encoded
+const MockSignatureHeader = "X-Mock-Signature"
+
+// MockPaymentProvider is an in-memory gateway for offline use and tests. Payments stay pending until
+// SimulateCallback completes or fails them, which produces the same signed webhook a real gateway sends.
+type MockPaymentProvider struct {
+	secret  []byte
+	mu      sync.Mutex
+	intents map[string]*mockIntent
+}
+
+type mockIntent struct {
+	Amount   money.Amount
+	Status   string
+	Refunded money.Amount
+}
+
+// mockCallbackBody is the webhook payload of the mock provider
+type mockCallbackBody struct {
+	Reference     string       `json:"reference"`
+	Status        string       `json:"status"`
+	Amount        money.Amount `json:"amount"`
+	FailureReason string       `json:"failure_reason,omitempty"`
+}
+
+func NewMockPaymentProvider(secret string) *MockPaymentProvider {
+	return &MockPaymentProvider{
+		secret:  []byte(secret),
+		intents: make(map[string]*mockIntent),
+	}
+}
+
+func (p *MockPaymentProvider) Name() string {
+	return "mock"
+}
+
+func mockReference(prefix string) string {
+	buf := make([]byte, 6)
+	_, _ = rand.Read(buf)
+	return prefix + "-" + hex.EncodeToString(buf)
+}
+
+func (p *MockPaymentProvider) Initiate(ctx context.Context, req PaymentIntentRequest) (*PaymentIntent, error) {
+	if req.Amount <= 0 {
+		return nil, errors.New("mock: amount must be greater than zero")
+	}
+	reference := mockReference("MOCK")
+	expiresAt := time.Now().Add(15 * time.Minute)
+
+	p.mu.Lock()
+	p.intents[reference] = &mockIntent{Amount: req.Amount, Status: ProviderStatusPending}
+	p.mu.Unlock()
+
+	return &PaymentIntent{
+		Reference: reference,
+		Status:    ProviderStatusPending,
+		ExpiresAt: &expiresAt,
+	}, nil
+}
+
+func (p *MockPaymentProvider) QueryStatus(ctx context.Context, reference string) (*PaymentCallback, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	intent, ok := p.intents[reference]
+	if !ok {
+		return nil, fmt.Errorf("mock: unknown payment reference %s", reference)
+	}
+	return &PaymentCallback{Reference: reference, Status: intent.Status, Amount: intent.Amount}, nil
+}
+
+func (p *MockPaymentProvider) Refund(ctx context.Context, reference string, amount money.Amount, reason string) (*ProviderRefund, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	intent, ok := p.intents[reference]
+	if !ok {
+		return nil, fmt.Errorf("mock: unknown payment reference %s", reference)
+	}
+	if intent.Status != ProviderStatusCompleted {
+		return nil, fmt.Errorf("mock: cannot refund %s payment", intent.Status)
+	}
+	if intent.Refunded+amount > intent.Amount {
+		return nil, errors.New("mock: refund exceeds payment amount")
+	}
+	intent.Refunded += amount
+	return &ProviderRefund{Reference: mockReference("MOCKR"), Status: ProviderStatusCompleted}, nil
+}
+
+func (p *MockPaymentProvider) sign(body []byte) string {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	return hex.EncodeToString(mac.Sum(nil))
+}
+
+func (p *MockPaymentProvider) VerifyCallback(header http.Header, body []byte) (*PaymentCallback, error) {
+	signature, err := hex.DecodeString(header.Get(MockSignatureHeader))
+	if err != nil || len(signature) == 0 {
+		return nil, ErrInvalidCallbackSignature
+	}
+	expected, _ := hex.DecodeString(p.sign(body))
+	if !hmac.Equal(signature, expected) {
+		return nil, ErrInvalidCallbackSignature
+	}
+
+	var payload mockCallbackBody
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, fmt.Errorf("mock: invalid callback body: %w", err)
+	}
+	if payload.Status != ProviderStatusCompleted && payload.Status != ProviderStatusFailed {
+		return nil, fmt.Errorf("mock: invalid callback status %q", payload.Status)
+	}
+	return &PaymentCallback{
+		Reference:     payload.Reference,
+		Status:        payload.Status,
+		Amount:        payload.Amount,
+		FailureReason: payload.FailureReason,
+	}, nil
+}
+
+// SimulateCallback settles a pending mock payment as the customer would at a real gateway and
+// returns the signed webhook request for it
+func (p *MockPaymentProvider) SimulateCallback(reference, status, failureReason string) (http.Header, []byte, error) {
+	if status != ProviderStatusCompleted && status != ProviderStatusFailed {
+		return nil, nil, fmt.Errorf("mock: status must be %s or %s", ProviderStatusCompleted, ProviderStatusFailed)
+	}
+
+	p.mu.Lock()
+	intent, ok := p.intents[reference]
+	if !ok {
+		p.mu.Unlock()
+		return nil, nil, fmt.Errorf("mock: unknown payment reference %s", reference)
+	}
+	if intent.Status != ProviderStatusPending {
+		p.mu.Unlock()
+		return nil, nil, fmt.Errorf("mock: payment is already %s", intent.Status)
+	}
+	intent.Status = status
+	amount := intent.Amount
+	p.mu.Unlock()
+
+	body, err := json.Marshal(mockCallbackBody{
+		Reference:     reference,
+		Status:        status,
+		Amount:        amount,
+		FailureReason: failureReason,
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+	header := http.Header{}
+	header.Set("Content-Type", "application/json")
+	header.Set(MockSignatureHeader, p.sign(body))
+	return header, body, nil
+}
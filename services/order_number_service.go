@@ -0,0 +1,376 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+	"strconv"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// maxOrderNumberReservation caps how many numbers an offline client can reserve at once
+const maxOrderNumberReservation = 1000
+
+type OrderNumberService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewOrderNumberService(db *gorm.DB, auditTrailService *AuditTrailService) *OrderNumberService {
+	return &OrderNumberService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// defaultOrderNumberFormat is used when the tenant has not configured a format
+func defaultOrderNumberFormat(tenantID uint) models.OrderNumberFormat {
+	return models.OrderNumberFormat{
+		TenantID:   tenantID,
+		Prefix:     "ORD",
+		DateFormat: "20060102",
+		Padding:    4,
+		Separator:  "-",
+	}
+}
+
+// GetFormat returns the format used by a branch: its own, the tenant wide one or the default
+func (s *OrderNumberService) GetFormat(tenantID, branchID uint) (*models.OrderNumberFormat, error) {
+	return s.formatFor(s.db, tenantID, branchID)
+}
+
+func (s *OrderNumberService) formatFor(tx *gorm.DB, tenantID, branchID uint) (*models.OrderNumberFormat, error) {
+	var format models.OrderNumberFormat
+	err := tx.Where("tenant_id = ? AND branch_id = ?", tenantID, branchID).First(&format).Error
+	if err == nil {
+		return &format, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = tx.Where("tenant_id = ? AND branch_id IS NULL", tenantID).First(&format).Error
+	if err == nil {
+		return &format, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	format = defaultOrderNumberFormat(tenantID)
+	return &format, nil
+}
+
+// FormatOrderNumber builds the order number for counter n of a branch on the given day
+func FormatOrderNumber(format *models.OrderNumberFormat, branchID uint, date time.Time, n int) string {
+	return orderNumberPrefix(format, branchID, date) + fmt.Sprintf("%0*d", orderNumberPadding(format), n)
+}
+
+// orderNumberPrefix is the part of the order numbers of a branch on the given day before the counter
+func orderNumberPrefix(format *models.OrderNumberFormat, branchID uint, date time.Time) string {
+	var parts []string
+	if format.Prefix != "" {
+		parts = append(parts, format.Prefix)
+	}
+
+	branchCode := format.BranchCode
+	if branchCode == "" {
+		branchCode = fmt.Sprintf("%03d", branchID)
+	}
+	parts = append(parts, branchCode)
+
+	if format.DateFormat != "" {
+		parts = append(parts, date.Format(format.DateFormat))
+	}
+
+	return strings.Join(parts, format.Separator) + format.Separator
+}
+
+// orderNumberPadding is the width of the counter of an order number
+func orderNumberPadding(format *models.OrderNumberFormat) int {
+	if format.Padding < 1 {
+		return 1
+	}
+	return format.Padding
+}
+
+// orderNumberDateIsDaily reports whether a date layout tells every calendar day apart. Only then may
+// the counter restart daily; formats without a daily date part keep one running counter.
+func orderNumberDateIsDaily(layout string) bool {
+	if layout == "" {
+		return false
+	}
+	day := time.Date(2001, 2, 3, 0, 0, 0, 0, time.UTC)
+	for _, other := range []time.Time{day.AddDate(0, 0, 1), day.AddDate(0, 0, 7), day.AddDate(0, 1, 0), day.AddDate(1, 0, 0)} {
+		if day.Format(layout) == other.Format(layout) {
+			return false
+		}
+	}
+	return true
+}
+
+// orderNumberSequenceDate is the counter key of an order number issued on date: the day, or empty
+// for formats whose counter never resets
+func orderNumberSequenceDate(format *models.OrderNumberFormat, date time.Time) string {
+	if !orderNumberDateIsDaily(format.DateFormat) {
+		return ""
+	}
+	return date.Format("2006-01-02")
+}
+
+// nextSequence atomically advances the counter of a branch/day by count and returns the last number
+// issued. The row stays locked until tx ends, so a rolled back order releases its number again.
+func nextSequence(tx *gorm.DB, tenantID, branchID uint, sequenceDate string, count int) (int, error) {
+	var lastNumber int
+	err := tx.Raw(`INSERT INTO order_number_sequences (tenant_id, branch_id, sequence_date, last_number, created_at, updated_at)
+		VALUES (?, ?, ?, ?, NOW(), NOW())
+		ON CONFLICT (tenant_id, branch_id, sequence_date)
+		DO UPDATE SET last_number = order_number_sequences.last_number + EXCLUDED.last_number, updated_at = NOW()
+		RETURNING last_number`, tenantID, branchID, sequenceDate, count).
+		Scan(&lastNumber).Error
+	if err != nil {
+		return 0, err
+	}
+	return lastNumber, nil
+}
+
+// NextOrderNumber issues the next order number of a branch for the day of at in the branch's timezone.
+// It must run inside the transaction creating the order so numbers stay gap-free.
+func (s *OrderNumberService) NextOrderNumber(tx *gorm.DB, tenantID, branchID uint, at time.Time) (string, error) {
+	format, err := s.formatFor(tx, tenantID, branchID)
+	if err != nil {
+		return "", err
+	}
+
+	at = at.In(branchLocation(tx, tenantID, branchID))
+	n, err := nextSequence(tx, tenantID, branchID, orderNumberSequenceDate(format, at), 1)
+	if err != nil {
+		return "", err
+	}
+
+	return FormatOrderNumber(format, branchID, at, n), nil
+}
+
+// ReserveBlock hands a block of consecutive numbers to an offline client so receipts printed offline
+// carry the numbers the server will keep when the orders are synced
+func (s *OrderNumberService) ReserveBlock(tenantID, branchID uint, req dto.ReserveOrderNumbersRequest) (*models.OrderNumberReservation, []string, error) {
+	if req.Count < 1 || req.Count > maxOrderNumberReservation {
+		return nil, nil, fmt.Errorf("count must be between 1 and %d", maxOrderNumberReservation)
+	}
+
+	loc := branchLocation(s.db, tenantID, branchID)
+	date := time.Now().In(loc)
+	if req.Date != "" {
+		parsed, err := time.ParseInLocation("2006-01-02", req.Date, loc)
+		if err != nil {
+			return nil, nil, errors.New("invalid date format, use YYYY-MM-DD")
+		}
+		date = parsed
+	}
+
+	var reservation *models.OrderNumberReservation
+	var numbers []string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		format, err := s.formatFor(tx, tenantID, branchID)
+		if err != nil {
+			return err
+		}
+
+		sequenceDate := orderNumberSequenceDate(format, date)
+		last, err := nextSequence(tx, tenantID, branchID, sequenceDate, req.Count)
+		if err != nil {
+			return err
+		}
+
+		reservation = &models.OrderNumberReservation{
+			TenantID:     tenantID,
+			BranchID:     branchID,
+			ClientID:     req.ClientID,
+			SequenceDate: sequenceDate,
+			StartNumber:  last - req.Count + 1,
+			EndNumber:    last,
+			NumberPrefix: orderNumberPrefix(format, branchID, date),
+			Padding:      orderNumberPadding(format),
+			CreatedBy:    req.CreatedBy,
+		}
+		if err := tx.Create(reservation).Error; err != nil {
+			return err
+		}
+
+		numbers = make([]string, 0, req.Count)
+		for n := reservation.StartNumber; n <= reservation.EndNumber; n++ {
+			numbers = append(numbers, FormatOrderNumber(format, branchID, date, n))
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, nil, err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"client_id":     reservation.ClientID,
+		"sequence_date": reservation.SequenceDate,
+		"start_number":  reservation.StartNumber,
+		"end_number":    reservation.EndNumber,
+		"first":         numbers[0],
+		"last":          numbers[len(numbers)-1],
+	}
+	var auditUserID uint
+	if req.CreatedBy != nil {
+		auditUserID = *req.CreatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, &branchID, auditUserID, "order_number_reservation", reservation.ID, "create", changes, "", "")
+
+	return reservation, numbers, nil
+}
+
+// isReservedNumber reports whether an order number belongs to the reservation. The
+// prefix and padding are kept on the reservation so later format changes don't affect it;
+// reservations made before they were kept use the branch's current format.
+func (s *OrderNumberService) isReservedNumber(tx *gorm.DB, reservation *models.OrderNumberReservation, orderNumber string) (bool, error) {
+	prefix, padding := reservation.NumberPrefix, reservation.Padding
+	if prefix == "" {
+		format, err := s.formatFor(tx, reservation.TenantID, reservation.BranchID)
+		if err != nil {
+			return false, err
+		}
+		loc := branchLocation(tx, reservation.TenantID, reservation.BranchID)
+		date := reservation.CreatedAt.In(loc)
+		if reservation.SequenceDate != "" {
+			if parsed, err := time.ParseInLocation("2006-01-02", reservation.SequenceDate, loc); err == nil {
+				date = parsed
+			}
+		}
+		prefix, padding = orderNumberPrefix(format, reservation.BranchID, date), orderNumberPadding(format)
+	}
+
+	counter := strings.TrimPrefix(orderNumber, prefix)
+	if counter == orderNumber {
+		return false, nil
+	}
+	n, err := strconv.Atoi(counter)
+	if err != nil || fmt.Sprintf("%0*d", padding, n) != counter {
+		return false, nil
+	}
+	return n >= reservation.StartNumber && n <= reservation.EndNumber, nil
+}
+
+// ClaimReservedNumber checks that an order number sent by an offline client was reserved by that
+// client for the branch and is not used by another order yet, and counts it as used on its
+// reservation. It must run inside the transaction creating the order.
+func (s *OrderNumberService) ClaimReservedNumber(tx *gorm.DB, tenantID, branchID uint, clientID, orderNumber string) error {
+	var reservations []models.OrderNumberReservation
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND branch_id = ? AND client_id = ?", tenantID, branchID, clientID).
+		Order("id ASC").Find(&reservations).Error; err != nil {
+		return err
+	}
+
+	for i := range reservations {
+		ok, err := s.isReservedNumber(tx, &reservations[i], orderNumber)
+		if err != nil {
+			return err
+		}
+		if !ok {
+			continue
+		}
+
+		var used int64
+		if err := tx.Model(&models.Order{}).Where("tenant_id = ? AND order_number = ?", tenantID, orderNumber).
+			Count(&used).Error; err != nil {
+			return err
+		}
+		if used > 0 {
+			return fmt.Errorf("order number %s is already used", orderNumber)
+		}
+		return tx.Model(&reservations[i]).Update("used_count", gorm.Expr("used_count + 1")).Error
+	}
+	return fmt.Errorf("order number %s was not reserved by this client for the branch", orderNumber)
+}
+
+// SaveFormat creates or updates the order number format of the tenant, or of one branch
+func (s *OrderNumberService) SaveFormat(tenantID uint, req dto.SaveOrderNumberFormatRequest) (*models.OrderNumberFormat, error) {
+	if req.BranchID != nil {
+		var count int64
+		if err := s.db.Model(&models.Branch{}).Where("id = ? AND tenant_id = ?", *req.BranchID, tenantID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, errors.New("branch not found or doesn't belong to this tenant")
+		}
+	}
+	// A layout without any date element formats to itself
+	if req.DateFormat != "" && time.Date(2001, 2, 3, 4, 5, 6, 0, time.UTC).Format(req.DateFormat) == req.DateFormat {
+		return nil, errors.New("invalid date format, use a Go layout such as 20060102")
+	}
+
+	var format models.OrderNumberFormat
+	query := s.db.Where("tenant_id = ?", tenantID)
+	if req.BranchID != nil {
+		query = query.Where("branch_id = ?", *req.BranchID)
+	} else {
+		query = query.Where("branch_id IS NULL")
+	}
+	err := query.First(&format).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+
+	oldValues := map[string]interface{}{
+		"prefix":      format.Prefix,
+		"branch_code": format.BranchCode,
+		"date_format": format.DateFormat,
+		"padding":     format.Padding,
+		"separator":   format.Separator,
+	}
+
+	format.TenantID = tenantID
+	format.BranchID = req.BranchID
+	format.Prefix = req.Prefix
+	format.BranchCode = req.BranchCode
+	format.DateFormat = req.DateFormat
+	format.Padding = req.Padding
+	format.Separator = req.Separator
+	if isNew {
+		format.CreatedBy = req.UpdatedBy
+	}
+	format.UpdatedBy = req.UpdatedBy
+
+	if err := s.db.Save(&format).Error; err != nil {
+		return nil, err
+	}
+
+	// Create audit trail
+	action := "update"
+	changes := map[string]interface{}{
+		"prefix":      map[string]interface{}{"old": oldValues["prefix"], "new": format.Prefix},
+		"branch_code": map[string]interface{}{"old": oldValues["branch_code"], "new": format.BranchCode},
+		"date_format": map[string]interface{}{"old": oldValues["date_format"], "new": format.DateFormat},
+		"padding":     map[string]interface{}{"old": oldValues["padding"], "new": format.Padding},
+		"separator":   map[string]interface{}{"old": oldValues["separator"], "new": format.Separator},
+	}
+	if isNew {
+		action = "create"
+		changes = map[string]interface{}{
+			"prefix":      format.Prefix,
+			"branch_code": format.BranchCode,
+			"date_format": format.DateFormat,
+			"padding":     format.Padding,
+			"separator":   format.Separator,
+		}
+	}
+	var auditUserID uint
+	if req.UpdatedBy != nil {
+		auditUserID = *req.UpdatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, format.BranchID, auditUserID, "order_number_format", format.ID, action, changes, "", "")
+
+	return &format, nil
+}
@@ -1,31 +1,42 @@
 package services
 
 import (
-	"encoding/json"
 	"errors"
 	"fmt"
+	"myposcore/dto"
 	"myposcore/models"
+	"myposcore/money"
+	"strings"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type OrderService struct {
-	db                *gorm.DB
-	auditTrailService *AuditTrailService
+	db                 *gorm.DB
+	auditTrailService  *AuditTrailService
+	promotionService   *PromotionService
+	taxService         *TaxService
+	orderNumberService *OrderNumberService
+	approvalService    *ApprovalService
 }
 
-func NewOrderService(db *gorm.DB, auditTrailService *AuditTrailService) *OrderService {
+func NewOrderService(db *gorm.DB, auditTrailService *AuditTrailService, promotionService *PromotionService, taxService *TaxService, orderNumberService *OrderNumberService, approvalService *ApprovalService) *OrderService {
 	return &OrderService{
-		db:                db,
-		auditTrailService: auditTrailService,
+		db:                 db,
+		auditTrailService:  auditTrailService,
+		promotionService:   promotionService,
+		taxService:         taxService,
+		orderNumberService: orderNumberService,
+		approvalService:    approvalService,
 	}
 }
 
 func (s *OrderService) CreateOrder(tenantID, branchID, userID uint, createdBy *uint, items []struct {
 	ProductID uint
 	Quantity  int
-}) (*models.Order, error) {
+}, couponCode string, customerID *uint, memberPhone string) (*models.Order, error) {
 	// Start transaction
 	tx := s.db.Begin()
 	defer func() {
@@ -34,6 +45,17 @@ func (s *OrderService) CreateOrder(tenantID, branchID, userID uint, createdBy *u
 		}
 	}()
 
+	if _, err := tenantBranch(tx, tenantID, branchID); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	customerID, err := resolveOrderCustomer(tx, tenantID, customerID, memberPhone)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
 	// Validate all products exist and belong to tenant
 	var products []models.Product
 	productIDs := make([]uint, len(items))
@@ -59,13 +81,18 @@ func (s *OrderService) CreateOrder(tenantID, branchID, userID uint, createdBy *u
 	}
 
 	// Generate order number
-	orderNumber := fmt.Sprintf("ORD-%d-%d", tenantID, time.Now().Unix())
+	orderNumber, err := s.orderNumberService.NextOrderNumber(tx, tenantID, branchID, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
 
 	// Create order
 	order := &models.Order{
 		TenantID:    tenantID,
 		BranchID:    branchID,
 		UserID:      userID,
+		CustomerID:  customerID,
 		OrderNumber: orderNumber,
 		Status:      "pending",
 		CreatedBy:   createdBy,
@@ -76,9 +103,13 @@ func (s *OrderService) CreateOrder(tenantID, branchID, userID uint, createdBy *u
 		return nil, err
 	}
 
-	// Create order items and calculate total
-	var totalAmount float64
-	orderItems := make([]models.OrderItem, len(items))
+	// Check stock at the selling branch and collect lines for pricing
+	stockLevels, err := branchStockLevels(tx, branchID, productIDs)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	lines := make([]PromotionLine, len(items))
 	for i, item := range items {
 		product := productMap[item.ProductID]
 		if product == nil {
@@ -86,25 +117,51 @@ func (s *OrderService) CreateOrder(tenantID, branchID, userID uint, createdBy *u
 			return nil, fmt.Errorf("product ID %d not found", item.ProductID)
 		}
 
-		// Check stock
-		if product.Stock < item.Quantity {
+		// Check stock; gift cards are not stocked
+		if !product.IsGiftCard && stockLevels[item.ProductID] < item.Quantity {
 			tx.Rollback()
 			return nil, fmt.Errorf("insufficient stock for product %s", product.Name)
 		}
 
-		subtotal := product.Price * float64(item.Quantity)
+		lines[i] = PromotionLine{
+			ProductID:  item.ProductID,
+			CategoryID: product.CategoryID,
+			Quantity:   item.Quantity,
+			Price:      product.Price,
+			NoDiscount: product.IsGiftCard,
+		}
+	}
+
+	// Apply automatic promotions and the coupon, if any
+	pricing, err := s.promotionService.ApplyPromotions(tx, tenantID, branchID, lines, couponCode, time.Now())
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Create order items
+	orderItems := make([]models.OrderItem, len(pricing.Lines))
+	taxLines := make([]TaxLine, len(pricing.Lines))
+	for i, line := range pricing.Lines {
 		orderItems[i] = models.OrderItem{
-			OrderID:   order.ID,
-			ProductID: item.ProductID,
-			Quantity:  item.Quantity,
-			Price:     product.Price,
-			Subtotal:  subtotal,
+			OrderID:        order.ID,
+			ProductID:      line.ProductID,
+			Quantity:       line.Quantity,
+			Price:          line.Price,
+			Subtotal:       line.Subtotal,
+			DiscountAmount: line.Discount,
+			NetAmount:      line.Subtotal - line.Discount,
+			PromotionID:    line.PromotionID,
+			UnitCost:       productMap[line.ProductID].CostPrice,
+		}
+		taxLines[i] = TaxLine{
+			CategoryID: productMap[line.ProductID].CategoryID,
+			NetAmount:  orderItems[i].NetAmount,
+			TaxExempt:  productMap[line.ProductID].IsGiftCard,
 		}
-		totalAmount += subtotal
 
-		// Update product stock
-		if err := tx.Model(&models.Product{}).Where("id = ?", item.ProductID).
-			Update("stock", gorm.Expr("stock - ?", item.Quantity)).Error; err != nil {
+		// Take the sold units out of stock
+		if err := recordStockMovement(tx, orderStockMovement(order, line.ProductID, -line.Quantity, "", createdBy)); err != nil {
 			tx.Rollback()
 			return nil, err
 		}
@@ -115,8 +172,47 @@ func (s *OrderService) CreateOrder(tenantID, branchID, userID uint, createdBy *u
 		return nil, err
 	}
 
-	// Update order total
-	order.TotalAmount = totalAmount
+	// Store the discount granted by each promotion
+	if len(pricing.Applied) > 0 {
+		for i := range pricing.Applied {
+			pricing.Applied[i].OrderID = order.ID
+		}
+		if err := tx.Create(&pricing.Applied).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// Apply service charges and taxes of the branch on the discounted lines
+	taxes, err := s.taxService.CalculateTaxes(tx, tenantID, branchID, taxLines)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if len(taxes.Taxes) > 0 {
+		for i := range taxes.Taxes {
+			taxes.Taxes[i].OrderID = order.ID
+		}
+		if err := tx.Create(&taxes.Taxes).Error; err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	if err := recordOrderStatusChange(tx, order, "", order.Status, "", createdBy); err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	// Update order totals
+	order.GrossAmount = pricing.GrossAmount
+	order.DiscountAmount = pricing.DiscountAmount
+	order.SubtotalAmount = taxes.SubtotalAmount
+	order.ServiceChargeAmount = taxes.ServiceChargeAmount
+	order.TaxAmount = taxes.TaxAmount
+	order.InclusiveTaxAmount = taxes.InclusiveTaxAmount
+	order.TotalAmount = taxes.GrandTotal
+	order.CouponCode = strings.ToUpper(strings.TrimSpace(couponCode))
 	if err := tx.Save(order).Error; err != nil {
 		tx.Rollback()
 		return nil, err
@@ -128,7 +224,7 @@ func (s *OrderService) CreateOrder(tenantID, branchID, userID uint, createdBy *u
 	}
 
 	// Load order items with products
-	s.db.Preload("OrderItems.Product").First(order, order.ID)
+	s.db.Preload("OrderItems.Product").Preload("Promotions").Preload("Taxes").First(order, order.ID)
 
 	// Create audit trail
 	orderItemsData := make([]map[string]interface{}, len(orderItems))
@@ -138,13 +234,20 @@ func (s *OrderService) CreateOrder(tenantID, branchID, userID uint, createdBy *u
 			"quantity":   item.Quantity,
 			"price":      item.Price,
 			"subtotal":   item.Subtotal,
+			"discount":   item.DiscountAmount,
 		}
 	}
 	changes := map[string]interface{}{
-		"order_number": order.OrderNumber,
-		"total_amount": order.TotalAmount,
-		"status":       order.Status,
-		"items":        orderItemsData,
+		"order_number":    order.OrderNumber,
+		"gross_amount":    order.GrossAmount,
+		"discount_amount": order.DiscountAmount,
+		"service_charge":  order.ServiceChargeAmount,
+		"tax_amount":      order.TaxAmount,
+		"total_amount":    order.TotalAmount,
+		"coupon_code":     order.CouponCode,
+		"customer_id":     order.CustomerID,
+		"status":          order.Status,
+		"items":           orderItemsData,
 	}
 	var auditUserID uint
 	if createdBy != nil {
@@ -157,7 +260,7 @@ func (s *OrderService) CreateOrder(tenantID, branchID, userID uint, createdBy *u
 
 func (s *OrderService) GetOrder(orderID, tenantID uint) (*models.Order, error) {
 	var order models.Order
-	if err := s.db.Preload("Creator").Preload("Updater").Preload("OrderItems.Product").
+	if err := s.db.Preload("Creator").Preload("Updater").Preload("OrderItems.Product").Preload("Promotions").Preload("Taxes").
 		Where("id = ? AND tenant_id = ?", orderID, tenantID).
 		First(&order).Error; err != nil {
 		return nil, err
@@ -165,14 +268,42 @@ func (s *OrderService) GetOrder(orderID, tenantID uint) (*models.Order, error) {
 	return &order, nil
 }
 
-func (s *OrderService) ListOrders(tenantID, branchID uint, page, perPage int) ([]models.Order, int64, error) {
+// orderListFields maps the shared list filter onto orders
+var orderListFields = listFilterFields{
+	CreatedAt:     "orders.created_at",
+	Status:        "orders.status",
+	PaymentMethod: "EXISTS (SELECT 1 FROM payments WHERE payments.order_id = orders.id AND payments.deleted_at IS NULL AND payments.payment_method IN ?)",
+	UserID:        "orders.user_id",
+	CustomerID:    "orders.customer_id",
+	Amount:        "orders.total_amount",
+	OrderNumber:   "orders.order_number",
+	Sorts: map[string]string{
+		"created_at":   "orders.created_at",
+		"total_amount": "orders.total_amount",
+		"paid_amount":  "orders.paid_amount",
+		"order_number": "orders.order_number",
+		"status":       "orders.status",
+	},
+	DefaultSort: "created_at",
+}
+
+func (s *OrderService) ListOrders(tenantID, branchID uint, filter *dto.ListFilter) ([]models.Order, int64, error) {
 	var orders []models.Order
 	var total int64
 
-	query := s.db.Model(&models.Order{}).Where("tenant_id = ?", tenantID)
+	query := s.db.Model(&models.Order{}).Where("orders.tenant_id = ?", tenantID)
 
 	if branchID > 0 {
-		query = query.Where("branch_id = ?", branchID)
+		query = query.Where("orders.branch_id = ?", branchID)
+	}
+
+	query, err := applyListFilter(s.db, query, tenantID, branchID, filter, orderListFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	orderBy, err := listFilterOrder(filter, orderListFields)
+	if err != nil {
+		return nil, 0, err
 	}
 
 	// Get total count
@@ -181,30 +312,170 @@ func (s *OrderService) ListOrders(tenantID, branchID uint, page, perPage int) ([
 	}
 
 	// Get paginated results
-	offset := (page - 1) * perPage
-	if err := query.Preload("Creator").Preload("Updater").Preload("OrderItems.Product").
-		Order("created_at DESC").
-		Offset(offset).
-		Limit(perPage).
+	if err := query.Preload("Creator").Preload("Updater").Preload("OrderItems.Product").Preload("Promotions").Preload("Taxes").
+		Order(orderBy).
+		Offset(filter.Offset()).
+		Limit(filter.PerPage).
 		Find(&orders).Error; err != nil {
 		return nil, 0, err
 	}
 	return orders, total, nil
 }
 
-func (s *OrderService) UpdateOrderStatus(orderID, tenantID uint, status string) error {
-	// Get old order for audit trail
-	var order models.Order
-	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
-		return err
+// orderStatusTransitions lists the statuses an order may move to from its current status.
+// completed is only reachable from ready here; PaymentService completes orders directly once paid.
+// partially_refunded and refunded are only entered through RefundService.
+var orderStatusTransitions = map[string][]string{
+	"pending":            {"confirmed", "cancelled", "voided"},
+	"confirmed":          {"preparing", "cancelled", "voided"},
+	"preparing":          {"ready", "cancelled", "voided"},
+	"ready":              {"completed", "cancelled", "voided"},
+	"completed":          {"voided"},
+	"cancelled":          {},
+	"voided":             {},
+	"partially_refunded": {},
+	"refunded":           {},
+}
+
+// CanTransitionOrderStatus reports whether an order may move from one status to another
+func CanTransitionOrderStatus(from, to string) bool {
+	for _, next := range orderStatusTransitions[from] {
+		if next == to {
+			return true
+		}
 	}
-	oldStatus := order.Status
+	return false
+}
 
-	if err := s.db.Model(&models.Order{}).
-		Where("id = ? AND tenant_id = ?", orderID, tenantID).
-		Update("status", status).Error; err != nil {
+// orderStatusRestoresStock reports whether moving into status should put sold items back into stock
+func orderStatusRestoresStock(status string) bool {
+	return status == "cancelled" || status == "voided"
+}
+
+// recordOrderStatusChange writes an order_status_history row, the matching kitchen event and any
+// loyalty points and gift cards issued or reversed inside the caller's transaction
+func recordOrderStatusChange(tx *gorm.DB, order *models.Order, fromStatus, toStatus, reason string, changedBy *uint) error {
+	history := &models.OrderStatusHistory{
+		TenantID:   order.TenantID,
+		BranchID:   order.BranchID,
+		OrderID:    order.ID,
+		FromStatus: fromStatus,
+		ToStatus:   toStatus,
+		Reason:     reason,
+		ChangedBy:  changedBy,
+	}
+	if err := tx.Create(history).Error; err != nil {
+		return err
+	}
+	if err := recordKitchenStatusEvent(tx, order, fromStatus, toStatus, reason); err != nil {
+		return err
+	}
+	if err := recordLoyaltyStatusChange(tx, order, toStatus, changedBy); err != nil {
 		return err
 	}
+	return recordGiftCardStatusChange(tx, order, toStatus, changedBy)
+}
+
+// orderStockMovement is a sale movement of quantity units of a product on the order; negative
+// quantities take stock out, positive ones put sold units back
+func orderStockMovement(order *models.Order, productID uint, quantity int, reason string, createdBy *uint) *models.StockMovement {
+	return &models.StockMovement{
+		TenantID:      order.TenantID,
+		BranchID:      order.BranchID,
+		ProductID:     productID,
+		Type:          StockMovementSale,
+		Quantity:      quantity,
+		Reason:        reason,
+		ReferenceType: "order",
+		ReferenceID:   &order.ID,
+		CreatedBy:     createdBy,
+	}
+}
+
+// restoreOrderStock puts the quantities of every item on the order back into product stock
+func restoreOrderStock(tx *gorm.DB, order *models.Order, reason string, changedBy *uint) ([]models.OrderItem, error) {
+	var items []models.OrderItem
+	if err := tx.Where("order_id = ?", order.ID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	for _, item := range items {
+		if err := recordStockMovement(tx, orderStockMovement(order, item.ProductID, item.Quantity, reason, changedBy)); err != nil {
+			return nil, err
+		}
+	}
+
+	return items, nil
+}
+
+// UpdateOrderStatus moves an order through its lifecycle, validating the transition,
+// restoring stock on cancel/void and recording the change in order_status_history
+func (s *OrderService) UpdateOrderStatus(orderID, tenantID uint, status, reason string, approval *dto.SupervisorApproval, updatedBy *uint) (*models.Order, error) {
+	if _, ok := orderStatusTransitions[status]; !ok {
+		return nil, fmt.Errorf("invalid order status: %s", status)
+	}
+	if orderStatusRestoresStock(status) && reason == "" {
+		return nil, errors.New("reason is required when cancelling or voiding an order")
+	}
+
+	var order models.Order
+	var oldStatus string
+	var approved *ApprovedAction
+	var restoredItems []models.OrderItem
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Locked so concurrent changes cannot both pass the transition check and restore stock twice
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("order not found")
+			}
+			return err
+		}
+		oldStatus = order.Status
+
+		if !CanTransitionOrderStatus(oldStatus, status) {
+			return fmt.Errorf("cannot change order status from %s to %s", oldStatus, status)
+		}
+		if status == "completed" && order.PaidAmount < order.TotalAmount {
+			return errors.New("order must be fully paid before it is completed")
+		}
+
+		// Cancelling an order that already took money needs a supervisor
+		if orderStatusRestoresStock(status) && order.PaidAmount > 0 {
+			var err error
+			approved, err = s.approvalService.Verify(tenantID, updatedBy, ApprovalCancelPaidOrder, approval)
+			if err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{
+			"status":  status,
+			"version": gorm.Expr("version + 1"),
+		}
+		if updatedBy != nil {
+			updates["updated_by"] = *updatedBy
+		}
+		if err := tx.Model(&order).Updates(updates).Error; err != nil {
+			return err
+		}
+
+		if orderStatusRestoresStock(status) {
+			items, err := restoreOrderStock(tx, &order, fmt.Sprintf("order %s: %s", status, reason), updatedBy)
+			if err != nil {
+				return err
+			}
+			restoredItems = items
+			if err := returnPromotionUsage(tx, order.ID); err != nil {
+				return err
+			}
+		}
+
+		return recordOrderStatusChange(tx, &order, oldStatus, status, reason, updatedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
 
 	// Create audit trail
 	changes := map[string]interface{}{
@@ -212,11 +483,536 @@ func (s *OrderService) UpdateOrderStatus(orderID, tenantID uint, status string)
 			"old": oldStatus,
 			"new": status,
 		},
+		"reason": reason,
+	}
+	if len(restoredItems) > 0 {
+		restored := make([]map[string]interface{}, len(restoredItems))
+		for i, item := range restoredItems {
+			restored[i] = map[string]interface{}{
+				"product_id": item.ProductID,
+				"quantity":   item.Quantity,
+			}
+		}
+		changes["stock_restored"] = restored
 	}
-	changesJSON, _ := json.Marshal(changes)
-	var changesMap map[string]interface{}
-	_ = json.Unmarshal(changesJSON, &changesMap)
-	_ = s.auditTrailService.CreateAuditTrail(&tenantID, &order.BranchID, 0, "order", orderID, "update", changesMap, "", "")
+	var auditUserID uint
+	if updatedBy != nil {
+		auditUserID = *updatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, &order.BranchID, auditUserID, "order", orderID, "update", changes, "", "")
+
+	if approved != nil {
+		s.approvalService.Record(tenantID, &order.BranchID, approved, "order", orderID, map[string]interface{}{
+			"order_number": order.OrderNumber,
+			"from_status":  oldStatus,
+			"to_status":    status,
+			"paid_amount":  order.PaidAmount,
+			"reason":       reason,
+		})
+	}
+
+	return s.GetOrder(orderID, tenantID)
+}
+
+// GetOrderStatusHistory returns all status transitions of an order, oldest first
+func (s *OrderService) GetOrderStatusHistory(orderID, tenantID uint) ([]models.OrderStatusHistory, error) {
+	var history []models.OrderStatusHistory
+	if err := s.db.Preload("Changer").
+		Where("order_id = ? AND tenant_id = ?", orderID, tenantID).
+		Order("created_at ASC, id ASC").
+		Find(&history).Error; err != nil {
+		return nil, err
+	}
+	return history, nil
+}
+
+// openOrderStatuses lists the statuses in which items can still be added to or removed from an order
+var openOrderStatuses = map[string]bool{
+	"pending":   true,
+	"confirmed": true,
+	"preparing": true,
+	"ready":     true,
+}
+
+// ErrOrderVersionConflict is returned when an order was changed by someone else since it was read
+var ErrOrderVersionConflict = errors.New("version conflict: order was modified by another terminal, reload and try again")
+
+// lockOpenOrder loads an open order and bumps its version, failing when expectedVersion is stale
+func lockOpenOrder(tx *gorm.DB, orderID, tenantID uint, expectedVersion int, updatedBy *uint) (*models.Order, error) {
+	var order models.Order
+	if err := tx.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+	if !openOrderStatuses[order.Status] {
+		return nil, fmt.Errorf("cannot change items of %s order", order.Status)
+	}
+
+	updates := map[string]interface{}{
+		"version": gorm.Expr("version + 1"),
+	}
+	if updatedBy != nil {
+		updates["updated_by"] = *updatedBy
+	}
+	res := tx.Model(&models.Order{}).Where("id = ? AND version = ?", order.ID, expectedVersion).Updates(updates)
+	if res.Error != nil {
+		return nil, res.Error
+	}
+	if res.RowsAffected == 0 {
+		return nil, ErrOrderVersionConflict
+	}
+	order.Version = expectedVersion + 1
+	return &order, nil
+}
 
+// returnPromotionUsage gives back the usage consumed by the promotions applied to an order. The
+// applied promotions stay on the order as a record of its discounts.
+func returnPromotionUsage(tx *gorm.DB, orderID uint) error {
+	var applied []models.OrderPromotion
+	if err := tx.Where("order_id = ?", orderID).Find(&applied).Error; err != nil {
+		return err
+	}
+	for _, promo := range applied {
+		if err := tx.Model(&models.Promotion{}).Where("id = ? AND usage_count > 0", promo.PromotionID).
+			Update("usage_count", gorm.Expr("usage_count - 1")).Error; err != nil {
+			return err
+		}
+	}
 	return nil
 }
+
+// releasePromotionUsage gives back the usage consumed by the promotions applied to an order and removes them
+func releasePromotionUsage(tx *gorm.DB, orderID uint) error {
+	if err := returnPromotionUsage(tx, orderID); err != nil {
+		return err
+	}
+	return tx.Where("order_id = ?", orderID).Delete(&models.OrderPromotion{}).Error
+}
+
+// repriceOrder recalculates promotions, taxes and totals of an order from its current items
+func (s *OrderService) repriceOrder(tx *gorm.DB, order *models.Order) error {
+	var items []models.OrderItem
+	if err := tx.Preload("Product").Where("order_id = ?", order.ID).Order("id ASC").Find(&items).Error; err != nil {
+		return err
+	}
+	if len(items) == 0 {
+		return errors.New("order must have at least one item, cancel the order instead")
+	}
+
+	if err := releasePromotionUsage(tx, order.ID); err != nil {
+		return err
+	}
+
+	lines := make([]PromotionLine, len(items))
+	for i, item := range items {
+		lines[i] = PromotionLine{
+			ProductID:  item.ProductID,
+			CategoryID: item.Product.CategoryID,
+			Quantity:   item.Quantity,
+			Price:      item.Price,
+			NoDiscount: item.Product.IsGiftCard,
+		}
+	}
+
+	pricing, err := s.promotionService.ApplyPromotions(tx, order.TenantID, order.BranchID, lines, order.CouponCode, time.Now())
+	if err != nil {
+		return err
+	}
+	applyManualDiscount(order, pricing)
+
+	taxLines := make([]TaxLine, len(items))
+	for i, line := range pricing.Lines {
+		netAmount := line.Subtotal - line.Discount
+		if err := tx.Model(&models.OrderItem{}).Where("id = ?", items[i].ID).Updates(map[string]interface{}{
+			"subtotal":        line.Subtotal,
+			"discount_amount": line.Discount,
+			"net_amount":      netAmount,
+			"promotion_id":    line.PromotionID,
+		}).Error; err != nil {
+			return err
+		}
+		taxLines[i] = TaxLine{
+			CategoryID: items[i].Product.CategoryID,
+			NetAmount:  netAmount,
+			TaxExempt:  items[i].Product.IsGiftCard,
+		}
+	}
+
+	if len(pricing.Applied) > 0 {
+		for i := range pricing.Applied {
+			pricing.Applied[i].OrderID = order.ID
+		}
+		if err := tx.Create(&pricing.Applied).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderTax{}).Error; err != nil {
+		return err
+	}
+	taxes, err := s.taxService.CalculateTaxes(tx, order.TenantID, order.BranchID, taxLines)
+	if err != nil {
+		return err
+	}
+	if len(taxes.Taxes) > 0 {
+		for i := range taxes.Taxes {
+			taxes.Taxes[i].OrderID = order.ID
+		}
+		if err := tx.Create(&taxes.Taxes).Error; err != nil {
+			return err
+		}
+	}
+
+	if taxes.GrandTotal < order.PaidAmount {
+		return fmt.Errorf("order total cannot be less than the %s already paid", pricing.Currency.Format(order.PaidAmount))
+	}
+
+	order.GrossAmount = pricing.GrossAmount
+	order.DiscountAmount = pricing.DiscountAmount
+	order.SubtotalAmount = taxes.SubtotalAmount
+	order.ServiceChargeAmount = taxes.ServiceChargeAmount
+	order.TaxAmount = taxes.TaxAmount
+	order.InclusiveTaxAmount = taxes.InclusiveTaxAmount
+	order.TotalAmount = taxes.GrandTotal
+	return tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+		"gross_amount":           order.GrossAmount,
+		"discount_amount":        order.DiscountAmount,
+		"subtotal_amount":        order.SubtotalAmount,
+		"service_charge_amount":  order.ServiceChargeAmount,
+		"tax_amount":             order.TaxAmount,
+		"inclusive_tax_amount":   order.InclusiveTaxAmount,
+		"total_amount":           order.TotalAmount,
+		"manual_discount_amount": order.ManualDiscountAmount,
+	}).Error
+}
+
+// applyManualDiscount spreads the manual discount of the order over the lines left after promotions
+// and resolves its amount, which is capped at what remains to be discounted
+func applyManualDiscount(order *models.Order, pricing *PromotionResult) {
+	order.ManualDiscountAmount = 0
+	discountable := discountableAmount(pricing.Lines)
+	if order.ManualDiscountType == "" || order.ManualDiscountValue <= 0 || discountable <= 0 {
+		return
+	}
+
+	amount := order.ManualDiscountValue
+	if order.ManualDiscountType == "percentage" {
+		amount = pricing.Currency.Percent(discountable, order.ManualDiscountValue.Float64())
+	}
+	amount = money.Min(pricing.Currency.Round(amount), discountable)
+
+	allocateOrderDiscount(pricing.Lines, pricing.Currency, amount)
+	order.ManualDiscountAmount = amount
+	pricing.DiscountAmount += amount
+	pricing.NetAmount -= amount
+}
+
+// ApplyManualDiscount sets, changes or removes the manual discount of an open order. Granting a
+// discount needs a supervisor's approval; removing one does not.
+func (s *OrderService) ApplyManualDiscount(orderID, tenantID uint, req dto.ApplyManualDiscountRequest, updatedBy *uint) (*models.Order, error) {
+	removing := req.Value == 0
+	if !removing && req.Type == "" {
+		return nil, errors.New("discount type is required")
+	}
+	if req.Type == "percentage" && req.Value > money.New(100) {
+		return nil, errors.New("percentage discount cannot exceed 100")
+	}
+	if !removing && strings.TrimSpace(req.Reason) == "" {
+		return nil, errors.New("reason is required for a manual discount")
+	}
+
+	var approved *ApprovedAction
+	if !removing {
+		var err error
+		approved, err = s.approvalService.Verify(tenantID, updatedBy, ApprovalManualDiscount, req.Approval)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	var order *models.Order
+	var oldAmount money.Amount
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOpenOrder(tx, orderID, tenantID, req.Version, updatedBy)
+		if err != nil {
+			return err
+		}
+		oldAmount = order.ManualDiscountAmount
+
+		order.ManualDiscountType = req.Type
+		order.ManualDiscountValue = req.Value
+		order.ManualDiscountReason = req.Reason
+		order.ManualDiscountApprovedBy = nil
+		if removing {
+			order.ManualDiscountType = ""
+			order.ManualDiscountReason = ""
+		} else {
+			order.ManualDiscountApprovedBy = &approved.Approver.ID
+		}
+		if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(map[string]interface{}{
+			"manual_discount_type":        order.ManualDiscountType,
+			"manual_discount_value":       order.ManualDiscountValue,
+			"manual_discount_reason":      order.ManualDiscountReason,
+			"manual_discount_approved_by": order.ManualDiscountApprovedBy,
+		}).Error; err != nil {
+			return err
+		}
+
+		return s.repriceOrder(tx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{
+		"manual_discount": map[string]interface{}{
+			"type":   order.ManualDiscountType,
+			"value":  order.ManualDiscountValue,
+			"reason": order.ManualDiscountReason,
+			"old":    oldAmount,
+			"new":    order.ManualDiscountAmount,
+		},
+		"total_amount": order.TotalAmount,
+		"version":      order.Version,
+	}
+	s.auditOrderItemChange(order, updatedBy, changes)
+
+	if approved != nil {
+		s.approvalService.Record(tenantID, &order.BranchID, approved, "order", order.ID, map[string]interface{}{
+			"order_number":    order.OrderNumber,
+			"discount_type":   order.ManualDiscountType,
+			"discount_value":  order.ManualDiscountValue,
+			"discount_amount": order.ManualDiscountAmount,
+			"reason":          order.ManualDiscountReason,
+		})
+	}
+
+	return s.GetOrder(orderID, tenantID)
+}
+
+// adjustProductStock takes delta units of an order's product out of stock (or puts them back when
+// delta is negative)
+func adjustProductStock(tx *gorm.DB, order *models.Order, product *models.Product, delta int, updatedBy *uint) error {
+	return recordStockMovement(tx, orderStockMovement(order, product.ID, -delta, "", updatedBy))
+}
+
+// AddOrderItem adds a product to an open order (tab). A product already on the order has its quantity increased.
+func (s *OrderService) AddOrderItem(orderID, tenantID uint, productID uint, quantity, version int, updatedBy *uint) (*models.Order, error) {
+	var orderItem models.OrderItem
+	var order *models.Order
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOpenOrder(tx, orderID, tenantID, version, updatedBy)
+		if err != nil {
+			return err
+		}
+
+		var product models.Product
+		if err := tx.Where("id = ? AND tenant_id = ? AND is_active = ?", productID, tenantID, true).First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("product not found or inactive")
+			}
+			return err
+		}
+
+		if err := adjustProductStock(tx, order, &product, quantity, updatedBy); err != nil {
+			return err
+		}
+
+		err = tx.Where("order_id = ? AND product_id = ?", order.ID, product.ID).First(&orderItem).Error
+		if err == nil {
+			orderItem.Quantity += quantity
+			if err := tx.Model(&orderItem).Update("quantity", orderItem.Quantity).Error; err != nil {
+				return err
+			}
+		} else if errors.Is(err, gorm.ErrRecordNotFound) {
+			orderItem = models.OrderItem{
+				OrderID:   order.ID,
+				ProductID: product.ID,
+				Quantity:  quantity,
+				Price:     product.Price,
+				Subtotal:  product.Price.Mul(quantity),
+				UnitCost:  product.CostPrice,
+			}
+			if err := tx.Create(&orderItem).Error; err != nil {
+				return err
+			}
+		} else {
+			return err
+		}
+
+		if err := recordKitchenEvent(tx, order, KitchenEventItemAdded, map[string]interface{}{
+			"item":          kitchenItem(&orderItem, product.Name),
+			"quantity_diff": quantity,
+		}); err != nil {
+			return err
+		}
+
+		return s.repriceOrder(tx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{
+		"item_added": map[string]interface{}{
+			"order_item_id": orderItem.ID,
+			"product_id":    productID,
+			"quantity":      quantity,
+		},
+		"total_amount": order.TotalAmount,
+		"version":      order.Version,
+	}
+	s.auditOrderItemChange(order, updatedBy, changes)
+
+	return s.GetOrder(orderID, tenantID)
+}
+
+// UpdateOrderItemQuantity changes the quantity of an item on an open order, adjusting stock by the difference
+func (s *OrderService) UpdateOrderItemQuantity(orderID, tenantID, itemID uint, quantity, version int, approval *dto.SupervisorApproval, updatedBy *uint) (*models.Order, error) {
+	var oldQuantity int
+	var order *models.Order
+	var approved *ApprovedAction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOpenOrder(tx, orderID, tenantID, version, updatedBy)
+		if err != nil {
+			return err
+		}
+
+		var orderItem models.OrderItem
+		if err := tx.Preload("Product").Where("id = ? AND order_id = ?", itemID, order.ID).First(&orderItem).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("order item not found")
+			}
+			return err
+		}
+		oldQuantity = orderItem.Quantity
+
+		// Lowering a quantity voids units and needs a supervisor
+		if quantity < oldQuantity {
+			approved, err = s.approvalService.Verify(tenantID, updatedBy, ApprovalVoidItem, approval)
+			if err != nil {
+				return err
+			}
+		}
+
+		if err := adjustProductStock(tx, order, &orderItem.Product, quantity-oldQuantity, updatedBy); err != nil {
+			return err
+		}
+		if err := tx.Model(&orderItem).Update("quantity", quantity).Error; err != nil {
+			return err
+		}
+		orderItem.Quantity = quantity
+
+		if err := recordKitchenEvent(tx, order, KitchenEventItemUpdated, map[string]interface{}{
+			"item":          kitchenItem(&orderItem, orderItem.Product.Name),
+			"quantity_diff": quantity - oldQuantity,
+		}); err != nil {
+			return err
+		}
+
+		return s.repriceOrder(tx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{
+		"item_quantity": map[string]interface{}{
+			"order_item_id": itemID,
+			"old":           oldQuantity,
+			"new":           quantity,
+		},
+		"total_amount": order.TotalAmount,
+		"version":      order.Version,
+	}
+	s.auditOrderItemChange(order, updatedBy, changes)
+
+	if approved != nil {
+		s.approvalService.Record(tenantID, &order.BranchID, approved, "order_item", itemID, map[string]interface{}{
+			"order_id":        order.ID,
+			"order_number":    order.OrderNumber,
+			"voided_quantity": oldQuantity - quantity,
+		})
+	}
+
+	return s.GetOrder(orderID, tenantID)
+}
+
+// RemoveOrderItem removes an item from an open order and puts its quantity back into stock
+func (s *OrderService) RemoveOrderItem(orderID, tenantID, itemID uint, version int, approval *dto.SupervisorApproval, updatedBy *uint) (*models.Order, error) {
+	var orderItem models.OrderItem
+	var order *models.Order
+	var approved *ApprovedAction
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockOpenOrder(tx, orderID, tenantID, version, updatedBy)
+		if err != nil {
+			return err
+		}
+
+		if err := tx.Preload("Product").Where("id = ? AND order_id = ?", itemID, order.ID).First(&orderItem).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("order item not found")
+			}
+			return err
+		}
+
+		approved, err = s.approvalService.Verify(tenantID, updatedBy, ApprovalVoidItem, approval)
+		if err != nil {
+			return err
+		}
+
+		if err := adjustProductStock(tx, order, &orderItem.Product, -orderItem.Quantity, updatedBy); err != nil {
+			return err
+		}
+		if err := tx.Delete(&orderItem).Error; err != nil {
+			return err
+		}
+
+		if err := recordKitchenEvent(tx, order, KitchenEventItemRemoved, map[string]interface{}{
+			"item":          kitchenItem(&orderItem, orderItem.Product.Name),
+			"quantity_diff": -orderItem.Quantity,
+		}); err != nil {
+			return err
+		}
+
+		return s.repriceOrder(tx, order)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{
+		"item_removed": map[string]interface{}{
+			"order_item_id": orderItem.ID,
+			"product_id":    orderItem.ProductID,
+			"quantity":      orderItem.Quantity,
+		},
+		"total_amount": order.TotalAmount,
+		"version":      order.Version,
+	}
+	s.auditOrderItemChange(order, updatedBy, changes)
+
+	s.approvalService.Record(tenantID, &order.BranchID, approved, "order_item", orderItem.ID, map[string]interface{}{
+		"order_id":        order.ID,
+		"order_number":    order.OrderNumber,
+		"product_id":      orderItem.ProductID,
+		"product_name":    orderItem.Product.Name,
+		"voided_quantity": orderItem.Quantity,
+	})
+
+	return s.GetOrder(orderID, tenantID)
+}
+
+func (s *OrderService) auditOrderItemChange(order *models.Order, updatedBy *uint, changes map[string]interface{}) {
+	var auditUserID uint
+	if updatedBy != nil {
+		auditUserID = *updatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&order.TenantID, &order.BranchID, auditUserID, "order", order.ID, "update", changes, "", "")
+}
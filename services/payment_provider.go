@@ -0,0 +1,150 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"myposcore/money"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+)
+
+// Provider payment statuses, shared by every provider implementation
+const (
+	ProviderStatusPending   = "pending"
+	ProviderStatusCompleted = "completed"
+	ProviderStatusFailed    = "failed"
+)
+
+var (
+	// ErrPaymentProviderNotFound is returned for webhooks and lookups naming an unregistered provider
+	ErrPaymentProviderNotFound = errors.New("payment provider not found")
+	// ErrInvalidCallbackSignature is returned when a provider callback fails signature verification
+	ErrInvalidCallbackSignature = errors.New("invalid callback signature")
+)
+
+// PaymentIntentRequest describes the payment a provider is asked to collect
+type PaymentIntentRequest struct {
+	PaymentID   uint
+	TenantID    uint
+	BranchID    uint
+	OrderNumber string
+	Method      string
+	Amount      money.Amount
+}
+
+// PaymentIntent is the provider's answer to an initiate call. Terminals show ActionURL or QRString
+// to the customer and wait for the payment to leave pending.
+type PaymentIntent struct {
+	Reference string
+	Status    string
+	ActionURL string
+	QRString  string
+	ExpiresAt *time.Time
+}
+
+// PaymentCallback is a verified status notification from a provider
+type PaymentCallback struct {
+	Reference     string
+	Status        string
+	Amount        money.Amount
+	FailureReason string
+}
+
+// ProviderRefund is the provider's answer to a refund call
+type ProviderRefund struct {
+	Reference string
+	Status    string
+}
+
+// PaymentProvider collects non-cash payments asynchronously: a payment is initiated as pending and
+// later completed or failed by the provider's callback (or a status query when the callback is lost).
+type PaymentProvider interface {
+	// Name identifies the provider in webhook URLs and on payments
+	Name() string
+	// Initiate creates the payment at the provider
+	Initiate(ctx context.Context, req PaymentIntentRequest) (*PaymentIntent, error)
+	// QueryStatus asks the provider for the current status of a payment
+	QueryStatus(ctx context.Context, reference string) (*PaymentCallback, error)
+	// Refund returns all or part of a completed payment
+	Refund(ctx context.Context, reference string, amount money.Amount, reason string) (*ProviderRefund, error)
+	// VerifyCallback checks the signature of a webhook call and parses it
+	VerifyCallback(header http.Header, body []byte) (*PaymentCallback, error)
+}
+
+// PaymentProviderRegistry maps payment methods onto the providers that collect them.
+// Methods without a provider complete immediately, as cash always does.
+type PaymentProviderRegistry struct {
+	mu        sync.RWMutex
+	providers map[string]PaymentProvider // name => provider
+	methods   map[string]string          // payment method => provider name
+}
+
+func NewPaymentProviderRegistry() *PaymentProviderRegistry {
+	return &PaymentProviderRegistry{
+		providers: make(map[string]PaymentProvider),
+		methods:   make(map[string]string),
+	}
+}
+
+// synchronousPaymentMethods can never be routed through a provider
+var synchronousPaymentMethods = map[string]bool{
+	"cash":               true,
+	LoyaltyPaymentMethod: true,
+}
+
+// Register adds a provider and routes the given payment methods to it
+func (r *PaymentProviderRegistry) Register(provider PaymentProvider, methods ...string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.providers[provider.Name()] = provider
+	for _, method := range methods {
+		if synchronousPaymentMethods[method] {
+			return fmt.Errorf("payment method %s cannot use a provider", method)
+		}
+		r.methods[method] = provider.Name()
+	}
+	return nil
+}
+
+// AssignMethods routes methods to registered providers from a "method=provider,..." list,
+// e.g. "card=mock,ewallet=mock"
+func (r *PaymentProviderRegistry) AssignMethods(spec string) error {
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+		parts := strings.SplitN(pair, "=", 2)
+		if len(parts) != 2 {
+			return fmt.Errorf("invalid payment provider mapping %q", pair)
+		}
+		provider := r.Provider(strings.TrimSpace(parts[1]))
+		if provider == nil {
+			return fmt.Errorf("%w: %s", ErrPaymentProviderNotFound, strings.TrimSpace(parts[1]))
+		}
+		if err := r.Register(provider, strings.TrimSpace(parts[0])); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Provider returns the provider registered under name, or nil
+func (r *PaymentProviderRegistry) Provider(name string) PaymentProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.providers[name]
+}
+
+// ForMethod returns the provider collecting a payment method, or nil when it completes immediately
+func (r *PaymentProviderRegistry) ForMethod(method string) PaymentProvider {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	if name, ok := r.methods[method]; ok {
+		return r.providers[name]
+	}
+	return nil
+}
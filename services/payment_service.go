@@ -1,27 +1,46 @@
 package services
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
+	"myposcore/dto"
 	"myposcore/models"
+	"myposcore/money"
+	"net/http"
+	"sort"
+	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type PaymentService struct {
 	db                *gorm.DB
 	auditTrailService *AuditTrailService
+	providers         *PaymentProviderRegistry
 }
 
-func NewPaymentService(db *gorm.DB, auditTrailService *AuditTrailService) *PaymentService {
+func NewPaymentService(db *gorm.DB, auditTrailService *AuditTrailService, providers *PaymentProviderRegistry) *PaymentService {
 	return &PaymentService{
 		db:                db,
 		auditTrailService: auditTrailService,
+		providers:         providers,
 	}
 }
 
-func (s *PaymentService) CreatePayment(orderID uint, amount float64, paymentMethod, notes string, tenantID, branchID uint, createdBy *uint) (*models.Payment, error) {
+// paymentProviderTimeout bounds every call to an external payment provider
+const paymentProviderTimeout = 30 * time.Second
+
+// CreatePayment records one tender against an order. An order can be paid with several payments,
+// each with its own method; it completes once the payments cover the total. Only cash may exceed
+// the balance due, the excess is returned as change. loyalty_points payments redeem the member's
+// points for the amount and gift_card payments take it off the card with giftCardCode. Methods
+// routed to a payment provider start out pending and only count towards the order once the
+// provider reports them completed. A tip comes out of the tendered amount first and is kept apart
+// from what is applied to the order.
+func (s *PaymentService) CreatePayment(orderID uint, amount money.Amount, paymentMethod, notes, giftCardCode string, tip dto.PaymentTipRequest, tenantID, branchID uint, createdBy *uint) (*models.Payment, error) {
 	// Verify order exists and belongs to tenant
 	var order models.Order
 	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
@@ -36,14 +55,40 @@ func (s *PaymentService) CreatePayment(orderID uint, amount float64, paymentMeth
 		return nil, errors.New("order already completed")
 	}
 
-	// Check if order is cancelled
-	if order.Status == "cancelled" {
-		return nil, errors.New("cannot pay cancelled order")
+	// Check if order is cancelled, voided or refunded
+	if order.Status == "cancelled" || order.Status == "voided" || order.Status == "partially_refunded" || order.Status == "refunded" {
+		return nil, fmt.Errorf("cannot pay %s order", order.Status)
 	}
 
-	// Validate payment amount
-	if amount < order.TotalAmount {
-		return nil, errors.New("payment amount less than order total")
+	cur, err := tenantCurrency(s.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	amount = cur.Round(amount)
+	if amount <= 0 {
+		return nil, errors.New("payment amount must be greater than zero")
+	}
+	tipAmount, tipUserID, err := resolvePaymentTip(s.db, cur, &order, tip)
+	if err != nil {
+		return nil, err
+	}
+	if tipAmount > 0 && paymentMethod == LoyaltyPaymentMethod {
+		return nil, errors.New("tips cannot be paid with loyalty points")
+	}
+	if tipAmount > 0 && paymentMethod == GiftCardPaymentMethod {
+		return nil, errors.New("tips cannot be paid with a gift card")
+	}
+	if tipAmount >= amount {
+		return nil, fmt.Errorf("payment amount must be greater than the tip %s", cur.Format(tipAmount))
+	}
+	tendered := amount
+	amount -= tipAmount
+
+	var giftCard *models.GiftCard
+	if paymentMethod == GiftCardPaymentMethod {
+		if giftCard, err = findGiftCard(s.db, tenantID, giftCardCode); err != nil {
+			return nil, err
+		}
 	}
 
 	// Start transaction
@@ -54,16 +99,90 @@ func (s *PaymentService) CreatePayment(orderID uint, amount float64, paymentMeth
 		}
 	}()
 
+	// Lock the order so concurrent tenders see each other's payments
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, order.ID).Error; err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+
+	balanceDue, pending, err := orderBalanceDue(tx, &order)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if balanceDue <= 0 {
+		tx.Rollback()
+		if pending > 0 {
+			return nil, errors.New("pending payments already cover the balance due")
+		}
+		return nil, errors.New("order already completed")
+	}
+
+	// Cash can only settle the balance to the smallest denomination in circulation; the difference
+	// is written off as rounding when the cash covers the rest of the order
+	due := balanceDue
+	var rounding money.Amount
+	if paymentMethod == "cash" {
+		due = cur.RoundCash(balanceDue)
+		if amount >= due {
+			rounding = balanceDue - due
+		}
+	}
+
+	// Validate payment amount
+	applied := amount
+	var change money.Amount
+	if amount > due {
+		if paymentMethod != "cash" {
+			tx.Rollback()
+			if tipAmount > 0 {
+				return nil, fmt.Errorf("payment amount exceeds balance due %s plus tip %s", cur.Format(balanceDue), cur.Format(tipAmount))
+			}
+			return nil, fmt.Errorf("payment amount exceeds balance due %s", cur.Format(balanceDue))
+		}
+		applied = due
+		change = amount - due
+	}
+
+	// Cash goes into the cashier's drawer, so it needs an open shift; other tenders are
+	// attached to the shift when there is one for the X/Z reports
+	shift, err := openCashShift(tx, tenantID, branchID, createdBy)
+	if err != nil {
+		tx.Rollback()
+		return nil, err
+	}
+	if shift == nil && paymentMethod == "cash" {
+		tx.Rollback()
+		return nil, ErrNoOpenCashShift
+	}
+
+	provider := s.providers.ForMethod(paymentMethod)
+
 	// Create payment
 	payment := &models.Payment{
-		TenantID:      tenantID,
-		BranchID:      branchID,
-		OrderID:       orderID,
-		Amount:        amount,
-		PaymentMethod: paymentMethod,
-		Status:        "completed",
-		Notes:         notes,
-		CreatedBy:     createdBy,
+		TenantID:       tenantID,
+		BranchID:       branchID,
+		OrderID:        orderID,
+		Amount:         applied,
+		TenderedAmount: tendered,
+		ChangeAmount:   change,
+		RoundingAmount: rounding,
+		TipAmount:      tipAmount,
+		TipUserID:      tipUserID,
+		PaymentMethod:  paymentMethod,
+		Status:         "completed",
+		Notes:          notes,
+		CreatedBy:      createdBy,
+	}
+	if shift != nil {
+		payment.ShiftID = &shift.ID
+	}
+	if giftCard != nil {
+		payment.GiftCardID = &giftCard.ID
+	}
+	if provider != nil {
+		payment.Status = "pending"
+		payment.Provider = provider.Name()
 	}
 
 	if err := tx.Create(payment).Error; err != nil {
@@ -71,12 +190,25 @@ func (s *PaymentService) CreatePayment(orderID uint, amount float64, paymentMeth
 		return nil, err
 	}
 
-	// Update order status
-	if err := tx.Model(&order).Updates(map[string]interface{}{
-		"status": "completed",
-	}).Error; err != nil {
-		tx.Rollback()
-		return nil, err
+	if paymentMethod == LoyaltyPaymentMethod {
+		if err := redeemLoyaltyPoints(tx, &order, payment, createdBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+	if giftCard != nil {
+		if err := redeemGiftCard(tx, &order, payment, createdBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
+	}
+
+	// Update paid amount and complete the order once fully paid
+	if provider == nil {
+		if _, err := applyOrderPayments(tx, &order, "payment received", createdBy); err != nil {
+			tx.Rollback()
+			return nil, err
+		}
 	}
 
 	// Commit transaction
@@ -84,13 +216,26 @@ func (s *PaymentService) CreatePayment(orderID uint, amount float64, paymentMeth
 		return nil, err
 	}
 
+	if provider != nil {
+		if err := s.initiateProviderPayment(provider, payment, &order, createdBy); err != nil {
+			return nil, err
+		}
+	}
+
 	// Create audit trail
 	changes := map[string]interface{}{
-		"order_id":       payment.OrderID,
-		"amount":         payment.Amount,
-		"payment_method": payment.PaymentMethod,
-		"status":         payment.Status,
-		"notes":          payment.Notes,
+		"order_id":        payment.OrderID,
+		"amount":          payment.Amount,
+		"tendered_amount": payment.TenderedAmount,
+		"change_amount":   payment.ChangeAmount,
+		"rounding_amount": payment.RoundingAmount,
+		"tip_amount":      payment.TipAmount,
+		"tip_user_id":     payment.TipUserID,
+		"payment_method":  payment.PaymentMethod,
+		"gift_card_id":    payment.GiftCardID,
+		"status":          payment.Status,
+		"notes":           payment.Notes,
+		"balance_due":     order.TotalAmount - order.PaidAmount,
 	}
 	changesJSON, _ := json.Marshal(changes)
 	var changesMap map[string]interface{}
@@ -104,6 +249,232 @@ func (s *PaymentService) CreatePayment(orderID uint, amount float64, paymentMeth
 	return payment, nil
 }
 
+// initiateProviderPayment creates the payment at its provider. A payment the provider rejects is
+// marked failed so it no longer holds the order's balance.
+func (s *PaymentService) initiateProviderPayment(provider PaymentProvider, payment *models.Payment, order *models.Order, createdBy *uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), paymentProviderTimeout)
+	defer cancel()
+
+	intent, err := provider.Initiate(ctx, PaymentIntentRequest{
+		PaymentID:   payment.ID,
+		TenantID:    payment.TenantID,
+		BranchID:    payment.BranchID,
+		OrderNumber: order.OrderNumber,
+		Method:      payment.PaymentMethod,
+		Amount:      payment.Amount + payment.TipAmount,
+	})
+	if err != nil {
+		payment.Status = "failed"
+		payment.FailureReason = err.Error()
+		_ = s.db.Model(payment).Updates(map[string]interface{}{
+			"status":         payment.Status,
+			"failure_reason": payment.FailureReason,
+		}).Error
+		return fmt.Errorf("payment provider %s: %w", provider.Name(), err)
+	}
+
+	payment.ProviderReference = intent.Reference
+	payment.ProviderActionURL = intent.ActionURL
+	payment.ProviderQRString = intent.QRString
+	payment.ProviderExpiresAt = intent.ExpiresAt
+	if err := s.db.Model(payment).Updates(map[string]interface{}{
+		"provider_reference":  payment.ProviderReference,
+		"provider_action_url": payment.ProviderActionURL,
+		"provider_qr_string":  payment.ProviderQRString,
+		"provider_expires_at": payment.ProviderExpiresAt,
+	}).Error; err != nil {
+		return err
+	}
+
+	// Some providers settle on the spot
+	if intent.Status == ProviderStatusCompleted || intent.Status == ProviderStatusFailed {
+		settled, err := s.settleProviderPayment(payment.ID, intent.Status, "", createdBy)
+		if err != nil {
+			return err
+		}
+		*payment = *settled
+	}
+	return nil
+}
+
+// settleProviderPayment moves a pending provider payment to completed or failed. A completed payment
+// counts towards the order, which completes once fully paid. Settling twice is a no-op, so repeated
+// webhooks are harmless.
+func (s *PaymentService) settleProviderPayment(paymentID uint, status, failureReason string, changedBy *uint) (*models.Payment, error) {
+	var payment models.Payment
+	if err := s.db.First(&payment, paymentID).Error; err != nil {
+		return nil, err
+	}
+
+	settled := false
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the order before the payment, in the same order as CreatePayment
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, payment.OrderID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+			return err
+		}
+		if payment.Status != "pending" {
+			return nil
+		}
+
+		payment.Status = status
+		payment.FailureReason = failureReason
+		updates := map[string]interface{}{
+			"status":         payment.Status,
+			"failure_reason": payment.FailureReason,
+		}
+		if changedBy != nil {
+			updates["updated_by"] = *changedBy
+		}
+		if err := tx.Model(&payment).Updates(updates).Error; err != nil {
+			return err
+		}
+		settled = true
+
+		if status == ProviderStatusCompleted {
+			if _, err := applyOrderPayments(tx, &order, "payment completed by "+payment.Provider, changedBy); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if settled {
+		changes := map[string]interface{}{
+			"provider":           payment.Provider,
+			"provider_reference": payment.ProviderReference,
+			"status":             map[string]interface{}{"old": "pending", "new": payment.Status},
+			"failure_reason":     payment.FailureReason,
+		}
+		var auditUserID uint
+		if changedBy != nil {
+			auditUserID = *changedBy
+		}
+		_ = s.auditTrailService.CreateAuditTrail(&payment.TenantID, &payment.BranchID, auditUserID, "payment", payment.ID, "update", changes, "", "")
+	}
+	return &payment, nil
+}
+
+// HandleProviderCallback verifies and applies a provider's webhook call
+func (s *PaymentService) HandleProviderCallback(providerName string, header http.Header, body []byte) (*models.Payment, error) {
+	provider := s.providers.Provider(providerName)
+	if provider == nil {
+		return nil, ErrPaymentProviderNotFound
+	}
+
+	callback, err := provider.VerifyCallback(header, body)
+	if err != nil {
+		return nil, err
+	}
+
+	var payment models.Payment
+	if err := s.db.Where("provider = ? AND provider_reference = ?", providerName, callback.Reference).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("payment not found")
+		}
+		return nil, err
+	}
+	// The provider charged the tip together with the payment
+	charged := payment.Amount + payment.TipAmount
+	if callback.Amount > 0 && callback.Amount != charged {
+		return nil, fmt.Errorf("callback amount %s does not match payment amount %s", callback.Amount, charged)
+	}
+	if callback.Status == ProviderStatusPending {
+		return &payment, nil
+	}
+	return s.settleProviderPayment(payment.ID, callback.Status, callback.FailureReason, nil)
+}
+
+// RefreshPaymentStatus asks the provider for the status of a pending payment, for when its
+// callback is late or lost
+func (s *PaymentService) RefreshPaymentStatus(paymentID, tenantID uint, changedBy *uint) (*models.Payment, error) {
+	payment, err := s.GetPayment(paymentID, tenantID)
+	if err != nil {
+		return nil, errors.New("payment not found")
+	}
+	if payment.Provider == "" || payment.Status != "pending" {
+		return payment, nil
+	}
+	provider := s.providers.Provider(payment.Provider)
+	if provider == nil {
+		return nil, ErrPaymentProviderNotFound
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), paymentProviderTimeout)
+	defer cancel()
+	status, err := provider.QueryStatus(ctx, payment.ProviderReference)
+	if err != nil {
+		return nil, fmt.Errorf("payment provider %s: %w", provider.Name(), err)
+	}
+	if status.Status == ProviderStatusPending {
+		return payment, nil
+	}
+	if _, err := s.settleProviderPayment(payment.ID, status.Status, status.FailureReason, changedBy); err != nil {
+		return nil, err
+	}
+	return s.GetPayment(paymentID, tenantID)
+}
+
+// paidPaymentStatuses lists the payment statuses that count towards what was paid on an order
+var paidPaymentStatuses = []string{"completed", "partially_refunded", "refunded"}
+
+// orderBalanceDue returns what is left to pay on an order. Pending provider payments hold their
+// share of the balance until they settle, so they are returned separately as well.
+func orderBalanceDue(tx *gorm.DB, order *models.Order) (money.Amount, money.Amount, error) {
+	var pending money.Amount
+	if err := tx.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").
+		Where("order_id = ? AND status = ?", order.ID, "pending").
+		Scan(&pending).Error; err != nil {
+		return 0, 0, err
+	}
+	return order.TotalAmount - order.PaidAmount - pending, pending, nil
+}
+
+// applyOrderPayments recalculates the paid amount of an order from its payments and completes the
+// order once the payments cover its total, unless it was already closed. Cash rounding written off counts as paid. It returns true
+// when the order was completed by this call.
+func applyOrderPayments(tx *gorm.DB, order *models.Order, reason string, changedBy *uint) (bool, error) {
+	var paid money.Amount
+	if err := tx.Model(&models.Payment{}).
+		Select("COALESCE(SUM(amount + rounding_amount), 0)").
+		Where("order_id = ? AND status IN ?", order.ID, paidPaymentStatuses).
+		Scan(&paid).Error; err != nil {
+		return false, err
+	}
+	order.PaidAmount = paid
+
+	updates := map[string]interface{}{
+		"paid_amount": order.PaidAmount,
+	}
+	if changedBy != nil {
+		updates["updated_by"] = *changedBy
+	}
+
+	oldStatus := order.Status
+	completed := order.PaidAmount >= order.TotalAmount && openOrderStatuses[oldStatus]
+	if completed {
+		order.Status = "completed"
+		updates["status"] = order.Status
+	}
+
+	if err := tx.Model(&models.Order{}).Where("id = ?", order.ID).Updates(updates).Error; err != nil {
+		return false, err
+	}
+
+	if completed {
+		if err := recordOrderStatusChange(tx, order, oldStatus, order.Status, reason, changedBy); err != nil {
+			return false, err
+		}
+	}
+	return completed, nil
+}
+
 func (s *PaymentService) GetPaymentWithDetails(paymentID, tenantID uint) (*models.Payment, *models.Order, error) {
 	var payment models.Payment
 	if err := s.db.Joins("JOIN orders ON orders.id = payments.order_id").
@@ -115,6 +486,7 @@ func (s *PaymentService) GetPaymentWithDetails(paymentID, tenantID uint) (*model
 	// Get order with all related data
 	var order models.Order
 	if err := s.db.Preload("OrderItems.Product").
+		Preload("Taxes").
 		Preload("User").
 		Preload("Branch").
 		Where("id = ?", payment.OrderID).
@@ -125,14 +497,24 @@ func (s *PaymentService) GetPaymentWithDetails(paymentID, tenantID uint) (*model
 	return &payment, &order, nil
 }
 
-func (s *PaymentService) GetPaymentsByOrder(orderID, tenantID uint) ([]models.Payment, error) {
+// GetPaymentsByOrder returns the payments of an order, oldest first, together with the order
+// so callers can show the running balance
+func (s *PaymentService) GetPaymentsByOrder(orderID, tenantID uint) ([]models.Payment, *models.Order, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, errors.New("order not found")
+		}
+		return nil, nil, err
+	}
+
 	var payments []models.Payment
-	if err := s.db.Joins("JOIN orders ON orders.id = payments.order_id").
-		Where("payments.order_id = ? AND orders.tenant_id = ?", orderID, tenantID).
+	if err := s.db.Where("order_id = ?", order.ID).
+		Order("created_at ASC, id ASC").
 		Find(&payments).Error; err != nil {
-		return nil, err
+		return nil, nil, err
 	}
-	return payments, nil
+	return payments, &order, nil
 }
 
 func (s *PaymentService) GetPayment(paymentID, tenantID uint) (*models.Payment, error) {
@@ -145,7 +527,26 @@ func (s *PaymentService) GetPayment(paymentID, tenantID uint) (*models.Payment,
 	return &payment, nil
 }
 
-func (s *PaymentService) ListPayments(tenantID, branchID uint, page, perPage int) ([]models.Payment, int64, error) {
+// paymentListFields maps the shared list filter onto payments; user, customer and order number refer to the order
+var paymentListFields = listFilterFields{
+	CreatedAt:     "payments.created_at",
+	Status:        "payments.status",
+	PaymentMethod: "payments.payment_method IN ?",
+	UserID:        "orders.user_id",
+	CustomerID:    "orders.customer_id",
+	Amount:        "payments.amount",
+	OrderNumber:   "orders.order_number",
+	Sorts: map[string]string{
+		"created_at":     "payments.created_at",
+		"amount":         "payments.amount",
+		"payment_method": "payments.payment_method",
+		"status":         "payments.status",
+		"order_number":   "orders.order_number",
+	},
+	DefaultSort: "created_at",
+}
+
+func (s *PaymentService) ListPayments(tenantID, branchID uint, filter *dto.ListFilter) ([]models.Payment, int64, error) {
 	var payments []models.Payment
 	var total int64
 
@@ -157,28 +558,37 @@ func (s *PaymentService) ListPayments(tenantID, branchID uint, page, perPage int
 		query = query.Where("orders.branch_id = ?", branchID)
 	}
 
+	query, err := applyListFilter(s.db, query, tenantID, branchID, filter, paymentListFields)
+	if err != nil {
+		return nil, 0, err
+	}
+	orderBy, err := listFilterOrder(filter, paymentListFields)
+	if err != nil {
+		return nil, 0, err
+	}
+
 	// Get total count
 	if err := query.Count(&total).Error; err != nil {
 		return nil, 0, err
 	}
 
 	// Get paginated results
-	offset := (page - 1) * perPage
-	if err := query.Preload("Creator").Preload("Updater").Order("payments.created_at DESC").
-		Offset(offset).
-		Limit(perPage).
+	if err := query.Preload("Creator").Preload("Updater").Order(orderBy).
+		Offset(filter.Offset()).
+		Limit(filter.PerPage).
 		Find(&payments).Error; err != nil {
 		return nil, 0, err
 	}
 	return payments, total, nil
 }
 
-// GetPaymentPerformance returns daily payment statistics for the last N days
+// GetPaymentPerformance returns daily payment statistics for the last N days. Refunds are counted on
+// the day they were made and subtracted from the gross amount.
 func (s *PaymentService) GetPaymentPerformance(tenantID, branchID uint, days int) ([]map[string]interface{}, error) {
 	type DailyStats struct {
-		Date        string  `json:"date"`
-		Qty         int     `json:"qty"`
-		TotalAmount float64 `json:"total_amount"`
+		Date        string       `json:"date"`
+		Qty         int          `json:"qty"`
+		TotalAmount money.Amount `json:"total_amount"`
 	}
 
 	var results []DailyStats
@@ -189,7 +599,7 @@ func (s *PaymentService) GetPaymentPerformance(tenantID, branchID uint, days int
 	query := s.db.Table("payments").
 		Select("DATE(payments.created_at) as date, COUNT(*) as qty, SUM(payments.amount) as total_amount").
 		Joins("JOIN orders ON orders.id = payments.order_id").
-		Where("orders.tenant_id = ? AND payments.status = 'completed' AND "+intervalCondition, tenantID).
+		Where("orders.tenant_id = ? AND payments.status IN ('completed', 'partially_refunded', 'refunded') AND "+intervalCondition, tenantID).
 		Group("DATE(payments.created_at)").
 		Order("date ASC")
 
@@ -201,13 +611,52 @@ func (s *PaymentService) GetPaymentPerformance(tenantID, branchID uint, days int
 		return nil, err
 	}
 
+	var refundResults []DailyStats
+	refundQuery := s.db.Table("refunds").
+		Select("DATE(refunds.created_at) as date, COUNT(*) as qty, SUM(refunds.amount) as total_amount").
+		Where("refunds.tenant_id = ? AND refunds.status = 'completed' AND refunds.deleted_at IS NULL", tenantID).
+		Where(fmt.Sprintf("refunds.created_at >= CURRENT_DATE - INTERVAL '%d days'", days)).
+		Group("DATE(refunds.created_at)").
+		Order("date ASC")
+
+	if branchID > 0 {
+		refundQuery = refundQuery.Where("refunds.branch_id = ?", branchID)
+	}
+
+	if err := refundQuery.Scan(&refundResults).Error; err != nil {
+		return nil, err
+	}
+
+	// Merge refunds into the payment days, adding days that only had refunds
+	refundsByDate := make(map[string]DailyStats, len(refundResults))
+	for _, refund := range refundResults {
+		refundsByDate[refund.Date] = refund
+	}
+	for _, refund := range refundResults {
+		found := false
+		for _, result := range results {
+			if result.Date == refund.Date {
+				found = true
+				break
+			}
+		}
+		if !found {
+			results = append(results, DailyStats{Date: refund.Date})
+		}
+	}
+	sort.Slice(results, func(i, j int) bool { return results[i].Date < results[j].Date })
+
 	// Convert to map array for flexible JSON response
 	performance := make([]map[string]interface{}, len(results))
 	for i, result := range results {
+		refund := refundsByDate[result.Date]
 		performance[i] = map[string]interface{}{
-			"date":         result.Date,
-			"qty":          result.Qty,
-			"total_amount": result.TotalAmount,
+			"date":          result.Date,
+			"qty":           result.Qty,
+			"total_amount":  result.TotalAmount,
+			"refund_qty":    refund.Qty,
+			"refund_amount": refund.TotalAmount,
+			"net_amount":    result.TotalAmount - refund.TotalAmount,
 		}
 	}
 
@@ -0,0 +1,97 @@
+package services
+
+import (
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"testing"
+
+	"gorm.io/driver/sqlite"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// newPaymentTestDB opens an in-memory database with the tables a payment touches
+func newPaymentTestDB(t *testing.T) *gorm.DB {
+	t.Helper()
+	db, err := gorm.Open(sqlite.Open("file::memory:"), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Skipf("sqlite unavailable: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.Tenant{}, &models.Branch{}, &models.User{}, &models.Category{}, &models.Product{}, &models.Customer{},
+		&models.Order{}, &models.OrderItem{}, &models.OrderStatusHistory{}, &models.KitchenEvent{},
+		&models.Payment{}, &models.CashShift{}, &models.TipPreset{},
+		&models.LoyaltyProgram{}, &models.LoyaltyTransaction{}, &models.GiftCard{}, &models.GiftCardTransaction{},
+		&models.AuditTrail{},
+	); err != nil {
+		t.Fatalf("migrate: %v", err)
+	}
+	return db
+}
+
+func TestTippedProviderPaymentSettlesThroughCallback(t *testing.T) {
+	db := newPaymentTestDB(t)
+
+	tenant := models.Tenant{Name: "Tenant"}
+	if err := db.Create(&tenant).Error; err != nil {
+		t.Fatalf("create tenant: %v", err)
+	}
+	branch := models.Branch{TenantID: tenant.ID, Name: "Branch"}
+	if err := db.Create(&branch).Error; err != nil {
+		t.Fatalf("create branch: %v", err)
+	}
+	cashier := models.User{TenantID: tenant.ID, BranchID: &branch.ID, Email: "cashier@example.com", Password: "x", FullName: "Cashier", Role: "staff", IsActive: true}
+	if err := db.Create(&cashier).Error; err != nil {
+		t.Fatalf("create user: %v", err)
+	}
+	order := models.Order{
+		TenantID:       tenant.ID,
+		BranchID:       branch.ID,
+		UserID:         cashier.ID,
+		OrderNumber:    "ORD-001-0001",
+		GrossAmount:    money.New(100000),
+		SubtotalAmount: money.New(100000),
+		TotalAmount:    money.New(100000),
+		Status:         "pending",
+	}
+	if err := db.Create(&order).Error; err != nil {
+		t.Fatalf("create order: %v", err)
+	}
+
+	mock := NewMockPaymentProvider("test-secret")
+	providers := NewPaymentProviderRegistry()
+	if err := providers.Register(mock, "card"); err != nil {
+		t.Fatalf("register provider: %v", err)
+	}
+	service := NewPaymentService(db, NewAuditTrailService(db), providers)
+
+	payment, err := service.CreatePayment(order.ID, money.New(110000), "card", "", "",
+		dto.PaymentTipRequest{TipAmount: money.New(10000)}, tenant.ID, branch.ID, &cashier.ID)
+	if err != nil {
+		t.Fatalf("create payment: %v", err)
+	}
+	if payment.Status != "pending" || payment.Amount != money.New(100000) || payment.TipAmount != money.New(10000) {
+		t.Fatalf("payment = %s amount %s tip %s, want pending 100000 tip 10000", payment.Status, payment.Amount, payment.TipAmount)
+	}
+
+	// The gateway charged payment and tip together and reports the full amount back
+	header, body, err := mock.SimulateCallback(payment.ProviderReference, ProviderStatusCompleted, "")
+	if err != nil {
+		t.Fatalf("simulate callback: %v", err)
+	}
+	settled, err := service.HandleProviderCallback(mock.Name(), header, body)
+	if err != nil {
+		t.Fatalf("handle callback: %v", err)
+	}
+	if settled.Status != "completed" {
+		t.Fatalf("payment status = %s, want completed", settled.Status)
+	}
+
+	if err := db.First(&order, order.ID).Error; err != nil {
+		t.Fatalf("reload order: %v", err)
+	}
+	if order.Status != "completed" || order.PaidAmount != money.New(100000) {
+		t.Fatalf("order = %s paid %s, want completed paid 100000", order.Status, order.PaidAmount)
+	}
+}
@@ -21,7 +21,9 @@ func NewProductService(auditTrailService *AuditTrailService) *ProductService {
 	}
 }
 
-func (s *ProductService) ListProducts(tenantID uint, search string, page, pageSize int) ([]models.Product, int64, error) {
+// ListProducts returns one page of the tenant's products with their stock at the branch, and at
+// every branch when includeBranches is set
+func (s *ProductService) ListProducts(tenantID, branchID uint, search string, includeBranches bool, page, pageSize int) ([]models.Product, int64, error) {
 	var products []models.Product
 	var total int64
 
@@ -48,11 +50,14 @@ func (s *ProductService) ListProducts(tenantID uint, search string, page, pageSi
 	if err := query2.Order("name ASC").Limit(pageSize).Offset(offset).Find(&products).Error; err != nil {
 		return nil, 0, err
 	}
+	if err := s.loadBranchStock(products, branchID, includeBranches); err != nil {
+		return nil, 0, err
+	}
 	return products, total, nil
 }
 
-// ListProductsByCategoryID returns paginated products filtered by category_id
-func (s *ProductService) ListProductsByCategoryID(tenantID, categoryID uint, search string, page, pageSize int) ([]models.Product, int64, error) {
+// ListProductsByCategoryID returns paginated products filtered by category_id, with their stock like ListProducts
+func (s *ProductService) ListProductsByCategoryID(tenantID, categoryID, branchID uint, search string, includeBranches bool, page, pageSize int) ([]models.Product, int64, error) {
 	var products []models.Product
 	var total int64
 
@@ -77,6 +82,9 @@ func (s *ProductService) ListProductsByCategoryID(tenantID, categoryID uint, sea
 		Find(&products).Error; err != nil {
 		return nil, 0, err
 	}
+	if err := s.loadBranchStock(products, branchID, includeBranches); err != nil {
+		return nil, 0, err
+	}
 
 	return products, total, nil
 }
@@ -93,7 +101,56 @@ func (s *ProductService) GetCategories(tenantID uint) ([]string, error) {
 	return categories, nil
 }
 
-func (s *ProductService) GetProduct(id, tenantID uint) (*models.Product, error) {
+// GetProduct returns a product with its stock at the branch, and at every branch when includeBranches is set
+func (s *ProductService) GetProduct(id, tenantID, branchID uint, includeBranches bool) (*models.Product, error) {
+	product, err := s.findProduct(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	products := []models.Product{*product}
+	if err := s.loadBranchStock(products, branchID, includeBranches); err != nil {
+		return nil, err
+	}
+	return &products[0], nil
+}
+
+// loadBranchStock sets BranchStock of the products to their stock at the branch, and fills
+// BranchStocks with their stock at every branch when includeBranches is set
+func (s *ProductService) loadBranchStock(products []models.Product, branchID uint, includeBranches bool) error {
+	if len(products) == 0 {
+		return nil
+	}
+	productIDs := make([]uint, len(products))
+	for i := range products {
+		productIDs[i] = products[i].ID
+	}
+
+	levels, err := branchStockLevels(s.db, branchID, productIDs)
+	if err != nil {
+		return err
+	}
+	for i := range products {
+		products[i].BranchStock = levels[products[i].ID]
+	}
+	if !includeBranches {
+		return nil
+	}
+
+	var stocks []models.ProductStock
+	if err := s.db.Preload("Branch").Where("product_id IN ?", productIDs).Order("branch_id ASC").Find(&stocks).Error; err != nil {
+		return err
+	}
+	byProduct := make(map[uint][]models.ProductStock, len(products))
+	for _, stock := range stocks {
+		byProduct[stock.ProductID] = append(byProduct[stock.ProductID], stock)
+	}
+	for i := range products {
+		products[i].BranchStocks = byProduct[products[i].ID]
+	}
+	return nil
+}
+
+func (s *ProductService) findProduct(id, tenantID uint) (*models.Product, error) {
 	var product models.Product
 	if err := s.db.Preload("Creator").Preload("Updater").Preload("CategoryDetail").Where("id = ? AND tenant_id = ?", id, tenantID).First(&product).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
@@ -104,7 +161,8 @@ func (s *ProductService) GetProduct(id, tenantID uint) (*models.Product, error)
 	return &product, nil
 }
 
-func (s *ProductService) CreateProduct(tenantID uint, req dto.CreateProductRequest) (*models.Product, error) {
+// CreateProduct creates a product; its opening stock is recorded as an adjustment at the branch
+func (s *ProductService) CreateProduct(tenantID, branchID uint, req dto.CreateProductRequest) (*models.Product, error) {
 	product := models.Product{
 		TenantID:    tenantID,
 		Name:        req.Name,
@@ -112,12 +170,41 @@ func (s *ProductService) CreateProduct(tenantID uint, req dto.CreateProductReque
 		CategoryID:  req.CategoryID,
 		SKU:         req.SKU,
 		Price:       req.Price,
-		Stock:       req.Stock,
 		IsActive:    req.IsActive,
 		CreatedBy:   req.CreatedBy,
+
+		IsGiftCard:           req.IsGiftCard,
+		GiftCardValidityDays: req.GiftCardValidityDays,
 	}
 
-	if err := s.db.Create(&product).Error; err != nil {
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&product).Error; err != nil {
+			return err
+		}
+		if req.Stock != 0 && !product.IsGiftCard {
+			if _, err := tenantBranch(tx, tenantID, branchID); err != nil {
+				return err
+			}
+		}
+		movement := &models.StockMovement{
+			TenantID:      tenantID,
+			BranchID:      branchID,
+			ProductID:     product.ID,
+			Type:          StockMovementAdjustment,
+			Quantity:      req.Stock,
+			Reason:        "opening stock",
+			ReferenceType: "product",
+			ReferenceID:   &product.ID,
+			CreatedBy:     req.CreatedBy,
+		}
+		if err := recordStockMovement(tx, movement); err != nil {
+			return err
+		}
+		product.Stock = req.Stock
+		product.BranchStock = req.Stock
+		return nil
+	})
+	if err != nil {
 		return nil, err
 	}
 
@@ -131,17 +218,23 @@ func (s *ProductService) CreateProduct(tenantID uint, req dto.CreateProductReque
 		"stock":       product.Stock,
 		"is_active":   product.IsActive,
 	}
+	if product.IsGiftCard {
+		changes["is_gift_card"] = product.IsGiftCard
+		changes["gift_card_validity_days"] = product.GiftCardValidityDays
+	}
 	var auditUserID uint
 	if req.CreatedBy != nil {
 		auditUserID = *req.CreatedBy
 	}
-	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "product", product.ID, "create", changes, "", "")
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, &branchID, auditUserID, "product", product.ID, "create", changes, "", "")
 
 	return &product, nil
 }
 
-func (s *ProductService) UpdateProduct(id, tenantID uint, req dto.UpdateProductRequest) (*models.Product, error) {
-	product, err := s.GetProduct(id, tenantID)
+// UpdateProduct updates a product. A new stock level is recorded as an adjustment at the branch
+// for the difference, so the stock ledger explains it.
+func (s *ProductService) UpdateProduct(id, tenantID, branchID uint, req dto.UpdateProductRequest) (*models.Product, error) {
+	product, err := s.GetProduct(id, tenantID, branchID, false)
 	if err != nil {
 		return nil, err
 	}
@@ -163,12 +256,15 @@ func (s *ProductService) UpdateProduct(id, tenantID uint, req dto.UpdateProductR
 	if req.Price > 0 {
 		updates["price"] = req.Price
 	}
-	if req.Stock >= 0 {
-		updates["stock"] = req.Stock
-	}
 	if req.IsActive != nil {
 		updates["is_active"] = *req.IsActive
 	}
+	if req.IsGiftCard != nil {
+		updates["is_gift_card"] = *req.IsGiftCard
+	}
+	if req.GiftCardValidityDays != nil {
+		updates["gift_card_validity_days"] = *req.GiftCardValidityDays
+	}
 
 	// Set updated_by
 	if req.UpdatedBy != nil {
@@ -182,18 +278,51 @@ func (s *ProductService) UpdateProduct(id, tenantID uint, req dto.UpdateProductR
 		"category_id": product.CategoryID,
 		"sku":         product.SKU,
 		"price":       product.Price,
-		"stock":       product.Stock,
+		"stock":       product.BranchStock,
 		"is_active":   product.IsActive,
+
+		"is_gift_card":            product.IsGiftCard,
+		"gift_card_validity_days": product.GiftCardValidityDays,
 	}
 
-	if err := s.db.Model(product).Updates(updates).Error; err != nil {
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(product).Updates(updates).Error; err != nil {
+			return err
+		}
+		if req.Stock == nil {
+			return nil
+		}
+		if _, err := tenantBranch(tx, tenantID, branchID); err != nil {
+			return err
+		}
+		return recordStockMovement(tx, &models.StockMovement{
+			TenantID:      tenantID,
+			BranchID:      branchID,
+			ProductID:     product.ID,
+			Type:          StockMovementAdjustment,
+			Quantity:      *req.Stock - product.BranchStock,
+			Reason:        "stock set on product update",
+			ReferenceType: "product",
+			ReferenceID:   &product.ID,
+			CreatedBy:     req.UpdatedBy,
+		})
+	})
+	if err != nil {
 		return nil, err
 	}
+	if req.Stock != nil && *req.Stock != product.BranchStock {
+		updates["stock"] = *req.Stock
+	}
 
 	// Reload to get updated values
 	if err := s.db.First(product, id).Error; err != nil {
 		return nil, err
 	}
+	products := []models.Product{*product}
+	if err := s.loadBranchStock(products, branchID, false); err != nil {
+		return nil, err
+	}
+	product = &products[0]
 
 	// Create audit trail with changes
 	if len(updates) > 0 {
@@ -219,7 +348,7 @@ func (s *ProductService) UpdateProduct(id, tenantID uint, req dto.UpdateProductR
 }
 
 func (s *ProductService) DeleteProduct(id, tenantID uint, deletedBy *uint) error {
-	product, err := s.GetProduct(id, tenantID)
+	product, err := s.findProduct(id, tenantID)
 	if err != nil {
 		return err
 	}
@@ -250,8 +379,8 @@ func (s *ProductService) DeleteProduct(id, tenantID uint, deletedBy *uint) error
 	return nil
 }
 
-func (s *ProductService) UpdateProductImage(id, tenantID uint, imageURL string) (*models.Product, error) {
-	product, err := s.GetProduct(id, tenantID)
+func (s *ProductService) UpdateProductImage(id, tenantID, branchID uint, imageURL string) (*models.Product, error) {
+	product, err := s.GetProduct(id, tenantID, branchID, false)
 	if err != nil {
 		return nil, err
 	}
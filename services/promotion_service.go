@@ -0,0 +1,592 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type PromotionService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewPromotionService(db *gorm.DB, auditTrailService *AuditTrailService) *PromotionService {
+	return &PromotionService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// PromotionLine is a single order line priced by the promotion engine
+type PromotionLine struct {
+	ProductID   uint
+	CategoryID  *uint
+	Quantity    int
+	Price       money.Amount
+	Subtotal    money.Amount
+	Discount    money.Amount
+	PromotionID *uint
+	NoDiscount  bool // Gift cards sell at face value: no promotion or discount applies, nor counts them
+}
+
+// PromotionResult holds the outcome of applying promotions to a set of order lines
+type PromotionResult struct {
+	Lines          []PromotionLine
+	GrossAmount    money.Amount
+	DiscountAmount money.Amount
+	NetAmount      money.Amount
+	Applied        []models.OrderPromotion
+	Currency       money.Currency // The tenant's, which the amounts are rounded in
+}
+
+// ApplyPromotions evaluates all active promotions of the tenant against the lines and returns the
+// discounted lines. Each line gets at most one item level promotion (the best one), then the best
+// order level promotion is spread over the lines pro-rata. Usage counters are incremented within tx.
+func (s *PromotionService) ApplyPromotions(tx *gorm.DB, tenantID, branchID uint, lines []PromotionLine, couponCode string, now time.Time) (*PromotionResult, error) {
+	cur, err := tenantCurrency(tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	result := &PromotionResult{Lines: lines, Currency: cur}
+	for i := range result.Lines {
+		result.Lines[i].Subtotal = cur.Round(result.Lines[i].Price.Mul(result.Lines[i].Quantity))
+		result.Lines[i].Discount = 0
+		result.Lines[i].PromotionID = nil
+		result.GrossAmount += result.Lines[i].Subtotal
+	}
+
+	couponCode = strings.TrimSpace(couponCode)
+	query := tx.Where("tenant_id = ? AND is_active = ?", tenantID, true).
+		Where("branch_id IS NULL OR branch_id = ?", branchID)
+	if couponCode != "" {
+		query = query.Where("coupon_code = '' OR coupon_code IS NULL OR UPPER(coupon_code) = UPPER(?)", couponCode)
+	} else {
+		query = query.Where("coupon_code = '' OR coupon_code IS NULL")
+	}
+
+	var promotions []models.Promotion
+	if err := query.Order("id ASC").Find(&promotions).Error; err != nil {
+		return nil, err
+	}
+
+	var candidates []models.Promotion
+	couponFound := false
+	for _, promo := range promotions {
+		if promo.CouponCode != "" {
+			couponFound = true
+		}
+		if err := promotionAvailable(&promo, cur, discountableAmount(result.Lines), now); err != nil {
+			if promo.CouponCode != "" {
+				return nil, fmt.Errorf("coupon %s cannot be used: %s", couponCode, err.Error())
+			}
+			continue
+		}
+		candidates = append(candidates, promo)
+	}
+	if couponCode != "" && !couponFound {
+		return nil, errors.New("invalid coupon code")
+	}
+
+	applied := make(map[uint]*models.OrderPromotion)
+
+	// Item level promotions: best single promotion per line
+	for i := range result.Lines {
+		line := &result.Lines[i]
+		if line.NoDiscount {
+			continue
+		}
+		for j := range candidates {
+			promo := &candidates[j]
+			if !promotionIsItemScoped(promo) || !promotionMatchesLine(promo, line) {
+				continue
+			}
+			discount := itemPromotionDiscount(promo, cur, line)
+			if discount > line.Discount {
+				line.Discount = discount
+				id := promo.ID
+				line.PromotionID = &id
+			}
+		}
+		if line.PromotionID != nil {
+			addAppliedPromotion(applied, candidates, *line.PromotionID, line.Discount)
+		}
+	}
+
+	// Order level promotion: best single promotion on the remaining net amount
+	netAfterItems := discountableAmount(result.Lines)
+
+	var bestOrderPromo *models.Promotion
+	var bestOrderDiscount money.Amount
+	for j := range candidates {
+		promo := &candidates[j]
+		if promotionIsItemScoped(promo) {
+			continue
+		}
+		discount := orderPromotionDiscount(promo, cur, netAfterItems)
+		if discount > bestOrderDiscount {
+			bestOrderDiscount = discount
+			bestOrderPromo = promo
+		}
+	}
+	if bestOrderPromo != nil {
+		allocateOrderDiscount(result.Lines, cur, bestOrderDiscount)
+		addAppliedPromotion(applied, candidates, bestOrderPromo.ID, bestOrderDiscount)
+	}
+
+	for i := range result.Lines {
+		result.DiscountAmount += result.Lines[i].Discount
+	}
+	result.NetAmount = result.GrossAmount - result.DiscountAmount
+
+	// Consume usage for every promotion that granted a discount
+	for _, promo := range candidates {
+		orderPromo, ok := applied[promo.ID]
+		if !ok {
+			continue
+		}
+		res := tx.Model(&models.Promotion{}).
+			Where("id = ? AND (usage_limit = 0 OR usage_count < usage_limit)", promo.ID).
+			Update("usage_count", gorm.Expr("usage_count + 1"))
+		if res.Error != nil {
+			return nil, res.Error
+		}
+		if res.RowsAffected == 0 {
+			return nil, fmt.Errorf("promotion %s has reached its usage limit", promo.Name)
+		}
+		result.Applied = append(result.Applied, *orderPromo)
+	}
+
+	return result, nil
+}
+
+// promotionAvailable checks date/time window, usage limit and minimum spend
+func promotionAvailable(promo *models.Promotion, cur money.Currency, grossAmount money.Amount, now time.Time) error {
+	if promo.StartDate != nil && now.Before(*promo.StartDate) {
+		return errors.New("promotion has not started")
+	}
+	if promo.EndDate != nil && now.After(*promo.EndDate) {
+		return errors.New("promotion has expired")
+	}
+	if promo.StartTime != "" && promo.EndTime != "" {
+		clock := now.Format("15:04")
+		if promo.StartTime <= promo.EndTime {
+			if clock < promo.StartTime || clock >= promo.EndTime {
+				return errors.New("promotion is outside its time window")
+			}
+		} else if clock < promo.StartTime && clock >= promo.EndTime {
+			// Window wraps past midnight, e.g. 22:00-02:00
+			return errors.New("promotion is outside its time window")
+		}
+	}
+	if promo.UsageLimit > 0 && promo.UsageCount >= promo.UsageLimit {
+		return errors.New("usage limit reached")
+	}
+	if promo.MinSpend > 0 && grossAmount < promo.MinSpend {
+		return fmt.Errorf("minimum spend is %s", cur.Format(promo.MinSpend))
+	}
+	return nil
+}
+
+func promotionIsItemScoped(promo *models.Promotion) bool {
+	return promo.Type == "buy_x_get_y" || promo.CategoryID != nil || promo.ProductID != nil
+}
+
+func promotionMatchesLine(promo *models.Promotion, line *PromotionLine) bool {
+	if promo.ProductID != nil && *promo.ProductID != line.ProductID {
+		return false
+	}
+	if promo.CategoryID != nil && (line.CategoryID == nil || *promo.CategoryID != *line.CategoryID) {
+		return false
+	}
+	return true
+}
+
+// itemPromotionDiscount returns the discount an item scoped promotion grants on a line
+func itemPromotionDiscount(promo *models.Promotion, cur money.Currency, line *PromotionLine) money.Amount {
+	var discount money.Amount
+	switch promo.Type {
+	case "percentage":
+		discount = cur.Percent(line.Subtotal, promo.DiscountValue.Float64())
+	case "fixed":
+		discount = promo.DiscountValue.Mul(line.Quantity)
+	case "buy_x_get_y":
+		groupSize := promo.BuyQuantity + promo.GetQuantity
+		if promo.BuyQuantity < 1 || promo.GetQuantity < 1 || line.Quantity < groupSize {
+			return 0
+		}
+		freeUnits := (line.Quantity / groupSize) * promo.GetQuantity
+		discount = line.Price.Mul(freeUnits)
+	}
+	if promo.MaxDiscount > 0 && discount > promo.MaxDiscount {
+		discount = promo.MaxDiscount
+	}
+	if discount > line.Subtotal {
+		discount = line.Subtotal
+	}
+	return cur.Round(discount)
+}
+
+// orderPromotionDiscount returns the discount an order level promotion grants on the net amount
+func orderPromotionDiscount(promo *models.Promotion, cur money.Currency, netAmount money.Amount) money.Amount {
+	var discount money.Amount
+	switch promo.Type {
+	case "percentage":
+		discount = cur.Percent(netAmount, promo.DiscountValue.Float64())
+	case "fixed":
+		discount = promo.DiscountValue
+	}
+	if promo.MaxDiscount > 0 && discount > promo.MaxDiscount {
+		discount = promo.MaxDiscount
+	}
+	if discount > netAmount {
+		discount = netAmount
+	}
+	return cur.Round(discount)
+}
+
+// discountableAmount returns the net amount of the lines a discount may apply to
+func discountableAmount(lines []PromotionLine) money.Amount {
+	var amount money.Amount
+	for _, line := range lines {
+		if !line.NoDiscount {
+			amount += line.Subtotal - line.Discount
+		}
+	}
+	return amount
+}
+
+// allocateOrderDiscount spreads an order level discount across the discountable lines in proportion
+// to their net amount, giving the rounding remainder to the last line so the parts add up to the whole
+func allocateOrderDiscount(lines []PromotionLine, cur money.Currency, discount money.Amount) {
+	base := discountableAmount(lines)
+	if base <= 0 {
+		return
+	}
+
+	remaining := discount
+	last := -1
+	for i := range lines {
+		if !lines[i].NoDiscount && lines[i].Subtotal-lines[i].Discount > 0 {
+			last = i
+		}
+	}
+	for i := range lines {
+		lineNet := lines[i].Subtotal - lines[i].Discount
+		if lines[i].NoDiscount || lineNet <= 0 {
+			continue
+		}
+		share := cur.Share(discount, lineNet, base)
+		if i == last {
+			share = remaining
+		}
+		if share > lineNet {
+			share = lineNet
+		}
+		lines[i].Discount += share
+		remaining -= share
+	}
+}
+
+func addAppliedPromotion(applied map[uint]*models.OrderPromotion, candidates []models.Promotion, promotionID uint, discount money.Amount) {
+	if existing, ok := applied[promotionID]; ok {
+		existing.DiscountAmount += discount
+		return
+	}
+	for _, promo := range candidates {
+		if promo.ID == promotionID {
+			applied[promotionID] = &models.OrderPromotion{
+				TenantID:       promo.TenantID,
+				PromotionID:    promo.ID,
+				PromotionName:  promo.Name,
+				CouponCode:     promo.CouponCode,
+				DiscountAmount: discount,
+			}
+			return
+		}
+	}
+}
+
+// validatePromotion checks that the promotion definition is consistent
+func validatePromotion(promo *models.Promotion) error {
+	switch promo.Type {
+	case "percentage":
+		if promo.DiscountValue <= 0 || promo.DiscountValue > money.New(100) {
+			return errors.New("percentage discount must be between 0 and 100")
+		}
+	case "fixed":
+		if promo.DiscountValue <= 0 {
+			return errors.New("fixed discount must be greater than 0")
+		}
+	case "buy_x_get_y":
+		if promo.BuyQuantity < 1 || promo.GetQuantity < 1 {
+			return errors.New("buy_quantity and get_quantity must be at least 1")
+		}
+		if promo.ProductID == nil && promo.CategoryID == nil {
+			return errors.New("buy_x_get_y promotion requires product_id or category_id")
+		}
+	default:
+		return errors.New("invalid promotion type")
+	}
+
+	if (promo.StartTime == "") != (promo.EndTime == "") {
+		return errors.New("start_time and end_time must be set together")
+	}
+	for _, clock := range []string{promo.StartTime, promo.EndTime} {
+		if clock == "" {
+			continue
+		}
+		if _, err := time.Parse("15:04", clock); err != nil {
+			return errors.New("time window must use HH:MM format")
+		}
+	}
+	if promo.StartDate != nil && promo.EndDate != nil && promo.EndDate.Before(*promo.StartDate) {
+		return errors.New("end_date must be after start_date")
+	}
+	return nil
+}
+
+func (s *PromotionService) CreatePromotion(tenantID uint, req dto.CreatePromotionRequest) (*models.Promotion, error) {
+	promo := models.Promotion{
+		TenantID:      tenantID,
+		BranchID:      req.BranchID,
+		Name:          req.Name,
+		Description:   req.Description,
+		Type:          req.Type,
+		DiscountValue: req.DiscountValue,
+		MaxDiscount:   req.MaxDiscount,
+		CategoryID:    req.CategoryID,
+		ProductID:     req.ProductID,
+		BuyQuantity:   req.BuyQuantity,
+		GetQuantity:   req.GetQuantity,
+		MinSpend:      req.MinSpend,
+		StartDate:     req.StartDate,
+		EndDate:       req.EndDate,
+		StartTime:     req.StartTime,
+		EndTime:       req.EndTime,
+		CouponCode:    strings.ToUpper(strings.TrimSpace(req.CouponCode)),
+		UsageLimit:    req.UsageLimit,
+		IsActive:      true,
+		CreatedBy:     req.CreatedBy,
+	}
+	if req.IsActive != nil {
+		promo.IsActive = *req.IsActive
+	}
+
+	if err := validatePromotion(&promo); err != nil {
+		return nil, err
+	}
+	if err := s.checkCouponCodeUnique(tenantID, promo.CouponCode, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&promo).Error; err != nil {
+		return nil, err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"name":           promo.Name,
+		"type":           promo.Type,
+		"discount_value": promo.DiscountValue,
+		"coupon_code":    promo.CouponCode,
+		"is_active":      promo.IsActive,
+	}
+	var auditUserID uint
+	if req.CreatedBy != nil {
+		auditUserID = *req.CreatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, promo.BranchID, auditUserID, "promotion", promo.ID, "create", changes, "", "")
+
+	return s.GetPromotion(promo.ID, tenantID)
+}
+
+func (s *PromotionService) GetPromotion(id, tenantID uint) (*models.Promotion, error) {
+	var promo models.Promotion
+	if err := s.db.Preload("Creator").Preload("Updater").
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&promo).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("promotion not found")
+		}
+		return nil, err
+	}
+	return &promo, nil
+}
+
+func (s *PromotionService) ListPromotions(tenantID uint, search string, activeOnly bool, page, pageSize int) ([]models.Promotion, int64, error) {
+	var promotions []models.Promotion
+	var total int64
+
+	query := s.db.Model(&models.Promotion{}).Where("tenant_id = ?", tenantID)
+
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	// Search by name or coupon code if provided
+	if search != "" {
+		searchPattern := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR coupon_code ILIKE ?", searchPattern, searchPattern)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Creator").Preload("Updater").
+		Order("created_at DESC").
+		Limit(pageSize).
+		Offset(offset).
+		Find(&promotions).Error; err != nil {
+		return nil, 0, err
+	}
+	return promotions, total, nil
+}
+
+func (s *PromotionService) UpdatePromotion(id, tenantID uint, req dto.UpdatePromotionRequest) (*models.Promotion, error) {
+	promo, err := s.GetPromotion(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	old := *promo
+
+	if req.Name != nil {
+		promo.Name = *req.Name
+	}
+	if req.Description != nil {
+		promo.Description = *req.Description
+	}
+	if req.BranchID != nil {
+		promo.BranchID = req.BranchID
+	}
+	if req.Type != nil {
+		promo.Type = *req.Type
+	}
+	if req.DiscountValue != nil {
+		promo.DiscountValue = *req.DiscountValue
+	}
+	if req.MaxDiscount != nil {
+		promo.MaxDiscount = *req.MaxDiscount
+	}
+	if req.CategoryID != nil {
+		promo.CategoryID = req.CategoryID
+	}
+	if req.ProductID != nil {
+		promo.ProductID = req.ProductID
+	}
+	if req.BuyQuantity != nil {
+		promo.BuyQuantity = *req.BuyQuantity
+	}
+	if req.GetQuantity != nil {
+		promo.GetQuantity = *req.GetQuantity
+	}
+	if req.MinSpend != nil {
+		promo.MinSpend = *req.MinSpend
+	}
+	if req.StartDate != nil {
+		promo.StartDate = req.StartDate
+	}
+	if req.EndDate != nil {
+		promo.EndDate = req.EndDate
+	}
+	if req.StartTime != nil {
+		promo.StartTime = *req.StartTime
+	}
+	if req.EndTime != nil {
+		promo.EndTime = *req.EndTime
+	}
+	if req.CouponCode != nil {
+		promo.CouponCode = strings.ToUpper(strings.TrimSpace(*req.CouponCode))
+	}
+	if req.UsageLimit != nil {
+		promo.UsageLimit = *req.UsageLimit
+	}
+	if req.IsActive != nil {
+		promo.IsActive = *req.IsActive
+	}
+	promo.UpdatedBy = req.UpdatedBy
+
+	if err := validatePromotion(promo); err != nil {
+		return nil, err
+	}
+	if err := s.checkCouponCodeUnique(tenantID, promo.CouponCode, promo.ID); err != nil {
+		return nil, err
+	}
+
+	// Relations are preloaded; omit them so Save only touches the promotion row
+	if err := s.db.Omit("Creator", "Updater", "Deleter", "Category", "Product", "Tenant").Save(promo).Error; err != nil {
+		return nil, err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"name":           map[string]interface{}{"old": old.Name, "new": promo.Name},
+		"type":           map[string]interface{}{"old": old.Type, "new": promo.Type},
+		"discount_value": map[string]interface{}{"old": old.DiscountValue, "new": promo.DiscountValue},
+		"coupon_code":    map[string]interface{}{"old": old.CouponCode, "new": promo.CouponCode},
+		"usage_limit":    map[string]interface{}{"old": old.UsageLimit, "new": promo.UsageLimit},
+		"is_active":      map[string]interface{}{"old": old.IsActive, "new": promo.IsActive},
+	}
+	var auditUserID uint
+	if req.UpdatedBy != nil {
+		auditUserID = *req.UpdatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, promo.BranchID, auditUserID, "promotion", promo.ID, "update", changes, "", "")
+
+	return s.GetPromotion(promo.ID, tenantID)
+}
+
+func (s *PromotionService) DeletePromotion(id, tenantID uint, deletedBy *uint) error {
+	promo, err := s.GetPromotion(id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if deletedBy != nil {
+		if err := s.db.Model(promo).Update("deleted_by", deletedBy).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.Delete(promo).Error; err != nil {
+		return err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"name":        promo.Name,
+		"coupon_code": promo.CouponCode,
+	}
+	var auditUserID uint
+	if deletedBy != nil {
+		auditUserID = *deletedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, promo.BranchID, auditUserID, "promotion", promo.ID, "delete", changes, "", "")
+
+	return nil
+}
+
+func (s *PromotionService) checkCouponCodeUnique(tenantID uint, couponCode string, excludeID uint) error {
+	if couponCode == "" {
+		return nil
+	}
+	var count int64
+	if err := s.db.Model(&models.Promotion{}).
+		Where("tenant_id = ? AND UPPER(coupon_code) = ? AND id != ?", tenantID, couponCode, excludeID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("coupon code already exists")
+	}
+	return nil
+}
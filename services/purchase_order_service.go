@@ -0,0 +1,444 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Purchase order statuses
+const (
+	PurchaseOrderDraft             = "draft"
+	PurchaseOrderOrdered           = "ordered"
+	PurchaseOrderPartiallyReceived = "partially_received"
+	PurchaseOrderReceived          = "received"
+	PurchaseOrderClosed            = "closed"
+	PurchaseOrderCancelled         = "cancelled"
+)
+
+// purchaseOrderOpenStatuses are the statuses of orders still expecting goods
+var purchaseOrderOpenStatuses = []string{PurchaseOrderDraft, PurchaseOrderOrdered, PurchaseOrderPartiallyReceived}
+
+type PurchaseOrderService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewPurchaseOrderService(db *gorm.DB, auditTrailService *AuditTrailService) *PurchaseOrderService {
+	return &PurchaseOrderService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// lockPurchaseOrder returns a purchase order of the tenant with its items, locked so its status
+// and received quantities cannot change under the caller
+func lockPurchaseOrder(tx *gorm.DB, orderID, tenantID uint) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("purchase order not found")
+		}
+		return nil, err
+	}
+	if err := tx.Where("purchase_order_id = ?", order.ID).Order("id ASC").Find(&order.Items).Error; err != nil {
+		return nil, err
+	}
+	return &order, nil
+}
+
+// receiveProductCost moves a product's weighted average cost for quantity units bought at unitCost,
+// after the units have been added to its stock. Stock without a known cost takes the new cost. The
+// product is locked so concurrent receipts average over each other's costs, and the average keeps
+// the full scale of money.Amount rather than the currency's minor unit.
+func receiveProductCost(tx *gorm.DB, productID uint, quantity int, unitCost money.Amount) error {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id, stock, cost_price").First(&product, productID).Error; err != nil {
+		return err
+	}
+
+	cost := unitCost
+	if onHand := product.Stock - quantity; product.CostPrice > 0 && onHand > 0 {
+		value := product.CostPrice.Mul(onHand) + unitCost.Mul(quantity)
+		cost = value.MulFrac(1, int64(onHand+quantity), money.Scale, money.RoundHalfUp)
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", productID).UpdateColumn("cost_price", cost).Error
+}
+
+func (s *PurchaseOrderService) audit(order *models.PurchaseOrder, userID *uint, action string, changes map[string]interface{}) {
+	var auditUserID uint
+	if userID != nil {
+		auditUserID = *userID
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&order.TenantID, &order.BranchID, auditUserID, "purchase_order", order.ID, action, changes, "", "")
+}
+
+// CreatePurchaseOrder drafts a purchase order with an active supplier for a branch of the tenant
+func (s *PurchaseOrderService) CreatePurchaseOrder(tenantID, branchID uint, req dto.CreatePurchaseOrderRequest, createdBy *uint) (*models.PurchaseOrder, error) {
+	if req.BranchID != nil {
+		branchID = *req.BranchID
+	}
+
+	order := &models.PurchaseOrder{
+		TenantID:   tenantID,
+		BranchID:   branchID,
+		SupplierID: req.SupplierID,
+		Status:     PurchaseOrderDraft,
+		Notes:      req.Notes,
+		CreatedBy:  createdBy,
+		UpdatedBy:  createdBy,
+	}
+	if req.ExpectedAt != "" {
+		expectedAt, err := time.Parse("2006-01-02", req.ExpectedAt)
+		if err != nil {
+			return nil, errors.New("expected_at must use YYYY-MM-DD format")
+		}
+		order.ExpectedAt = &expectedAt
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := tenantBranch(tx, tenantID, branchID); err != nil {
+			return err
+		}
+		var supplier models.Supplier
+		if err := tx.Where("id = ? AND tenant_id = ?", req.SupplierID, tenantID).First(&supplier).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("supplier not found")
+			}
+			return err
+		}
+		if !supplier.IsActive {
+			return errors.New("supplier is inactive")
+		}
+		cur, err := tenantCurrency(tx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		seen := make(map[uint]bool, len(req.Items))
+		for _, item := range req.Items {
+			if seen[item.ProductID] {
+				return fmt.Errorf("product ID %d is on the purchase order more than once", item.ProductID)
+			}
+			seen[item.ProductID] = true
+
+			var count int64
+			// Gift cards are not stocked
+			if err := tx.Model(&models.Product{}).Where("id = ? AND tenant_id = ? AND is_gift_card = ?", item.ProductID, tenantID, false).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("product ID %d not found or not stocked", item.ProductID)
+			}
+
+			unitCost := cur.Round(item.UnitCost)
+			subtotal := unitCost.Mul(item.Quantity)
+			order.Items = append(order.Items, models.PurchaseOrderItem{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+				UnitCost:  unitCost,
+				Subtotal:  subtotal,
+			})
+			order.TotalAmount += subtotal
+		}
+		return tx.Create(order).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]map[string]interface{}, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = map[string]interface{}{"product_id": item.ProductID, "quantity": item.Quantity, "unit_cost": item.UnitCost}
+	}
+	s.audit(order, createdBy, "create", map[string]interface{}{
+		"supplier_id":  order.SupplierID,
+		"branch_id":    order.BranchID,
+		"total_amount": order.TotalAmount,
+		"items":        items,
+	})
+
+	return s.GetPurchaseOrder(order.ID, tenantID)
+}
+
+// PlaceOrder marks a draft purchase order as ordered from the supplier; goods can be received
+// against it from then on
+func (s *PurchaseOrderService) PlaceOrder(orderID, tenantID uint, orderedBy *uint) (*models.PurchaseOrder, error) {
+	var order *models.PurchaseOrder
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockPurchaseOrder(tx, orderID, tenantID)
+		if err != nil {
+			return err
+		}
+		if order.Status != PurchaseOrderDraft {
+			return fmt.Errorf("only draft purchase orders can be ordered, this one is %s", order.Status)
+		}
+
+		now := time.Now()
+		order.Status = PurchaseOrderOrdered
+		order.OrderedAt = &now
+		order.OrderedBy = orderedBy
+		return tx.Model(order).Updates(map[string]interface{}{
+			"status":     order.Status,
+			"ordered_at": order.OrderedAt,
+			"ordered_by": order.OrderedBy,
+			"updated_by": orderedBy,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(order, orderedBy, "order", map[string]interface{}{
+		"status": map[string]interface{}{"old": PurchaseOrderDraft, "new": PurchaseOrderOrdered},
+	})
+
+	return s.GetPurchaseOrder(order.ID, tenantID)
+}
+
+// ReceiveGoods records a delivery against an ordered purchase order. The delivered units go into
+// the stock of the order's branch at the cost paid for them, which moves the products' average
+// cost. The order is received once every item has been delivered in full.
+func (s *PurchaseOrderService) ReceiveGoods(orderID, tenantID uint, req dto.CreateGoodsReceiptRequest, receivedBy *uint) (*models.PurchaseOrder, error) {
+	var order *models.PurchaseOrder
+	var oldStatus string
+	receipt := &models.GoodsReceipt{
+		TenantID:   tenantID,
+		Notes:      req.Notes,
+		ReceivedBy: receivedBy,
+		ReceivedAt: time.Now(),
+	}
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockPurchaseOrder(tx, orderID, tenantID)
+		if err != nil {
+			return err
+		}
+		oldStatus = order.Status
+		if order.Status != PurchaseOrderOrdered && order.Status != PurchaseOrderPartiallyReceived {
+			return fmt.Errorf("goods can only be received on ordered purchase orders, this one is %s", order.Status)
+		}
+		var supplier models.Supplier
+		if err := tx.Unscoped().Select("id, name").First(&supplier, order.SupplierID).Error; err != nil {
+			return err
+		}
+		cur, err := tenantCurrency(tx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		items := make(map[uint]*models.PurchaseOrderItem, len(order.Items))
+		for i := range order.Items {
+			items[order.Items[i].ID] = &order.Items[i]
+		}
+		seen := make(map[uint]bool, len(req.Items))
+		for _, line := range req.Items {
+			item, exists := items[line.ItemID]
+			if !exists {
+				return fmt.Errorf("item ID %d is not on this purchase order", line.ItemID)
+			}
+			if seen[line.ItemID] {
+				return fmt.Errorf("item ID %d is received more than once", line.ItemID)
+			}
+			seen[line.ItemID] = true
+			if remaining := item.Quantity - item.ReceivedQuantity; line.Quantity > remaining {
+				return fmt.Errorf("only %d units of item ID %d are still to be received", remaining, line.ItemID)
+			}
+
+			unitCost := item.UnitCost
+			if line.UnitCost != nil {
+				unitCost = cur.Round(*line.UnitCost)
+			}
+			receipt.Items = append(receipt.Items, models.GoodsReceiptItem{
+				PurchaseOrderItemID: item.ID,
+				ProductID:           item.ProductID,
+				Quantity:            line.Quantity,
+				UnitCost:            unitCost,
+			})
+		}
+
+		receipt.BranchID = order.BranchID
+		receipt.PurchaseOrderID = order.ID
+		if err := tx.Create(receipt).Error; err != nil {
+			return err
+		}
+
+		reason := fmt.Sprintf("purchase order %d from %s", order.ID, supplier.Name)
+		for _, line := range receipt.Items {
+			if err := recordStockMovement(tx, &models.StockMovement{
+				TenantID:      tenantID,
+				BranchID:      order.BranchID,
+				ProductID:     line.ProductID,
+				Type:          StockMovementReceipt,
+				Quantity:      line.Quantity,
+				Reason:        reason,
+				ReferenceType: "goods_receipt",
+				ReferenceID:   &receipt.ID,
+				CreatedBy:     receivedBy,
+			}); err != nil {
+				return err
+			}
+			if err := receiveProductCost(tx, line.ProductID, line.Quantity, line.UnitCost); err != nil {
+				return err
+			}
+
+			item := items[line.PurchaseOrderItemID]
+			item.ReceivedQuantity += line.Quantity
+			if err := tx.Model(item).Update("received_quantity", item.ReceivedQuantity).Error; err != nil {
+				return err
+			}
+		}
+
+		updates := map[string]interface{}{"updated_by": receivedBy}
+		order.Status = PurchaseOrderReceived
+		for _, item := range order.Items {
+			if item.ReceivedQuantity < item.Quantity {
+				order.Status = PurchaseOrderPartiallyReceived
+				break
+			}
+		}
+		if order.Status == PurchaseOrderReceived {
+			order.ClosedAt = &receipt.ReceivedAt
+			order.ClosedBy = receivedBy
+			updates["closed_at"] = order.ClosedAt
+			updates["closed_by"] = order.ClosedBy
+		}
+		updates["status"] = order.Status
+		return tx.Model(order).Updates(updates).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]map[string]interface{}, len(receipt.Items))
+	for i, line := range receipt.Items {
+		items[i] = map[string]interface{}{"product_id": line.ProductID, "quantity": line.Quantity, "unit_cost": line.UnitCost}
+	}
+	changes := map[string]interface{}{
+		"goods_receipt_id": receipt.ID,
+		"items":            items,
+		"notes":            receipt.Notes,
+	}
+	if order.Status != oldStatus {
+		changes["status"] = map[string]interface{}{"old": oldStatus, "new": order.Status}
+	}
+	s.audit(order, receivedBy, "receive", changes)
+
+	return s.GetPurchaseOrder(order.ID, tenantID)
+}
+
+// ClosePurchaseOrder closes a partially received purchase order whose remaining quantities will not
+// be delivered
+func (s *PurchaseOrderService) ClosePurchaseOrder(orderID, tenantID uint, req dto.PurchaseOrderNotesRequest, closedBy *uint) (*models.PurchaseOrder, error) {
+	return s.finishPurchaseOrder(orderID, tenantID, []string{PurchaseOrderPartiallyReceived}, PurchaseOrderClosed, req.Notes, closedBy)
+}
+
+// CancelPurchaseOrder cancels a purchase order nothing has been received on yet
+func (s *PurchaseOrderService) CancelPurchaseOrder(orderID, tenantID uint, req dto.PurchaseOrderNotesRequest, cancelledBy *uint) (*models.PurchaseOrder, error) {
+	return s.finishPurchaseOrder(orderID, tenantID, []string{PurchaseOrderDraft, PurchaseOrderOrdered}, PurchaseOrderCancelled, req.Notes, cancelledBy)
+}
+
+// finishPurchaseOrder moves a purchase order from one of the from statuses to a final one
+func (s *PurchaseOrderService) finishPurchaseOrder(orderID, tenantID uint, from []string, to, notes string, userID *uint) (*models.PurchaseOrder, error) {
+	var order *models.PurchaseOrder
+	var oldStatus string
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		order, err = lockPurchaseOrder(tx, orderID, tenantID)
+		if err != nil {
+			return err
+		}
+		oldStatus = order.Status
+		allowed := false
+		for _, status := range from {
+			allowed = allowed || order.Status == status
+		}
+		if !allowed {
+			return fmt.Errorf("this purchase order is %s and cannot be %s", order.Status, to)
+		}
+
+		now := time.Now()
+		order.Status = to
+		order.ClosedAt = &now
+		order.ClosedBy = userID
+		order.ClosedNotes = notes
+		return tx.Model(order).Updates(map[string]interface{}{
+			"status":       order.Status,
+			"closed_at":    order.ClosedAt,
+			"closed_by":    order.ClosedBy,
+			"closed_notes": order.ClosedNotes,
+			"updated_by":   userID,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	action := "close"
+	if to == PurchaseOrderCancelled {
+		action = "cancel"
+	}
+	s.audit(order, userID, action, map[string]interface{}{
+		"status": map[string]interface{}{"old": oldStatus, "new": to},
+		"notes":  notes,
+	})
+
+	return s.GetPurchaseOrder(order.ID, tenantID)
+}
+
+// GetPurchaseOrder gets a purchase order of the tenant with its items and goods receipts
+func (s *PurchaseOrderService) GetPurchaseOrder(orderID, tenantID uint) (*models.PurchaseOrder, error) {
+	var order models.PurchaseOrder
+	if err := s.db.Preload("Branch").Preload("Creator").
+		Preload("Supplier", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).Preload("Items.Product").
+		Preload("Receipts", func(db *gorm.DB) *gorm.DB { return db.Order("received_at ASC, id ASC") }).
+		Preload("Receipts.Items").Preload("Receipts.Receiver").
+		Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("purchase order not found")
+		}
+		return nil, err
+	}
+	return &order, nil
+}
+
+// ListPurchaseOrders lists the tenant's purchase orders, newest first. Zero branchID/supplierID and
+// an empty status match all.
+func (s *PurchaseOrderService) ListPurchaseOrders(tenantID, branchID, supplierID uint, status string, page, pageSize int) ([]models.PurchaseOrder, int64, error) {
+	var orders []models.PurchaseOrder
+	var total int64
+
+	query := s.db.Model(&models.PurchaseOrder{}).Where("tenant_id = ?", tenantID)
+	if branchID != 0 {
+		query = query.Where("branch_id = ?", branchID)
+	}
+	if supplierID != 0 {
+		query = query.Where("supplier_id = ?", supplierID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Branch").Preload("Creator").
+		Preload("Supplier", func(db *gorm.DB) *gorm.DB { return db.Unscoped() }).
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).Preload("Items.Product").
+		Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&orders).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return orders, total, nil
+}
@@ -0,0 +1,369 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/money"
+	"strconv"
+	"strings"
+)
+
+// EMVCo merchant-presented QR data object IDs used by QRIS
+const (
+	qrisTagPayloadFormat     = "00"
+	qrisTagInitiationMethod  = "01"
+	qrisTagAcquirerAccount   = "26"
+	qrisTagNationalAccount   = "51"
+	qrisTagCategoryCode      = "52"
+	qrisTagCurrency          = "53"
+	qrisTagAmount            = "54"
+	qrisTagCountryCode       = "58"
+	qrisTagMerchantName      = "59"
+	qrisTagMerchantCity      = "60"
+	qrisTagPostalCode        = "61"
+	qrisTagAdditionalData    = "62"
+	qrisTagCRC               = "63"
+	qrisAdditionalBillNumber = "01"
+	qrisAdditionalReference  = "05"
+	qrisAdditionalTerminal   = "07"
+	qrisNationalDomain       = "ID.CO.QRIS.WWW"
+	qrisCurrencyIDR          = "360"
+	qrisCountryID            = "ID"
+)
+
+// QRIS point of initiation methods
+const (
+	QRISStatic  = "static"  // reusable, the customer enters the amount
+	QRISDynamic = "dynamic" // single use, carries the amount
+)
+
+// QRISMerchantData is the merchant account information encoded in a QRIS payload
+type QRISMerchantData struct {
+	MerchantName     string
+	MerchantCity     string
+	PostalCode       string
+	CategoryCode     string
+	AcquirerDomain   string
+	MerchantPAN      string
+	MerchantID       string
+	NMID             string
+	MerchantCriteria string
+	TerminalLabel    string
+}
+
+// QRISPayloadRequest describes the QR to build. Amount, BillNumber and ReferenceLabel are only
+// encoded in dynamic QRs.
+type QRISPayloadRequest struct {
+	Merchant       QRISMerchantData
+	Type           string
+	Amount         money.Amount
+	BillNumber     string
+	ReferenceLabel string
+}
+
+// QRISField is one data object of a payload; templates (26-51, 62) carry their sub objects
+type QRISField struct {
+	ID       string
+	Value    string
+	Children []QRISField
+}
+
+// QRISPayload is a parsed and validated QRIS string
+type QRISPayload struct {
+	Type         string
+	MerchantName string
+	MerchantCity string
+	PostalCode   string
+	CategoryCode string
+	Currency     string
+	CountryCode  string
+	Amount       *money.Amount
+	NMID         string
+	BillNumber   string
+	Reference    string
+	Terminal     string
+	CRC          string
+	Fields       []QRISField
+}
+
+// qrisTLV encodes one data object as ID, two digit length and value
+func qrisTLV(id, value string) string {
+	return fmt.Sprintf("%s%02d%s", id, len(value), value)
+}
+
+// qrisCRC16 is CRC-16/CCITT-FALSE (polynomial 0x1021, initial value 0xFFFF) as EMVCo requires
+func qrisCRC16(data string) string {
+	crc := uint16(0xFFFF)
+	for i := 0; i < len(data); i++ {
+		crc ^= uint16(data[i]) << 8
+		for bit := 0; bit < 8; bit++ {
+			if crc&0x8000 != 0 {
+				crc = crc<<1 ^ 0x1021
+			} else {
+				crc <<= 1
+			}
+		}
+	}
+	return fmt.Sprintf("%04X", crc)
+}
+
+// formatQRISAmount writes an amount with at most 2 decimals and no trailing zeros, e.g. 15000 or 15000.5
+func formatQRISAmount(amount money.Amount) string {
+	return amount.Round(2, money.RoundHalfUp).String()
+}
+
+// validateQRISMerchant checks the merchant data against the EMVCo field limits
+func validateQRISMerchant(merchant QRISMerchantData) error {
+	limits := []struct {
+		name  string
+		value string
+		max   int
+	}{
+		{"merchant_name", merchant.MerchantName, 25},
+		{"merchant_city", merchant.MerchantCity, 15},
+		{"postal_code", merchant.PostalCode, 10},
+		{"acquirer_domain", merchant.AcquirerDomain, 32},
+		{"merchant_pan", merchant.MerchantPAN, 19},
+		{"merchant_id", merchant.MerchantID, 15},
+		{"nmid", merchant.NMID, 15},
+		{"terminal_label", merchant.TerminalLabel, 25},
+	}
+	for _, limit := range limits {
+		if len(limit.value) > limit.max {
+			return fmt.Errorf("%s must be at most %d characters", limit.name, limit.max)
+		}
+	}
+	if merchant.MerchantName == "" || merchant.MerchantCity == "" {
+		return errors.New("merchant_name and merchant_city are required")
+	}
+	if merchant.AcquirerDomain == "" || merchant.MerchantPAN == "" || merchant.MerchantID == "" || merchant.NMID == "" {
+		return errors.New("acquirer_domain, merchant_pan, merchant_id and nmid are required")
+	}
+	if len(merchant.CategoryCode) != 4 || !isDigits(merchant.CategoryCode) {
+		return errors.New("category_code must be 4 digits")
+	}
+	switch merchant.MerchantCriteria {
+	case "UMI", "UKE", "UME", "UBE", "URE":
+	default:
+		return errors.New("merchant_criteria must be UMI, UKE, UME, UBE or URE")
+	}
+	return nil
+}
+
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return value != ""
+}
+
+// BuildQRISPayload builds an EMVCo merchant-presented QR string, ending in its CRC
+func BuildQRISPayload(req QRISPayloadRequest) (string, error) {
+	if err := validateQRISMerchant(req.Merchant); err != nil {
+		return "", err
+	}
+
+	initiation := "11"
+	switch req.Type {
+	case QRISStatic:
+	case QRISDynamic:
+		initiation = "12"
+		if req.Amount.Round(2, money.RoundHalfUp) <= 0 {
+			return "", errors.New("dynamic QRIS requires an amount greater than zero")
+		}
+	default:
+		return "", fmt.Errorf("invalid QRIS type %q", req.Type)
+	}
+
+	merchant := req.Merchant
+	var b strings.Builder
+	b.WriteString(qrisTLV(qrisTagPayloadFormat, "01"))
+	b.WriteString(qrisTLV(qrisTagInitiationMethod, initiation))
+	b.WriteString(qrisTLV(qrisTagAcquirerAccount,
+		qrisTLV("00", merchant.AcquirerDomain)+
+			qrisTLV("01", merchant.MerchantPAN)+
+			qrisTLV("02", merchant.MerchantID)+
+			qrisTLV("03", merchant.MerchantCriteria)))
+	b.WriteString(qrisTLV(qrisTagNationalAccount,
+		qrisTLV("00", qrisNationalDomain)+
+			qrisTLV("02", merchant.NMID)+
+			qrisTLV("03", merchant.MerchantCriteria)))
+	b.WriteString(qrisTLV(qrisTagCategoryCode, merchant.CategoryCode))
+	b.WriteString(qrisTLV(qrisTagCurrency, qrisCurrencyIDR))
+	if req.Type == QRISDynamic {
+		amount := formatQRISAmount(req.Amount)
+		if len(amount) > 13 {
+			return "", errors.New("amount is too large for QRIS")
+		}
+		b.WriteString(qrisTLV(qrisTagAmount, amount))
+	}
+	b.WriteString(qrisTLV(qrisTagCountryCode, qrisCountryID))
+	b.WriteString(qrisTLV(qrisTagMerchantName, merchant.MerchantName))
+	b.WriteString(qrisTLV(qrisTagMerchantCity, merchant.MerchantCity))
+	if merchant.PostalCode != "" {
+		b.WriteString(qrisTLV(qrisTagPostalCode, merchant.PostalCode))
+	}
+
+	var additional strings.Builder
+	if req.Type == QRISDynamic {
+		if len(req.BillNumber) > 25 || len(req.ReferenceLabel) > 25 {
+			return "", errors.New("bill number and reference label must be at most 25 characters")
+		}
+		if req.BillNumber != "" {
+			additional.WriteString(qrisTLV(qrisAdditionalBillNumber, req.BillNumber))
+		}
+		if req.ReferenceLabel != "" {
+			additional.WriteString(qrisTLV(qrisAdditionalReference, req.ReferenceLabel))
+		}
+	}
+	if merchant.TerminalLabel != "" {
+		additional.WriteString(qrisTLV(qrisAdditionalTerminal, merchant.TerminalLabel))
+	}
+	if additional.Len() > 0 {
+		b.WriteString(qrisTLV(qrisTagAdditionalData, additional.String()))
+	}
+
+	b.WriteString(qrisTagCRC + "04")
+	payload := b.String()
+	return payload + qrisCRC16(payload), nil
+}
+
+// parseQRISFields splits a run of data objects
+func parseQRISFields(data string) ([]QRISField, error) {
+	var fields []QRISField
+	for pos := 0; pos < len(data); {
+		if pos+4 > len(data) {
+			return nil, fmt.Errorf("truncated data object at position %d", pos)
+		}
+		id := data[pos : pos+2]
+		if !isDigits(id) || !isDigits(data[pos+2:pos+4]) {
+			return nil, fmt.Errorf("invalid data object header at position %d", pos)
+		}
+		length, _ := strconv.Atoi(data[pos+2 : pos+4])
+		if pos+4+length > len(data) {
+			return nil, fmt.Errorf("data object %s overruns the payload", id)
+		}
+		fields = append(fields, QRISField{ID: id, Value: data[pos+4 : pos+4+length]})
+		pos += 4 + length
+	}
+	return fields, nil
+}
+
+// isQRISTemplate reports whether a data object holds nested data objects
+func isQRISTemplate(id string) bool {
+	n, _ := strconv.Atoi(id)
+	return (n >= 26 && n <= 51) || n == 62 || n == 64 || n >= 80
+}
+
+// ParseQRIS parses an incoming QR string and validates its structure, mandatory fields and CRC
+func ParseQRIS(raw string) (*QRISPayload, error) {
+	raw = strings.TrimSpace(raw)
+	if len(raw) < 8 {
+		return nil, errors.New("QR string is too short")
+	}
+
+	fields, err := parseQRISFields(raw)
+	if err != nil {
+		return nil, err
+	}
+	if fields[0].ID != qrisTagPayloadFormat || fields[0].Value != "01" {
+		return nil, errors.New("QR string must start with payload format indicator 01")
+	}
+	last := fields[len(fields)-1]
+	if last.ID != qrisTagCRC || len(last.Value) != 4 {
+		return nil, errors.New("QR string must end with a 4 character CRC")
+	}
+	if expected := qrisCRC16(raw[:len(raw)-4]); !strings.EqualFold(expected, last.Value) {
+		return nil, fmt.Errorf("CRC mismatch: expected %s, got %s", expected, last.Value)
+	}
+
+	payload := &QRISPayload{Type: QRISStatic, CRC: strings.ToUpper(last.Value)}
+	seen := make(map[string]bool, len(fields))
+	hasAccount := false
+	for i := range fields {
+		field := &fields[i]
+		if seen[field.ID] {
+			return nil, fmt.Errorf("data object %s appears more than once", field.ID)
+		}
+		seen[field.ID] = true
+
+		if isQRISTemplate(field.ID) {
+			children, err := parseQRISFields(field.Value)
+			if err != nil {
+				return nil, fmt.Errorf("data object %s: %w", field.ID, err)
+			}
+			field.Children = children
+		}
+
+		n, _ := strconv.Atoi(field.ID)
+		switch {
+		case n >= 2 && n <= 51:
+			hasAccount = true
+			if field.ID == qrisTagNationalAccount {
+				for _, child := range field.Children {
+					if child.ID == "02" {
+						payload.NMID = child.Value
+					}
+				}
+			}
+		case field.ID == qrisTagInitiationMethod:
+			switch field.Value {
+			case "11":
+				payload.Type = QRISStatic
+			case "12":
+				payload.Type = QRISDynamic
+			default:
+				return nil, fmt.Errorf("invalid point of initiation method %q", field.Value)
+			}
+		case field.ID == qrisTagCategoryCode:
+			payload.CategoryCode = field.Value
+		case field.ID == qrisTagCurrency:
+			payload.Currency = field.Value
+		case field.ID == qrisTagAmount:
+			amount, err := money.Parse(field.Value)
+			if err != nil || amount <= 0 {
+				return nil, fmt.Errorf("invalid transaction amount %q", field.Value)
+			}
+			payload.Amount = &amount
+		case field.ID == qrisTagCountryCode:
+			payload.CountryCode = field.Value
+		case field.ID == qrisTagMerchantName:
+			payload.MerchantName = field.Value
+		case field.ID == qrisTagMerchantCity:
+			payload.MerchantCity = field.Value
+		case field.ID == qrisTagPostalCode:
+			payload.PostalCode = field.Value
+		case field.ID == qrisTagAdditionalData:
+			for _, child := range field.Children {
+				switch child.ID {
+				case qrisAdditionalBillNumber:
+					payload.BillNumber = child.Value
+				case qrisAdditionalReference:
+					payload.Reference = child.Value
+				case qrisAdditionalTerminal:
+					payload.Terminal = child.Value
+				}
+			}
+		}
+	}
+
+	if !hasAccount {
+		return nil, errors.New("QR string has no merchant account information")
+	}
+	for _, required := range []struct{ id, name string }{
+		{qrisTagCategoryCode, "merchant category code"},
+		{qrisTagCurrency, "transaction currency"},
+		{qrisTagCountryCode, "country code"},
+		{qrisTagMerchantName, "merchant name"},
+		{qrisTagMerchantCity, "merchant city"},
+	} {
+		if !seen[required.id] {
+			return nil, fmt.Errorf("QR string is missing the %s", required.name)
+		}
+	}
+
+	payload.Fields = fields
+	return payload, nil
+}
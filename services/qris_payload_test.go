@@ -0,0 +1,210 @@
+package services
+
+import (
+	"myposcore/money"
+	"strings"
+	"testing"
+)
+
+// emvcoSamplePayload is the merchant-presented QR example published in the EMVCo QR Code
+// Specification for Payment Systems (MPM), whose CRC the specification gives as A13A
+const emvcoSamplePayload = "00020101021229300012D156000000000510A93FO3230Q31280012D15600000001030812345678520441115802CN5914BEST TRANSPORT6007BEIJING64200002ZH0104最佳运输0202北京540523.7253031565502016233030412340603***0708A60086670902ME91320016A0112233449988770708123456786304A13A"
+
+func testQRISMerchant() QRISMerchantData {
+	return QRISMerchantData{
+		MerchantName:     "WARUNG MAKAN SEDERHANA",
+		MerchantCity:     "JAKARTA SELATAN",
+		PostalCode:       "12190",
+		CategoryCode:     "5812",
+		AcquirerDomain:   "ID.CO.BANKABC.WWW",
+		MerchantPAN:      "9360001234567890123",
+		MerchantID:       "123456789012345",
+		NMID:             "ID1020012345678",
+		MerchantCriteria: "UMI",
+		TerminalLabel:    "KASIR01",
+	}
+}
+
+// withQRISCRC appends the CRC data object to a payload body
+func withQRISCRC(body string) string {
+	body += qrisTagCRC + "04"
+	return body + qrisCRC16(body)
+}
+
+func TestQRISCRC16(t *testing.T) {
+	tests := []struct {
+		name string
+		data string
+		want string
+	}{
+		{"CRC-16/CCITT-FALSE check value", "123456789", "29B1"},
+		{"empty input is the initial value", "", "FFFF"},
+		{"EMVCo MPM sample", strings.TrimSuffix(emvcoSamplePayload, "A13A"), "A13A"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := qrisCRC16(tt.data); got != tt.want {
+				t.Fatalf("qrisCRC16 = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildQRISPayload(t *testing.T) {
+	tests := []struct {
+		name string
+		req  QRISPayloadRequest
+		want string
+	}{
+		{
+			name: "static",
+			req:  QRISPayloadRequest{Merchant: testQRISMerchant(), Type: QRISStatic, Amount: money.New(5000), BillNumber: "ignored"},
+			want: "00020101021126700017ID.CO.BANKABC.WWW0119936000123456789012302151234567890123450303UMI" +
+				"51440014ID.CO.QRIS.WWW0215ID10200123456780303UMI5204581253033605802ID" +
+				"5922WARUNG MAKAN SEDERHANA6015JAKARTA SELATAN61051219062110707KASIR016304BDAF",
+		},
+		{
+			name: "dynamic",
+			req: QRISPayloadRequest{Merchant: testQRISMerchant(), Type: QRISDynamic, Amount: money.FromFloat(15000.5),
+				BillNumber: "ORD-001-0001", ReferenceLabel: "PAY-42"},
+			want: "00020101021226700017ID.CO.BANKABC.WWW0119936000123456789012302151234567890123450303UMI" +
+				"51440014ID.CO.QRIS.WWW0215ID10200123456780303UMI520458125303360540715000.55802ID" +
+				"5922WARUNG MAKAN SEDERHANA6015JAKARTA SELATAN61051219062370112ORD-001-00010506PAY-420707KASIR0163043CB3",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := BuildQRISPayload(tt.req)
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			if got != tt.want {
+				t.Fatalf("payload =\n%s\nwant\n%s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildQRISPayloadRejects(t *testing.T) {
+	noNMID := testQRISMerchant()
+	noNMID.NMID = ""
+	badCriteria := testQRISMerchant()
+	badCriteria.MerchantCriteria = "XXX"
+	longName := testQRISMerchant()
+	longName.MerchantName = strings.Repeat("A", 26)
+
+	tests := []struct {
+		name string
+		req  QRISPayloadRequest
+		want string
+	}{
+		{"unknown type", QRISPayloadRequest{Merchant: testQRISMerchant(), Type: "once"}, "invalid QRIS type"},
+		{"dynamic without amount", QRISPayloadRequest{Merchant: testQRISMerchant(), Type: QRISDynamic}, "amount greater than zero"},
+		{"dynamic amount below a cent", QRISPayloadRequest{Merchant: testQRISMerchant(), Type: QRISDynamic, Amount: 49}, "amount greater than zero"},
+		{"amount too long", QRISPayloadRequest{Merchant: testQRISMerchant(), Type: QRISDynamic, Amount: money.New(99999999999999)}, "too large"},
+		{"long bill number", QRISPayloadRequest{Merchant: testQRISMerchant(), Type: QRISDynamic, Amount: money.New(1), BillNumber: strings.Repeat("1", 26)}, "at most 25"},
+		{"missing NMID", QRISPayloadRequest{Merchant: noNMID, Type: QRISStatic}, "nmid are required"},
+		{"bad merchant criteria", QRISPayloadRequest{Merchant: badCriteria, Type: QRISStatic}, "merchant_criteria"},
+		{"long merchant name", QRISPayloadRequest{Merchant: longName, Type: QRISStatic}, "merchant_name must be at most 25"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := BuildQRISPayload(tt.req)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+}
+
+func TestQRISPayloadRoundTrip(t *testing.T) {
+	bare := testQRISMerchant()
+	bare.PostalCode = ""
+	bare.TerminalLabel = ""
+
+	tests := []struct {
+		name       string
+		req        QRISPayloadRequest
+		wantAmount string // empty = no amount
+	}{
+		{"static", QRISPayloadRequest{Merchant: testQRISMerchant(), Type: QRISStatic}, ""},
+		{"static without optional fields", QRISPayloadRequest{Merchant: bare, Type: QRISStatic}, ""},
+		{"dynamic whole amount", QRISPayloadRequest{Merchant: testQRISMerchant(), Type: QRISDynamic, Amount: money.New(25000), BillNumber: "ORD-1"}, "25000"},
+		{"dynamic amount rounded to cents", QRISPayloadRequest{Merchant: bare, Type: QRISDynamic, Amount: 150005550, ReferenceLabel: "REF-9"}, "15000.56"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			raw, err := BuildQRISPayload(tt.req)
+			if err != nil {
+				t.Fatalf("build: %v", err)
+			}
+			parsed, err := ParseQRIS(raw)
+			if err != nil {
+				t.Fatalf("parse %s: %v", raw, err)
+			}
+
+			merchant := tt.req.Merchant
+			if parsed.Type != tt.req.Type || parsed.MerchantName != merchant.MerchantName || parsed.MerchantCity != merchant.MerchantCity ||
+				parsed.PostalCode != merchant.PostalCode || parsed.CategoryCode != merchant.CategoryCode || parsed.NMID != merchant.NMID ||
+				parsed.Terminal != merchant.TerminalLabel {
+				t.Fatalf("parsed = %+v, want the merchant %+v as %s", parsed, merchant, tt.req.Type)
+			}
+			if parsed.Currency != "360" || parsed.CountryCode != "ID" || parsed.CRC != raw[len(raw)-4:] {
+				t.Fatalf("currency %s, country %s, CRC %s", parsed.Currency, parsed.CountryCode, parsed.CRC)
+			}
+			if tt.wantAmount == "" {
+				if parsed.Amount != nil || parsed.BillNumber != "" || parsed.Reference != "" {
+					t.Fatalf("static QR carries amount %v, bill %q, reference %q", parsed.Amount, parsed.BillNumber, parsed.Reference)
+				}
+				return
+			}
+			if parsed.Amount == nil || parsed.Amount.String() != tt.wantAmount {
+				t.Fatalf("amount = %v, want %s", parsed.Amount, tt.wantAmount)
+			}
+			if parsed.BillNumber != tt.req.BillNumber || parsed.Reference != tt.req.ReferenceLabel {
+				t.Fatalf("bill %q reference %q, want %q %q", parsed.BillNumber, parsed.Reference, tt.req.BillNumber, tt.req.ReferenceLabel)
+			}
+		})
+	}
+}
+
+func TestParseQRISRejects(t *testing.T) {
+	valid, err := BuildQRISPayload(QRISPayloadRequest{Merchant: testQRISMerchant(), Type: QRISStatic})
+	if err != nil {
+		t.Fatalf("build: %v", err)
+	}
+	body := strings.TrimSuffix(valid[:len(valid)-4], "6304")
+	account := "26700017ID.CO.BANKABC.WWW0119936000123456789012302151234567890123450303UMI"
+
+	tests := []struct {
+		name string
+		raw  string
+		want string
+	}{
+		{"too short", "000201", "too short"},
+		{"CRC mismatch", valid[:len(valid)-4] + "0000", "CRC mismatch"},
+		{"no CRC", body, "4 character CRC"},
+		{"truncated", valid[:len(valid)-10], "overruns"},
+		{"bad header", "0002010A" + valid[8:], "invalid data object header"},
+		{"wrong payload format", withQRISCRC("000202" + body[6:]), "payload format indicator"},
+		{"invalid initiation method", withQRISCRC("0002010102135204581253033605802ID5902AB6002JK" + account), "point of initiation"},
+		{"duplicate data object", withQRISCRC(body + "5802ID"), "more than once"},
+		{"no merchant account", withQRISCRC("0002010102115204581253033605802ID5902AB6002JK"), "no merchant account"},
+		{"missing merchant name", withQRISCRC("000201010211" + account + "5204581253033605802ID6002JK"), "merchant name"},
+		{"zero amount", withQRISCRC("000201010212" + account + "520458125303360540105802ID5902AB6002JK"), "invalid transaction amount"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, err := ParseQRIS(tt.raw)
+			if err == nil || !strings.Contains(err.Error(), tt.want) {
+				t.Fatalf("error = %v, want one containing %q", err, tt.want)
+			}
+		})
+	}
+
+	// The CRC is hex, so a lower case one from another encoder is accepted
+	lower := valid[:len(valid)-4] + strings.ToLower(valid[len(valid)-4:])
+	if _, err := ParseQRIS(lower); err != nil {
+		t.Fatalf("lower case CRC: %v", err)
+	}
+}
@@ -0,0 +1,258 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+
+	qrcode "github.com/skip2/go-qrcode"
+	"gorm.io/gorm"
+)
+
+type QRISService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewQRISService(db *gorm.DB, auditTrailService *AuditTrailService) *QRISService {
+	return &QRISService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// QRISCode is a generated QR payload with what it was generated for
+type QRISCode struct {
+	Type        string
+	Payload     string
+	Amount      money.Amount
+	OrderID     uint
+	OrderNumber string
+	BranchID    uint
+}
+
+// GetMerchant returns the merchant data used by a branch: its own or the tenant wide one
+func (s *QRISService) GetMerchant(tenantID, branchID uint) (*models.QRISMerchant, error) {
+	var merchant models.QRISMerchant
+	err := s.db.Where("tenant_id = ? AND branch_id = ?", tenantID, branchID).First(&merchant).Error
+	if err == nil {
+		return &merchant, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = s.db.Where("tenant_id = ? AND branch_id IS NULL", tenantID).First(&merchant).Error
+	if err == nil {
+		return &merchant, nil
+	}
+	if errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, errors.New("QRIS merchant not configured")
+	}
+	return nil, err
+}
+
+// SaveMerchant creates or updates the QRIS merchant data of the tenant, or of one branch
+func (s *QRISService) SaveMerchant(tenantID uint, req dto.SaveQRISMerchantRequest) (*models.QRISMerchant, error) {
+	if req.BranchID != nil {
+		var count int64
+		if err := s.db.Model(&models.Branch{}).Where("id = ? AND tenant_id = ?", *req.BranchID, tenantID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, errors.New("branch not found or doesn't belong to this tenant")
+		}
+	}
+
+	data := QRISMerchantData{
+		MerchantName:     req.MerchantName,
+		MerchantCity:     req.MerchantCity,
+		PostalCode:       req.PostalCode,
+		CategoryCode:     req.CategoryCode,
+		AcquirerDomain:   req.AcquirerDomain,
+		MerchantPAN:      req.MerchantPAN,
+		MerchantID:       req.MerchantID,
+		NMID:             req.NMID,
+		MerchantCriteria: req.MerchantCriteria,
+		TerminalLabel:    req.TerminalLabel,
+	}
+	if err := validateQRISMerchant(data); err != nil {
+		return nil, err
+	}
+
+	var merchant models.QRISMerchant
+	query := s.db.Where("tenant_id = ?", tenantID)
+	if req.BranchID != nil {
+		query = query.Where("branch_id = ?", *req.BranchID)
+	} else {
+		query = query.Where("branch_id IS NULL")
+	}
+	err := query.First(&merchant).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+
+	oldValues := qrisMerchantAuditValues(&merchant)
+
+	merchant.TenantID = tenantID
+	merchant.BranchID = req.BranchID
+	merchant.MerchantName = req.MerchantName
+	merchant.MerchantCity = req.MerchantCity
+	merchant.PostalCode = req.PostalCode
+	merchant.CategoryCode = req.CategoryCode
+	merchant.AcquirerDomain = req.AcquirerDomain
+	merchant.MerchantPAN = req.MerchantPAN
+	merchant.MerchantID = req.MerchantID
+	merchant.NMID = req.NMID
+	merchant.MerchantCriteria = req.MerchantCriteria
+	merchant.TerminalLabel = req.TerminalLabel
+	if isNew {
+		merchant.CreatedBy = req.UpdatedBy
+	}
+	merchant.UpdatedBy = req.UpdatedBy
+
+	if err := s.db.Save(&merchant).Error; err != nil {
+		return nil, err
+	}
+
+	// Create audit trail
+	action := "update"
+	newValues := qrisMerchantAuditValues(&merchant)
+	changes := make(map[string]interface{}, len(newValues))
+	for key, value := range newValues {
+		changes[key] = map[string]interface{}{"old": oldValues[key], "new": value}
+	}
+	if isNew {
+		action = "create"
+		changes = newValues
+	}
+	var auditUserID uint
+	if req.UpdatedBy != nil {
+		auditUserID = *req.UpdatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, merchant.BranchID, auditUserID, "qris_merchant", merchant.ID, action, changes, "", "")
+
+	return &merchant, nil
+}
+
+func qrisMerchantAuditValues(merchant *models.QRISMerchant) map[string]interface{} {
+	return map[string]interface{}{
+		"merchant_name":     merchant.MerchantName,
+		"merchant_city":     merchant.MerchantCity,
+		"postal_code":       merchant.PostalCode,
+		"category_code":     merchant.CategoryCode,
+		"acquirer_domain":   merchant.AcquirerDomain,
+		"merchant_pan":      merchant.MerchantPAN,
+		"merchant_id":       merchant.MerchantID,
+		"nmid":              merchant.NMID,
+		"merchant_criteria": merchant.MerchantCriteria,
+		"terminal_label":    merchant.TerminalLabel,
+	}
+}
+
+func qrisMerchantData(merchant *models.QRISMerchant) QRISMerchantData {
+	return QRISMerchantData{
+		MerchantName:     merchant.MerchantName,
+		MerchantCity:     merchant.MerchantCity,
+		PostalCode:       merchant.PostalCode,
+		CategoryCode:     merchant.CategoryCode,
+		AcquirerDomain:   merchant.AcquirerDomain,
+		MerchantPAN:      merchant.MerchantPAN,
+		MerchantID:       merchant.MerchantID,
+		NMID:             merchant.NMID,
+		MerchantCriteria: merchant.MerchantCriteria,
+		TerminalLabel:    merchant.TerminalLabel,
+	}
+}
+
+// GenerateStaticQRIS builds the reusable QR a branch displays at the counter
+func (s *QRISService) GenerateStaticQRIS(tenantID, branchID uint) (*QRISCode, error) {
+	merchant, err := s.GetMerchant(tenantID, branchID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := BuildQRISPayload(QRISPayloadRequest{Merchant: qrisMerchantData(merchant), Type: QRISStatic})
+	if err != nil {
+		return nil, err
+	}
+	return &QRISCode{Type: QRISStatic, Payload: payload, BranchID: branchID}, nil
+}
+
+// GenerateOrderQRIS builds a dynamic QR for the order's balance due. amount, when set, requests
+// a part of the balance for split payments.
+func (s *QRISService) GenerateOrderQRIS(orderID, tenantID uint, amount *money.Amount) (*QRISCode, error) {
+	var order models.Order
+	if err := s.db.Where("id = ? AND tenant_id = ?", orderID, tenantID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+
+	if order.Status == "completed" {
+		return nil, errors.New("order already completed")
+	}
+	if order.Status == "cancelled" || order.Status == "voided" || order.Status == "partially_refunded" || order.Status == "refunded" {
+		return nil, fmt.Errorf("cannot pay %s order", order.Status)
+	}
+
+	cur, err := tenantCurrency(s.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	balanceDue, pending, err := orderBalanceDue(s.db, &order)
+	if err != nil {
+		return nil, err
+	}
+	if balanceDue <= 0 {
+		if pending > 0 {
+			return nil, errors.New("pending payments already cover the balance due")
+		}
+		return nil, errors.New("order already completed")
+	}
+
+	qrAmount := balanceDue
+	if amount != nil {
+		qrAmount = cur.Round(*amount)
+		if qrAmount <= 0 {
+			return nil, errors.New("amount must be greater than zero")
+		}
+		if qrAmount > balanceDue {
+			return nil, fmt.Errorf("amount exceeds balance due %s", cur.Format(balanceDue))
+		}
+	}
+
+	merchant, err := s.GetMerchant(tenantID, order.BranchID)
+	if err != nil {
+		return nil, err
+	}
+
+	payload, err := BuildQRISPayload(QRISPayloadRequest{
+		Merchant:       qrisMerchantData(merchant),
+		Type:           QRISDynamic,
+		Amount:         qrAmount,
+		BillNumber:     order.OrderNumber,
+		ReferenceLabel: fmt.Sprintf("ORD%d", order.ID),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return &QRISCode{
+		Type:        QRISDynamic,
+		Payload:     payload,
+		Amount:      qrAmount,
+		OrderID:     order.ID,
+		OrderNumber: order.OrderNumber,
+		BranchID:    order.BranchID,
+	}, nil
+}
+
+// RenderQRISPNG encodes a payload as a square PNG of size pixels
+func RenderQRISPNG(payload string, size int) ([]byte, error) {
+	return qrcode.Encode(payload, qrcode.Medium, size)
+}
@@ -0,0 +1,411 @@
+package services
+
+import (
+	"bytes"
+	"fmt"
+	"html/template"
+	"myposcore/money"
+	"strings"
+	"unicode/utf8"
+)
+
+// receiptColumns returns the characters per line of the default printer font for a paper width
+func receiptColumns(paperWidth int) int {
+	if paperWidth == 58 {
+		return 32
+	}
+	return 48
+}
+
+type receiptAlign int
+
+const (
+	alignLeft receiptAlign = iota
+	alignCenter
+)
+
+// receiptRow is one printed line; the text renderers and the ESC/POS and PDF writers share them
+type receiptRow struct {
+	Text  string
+	Align receiptAlign
+	Bold  bool
+}
+
+// formatReceiptAmount formats an amount with thousands separators and the currency's decimals
+func formatReceiptAmount(cur money.Currency, amount money.Amount) string {
+	formatted := cur.Format(amount)
+	sign := ""
+	if strings.HasPrefix(formatted, "-") {
+		sign = "-"
+		formatted = formatted[1:]
+	}
+	whole, fraction := formatted, ""
+	if i := strings.IndexByte(formatted, '.'); i >= 0 {
+		whole, fraction = formatted[:i], formatted[i:]
+	}
+	var grouped []string
+	for len(whole) > 3 {
+		grouped = append([]string{whole[len(whole)-3:]}, grouped...)
+		whole = whole[:len(whole)-3]
+	}
+	grouped = append([]string{whole}, grouped...)
+	return sign + strings.Join(grouped, ",") + fraction
+}
+
+// receiptPair puts label on the left and value on the right of a line, wrapping a long label and
+// keeping its indentation
+func receiptPair(label, value string, columns int) []string {
+	space := columns - utf8.RuneCountInString(value) - 1
+	if space < 1 {
+		return []string{label, value}
+	}
+	trimmed := strings.TrimLeft(label, " ")
+	indent := label[:len(label)-len(trimmed)]
+	if len(indent) >= space {
+		indent = ""
+	}
+	labelLines := wrapReceiptText(trimmed, space-len(indent))
+	for i := range labelLines {
+		labelLines[i] = indent + labelLines[i]
+	}
+	last := labelLines[len(labelLines)-1]
+	padding := columns - utf8.RuneCountInString(last) - utf8.RuneCountInString(value)
+	labelLines[len(labelLines)-1] = last + strings.Repeat(" ", padding) + value
+	return labelLines
+}
+
+// wrapReceiptText breaks text into lines of at most columns characters, splitting on spaces when possible
+func wrapReceiptText(text string, columns int) []string {
+	words := strings.Fields(text)
+	if len(words) == 0 {
+		return []string{""}
+	}
+	var lines []string
+	current := ""
+	for _, word := range words {
+		for utf8.RuneCountInString(word) > columns {
+			if current != "" {
+				lines = append(lines, current)
+				current = ""
+			}
+			runes := []rune(word)
+			lines = append(lines, string(runes[:columns]))
+			word = string(runes[columns:])
+		}
+		switch {
+		case current == "":
+			current = word
+		case utf8.RuneCountInString(current)+1+utf8.RuneCountInString(word) <= columns:
+			current += " " + word
+		default:
+			lines = append(lines, current)
+			current = word
+		}
+	}
+	if current != "" {
+		lines = append(lines, current)
+	}
+	return lines
+}
+
+// receiptRows lays the receipt out as fixed width lines
+func receiptRows(r *Receipt) []receiptRow {
+	columns := receiptColumns(r.PaperWidth)
+	var rows []receiptRow
+	add := func(align receiptAlign, bold bool, lines ...string) {
+		for _, line := range lines {
+			rows = append(rows, receiptRow{Text: line, Align: align, Bold: bold})
+		}
+	}
+	centered := func(bold bool, text string) {
+		if text != "" {
+			add(alignCenter, bold, wrapReceiptText(text, columns)...)
+		}
+	}
+	separator := strings.Repeat("-", columns)
+
+	// Header
+	centered(true, r.TenantName)
+	for _, line := range r.HeaderLines {
+		centered(false, line)
+	}
+	centered(false, r.BranchName)
+	centered(false, r.BranchAddress)
+	centered(false, r.BranchPhone)
+	add(alignLeft, false, separator)
+
+	add(alignLeft, false, receiptPair("No", r.OrderNumber, columns)...)
+	add(alignLeft, false, receiptPair("Date", r.Date, columns)...)
+	if r.Cashier != "" {
+		add(alignLeft, false, receiptPair("Cashier", r.Cashier, columns)...)
+	}
+	add(alignLeft, false, separator)
+
+	// Items
+	for _, item := range r.Items {
+		add(alignLeft, false, wrapReceiptText(item.Name, columns)...)
+		detail := fmt.Sprintf("  %d x %s", item.Quantity, formatReceiptAmount(r.Currency, item.Price))
+		add(alignLeft, false, receiptPair(detail, formatReceiptAmount(r.Currency, item.Subtotal), columns)...)
+		if item.Discount > 0 {
+			add(alignLeft, false, receiptPair("  Discount", formatReceiptAmount(r.Currency, -item.Discount), columns)...)
+		}
+	}
+	add(alignLeft, false, separator)
+
+	// Totals
+	add(alignLeft, false, receiptPair("Subtotal", formatReceiptAmount(r.Currency, r.GrossAmount), columns)...)
+	if r.Discount > 0 {
+		add(alignLeft, false, receiptPair("Discount", formatReceiptAmount(r.Currency, -r.Discount), columns)...)
+	}
+	for _, charge := range r.Charges {
+		add(alignLeft, false, receiptPair(charge.Label, formatReceiptAmount(r.Currency, charge.Amount), columns)...)
+	}
+	add(alignLeft, true, receiptPair("TOTAL", formatReceiptAmount(r.Currency, r.Total), columns)...)
+
+	// Payments
+	if len(r.Payments) > 0 {
+		add(alignLeft, false, separator)
+		for _, payment := range r.Payments {
+			add(alignLeft, false, receiptPair(strings.ToUpper(payment.Label), formatReceiptAmount(r.Currency, payment.Amount), columns)...)
+		}
+		if r.Tip > 0 {
+			add(alignLeft, false, receiptPair("Tip", formatReceiptAmount(r.Currency, r.Tip), columns)...)
+		}
+		if r.Rounding != 0 {
+			add(alignLeft, false, receiptPair("Rounding", formatReceiptAmount(r.Currency, -r.Rounding), columns)...)
+		}
+		if r.Change > 0 {
+			add(alignLeft, false, receiptPair("Change", formatReceiptAmount(r.Currency, r.Change), columns)...)
+		}
+	}
+	if r.BalanceDue > 0 {
+		add(alignLeft, true, receiptPair("Balance due", formatReceiptAmount(r.Currency, r.BalanceDue), columns)...)
+	}
+	if r.Refunded > 0 {
+		add(alignLeft, false, receiptPair("Refunded", formatReceiptAmount(r.Currency, r.Refunded), columns)...)
+	}
+
+	// Gift cards sold
+	if len(r.GiftCards) > 0 {
+		add(alignLeft, false, separator)
+		add(alignLeft, true, "GIFT CARDS")
+		for _, card := range r.GiftCards {
+			add(alignLeft, false, receiptPair(card.Label, formatReceiptAmount(r.Currency, card.Amount), columns)...)
+		}
+	}
+
+	// Footer
+	if len(r.FooterLines) > 0 {
+		add(alignLeft, false, separator)
+		for _, line := range r.FooterLines {
+			centered(false, line)
+		}
+	}
+
+	return rows
+}
+
+// alignedText pads a centered row so it is centered in plain text output
+func alignedText(row receiptRow, columns int) string {
+	if row.Align != alignCenter {
+		return row.Text
+	}
+	padding := (columns - utf8.RuneCountInString(row.Text)) / 2
+	if padding < 0 {
+		padding = 0
+	}
+	return strings.Repeat(" ", padding) + row.Text
+}
+
+// RenderReceiptText renders the receipt as plain fixed width text
+func RenderReceiptText(r *Receipt) string {
+	columns := receiptColumns(r.PaperWidth)
+	var b strings.Builder
+	for _, row := range receiptRows(r) {
+		b.WriteString(alignedText(row, columns))
+		b.WriteByte('\n')
+	}
+	return b.String()
+}
+
+// ESC/POS commands
+var (
+	escposInit        = []byte{0x1b, 0x40}
+	escposAlignLeft   = []byte{0x1b, 0x61, 0x00}
+	escposAlignCenter = []byte{0x1b, 0x61, 0x01}
+	escposBoldOn      = []byte{0x1b, 0x45, 0x01}
+	escposBoldOff     = []byte{0x1b, 0x45, 0x00}
+	escposFeed        = []byte{0x1b, 0x64, 0x04}       // feed 4 lines
+	escposCut         = []byte{0x1d, 0x56, 0x42, 0x00} // feed to cutter and partial cut
+)
+
+// asciiOnly replaces characters the printer code page cannot show
+func asciiOnly(text string) string {
+	var b strings.Builder
+	for _, r := range text {
+		if r < 0x20 || r > 0x7e {
+			b.WriteByte('?')
+			continue
+		}
+		b.WriteRune(r)
+	}
+	return b.String()
+}
+
+// RenderReceiptESCPOS renders the receipt as raw ESC/POS bytes for a thermal printer using font A,
+// which fits 32 characters per line on 58mm paper and 48 on 80mm paper
+func RenderReceiptESCPOS(r *Receipt) []byte {
+	var b bytes.Buffer
+	b.Write(escposInit)
+	for _, row := range receiptRows(r) {
+		if row.Align == alignCenter {
+			b.Write(escposAlignCenter)
+		} else {
+			b.Write(escposAlignLeft)
+		}
+		if row.Bold {
+			b.Write(escposBoldOn)
+		}
+		b.WriteString(asciiOnly(row.Text))
+		b.WriteByte('\n')
+		if row.Bold {
+			b.Write(escposBoldOff)
+		}
+	}
+	b.Write(escposAlignLeft)
+	b.Write(escposFeed)
+	b.Write(escposCut)
+	return b.Bytes()
+}
+
+// pdfEscape escapes a string for a PDF literal string
+func pdfEscape(text string) string {
+	return strings.NewReplacer(`\`, `\\`, "(", `\(`, ")", `\)`).Replace(asciiOnly(text))
+}
+
+// RenderReceiptPDF renders the receipt as a single page PDF as wide as the paper roll and as long as
+// the receipt, using the built-in Courier fonts so no font has to be embedded
+func RenderReceiptPDF(r *Receipt) []byte {
+	const mmToPoint = 72.0 / 25.4
+	const margin = 8.0
+
+	columns := receiptColumns(r.PaperWidth)
+	rows := receiptRows(r)
+	pageWidth := float64(r.PaperWidth) * mmToPoint
+	fontSize := (pageWidth - 2*margin) / (float64(columns) * 0.6) // Courier glyphs are 0.6em wide
+	leading := fontSize * 1.25
+	pageHeight := 2*margin + float64(len(rows))*leading
+
+	var content bytes.Buffer
+	for i, row := range rows {
+		font := "F1"
+		if row.Bold {
+			font = "F2"
+		}
+		y := pageHeight - margin - float64(i+1)*leading + (leading-fontSize)/2
+		fmt.Fprintf(&content, "BT /%s %.2f Tf %.2f %.2f Td (%s) Tj ET\n", font, fontSize, margin, y, pdfEscape(alignedText(row, columns)))
+	}
+
+	objects := []string{
+		"<< /Type /Catalog /Pages 2 0 R >>",
+		"<< /Type /Pages /Kids [3 0 R] /Count 1 >>",
+		fmt.Sprintf("<< /Type /Page /Parent 2 0 R /MediaBox [0 0 %.2f %.2f] /Resources << /Font << /F1 4 0 R /F2 5 0 R >> >> /Contents 6 0 R >>", pageWidth, pageHeight),
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier /Encoding /WinAnsiEncoding >>",
+		"<< /Type /Font /Subtype /Type1 /BaseFont /Courier-Bold /Encoding /WinAnsiEncoding >>",
+		fmt.Sprintf("<< /Length %d >>\nstream\n%sendstream", content.Len(), content.String()),
+	}
+
+	var pdf bytes.Buffer
+	pdf.WriteString("%PDF-1.4\n")
+	offsets := make([]int, len(objects))
+	for i, object := range objects {
+		offsets[i] = pdf.Len()
+		fmt.Fprintf(&pdf, "%d 0 obj\n%s\nendobj\n", i+1, object)
+	}
+	xref := pdf.Len()
+	fmt.Fprintf(&pdf, "xref\n0 %d\n0000000000 65535 f \n", len(objects)+1)
+	for _, offset := range offsets {
+		fmt.Fprintf(&pdf, "%010d 00000 n \n", offset)
+	}
+	fmt.Fprintf(&pdf, "trailer\n<< /Size %d /Root 1 0 R >>\nstartxref\n%d\n%%%%EOF\n", len(objects)+1, xref)
+	return pdf.Bytes()
+}
+
+var receiptHTMLTemplate = template.Must(template.New("receipt").Funcs(template.FuncMap{
+	"amount": formatReceiptAmount,
+	"neg":    func(a money.Amount) money.Amount { return -a },
+	"upper":  strings.ToUpper,
+}).Parse(`<!DOCTYPE html>
+<html>
+<head>
+<meta charset="utf-8">
+<title>Receipt {{.OrderNumber}}</title>
+<style>
+body { font-family: monospace; font-size: 12px; margin: 0; }
+.receipt { width: {{.PaperWidth}}mm; padding: 4mm; box-sizing: border-box; }
+.center { text-align: center; }
+.logo { max-width: 60%; max-height: 25mm; }
+table { width: 100%; border-collapse: collapse; }
+td.amount { text-align: right; white-space: nowrap; }
+.total td { font-weight: bold; }
+hr { border: none; border-top: 1px dashed #000; }
+</style>
+</head>
+<body>
+<div class="receipt">
+<div class="center">
+{{if .LogoURL}}<img class="logo" src="{{.LogoURL}}" alt=""><br>{{end}}
+<strong>{{.TenantName}}</strong>
+{{range .HeaderLines}}<div>{{.}}</div>{{end}}
+{{if .BranchName}}<div>{{.BranchName}}</div>{{end}}
+{{if .BranchAddress}}<div>{{.BranchAddress}}</div>{{end}}
+{{if .BranchPhone}}<div>{{.BranchPhone}}</div>{{end}}
+</div>
+<hr>
+<table>
+<tr><td>No</td><td class="amount">{{.OrderNumber}}</td></tr>
+<tr><td>Date</td><td class="amount">{{.Date}}</td></tr>
+{{if .Cashier}}<tr><td>Cashier</td><td class="amount">{{.Cashier}}</td></tr>{{end}}
+</table>
+<hr>
+<table>
+{{range .Items}}<tr><td colspan="2">{{.Name}}</td></tr>
+<tr><td>&nbsp;&nbsp;{{.Quantity}} x {{amount $.Currency .Price}}</td><td class="amount">{{amount $.Currency .Subtotal}}</td></tr>
+{{if gt .Discount 0}}<tr><td>&nbsp;&nbsp;Discount</td><td class="amount">{{amount $.Currency (neg .Discount)}}</td></tr>{{end}}
+{{end}}</table>
+<hr>
+<table>
+<tr><td>Subtotal</td><td class="amount">{{amount $.Currency .GrossAmount}}</td></tr>
+{{if gt .Discount 0}}<tr><td>Discount</td><td class="amount">{{amount $.Currency (neg .Discount)}}</td></tr>{{end}}
+{{range .Charges}}<tr><td>{{.Label}}</td><td class="amount">{{amount $.Currency .Amount}}</td></tr>{{end}}
+<tr class="total"><td>TOTAL</td><td class="amount">{{amount $.Currency .Total}}</td></tr>
+</table>
+{{if .Payments}}<hr>
+<table>
+{{range .Payments}}<tr><td>{{upper .Label}}</td><td class="amount">{{amount $.Currency .Amount}}</td></tr>{{end}}
+{{if gt .Tip 0}}<tr><td>Tip</td><td class="amount">{{amount $.Currency .Tip}}</td></tr>{{end}}
+{{if ne .Rounding 0}}<tr><td>Rounding</td><td class="amount">{{amount $.Currency (neg .Rounding)}}</td></tr>{{end}}
+{{if gt .Change 0}}<tr><td>Change</td><td class="amount">{{amount $.Currency .Change}}</td></tr>{{end}}
+</table>{{end}}
+{{if gt .BalanceDue 0}}<table><tr class="total"><td>Balance due</td><td class="amount">{{amount $.Currency .BalanceDue}}</td></tr></table>{{end}}
+{{if gt .Refunded 0}}<table><tr><td>Refunded</td><td class="amount">{{amount $.Currency .Refunded}}</td></tr></table>{{end}}
+{{if .GiftCards}}<hr>
+<table>
+<tr class="total"><td colspan="2">GIFT CARDS</td></tr>
+{{range .GiftCards}}<tr><td>{{.Label}}</td><td class="amount">{{amount $.Currency .Amount}}</td></tr>{{end}}
+</table>{{end}}
+{{if .FooterLines}}<hr>
+<div class="center">{{range .FooterLines}}<div>{{.}}</div>{{end}}</div>{{end}}
+</div>
+</body>
+</html>
+`))
+
+// RenderReceiptHTML renders the receipt as a printable HTML page sized to the paper width
+func RenderReceiptHTML(r *Receipt) (string, error) {
+	var b bytes.Buffer
+	if err := receiptHTMLTemplate.Execute(&b, r); err != nil {
+		return "", err
+	}
+	return b.String(), nil
+}
@@ -0,0 +1,322 @@
+package services
+
+import (
+	"errors"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type ReceiptService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewReceiptService(db *gorm.DB, auditTrailService *AuditTrailService) *ReceiptService {
+	return &ReceiptService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// Receipt holds everything printed on a receipt, already resolved from the order and the template
+type Receipt struct {
+	TenantName    string
+	LogoURL       string
+	HeaderLines   []string
+	BranchName    string
+	BranchAddress string
+	BranchPhone   string
+	OrderNumber   string
+	Date          string
+	Cashier       string
+	Status        string
+	Items         []ReceiptItem
+	GrossAmount   money.Amount
+	Discount      money.Amount
+	Charges       []ReceiptLine // service charges and taxes
+	Total         money.Amount
+	Payments      []ReceiptLine
+	Paid          money.Amount
+	Change        money.Amount
+	Rounding      money.Amount // Cash rounding written off
+	Tip           money.Amount // Gratuity paid on top of the total
+	BalanceDue    money.Amount
+	Refunded      money.Amount
+	GiftCards     []ReceiptLine // Cards sold on the order, labelled with their code
+	FooterLines   []string
+	PaperWidth    int
+	Currency      money.Currency
+}
+
+type ReceiptItem struct {
+	Name     string
+	Quantity int
+	Price    money.Amount
+	Subtotal money.Amount
+	Discount money.Amount
+}
+
+type ReceiptLine struct {
+	Label  string
+	Amount money.Amount
+}
+
+// defaultReceiptTemplate is used when the tenant has not configured a template
+func defaultReceiptTemplate(tenantID uint) models.ReceiptTemplate {
+	return models.ReceiptTemplate{
+		TenantID:     tenantID,
+		FooterText:   "Thank you",
+		ShowLogo:     true,
+		ShowTaxLines: true,
+		ShowCashier:  true,
+		PaperWidth:   80,
+	}
+}
+
+// GetTemplate returns the template used by a branch: its own, the tenant wide one or the default
+func (s *ReceiptService) GetTemplate(tenantID, branchID uint) (*models.ReceiptTemplate, error) {
+	var template models.ReceiptTemplate
+	err := s.db.Where("tenant_id = ? AND branch_id = ?", tenantID, branchID).First(&template).Error
+	if err == nil {
+		return &template, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	err = s.db.Where("tenant_id = ? AND branch_id IS NULL", tenantID).First(&template).Error
+	if err == nil {
+		return &template, nil
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+
+	template = defaultReceiptTemplate(tenantID)
+	return &template, nil
+}
+
+// SaveTemplate creates or updates the receipt template of the tenant, or of one branch
+func (s *ReceiptService) SaveTemplate(tenantID uint, req dto.SaveReceiptTemplateRequest) (*models.ReceiptTemplate, error) {
+	if req.BranchID != nil {
+		var count int64
+		if err := s.db.Model(&models.Branch{}).Where("id = ? AND tenant_id = ?", *req.BranchID, tenantID).Count(&count).Error; err != nil {
+			return nil, err
+		}
+		if count == 0 {
+			return nil, errors.New("branch not found or doesn't belong to this tenant")
+		}
+	}
+
+	var template models.ReceiptTemplate
+	query := s.db.Where("tenant_id = ?", tenantID)
+	if req.BranchID != nil {
+		query = query.Where("branch_id = ?", *req.BranchID)
+	} else {
+		query = query.Where("branch_id IS NULL")
+	}
+	err := query.First(&template).Error
+	if err != nil && !errors.Is(err, gorm.ErrRecordNotFound) {
+		return nil, err
+	}
+	isNew := errors.Is(err, gorm.ErrRecordNotFound)
+
+	oldValues := map[string]interface{}{
+		"header_text":    template.HeaderText,
+		"footer_text":    template.FooterText,
+		"show_logo":      template.ShowLogo,
+		"show_tax_lines": template.ShowTaxLines,
+		"show_cashier":   template.ShowCashier,
+		"paper_width":    template.PaperWidth,
+	}
+
+	template.TenantID = tenantID
+	template.BranchID = req.BranchID
+	template.HeaderText = req.HeaderText
+	template.FooterText = req.FooterText
+	template.ShowLogo = req.ShowLogo
+	template.ShowTaxLines = req.ShowTaxLines
+	template.ShowCashier = req.ShowCashier
+	template.PaperWidth = req.PaperWidth
+	if isNew {
+		template.CreatedBy = req.UpdatedBy
+	}
+	template.UpdatedBy = req.UpdatedBy
+
+	if err := s.db.Save(&template).Error; err != nil {
+		return nil, err
+	}
+
+	// Create audit trail
+	action := "update"
+	newValues := map[string]interface{}{
+		"header_text":    template.HeaderText,
+		"footer_text":    template.FooterText,
+		"show_logo":      template.ShowLogo,
+		"show_tax_lines": template.ShowTaxLines,
+		"show_cashier":   template.ShowCashier,
+		"paper_width":    template.PaperWidth,
+	}
+	changes := make(map[string]interface{}, len(newValues))
+	for key, value := range newValues {
+		changes[key] = map[string]interface{}{"old": oldValues[key], "new": value}
+	}
+	if isNew {
+		action = "create"
+		changes = newValues
+	}
+	var auditUserID uint
+	if req.UpdatedBy != nil {
+		auditUserID = *req.UpdatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, template.BranchID, auditUserID, "receipt_template", template.ID, action, changes, "", "")
+
+	return &template, nil
+}
+
+// BuildReceipt collects the order, its payments and the branch template into a receipt.
+// paperWidth overrides the template width when it is 58 or 80.
+func (s *ReceiptService) BuildReceipt(orderID, tenantID uint, paperWidth int) (*Receipt, error) {
+	var order models.Order
+	if err := s.db.Preload("OrderItems.Product").
+		Preload("Taxes").
+		Preload("User").
+		Preload("Branch").
+		Preload("Tenant").
+		Where("id = ? AND tenant_id = ?", orderID, tenantID).
+		First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("order not found")
+		}
+		return nil, err
+	}
+
+	var payments []models.Payment
+	if err := s.db.Where("order_id = ? AND status IN ?", order.ID, paidPaymentStatuses).
+		Order("created_at ASC, id ASC").
+		Find(&payments).Error; err != nil {
+		return nil, err
+	}
+
+	template, err := s.GetTemplate(tenantID, order.BranchID)
+	if err != nil {
+		return nil, err
+	}
+	if paperWidth == 58 || paperWidth == 80 {
+		template.PaperWidth = paperWidth
+	}
+
+	var giftCards []models.GiftCard
+	if err := s.db.Where("order_id = ? AND type = ? AND status = ?", order.ID, GiftCardTypeGiftCard, "active").
+		Order("id ASC").
+		Find(&giftCards).Error; err != nil {
+		return nil, err
+	}
+
+	receipt := buildReceipt(&order, payments, template)
+	for _, card := range giftCards {
+		receipt.GiftCards = append(receipt.GiftCards, ReceiptLine{Label: card.Code, Amount: card.InitialBalance})
+	}
+	return receipt, nil
+}
+
+func buildReceipt(order *models.Order, payments []models.Payment, template *models.ReceiptTemplate) *Receipt {
+	loc, err := time.LoadLocation(order.Branch.Timezone)
+	if err != nil || order.Branch.Timezone == "" {
+		loc, _ = time.LoadLocation(defaultBranchTimezone)
+	}
+
+	var addressParts []string
+	for _, part := range []string{order.Branch.Address, order.Branch.City, order.Branch.PostalCode} {
+		if part = strings.TrimSpace(part); part != "" {
+			addressParts = append(addressParts, part)
+		}
+	}
+
+	receipt := &Receipt{
+		TenantName:    order.Tenant.Name,
+		HeaderLines:   splitReceiptText(template.HeaderText),
+		BranchName:    order.Branch.Name,
+		BranchAddress: strings.Join(addressParts, ", "),
+		BranchPhone:   order.Branch.Phone,
+		OrderNumber:   order.OrderNumber,
+		Date:          order.CreatedAt.In(loc).Format("2006-01-02 15:04"),
+		Status:        order.Status,
+		GrossAmount:   order.GrossAmount,
+		Discount:      order.DiscountAmount,
+		Total:         order.TotalAmount,
+		Paid:          order.PaidAmount,
+		Refunded:      order.RefundedAmount,
+		FooterLines:   splitReceiptText(template.FooterText),
+		PaperWidth:    template.PaperWidth,
+		Currency:      currencyOf(&order.Tenant),
+	}
+	if template.ShowLogo {
+		receipt.LogoURL = order.Tenant.Image
+	}
+	if template.ShowCashier {
+		receipt.Cashier = order.User.FullName
+	}
+
+	for _, item := range order.OrderItems {
+		receipt.Items = append(receipt.Items, ReceiptItem{
+			Name:     item.Product.Name,
+			Quantity: item.Quantity,
+			Price:    item.Price,
+			Subtotal: item.Subtotal,
+			Discount: item.DiscountAmount,
+		})
+	}
+
+	if template.ShowTaxLines {
+		for _, tax := range order.Taxes {
+			label := tax.Name
+			if tax.IsInclusive {
+				label += " (incl.)"
+			}
+			receipt.Charges = append(receipt.Charges, ReceiptLine{Label: label, Amount: tax.Amount})
+		}
+	} else {
+		if order.ServiceChargeAmount > 0 {
+			receipt.Charges = append(receipt.Charges, ReceiptLine{Label: "Service charge", Amount: order.ServiceChargeAmount})
+		}
+		if order.TaxAmount > 0 {
+			receipt.Charges = append(receipt.Charges, ReceiptLine{Label: "Tax", Amount: order.TaxAmount})
+		}
+		if order.InclusiveTaxAmount > 0 {
+			receipt.Charges = append(receipt.Charges, ReceiptLine{Label: "Tax (incl.)", Amount: order.InclusiveTaxAmount})
+		}
+	}
+
+	for _, payment := range payments {
+		amount := payment.Amount
+		if payment.TenderedAmount > amount {
+			amount = payment.TenderedAmount
+		}
+		receipt.Payments = append(receipt.Payments, ReceiptLine{Label: payment.PaymentMethod, Amount: amount})
+		receipt.Change += payment.ChangeAmount
+		receipt.Rounding += payment.RoundingAmount
+		receipt.Tip += payment.TipAmount
+	}
+
+	if balance := order.TotalAmount - order.PaidAmount; balance > 0 {
+		receipt.BalanceDue = balance
+	}
+
+	return receipt
+}
+
+func splitReceiptText(text string) []string {
+	var lines []string
+	for _, line := range strings.Split(strings.ReplaceAll(text, "\r\n", "\n"), "\n") {
+		if line = strings.TrimSpace(line); line != "" {
+			lines = append(lines, line)
+		}
+	}
+	return lines
+}
@@ -0,0 +1,496 @@
+package services
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type RefundService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+	providers         *PaymentProviderRegistry
+	approvalService   *ApprovalService
+}
+
+func NewRefundService(db *gorm.DB, auditTrailService *AuditTrailService, providers *PaymentProviderRegistry, approvalService *ApprovalService) *RefundService {
+	return &RefundService{
+		db:                db,
+		auditTrailService: auditTrailService,
+		providers:         providers,
+		approvalService:   approvalService,
+	}
+}
+
+// CreateRefund reverses all or part of a completed payment. When items are given, the refund
+// amount defaults to their share of the order total and the items can be put back into stock.
+// Without items the remaining refundable amount is refunded unless an amount is given.
+// Provider payments refunded with their own method are committed as a pending refund first, which
+// holds its amount, and sent to the provider after the commit so no locks are held while it
+// answers; the refund then completes, or fails without touching the order. Every refund needs a
+// supervisor's approval.
+func (s *RefundService) CreateRefund(paymentID, tenantID uint, req dto.CreateRefundRequest) (*models.Refund, error) {
+	var payment models.Payment
+	if err := s.db.Joins("JOIN orders ON orders.id = payments.order_id").
+		Where("payments.id = ? AND orders.tenant_id = ?", paymentID, tenantID).
+		First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("payment not found")
+		}
+		return nil, err
+	}
+
+	if payment.Status != "completed" && payment.Status != "partially_refunded" {
+		return nil, fmt.Errorf("cannot refund %s payment", payment.Status)
+	}
+
+	approved, err := s.approvalService.Verify(tenantID, req.CreatedBy, ApprovalRefund, req.Approval)
+	if err != nil {
+		return nil, err
+	}
+
+	var refund *models.Refund
+	var order models.Order
+	var oldStatus string
+	var provider PaymentProvider
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the order, then the payment, so concurrent refunds see each other's amounts
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("OrderItems.Product").Where("id = ?", payment.OrderID).First(&order).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, payment.ID).Error; err != nil {
+			return err
+		}
+		if payment.Status != "completed" && payment.Status != "partially_refunded" {
+			return fmt.Errorf("cannot refund %s payment", payment.Status)
+		}
+		if order.Status != "completed" && order.Status != "partially_refunded" {
+			return fmt.Errorf("cannot refund %s order", order.Status)
+		}
+		oldStatus = order.Status
+
+		cur, err := tenantCurrency(tx, tenantID)
+		if err != nil {
+			return err
+		}
+
+		// Refunds still waiting for their provider hold their amounts
+		orderPending, paymentPending, err := pendingRefundAmounts(tx, order.ID, payment.ID)
+		if err != nil {
+			return err
+		}
+		paymentRemaining := payment.Amount - payment.RefundedAmount - paymentPending
+		orderRemaining := order.TotalAmount - order.RefundedAmount - orderPending
+		refundable := money.Min(paymentRemaining, orderRemaining)
+		if refundable <= 0 {
+			return errors.New("payment already fully refunded")
+		}
+
+		refundItems, itemsAmount, err := s.buildRefundItems(tx, &order, cur, req.Items)
+		if err != nil {
+			return err
+		}
+
+		amount := cur.Round(req.Amount)
+		if amount == 0 {
+			if len(refundItems) > 0 {
+				amount = money.Min(itemsAmount, refundable)
+			} else {
+				amount = refundable
+			}
+		}
+		if amount > refundable {
+			return fmt.Errorf("refund amount exceeds refundable amount %s", cur.Format(refundable))
+		}
+
+		// Restocking without items returns every unit not yet returned, which only makes sense for a full refund
+		if req.Restock && len(refundItems) == 0 {
+			if amount < refundable {
+				return errors.New("restock on a partial refund requires items")
+			}
+			refundItems, _, err = s.remainingRefundItems(tx, &order, cur)
+			if err != nil {
+				return err
+			}
+		}
+
+		refund = &models.Refund{
+			TenantID:     tenantID,
+			BranchID:     order.BranchID,
+			OrderID:      order.ID,
+			PaymentID:    payment.ID,
+			Amount:       amount,
+			RefundMethod: req.RefundMethod,
+			Reason:       req.Reason,
+			Restock:      req.Restock,
+			Status:       "completed",
+			ApprovedBy:   &approved.Approver.ID,
+			CreatedBy:    req.CreatedBy,
+		}
+		if refund.RefundMethod == "" {
+			refund.RefundMethod = payment.PaymentMethod
+		}
+		// Redeemed points go back to the member's balance, never out as cash
+		if payment.PaymentMethod == LoyaltyPaymentMethod && refund.RefundMethod != LoyaltyPaymentMethod {
+			return errors.New("loyalty point payments can only be refunded as points")
+		}
+		// Gift card balance goes back onto a card, never out as cash
+		if payment.PaymentMethod == GiftCardPaymentMethod && refund.RefundMethod != GiftCardPaymentMethod && refund.RefundMethod != StoreCreditRefundMethod {
+			return errors.New("gift card payments can only be refunded to the card or as store credit")
+		}
+		if refund.RefundMethod == GiftCardPaymentMethod && payment.PaymentMethod != GiftCardPaymentMethod {
+			return errors.New("only gift card payments can be refunded to a gift card")
+		}
+		// Cash refunds are paid out of the refunding cashier's drawer
+		if refund.RefundMethod == "cash" {
+			shift, err := requireCashShift(tx, tenantID, order.BranchID, req.CreatedBy)
+			if err != nil {
+				return err
+			}
+			refund.ShiftID = &shift.ID
+		}
+		if payment.Provider != "" && refund.RefundMethod == payment.PaymentMethod {
+			if provider = s.providers.Provider(payment.Provider); provider == nil {
+				return fmt.Errorf("%w: %s", ErrPaymentProviderNotFound, payment.Provider)
+			}
+			refund.Status = "pending"
+		}
+		if err := tx.Create(refund).Error; err != nil {
+			return err
+		}
+
+		for i := range refundItems {
+			refundItems[i].RefundID = refund.ID
+			refundItems[i].Restocked = req.Restock
+		}
+		if len(refundItems) > 0 {
+			if err := tx.Create(&refundItems).Error; err != nil {
+				return err
+			}
+		}
+		refund.Items = refundItems
+
+		if provider != nil {
+			return nil
+		}
+		return applyRefund(tx, &order, &payment, refund, req.CreatedBy)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if provider != nil {
+		if err := s.refundAtProvider(provider, &payment, refund, &order, req.CreatedBy); err != nil {
+			return nil, err
+		}
+	}
+
+	// Create audit trail
+	items := make([]map[string]interface{}, len(refund.Items))
+	for i, item := range refund.Items {
+		items[i] = map[string]interface{}{
+			"order_item_id": item.OrderItemID,
+			"product_id":    item.ProductID,
+			"quantity":      item.Quantity,
+			"amount":        item.Amount,
+		}
+	}
+	changes := map[string]interface{}{
+		"payment_id":    refund.PaymentID,
+		"order_id":      refund.OrderID,
+		"amount":        refund.Amount,
+		"refund_method": refund.RefundMethod,
+		"gift_card_id":  refund.GiftCardID,
+		"reason":        refund.Reason,
+		"restock":       refund.Restock,
+		"approved_by":   refund.ApprovedBy,
+		"items":         items,
+		"order_status": map[string]interface{}{
+			"old": oldStatus,
+			"new": order.Status,
+		},
+	}
+	var auditUserID uint
+	if req.CreatedBy != nil {
+		auditUserID = *req.CreatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, &refund.BranchID, auditUserID, "refund", refund.ID, "create", changes, "", "")
+	s.approvalService.Record(tenantID, &refund.BranchID, approved, "refund", refund.ID, map[string]interface{}{
+		"payment_id": refund.PaymentID,
+		"order_id":   refund.OrderID,
+		"amount":     refund.Amount,
+		"reason":     refund.Reason,
+	})
+
+	return s.GetRefund(refund.ID, tenantID)
+}
+
+// pendingRefundAmounts returns the amounts of the order's and the payment's refunds still waiting
+// for their provider
+func pendingRefundAmounts(tx *gorm.DB, orderID, paymentID uint) (money.Amount, money.Amount, error) {
+	var pending []models.Refund
+	if err := tx.Select("payment_id, amount").Where("order_id = ? AND status = ?", orderID, "pending").
+		Find(&pending).Error; err != nil {
+		return 0, 0, err
+	}
+	var orderPending, paymentPending money.Amount
+	for _, refund := range pending {
+		orderPending += refund.Amount
+		if refund.PaymentID == paymentID {
+			paymentPending += refund.Amount
+		}
+	}
+	return orderPending, paymentPending, nil
+}
+
+// applyRefund applies a completed refund to its payment and order: returned items go back into
+// stock when asked, gift cards are credited or taken back and loyalty points earned are reversed
+func applyRefund(tx *gorm.DB, order *models.Order, payment *models.Payment, refund *models.Refund, changedBy *uint) error {
+	oldStatus := order.Status
+	for _, item := range refund.Items {
+		if !item.Restocked {
+			continue
+		}
+		if err := recordStockMovement(tx, &models.StockMovement{
+			TenantID:      refund.TenantID,
+			BranchID:      order.BranchID,
+			ProductID:     item.ProductID,
+			Type:          StockMovementRefund,
+			Quantity:      item.Quantity,
+			Reason:        refund.Reason,
+			ReferenceType: "refund",
+			ReferenceID:   &refund.ID,
+			CreatedBy:     changedBy,
+		}); err != nil {
+			return err
+		}
+	}
+
+	if err := creditRefundGiftCard(tx, order, payment, refund, changedBy); err != nil {
+		return err
+	}
+	// Gift cards sold on the returned items are taken back, so they must not have been used
+	for _, item := range refund.Items {
+		orderItemID := item.OrderItemID
+		if err := voidOrderGiftCards(tx, order, &orderItemID, item.Quantity, &refund.ID, changedBy); err != nil {
+			return err
+		}
+	}
+
+	// Update payment
+	payment.RefundedAmount += refund.Amount
+	paymentStatus := "partially_refunded"
+	if payment.RefundedAmount >= payment.Amount {
+		paymentStatus = "refunded"
+	}
+	if err := tx.Model(payment).Updates(map[string]interface{}{
+		"refunded_amount": payment.RefundedAmount,
+		"status":          paymentStatus,
+		"updated_by":      changedBy,
+	}).Error; err != nil {
+		return err
+	}
+
+	// Update order
+	order.RefundedAmount += refund.Amount
+	order.Status = "partially_refunded"
+	if order.RefundedAmount >= order.TotalAmount {
+		order.Status = "refunded"
+	}
+	if err := tx.Model(order).Updates(map[string]interface{}{
+		"refunded_amount": order.RefundedAmount,
+		"status":          order.Status,
+		"version":         gorm.Expr("version + 1"),
+		"updated_by":      changedBy,
+	}).Error; err != nil {
+		return err
+	}
+
+	if err := reverseOrderLoyalty(tx, order, &refund.ID, false, changedBy); err != nil {
+		return err
+	}
+	if order.Status == "refunded" {
+		if err := voidOrderGiftCards(tx, order, nil, 0, &refund.ID, changedBy); err != nil {
+			return err
+		}
+	}
+
+	return recordOrderStatusChange(tx, order, oldStatus, order.Status, refund.Reason, changedBy)
+}
+
+// refundAtProvider sends a pending refund to the provider that collected the payment, outside any
+// transaction, then completes it and applies it to the payment and order. A refund the provider
+// refuses is marked failed and releases its amount.
+func (s *RefundService) refundAtProvider(provider PaymentProvider, payment *models.Payment, refund *models.Refund, order *models.Order, changedBy *uint) error {
+	ctx, cancel := context.WithTimeout(context.Background(), paymentProviderTimeout)
+	defer cancel()
+	result, err := provider.Refund(ctx, payment.ProviderReference, refund.Amount, refund.Reason)
+	if err == nil && result.Status == ProviderStatusFailed {
+		err = errors.New("refund refused")
+	}
+	if err != nil {
+		err = fmt.Errorf("payment provider %s: %w", provider.Name(), err)
+		refund.Status = "failed"
+		refund.FailureReason = err.Error()
+		_ = s.db.Model(refund).Updates(map[string]interface{}{
+			"status":         refund.Status,
+			"failure_reason": refund.FailureReason,
+			"updated_by":     changedBy,
+		}).Error
+		return err
+	}
+
+	return s.db.Transaction(func(tx *gorm.DB) error {
+		// Lock the order, then the payment, in the same order as CreateRefund
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Preload("OrderItems.Product").First(order, refund.OrderID).Error; err != nil {
+			return err
+		}
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(payment, refund.PaymentID).Error; err != nil {
+			return err
+		}
+
+		refund.Status = "completed"
+		refund.ProviderReference = result.Reference
+		if err := tx.Model(refund).Updates(map[string]interface{}{
+			"status":             refund.Status,
+			"provider_reference": refund.ProviderReference,
+		}).Error; err != nil {
+			return err
+		}
+		return applyRefund(tx, order, payment, refund, changedBy)
+	})
+}
+
+func (s *RefundService) GetRefund(refundID, tenantID uint) (*models.Refund, error) {
+	var refund models.Refund
+	if err := s.db.Preload("Items.Product").Preload("GiftCard").Preload("Approver").Preload("Creator").
+		Where("id = ? AND tenant_id = ?", refundID, tenantID).
+		First(&refund).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("refund not found")
+		}
+		return nil, err
+	}
+	return &refund, nil
+}
+
+// GetRefundsByPayment returns all refunds of a payment, oldest first
+func (s *RefundService) GetRefundsByPayment(paymentID, tenantID uint) ([]models.Refund, error) {
+	var refunds []models.Refund
+	if err := s.db.Preload("Items.Product").Preload("GiftCard").Preload("Approver").Preload("Creator").
+		Where("payment_id = ? AND tenant_id = ?", paymentID, tenantID).
+		Order("created_at ASC, id ASC").
+		Find(&refunds).Error; err != nil {
+		return nil, err
+	}
+	return refunds, nil
+}
+
+// refundedQuantities returns how many units of each order item were already returned
+func (s *RefundService) refundedQuantities(tx *gorm.DB, orderID uint) (map[uint]int, error) {
+	type row struct {
+		OrderItemID uint
+		Quantity    int
+	}
+	var rows []row
+	if err := tx.Table("refund_items").
+		Select("refund_items.order_item_id, SUM(refund_items.quantity) as quantity").
+		Joins("JOIN refunds ON refunds.id = refund_items.refund_id").
+		Where("refunds.order_id = ? AND refunds.status <> ? AND refunds.deleted_at IS NULL", orderID, "failed").
+		Group("refund_items.order_item_id").
+		Scan(&rows).Error; err != nil {
+		return nil, err
+	}
+
+	refunded := make(map[uint]int, len(rows))
+	for _, r := range rows {
+		refunded[r.OrderItemID] = r.Quantity
+	}
+	return refunded, nil
+}
+
+// itemRefundAmount is the share of the order grand total paid for quantity units of the item,
+// so service charges and taxes are refunded along with the goods. Gift cards carry no charges, so
+// they are refunded at their net amount and the charges are shared over the other items only.
+func itemRefundAmount(order *models.Order, cur money.Currency, item *models.OrderItem, quantity int) money.Amount {
+	if item.Quantity == 0 {
+		return 0
+	}
+	amount := item.NetAmount.MulFrac(int64(quantity), int64(item.Quantity), money.Scale, money.RoundHalfUp)
+	if item.Product.IsGiftCard {
+		return cur.Round(amount)
+	}
+	var giftCards money.Amount
+	for i := range order.OrderItems {
+		if order.OrderItems[i].Product.IsGiftCard {
+			giftCards += order.OrderItems[i].NetAmount
+		}
+	}
+	if charged := order.SubtotalAmount - giftCards; charged > 0 {
+		amount = cur.Share(amount, order.TotalAmount-giftCards, charged)
+	}
+	return cur.Round(amount)
+}
+
+func (s *RefundService) buildRefundItems(tx *gorm.DB, order *models.Order, cur money.Currency, requested []dto.RefundItemRequest) ([]models.RefundItem, money.Amount, error) {
+	if len(requested) == 0 {
+		return nil, 0, nil
+	}
+
+	refunded, err := s.refundedQuantities(tx, order.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	itemMap := make(map[uint]*models.OrderItem, len(order.OrderItems))
+	for i := range order.OrderItems {
+		itemMap[order.OrderItems[i].ID] = &order.OrderItems[i]
+	}
+
+	var total money.Amount
+	items := make([]models.RefundItem, 0, len(requested))
+	for _, req := range requested {
+		item := itemMap[req.OrderItemID]
+		if item == nil {
+			return nil, 0, fmt.Errorf("order item ID %d not found on this order", req.OrderItemID)
+		}
+		remaining := item.Quantity - refunded[item.ID]
+		if req.Quantity > remaining {
+			return nil, 0, fmt.Errorf("only %d unit(s) of order item ID %d can be refunded", remaining, item.ID)
+		}
+		refunded[item.ID] += req.Quantity
+
+		amount := itemRefundAmount(order, cur, item, req.Quantity)
+		total += amount
+		items = append(items, models.RefundItem{
+			OrderItemID: item.ID,
+			ProductID:   item.ProductID,
+			Quantity:    req.Quantity,
+			Amount:      amount,
+		})
+	}
+
+	return items, total, nil
+}
+
+// remainingRefundItems returns every unit of the order not returned by an earlier refund
+func (s *RefundService) remainingRefundItems(tx *gorm.DB, order *models.Order, cur money.Currency) ([]models.RefundItem, money.Amount, error) {
+	refunded, err := s.refundedQuantities(tx, order.ID)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	var requested []dto.RefundItemRequest
+	for _, item := range order.OrderItems {
+		if remaining := item.Quantity - refunded[item.ID]; remaining > 0 {
+			requested = append(requested, dto.RefundItemRequest{OrderItemID: item.ID, Quantity: remaining})
+		}
+	}
+	return s.buildRefundItems(tx, order, cur, requested)
+}
@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stock movement types
+const (
+	StockMovementSale       = "sale"
+	StockMovementRefund     = "refund"
+	StockMovementAdjustment = "adjustment"
+	StockMovementReceipt    = "receipt"
+	StockMovementTransfer   = "transfer"
+	StockMovementStocktake  = "stocktake"
+	StockMovementSync       = "sync"
+)
+
+type StockService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewStockService(db *gorm.DB, auditTrailService *AuditTrailService) *StockService {
+	return &StockService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// lockBranchStock returns the stock row of a product at a branch, locked for update. The row is
+// created empty the first time the branch holds the product.
+func lockBranchStock(tx *gorm.DB, tenantID, branchID, productID uint) (*models.ProductStock, error) {
+	stock := models.ProductStock{TenantID: tenantID, BranchID: branchID, ProductID: productID}
+	if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&stock).Error; err != nil {
+		return nil, err
+	}
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("branch_id = ? AND product_id = ?", branchID, productID).
+		First(&stock).Error; err != nil {
+		return nil, err
+	}
+	return &stock, nil
+}
+
+// branchStockLevels returns the stock each of the products has at the branch
+func branchStockLevels(tx *gorm.DB, branchID uint, productIDs []uint) (map[uint]int, error) {
+	var stocks []models.ProductStock
+	if err := tx.Where("branch_id = ? AND product_id IN ?", branchID, productIDs).Find(&stocks).Error; err != nil {
+		return nil, err
+	}
+	levels := make(map[uint]int, len(stocks))
+	for _, stock := range stocks {
+		levels[stock.ProductID] = stock.Quantity
+	}
+	return levels, nil
+}
+
+// recordStockMovement appends a movement to the stock ledger and moves the stock of the product at
+// the movement's branch, and the product's total, with it. Stock cannot go below zero, except
+// through offline sales that already happened. Gift cards are not stocked, so they never move.
+func recordStockMovement(tx *gorm.DB, movement *models.StockMovement) error {
+	if movement.Quantity == 0 {
+		return nil
+	}
+	if movement.BranchID == 0 {
+		return errors.New("branch_id is required to move stock")
+	}
+
+	var product models.Product
+	if err := tx.Select("id, name, is_gift_card").
+		Where("id = ? AND tenant_id = ?", movement.ProductID, movement.TenantID).
+		First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("product ID %d not found", movement.ProductID)
+		}
+		return err
+	}
+	if product.IsGiftCard {
+		return nil
+	}
+
+	stock, err := lockBranchStock(tx, movement.TenantID, movement.BranchID, movement.ProductID)
+	if err != nil {
+		return err
+	}
+
+	balance := stock.Quantity + movement.Quantity
+	if movement.Quantity < 0 && balance < 0 && movement.Type != StockMovementSync {
+		return fmt.Errorf("insufficient stock for product %s", product.Name)
+	}
+
+	movement.BalanceAfter = balance
+	if err := tx.Create(movement).Error; err != nil {
+		return err
+	}
+	if err := tx.Model(stock).Updates(map[string]interface{}{"quantity": balance}).Error; err != nil {
+		return err
+	}
+	return tx.Model(&models.Product{}).Where("id = ?", product.ID).
+		UpdateColumn("stock", gorm.Expr("stock + ?", movement.Quantity)).Error
+}
+
+// AdjustStock corrects a product's stock at the branch: by a quantity for an adjustment, or to the
+// counted quantity for a stocktake
+func (s *StockService) AdjustStock(productID, tenantID, branchID uint, req dto.StockAdjustmentRequest, createdBy *uint) (*models.StockMovement, error) {
+	if (req.Quantity == 0) == (req.CountedQuantity == nil) {
+		return nil, errors.New("either quantity or counted_quantity is required")
+	}
+
+	movement := &models.StockMovement{
+		TenantID:      tenantID,
+		BranchID:      branchID,
+		ProductID:     productID,
+		Type:          StockMovementAdjustment,
+		Quantity:      req.Quantity,
+		Reason:        req.Reason,
+		ReferenceType: "product",
+		ReferenceID:   &productID,
+		CreatedBy:     createdBy,
+	}
+
+	var oldStock int
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := tenantBranch(tx, tenantID, branchID); err != nil {
+			return err
+		}
+		var product models.Product
+		if err := tx.Select("id, is_gift_card").Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("product not found")
+			}
+			return err
+		}
+		if product.IsGiftCard {
+			return errors.New("gift cards are not stocked")
+		}
+		stock, err := lockBranchStock(tx, tenantID, branchID, productID)
+		if err != nil {
+			return err
+		}
+		oldStock = stock.Quantity
+
+		if req.CountedQuantity != nil {
+			movement.Type = StockMovementStocktake
+			movement.Quantity = *req.CountedQuantity - stock.Quantity
+			if movement.Quantity == 0 {
+				// A count that matches is still worth keeping in the ledger
+				movement.BalanceAfter = stock.Quantity
+				return tx.Create(movement).Error
+			}
+		}
+		return recordStockMovement(tx, movement)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{
+		"type":     movement.Type,
+		"quantity": movement.Quantity,
+		"reason":   movement.Reason,
+		"stock": map[string]interface{}{
+			"old": oldStock,
+			"new": movement.BalanceAfter,
+		},
+	}
+	var auditUserID uint
+	if createdBy != nil {
+		auditUserID = *createdBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, &branchID, auditUserID, "product", productID, "stock_adjustment", changes, "", "")
+
+	return movement, nil
+}
+
+// ListMovements returns the product with one page of its stock movements, newest first. With a
+// branchID only that branch's movements are listed and the product's BranchStock is set to its
+// stock there. An empty movementType matches every type.
+func (s *StockService) ListMovements(productID, tenantID uint, branchID *uint, movementType string, page, pageSize int) (*models.Product, []models.StockMovement, int64, error) {
+	var product models.Product
+	if err := s.db.Where("id = ? AND tenant_id = ?", productID, tenantID).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, 0, errors.New("product not found")
+		}
+		return nil, nil, 0, err
+	}
+
+	var movements []models.StockMovement
+	var total int64
+
+	query := s.db.Model(&models.StockMovement{}).Where("product_id = ? AND tenant_id = ?", productID, tenantID)
+	if branchID != nil {
+		levels, err := branchStockLevels(s.db, *branchID, []uint{product.ID})
+		if err != nil {
+			return nil, nil, 0, err
+		}
+		product.BranchStock = levels[product.ID]
+		query = query.Where("branch_id = ?", *branchID)
+	}
+	if movementType != "" {
+		query = query.Where("type = ?", movementType)
+	}
+	if err := query.Count(&total).Error; err != nil {
+		return nil, nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Creator").Order("created_at DESC, id DESC").
+		Offset(offset).Limit(pageSize).Find(&movements).Error; err != nil {
+		return nil, nil, 0, err
+	}
+
+	return &product, movements, total, nil
+}
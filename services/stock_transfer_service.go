@@ -0,0 +1,441 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Stock transfer statuses
+const (
+	StockTransferDraft     = "draft"
+	StockTransferSent      = "sent"
+	StockTransferReceived  = "received"
+	StockTransferClosed    = "closed"
+	StockTransferCancelled = "cancelled"
+)
+
+// ErrStockTransferReceiver is returned when the user receiving a transfer doesn't work at its destination
+var ErrStockTransferReceiver = errors.New("only users of the receiving branch can receive this transfer")
+
+// tenantWideRoles work across all branches of their tenant
+var tenantWideRoles = map[string]bool{
+	"superadmin": true,
+	"owner":      true,
+}
+
+type StockTransferService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewStockTransferService(db *gorm.DB, auditTrailService *AuditTrailService) *StockTransferService {
+	return &StockTransferService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// tenantBranch returns a branch of the tenant
+func tenantBranch(tx *gorm.DB, tenantID, branchID uint) (*models.Branch, error) {
+	var branch models.Branch
+	if err := tx.Where("id = ? AND tenant_id = ?", branchID, tenantID).First(&branch).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("branch not found or doesn't belong to this tenant")
+		}
+		return nil, err
+	}
+	return &branch, nil
+}
+
+// lockStockTransfer returns a transfer of the tenant with its items, locked so its status cannot
+// change under the caller
+func lockStockTransfer(tx *gorm.DB, transferID, tenantID uint) (*models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND tenant_id = ?", transferID, tenantID).First(&transfer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("stock transfer not found")
+		}
+		return nil, err
+	}
+	if err := tx.Where("stock_transfer_id = ?", transfer.ID).Order("id ASC").Find(&transfer.Items).Error; err != nil {
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// transferStockMovement is a transfer movement of quantity units of a product at a branch
+func transferStockMovement(transfer *models.StockTransfer, branchID, productID uint, quantity int, reason string, createdBy *uint) *models.StockMovement {
+	return &models.StockMovement{
+		TenantID:      transfer.TenantID,
+		BranchID:      branchID,
+		ProductID:     productID,
+		Type:          StockMovementTransfer,
+		Quantity:      quantity,
+		Reason:        reason,
+		ReferenceType: "stock_transfer",
+		ReferenceID:   &transfer.ID,
+		CreatedBy:     createdBy,
+	}
+}
+
+func (s *StockTransferService) audit(transfer *models.StockTransfer, branchID, userID *uint, action string, changes map[string]interface{}) {
+	var auditUserID uint
+	if userID != nil {
+		auditUserID = *userID
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&transfer.TenantID, branchID, auditUserID, "stock_transfer", transfer.ID, action, changes, "", "")
+}
+
+// CreateTransfer drafts a transfer between two branches of the tenant. Stock does not move until
+// the transfer is sent.
+func (s *StockTransferService) CreateTransfer(tenantID, fromBranchID uint, req dto.CreateStockTransferRequest, createdBy *uint) (*models.StockTransfer, error) {
+	if req.FromBranchID != nil {
+		fromBranchID = *req.FromBranchID
+	}
+	if fromBranchID == req.ToBranchID {
+		return nil, errors.New("a transfer needs two different branches")
+	}
+
+	transfer := &models.StockTransfer{
+		TenantID:     tenantID,
+		FromBranchID: fromBranchID,
+		ToBranchID:   req.ToBranchID,
+		Status:       StockTransferDraft,
+		Notes:        req.Notes,
+		CreatedBy:    createdBy,
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if _, err := tenantBranch(tx, tenantID, fromBranchID); err != nil {
+			return err
+		}
+		if _, err := tenantBranch(tx, tenantID, req.ToBranchID); err != nil {
+			return err
+		}
+
+		seen := make(map[uint]bool, len(req.Items))
+		for _, item := range req.Items {
+			if seen[item.ProductID] {
+				return fmt.Errorf("product ID %d is on the transfer more than once", item.ProductID)
+			}
+			seen[item.ProductID] = true
+
+			var count int64
+			// Gift cards are not stocked
+			if err := tx.Model(&models.Product{}).Where("id = ? AND tenant_id = ? AND is_gift_card = ?", item.ProductID, tenantID, false).Count(&count).Error; err != nil {
+				return err
+			}
+			if count == 0 {
+				return fmt.Errorf("product ID %d not found or not stocked", item.ProductID)
+			}
+			transfer.Items = append(transfer.Items, models.StockTransferItem{
+				ProductID: item.ProductID,
+				Quantity:  item.Quantity,
+			})
+		}
+		return tx.Create(transfer).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	items := make([]map[string]interface{}, len(transfer.Items))
+	for i, item := range transfer.Items {
+		items[i] = map[string]interface{}{"product_id": item.ProductID, "quantity": item.Quantity}
+	}
+	s.audit(transfer, &transfer.FromBranchID, createdBy, "create", map[string]interface{}{
+		"from_branch_id": transfer.FromBranchID,
+		"to_branch_id":   transfer.ToBranchID,
+		"notes":          transfer.Notes,
+		"items":          items,
+	})
+
+	return s.GetTransfer(transfer.ID, tenantID)
+}
+
+// SendTransfer takes the items of a draft transfer out of the source branch's stock. They are in
+// transit until the destination receives them.
+func (s *StockTransferService) SendTransfer(transferID, tenantID uint, sentBy *uint) (*models.StockTransfer, error) {
+	var transfer *models.StockTransfer
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = lockStockTransfer(tx, transferID, tenantID)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != StockTransferDraft {
+			return fmt.Errorf("only draft transfers can be sent, this one is %s", transfer.Status)
+		}
+		toBranch, err := tenantBranch(tx, tenantID, transfer.ToBranchID)
+		if err != nil {
+			return err
+		}
+
+		reason := fmt.Sprintf("transfer %d sent to %s", transfer.ID, toBranch.Name)
+		for _, item := range transfer.Items {
+			if err := recordStockMovement(tx, transferStockMovement(transfer, transfer.FromBranchID, item.ProductID, -item.Quantity, reason, sentBy)); err != nil {
+				return err
+			}
+		}
+
+		now := time.Now()
+		transfer.Status = StockTransferSent
+		transfer.SentAt = &now
+		transfer.SentBy = sentBy
+		return tx.Model(transfer).Updates(map[string]interface{}{
+			"status":  transfer.Status,
+			"sent_at": transfer.SentAt,
+			"sent_by": transfer.SentBy,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(transfer, &transfer.FromBranchID, sentBy, "send", map[string]interface{}{
+		"status": map[string]interface{}{"old": StockTransferDraft, "new": StockTransferSent},
+	})
+
+	return s.GetTransfer(transfer.ID, tenantID)
+}
+
+// checkTransferReceiver makes sure the counts come from the destination: the receiving user belongs
+// to the branch the transfer goes to, or works across the tenant
+func checkTransferReceiver(tx *gorm.DB, transfer *models.StockTransfer, receivedBy *uint) error {
+	if receivedBy == nil {
+		return ErrStockTransferReceiver
+	}
+	var user models.User
+	if err := tx.Select("id, tenant_id, branch_id, role").Where("id = ? AND tenant_id = ?", *receivedBy, transfer.TenantID).
+		First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return ErrStockTransferReceiver
+		}
+		return err
+	}
+	if tenantWideRoles[user.Role] || (user.BranchID != nil && *user.BranchID == transfer.ToBranchID) {
+		return nil
+	}
+	return ErrStockTransferReceiver
+}
+
+// ReceiveTransfer books the quantities counted at the destination into its stock. Every item needs
+// a count; a count that differs from the quantity sent is kept as the item's discrepancy. Only users
+// of the destination branch, owners and superadmins may receive.
+func (s *StockTransferService) ReceiveTransfer(transferID, tenantID uint, req dto.ReceiveStockTransferRequest, receivedBy *uint) (*models.StockTransfer, error) {
+	var transfer *models.StockTransfer
+	discrepancies := make([]map[string]interface{}, 0)
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = lockStockTransfer(tx, transferID, tenantID)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != StockTransferSent {
+			return fmt.Errorf("only sent transfers can be received, this one is %s", transfer.Status)
+		}
+		if err := checkTransferReceiver(tx, transfer, receivedBy); err != nil {
+			return err
+		}
+		fromBranch, err := tenantBranch(tx, tenantID, transfer.FromBranchID)
+		if err != nil {
+			return err
+		}
+
+		onTransfer := make(map[uint]bool, len(transfer.Items))
+		for _, item := range transfer.Items {
+			onTransfer[item.ID] = true
+		}
+		counts := make(map[uint]dto.ReceiveStockTransferItem, len(req.Items))
+		for _, item := range req.Items {
+			if !onTransfer[item.ItemID] {
+				return fmt.Errorf("item ID %d is not on this transfer", item.ItemID)
+			}
+			if _, exists := counts[item.ItemID]; exists {
+				return fmt.Errorf("item ID %d is counted more than once", item.ItemID)
+			}
+			counts[item.ItemID] = item
+		}
+
+		reason := fmt.Sprintf("transfer %d received from %s", transfer.ID, fromBranch.Name)
+		for i := range transfer.Items {
+			item := &transfer.Items[i]
+			count, exists := counts[item.ID]
+			if !exists {
+				return fmt.Errorf("received quantity missing for item ID %d", item.ID)
+			}
+
+			item.ReceivedQuantity = count.ReceivedQuantity
+			item.Discrepancy = *count.ReceivedQuantity - item.Quantity
+			item.DiscrepancyNote = count.Note
+			if err := tx.Model(item).Updates(map[string]interface{}{
+				"received_quantity": item.ReceivedQuantity,
+				"discrepancy":       item.Discrepancy,
+				"discrepancy_note":  item.DiscrepancyNote,
+			}).Error; err != nil {
+				return err
+			}
+			if err := recordStockMovement(tx, transferStockMovement(transfer, transfer.ToBranchID, item.ProductID, *item.ReceivedQuantity, reason, receivedBy)); err != nil {
+				return err
+			}
+			if item.Discrepancy != 0 {
+				discrepancies = append(discrepancies, map[string]interface{}{
+					"product_id":  item.ProductID,
+					"sent":        item.Quantity,
+					"received":    *item.ReceivedQuantity,
+					"discrepancy": item.Discrepancy,
+					"note":        item.DiscrepancyNote,
+				})
+			}
+		}
+
+		now := time.Now()
+		transfer.Status = StockTransferReceived
+		transfer.ReceivedAt = &now
+		transfer.ReceivedBy = receivedBy
+		transfer.ReceivedNotes = req.Notes
+		return tx.Model(transfer).Updates(map[string]interface{}{
+			"status":         transfer.Status,
+			"received_at":    transfer.ReceivedAt,
+			"received_by":    transfer.ReceivedBy,
+			"received_notes": transfer.ReceivedNotes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.audit(transfer, &transfer.ToBranchID, receivedBy, "receive", map[string]interface{}{
+		"status":        map[string]interface{}{"old": StockTransferSent, "new": StockTransferReceived},
+		"notes":         transfer.ReceivedNotes,
+		"discrepancies": discrepancies,
+	})
+
+	return s.GetTransfer(transfer.ID, tenantID)
+}
+
+// CloseTransfer closes a received transfer once its discrepancies have been looked at
+func (s *StockTransferService) CloseTransfer(transferID, tenantID uint, req dto.StockTransferNotesRequest, closedBy *uint) (*models.StockTransfer, error) {
+	return s.finishTransfer(transferID, tenantID, StockTransferReceived, StockTransferClosed, req.Notes, closedBy)
+}
+
+// CancelTransfer cancels a draft transfer; no stock has moved yet
+func (s *StockTransferService) CancelTransfer(transferID, tenantID uint, req dto.StockTransferNotesRequest, cancelledBy *uint) (*models.StockTransfer, error) {
+	return s.finishTransfer(transferID, tenantID, StockTransferDraft, StockTransferCancelled, req.Notes, cancelledBy)
+}
+
+// finishTransfer moves a transfer from one status to a final one without moving stock
+func (s *StockTransferService) finishTransfer(transferID, tenantID uint, from, to, notes string, userID *uint) (*models.StockTransfer, error) {
+	var transfer *models.StockTransfer
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		var err error
+		transfer, err = lockStockTransfer(tx, transferID, tenantID)
+		if err != nil {
+			return err
+		}
+		if transfer.Status != from {
+			return fmt.Errorf("only %s transfers can be %s, this one is %s", from, to, transfer.Status)
+		}
+
+		now := time.Now()
+		transfer.Status = to
+		transfer.ClosedAt = &now
+		transfer.ClosedBy = userID
+		transfer.ClosedNotes = notes
+		return tx.Model(transfer).Updates(map[string]interface{}{
+			"status":       transfer.Status,
+			"closed_at":    transfer.ClosedAt,
+			"closed_by":    transfer.ClosedBy,
+			"closed_notes": transfer.ClosedNotes,
+		}).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	action := "close"
+	if to == StockTransferCancelled {
+		action = "cancel"
+	}
+	s.audit(transfer, &transfer.FromBranchID, userID, action, map[string]interface{}{
+		"status": map[string]interface{}{"old": from, "new": to},
+		"notes":  notes,
+	})
+
+	return s.GetTransfer(transfer.ID, tenantID)
+}
+
+// GetTransfer gets a transfer of the tenant with its items
+func (s *StockTransferService) GetTransfer(transferID, tenantID uint) (*models.StockTransfer, error) {
+	var transfer models.StockTransfer
+	if err := s.db.Preload("FromBranch").Preload("ToBranch").Preload("Creator").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).Preload("Items.Product").
+		Where("id = ? AND tenant_id = ?", transferID, tenantID).First(&transfer).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("stock transfer not found")
+		}
+		return nil, err
+	}
+	return &transfer, nil
+}
+
+// ListTransfers lists the tenant's transfers, newest first. A non-zero branchID matches transfers
+// from or to the branch; an empty status matches all.
+func (s *StockTransferService) ListTransfers(tenantID, branchID uint, status string, page, pageSize int) ([]models.StockTransfer, int64, error) {
+	var transfers []models.StockTransfer
+	var total int64
+
+	query := s.db.Model(&models.StockTransfer{}).Where("tenant_id = ?", tenantID)
+	if branchID != 0 {
+		query = query.Where("from_branch_id = ? OR to_branch_id = ?", branchID, branchID)
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("FromBranch").Preload("ToBranch").Preload("Creator").
+		Preload("Items", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).Preload("Items.Product").
+		Order("created_at DESC, id DESC").Offset(offset).Limit(pageSize).Find(&transfers).Error; err != nil {
+		return nil, 0, err
+	}
+
+	return transfers, total, nil
+}
+
+// ListInTransit sums the stock on sent transfers that have not been received yet, per product and
+// route. Non-zero branchID matches transfers from or to the branch, non-zero productID one product.
+func (s *StockTransferService) ListInTransit(tenantID, branchID, productID uint) ([]dto.InTransitStockResponse, error) {
+	query := s.db.Table("stock_transfer_items AS i").
+		Select("i.product_id, p.name AS product_name, t.from_branch_id, fb.name AS from_branch_name, "+
+			"t.to_branch_id, tb.name AS to_branch_name, SUM(i.quantity) AS quantity, COUNT(DISTINCT t.id) AS transfers").
+		Joins("JOIN stock_transfers t ON t.id = i.stock_transfer_id").
+		Joins("JOIN products p ON p.id = i.product_id").
+		Joins("JOIN branches fb ON fb.id = t.from_branch_id").
+		Joins("JOIN branches tb ON tb.id = t.to_branch_id").
+		Where("t.tenant_id = ? AND t.status = ?", tenantID, StockTransferSent)
+	if branchID != 0 {
+		query = query.Where("t.from_branch_id = ? OR t.to_branch_id = ?", branchID, branchID)
+	}
+	if productID != 0 {
+		query = query.Where("i.product_id = ?", productID)
+	}
+
+	var stock []dto.InTransitStockResponse
+	if err := query.Group("i.product_id, p.name, t.from_branch_id, fb.name, t.to_branch_id, tb.name").
+		Order("p.name ASC, t.from_branch_id ASC, t.to_branch_id ASC").Scan(&stock).Error; err != nil {
+		return nil, err
+	}
+	return stock, nil
+}
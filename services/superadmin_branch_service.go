@@ -5,6 +5,7 @@ import (
 	"myposcore/database"
 	"myposcore/dto"
 	"myposcore/models"
+	"time"
 
 	"gorm.io/gorm"
 )
@@ -59,6 +60,11 @@ func (s *SuperAdminBranchService) CreateBranch(req dto.CreateBranchRequest, imag
 		return nil, err
 	}
 
+	timezone, err := validateBranchTimezone(req.Timezone)
+	if err != nil {
+		return nil, err
+	}
+
 	// Create branch
 	branch := models.Branch{
 		TenantID:    req.TenantID,
@@ -69,6 +75,7 @@ func (s *SuperAdminBranchService) CreateBranch(req dto.CreateBranchRequest, imag
 		Email:       req.Email,
 		Phone:       req.Phone,
 		Image:       imageURL,
+		Timezone:    timezone,
 		IsActive:    req.Active,
 		CreatedBy:   createdBy,
 	}
@@ -90,6 +97,14 @@ func (s *SuperAdminBranchService) UpdateBranch(id uint, req dto.UpdateBranchRequ
 		return nil, err
 	}
 
+	if req.Timezone != "" {
+		timezone, err := validateBranchTimezone(req.Timezone)
+		if err != nil {
+			return nil, err
+		}
+		branch.Timezone = timezone
+	}
+
 	// Update branch
 	branch.Name = req.Name
 	branch.Description = req.Description
@@ -112,6 +127,17 @@ func (s *SuperAdminBranchService) UpdateBranch(id uint, req dto.UpdateBranchRequ
 	return &branch, nil
 }
 
+// validateBranchTimezone checks the timezone is a known IANA name, defaulting to Asia/Jakarta
+func validateBranchTimezone(timezone string) (string, error) {
+	if timezone == "" {
+		return defaultBranchTimezone, nil
+	}
+	if _, err := time.LoadLocation(timezone); err != nil {
+		return "", errors.New("invalid timezone, use an IANA name such as Asia/Jakarta")
+	}
+	return timezone, nil
+}
+
 func (s *SuperAdminBranchService) GetBranchByID(id uint) (*models.Branch, error) {
 	var branch models.Branch
 	if err := s.db.First(&branch, id).Error; err != nil {
@@ -4,6 +4,7 @@ import (
 	"myposcore/database"
 	"myposcore/dto"
 	"myposcore/models"
+	"myposcore/money"
 	"time"
 
 	"gorm.io/gorm"
@@ -62,59 +63,90 @@ func (s *SuperAdminDashboardService) GetDashboard() (*dto.DashboardResponse, err
 	start180Days := startOfDay.AddDate(0, 0, -180)
 	start360Days := startOfDay.AddDate(0, 0, -360)
 
-	// Calculate transaction statistics (count of payment records)
-	transactionStats := dto.TransactionStats{}
-
-	// Transactions all time
-	if err := s.db.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Scan(&transactionStats.AllTime).Error; err != nil {
-		return nil, err
+	periods := dashboardPeriods{
+		today:       startOfDay,
+		thisWeek:    startOfWeek,
+		thisMonth:   startOfMonth,
+		last7Days:   start7Days,
+		last30Days:  start30Days,
+		last90Days:  start90Days,
+		last180Days: start180Days,
+		last360Days: start360Days,
 	}
 
-	// Transactions today
-	if err := s.db.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Where("created_at >= ?", startOfDay).Scan(&transactionStats.Today).Error; err != nil {
+	// Calculate transaction statistics (sum of payment records)
+	transactionStats, err := s.sumAmounts(&models.Payment{}, periods)
+	if err != nil {
 		return nil, err
 	}
 
-	// Transactions this week
-	if err := s.db.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Where("created_at >= ?", startOfWeek).Scan(&transactionStats.ThisWeek).Error; err != nil {
+	// Calculate refund statistics (sum of refund records)
+	refundStats, err := s.sumAmounts(&models.Refund{}, periods)
+	if err != nil {
 		return nil, err
 	}
 
-	// Transactions this month
-	if err := s.db.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Where("created_at >= ?", startOfMonth).Scan(&transactionStats.ThisMonth).Error; err != nil {
-		return nil, err
+	netStats := dto.TransactionStats{
+		AllTime:     transactionStats.AllTime - refundStats.AllTime,
+		Today:       transactionStats.Today - refundStats.Today,
+		ThisWeek:    transactionStats.ThisWeek - refundStats.ThisWeek,
+		ThisMonth:   transactionStats.ThisMonth - refundStats.ThisMonth,
+		Last7Days:   transactionStats.Last7Days - refundStats.Last7Days,
+		Last30Days:  transactionStats.Last30Days - refundStats.Last30Days,
+		Last90Days:  transactionStats.Last90Days - refundStats.Last90Days,
+		Last180Days: transactionStats.Last180Days - refundStats.Last180Days,
+		Last360Days: transactionStats.Last360Days - refundStats.Last360Days,
 	}
 
-	// Transactions last 7 days
-	if err := s.db.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Where("created_at >= ?", start7Days).Scan(&transactionStats.Last7Days).Error; err != nil {
-		return nil, err
-	}
+	return &dto.DashboardResponse{
+		TotalTenants:    totalTenants,
+		TotalBranches:   totalBranches,
+		TotalUsers:      totalUsers,
+		TotalProducts:   totalProducts,
+		Transactions:    *transactionStats,
+		Refunds:         *refundStats,
+		NetTransactions: netStats,
+	}, nil
+}
 
-	// Transactions last 30 days
-	if err := s.db.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Where("created_at >= ?", start30Days).Scan(&transactionStats.Last30Days).Error; err != nil {
-		return nil, err
-	}
+// dashboardPeriods holds the start of every period shown on the dashboard
+type dashboardPeriods struct {
+	today       time.Time
+	thisWeek    time.Time
+	thisMonth   time.Time
+	last7Days   time.Time
+	last30Days  time.Time
+	last90Days  time.Time
+	last180Days time.Time
+	last360Days time.Time
+}
 
-	// Transactions last 90 days
-	if err := s.db.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Where("created_at >= ?", start90Days).Scan(&transactionStats.Last90Days).Error; err != nil {
-		return nil, err
-	}
+// sumAmounts sums the amount column of model for all time and every dashboard period
+func (s *SuperAdminDashboardService) sumAmounts(model interface{}, periods dashboardPeriods) (*dto.TransactionStats, error) {
+	stats := &dto.TransactionStats{}
 
-	// Transactions last 180 days
-	if err := s.db.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Where("created_at >= ?", start180Days).Scan(&transactionStats.Last180Days).Error; err != nil {
+	if err := s.db.Model(model).Select("COALESCE(SUM(amount), 0)").Scan(&stats.AllTime).Error; err != nil {
 		return nil, err
 	}
 
-	// Transactions last 360 days
-	if err := s.db.Model(&models.Payment{}).Select("COALESCE(SUM(amount), 0)").Where("created_at >= ?", start360Days).Scan(&transactionStats.Last360Days).Error; err != nil {
-		return nil, err
+	targets := []struct {
+		since time.Time
+		dest  *money.Amount
+	}{
+		{periods.today, &stats.Today},
+		{periods.thisWeek, &stats.ThisWeek},
+		{periods.thisMonth, &stats.ThisMonth},
+		{periods.last7Days, &stats.Last7Days},
+		{periods.last30Days, &stats.Last30Days},
+		{periods.last90Days, &stats.Last90Days},
+		{periods.last180Days, &stats.Last180Days},
+		{periods.last360Days, &stats.Last360Days},
+	}
+	for _, target := range targets {
+		if err := s.db.Model(model).Select("COALESCE(SUM(amount), 0)").Where("created_at >= ?", target.since).Scan(target.dest).Error; err != nil {
+			return nil, err
+		}
 	}
 
-	return &dto.DashboardResponse{
-		TotalTenants:  totalTenants,
-		TotalBranches: totalBranches,
-		TotalUsers:    totalUsers,
-		TotalProducts: totalProducts,
-		Transactions:  transactionStats,
-	}, nil
+	return stats, nil
 }
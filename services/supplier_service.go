@@ -0,0 +1,220 @@
+package services
+
+import (
+	"errors"
+	"myposcore/dto"
+	"myposcore/models"
+	"strings"
+
+	"gorm.io/gorm"
+)
+
+type SupplierService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewSupplierService(db *gorm.DB, auditTrailService *AuditTrailService) *SupplierService {
+	return &SupplierService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+func (s *SupplierService) CreateSupplier(tenantID uint, req dto.CreateSupplierRequest) (*models.Supplier, error) {
+	supplier := models.Supplier{
+		TenantID:    tenantID,
+		Name:        strings.TrimSpace(req.Name),
+		ContactName: strings.TrimSpace(req.ContactName),
+		Phone:       strings.TrimSpace(req.Phone),
+		Email:       strings.ToLower(strings.TrimSpace(req.Email)),
+		Address:     req.Address,
+		Notes:       req.Notes,
+		IsActive:    true,
+		CreatedBy:   req.CreatedBy,
+	}
+	if supplier.Name == "" {
+		return nil, errors.New("name is required")
+	}
+	if err := s.checkNameUnique(tenantID, supplier.Name, 0); err != nil {
+		return nil, err
+	}
+
+	if err := s.db.Create(&supplier).Error; err != nil {
+		return nil, err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"name":         supplier.Name,
+		"contact_name": supplier.ContactName,
+		"phone":        supplier.Phone,
+		"email":        supplier.Email,
+	}
+	var auditUserID uint
+	if req.CreatedBy != nil {
+		auditUserID = *req.CreatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "supplier", supplier.ID, "create", changes, "", "")
+
+	return s.GetSupplier(supplier.ID, tenantID)
+}
+
+func (s *SupplierService) GetSupplier(id, tenantID uint) (*models.Supplier, error) {
+	var supplier models.Supplier
+	if err := s.db.Preload("Creator").Preload("Updater").
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&supplier).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("supplier not found")
+		}
+		return nil, err
+	}
+	return &supplier, nil
+}
+
+// ListSuppliers searches the directory by name, contact, phone or email. activeOnly leaves out
+// suppliers that are no longer ordered from.
+func (s *SupplierService) ListSuppliers(tenantID uint, search string, activeOnly bool, page, pageSize int) ([]models.Supplier, int64, error) {
+	var suppliers []models.Supplier
+	var total int64
+
+	query := s.db.Model(&models.Supplier{}).Where("tenant_id = ?", tenantID)
+
+	if search != "" {
+		searchPattern := "%" + search + "%"
+		query = query.Where("name ILIKE ? OR contact_name ILIKE ? OR phone ILIKE ? OR email ILIKE ?", searchPattern, searchPattern, searchPattern, searchPattern)
+	}
+	if activeOnly {
+		query = query.Where("is_active = ?", true)
+	}
+
+	if err := query.Count(&total).Error; err != nil {
+		return nil, 0, err
+	}
+
+	offset := (page - 1) * pageSize
+	if err := query.Preload("Creator").Preload("Updater").
+		Order("name ASC, id ASC").
+		Limit(pageSize).
+		Offset(offset).
+		Find(&suppliers).Error; err != nil {
+		return nil, 0, err
+	}
+	return suppliers, total, nil
+}
+
+func (s *SupplierService) UpdateSupplier(id, tenantID uint, req dto.UpdateSupplierRequest) (*models.Supplier, error) {
+	supplier, err := s.GetSupplier(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	changes := map[string]interface{}{}
+	if req.Name != nil {
+		name := strings.TrimSpace(*req.Name)
+		if name == "" {
+			return nil, errors.New("name is required")
+		}
+		changes["name"] = map[string]interface{}{"old": supplier.Name, "new": name}
+		supplier.Name = name
+	}
+	if req.ContactName != nil {
+		contactName := strings.TrimSpace(*req.ContactName)
+		changes["contact_name"] = map[string]interface{}{"old": supplier.ContactName, "new": contactName}
+		supplier.ContactName = contactName
+	}
+	if req.Phone != nil {
+		phone := strings.TrimSpace(*req.Phone)
+		changes["phone"] = map[string]interface{}{"old": supplier.Phone, "new": phone}
+		supplier.Phone = phone
+	}
+	if req.Email != nil {
+		email := strings.ToLower(strings.TrimSpace(*req.Email))
+		changes["email"] = map[string]interface{}{"old": supplier.Email, "new": email}
+		supplier.Email = email
+	}
+	if req.Address != nil {
+		supplier.Address = *req.Address
+		changes["address"] = *req.Address
+	}
+	if req.Notes != nil {
+		supplier.Notes = *req.Notes
+		changes["notes"] = *req.Notes
+	}
+	if req.IsActive != nil {
+		changes["is_active"] = map[string]interface{}{"old": supplier.IsActive, "new": *req.IsActive}
+		supplier.IsActive = *req.IsActive
+	}
+	if err := s.checkNameUnique(tenantID, supplier.Name, supplier.ID); err != nil {
+		return nil, err
+	}
+
+	supplier.UpdatedBy = req.UpdatedBy
+	if err := s.db.Model(supplier).Select("name", "contact_name", "phone", "email", "address", "notes", "is_active", "updated_by").
+		Updates(supplier).Error; err != nil {
+		return nil, err
+	}
+
+	var auditUserID uint
+	if req.UpdatedBy != nil {
+		auditUserID = *req.UpdatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "supplier", supplier.ID, "update", changes, "", "")
+
+	return s.GetSupplier(supplier.ID, tenantID)
+}
+
+// DeleteSupplier soft deletes the supplier. Its purchase orders keep the supplier for reporting.
+func (s *SupplierService) DeleteSupplier(id, tenantID uint, deletedBy *uint) error {
+	supplier, err := s.GetSupplier(id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	var openOrders int64
+	if err := s.db.Model(&models.PurchaseOrder{}).
+		Where("tenant_id = ? AND supplier_id = ? AND status IN ?", tenantID, supplier.ID, purchaseOrderOpenStatuses).
+		Count(&openOrders).Error; err != nil {
+		return err
+	}
+	if openOrders > 0 {
+		return errors.New("supplier has open purchase orders")
+	}
+
+	if deletedBy != nil {
+		if err := s.db.Model(supplier).Update("deleted_by", deletedBy).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.Delete(supplier).Error; err != nil {
+		return err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"name": supplier.Name,
+	}
+	var auditUserID uint
+	if deletedBy != nil {
+		auditUserID = *deletedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "supplier", supplier.ID, "delete", changes, "", "")
+
+	return nil
+}
+
+// checkNameUnique keeps supplier names unique within the tenant's directory
+func (s *SupplierService) checkNameUnique(tenantID uint, name string, excludeID uint) error {
+	var count int64
+	if err := s.db.Model(&models.Supplier{}).
+		Where("tenant_id = ? AND LOWER(name) = LOWER(?) AND id != ?", tenantID, name, excludeID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return errors.New("supplier with this name already exists")
+	}
+	return nil
+}
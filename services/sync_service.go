@@ -6,17 +6,29 @@ import (
 	"fmt"
 	"myposcore/dto"
 	"myposcore/models"
+	"myposcore/money"
 	"time"
 
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type SyncService struct {
-	db *gorm.DB
+	db                 *gorm.DB
+	orderNumberService *OrderNumberService
+	taxService         *TaxService
+	approvalService    *ApprovalService
 }
 
-func NewSyncService(db *gorm.DB) *SyncService {
-	return &SyncService{db: db}
+func NewSyncService(db *gorm.DB, orderNumberService *OrderNumberService, taxService *TaxService, approvalService *ApprovalService) *SyncService {
+	return &SyncService{db: db, orderNumberService: orderNumberService, taxService: taxService, approvalService: approvalService}
+}
+
+// syncApproval is a supervisor approval given to a synced order, recorded once the upload committed
+type syncApproval struct {
+	approved *ApprovedAction
+	order    models.Order
+	details  map[string]interface{}
 }
 
 // UploadFromClient - Upload data dari mobile client ke server
@@ -52,6 +64,7 @@ func (s *SyncService) UploadFromClient(req *dto.SyncUploadRequest, tenantID, bra
 	}
 
 	// Process all entities in transaction
+	var approvals []syncApproval
 	err := s.db.Transaction(func(tx *gorm.DB) error {
 		// 1. Process Tenants
 		for _, tenantData := range req.Tenants {
@@ -135,7 +148,7 @@ func (s *SyncService) UploadFromClient(req *dto.SyncUploadRequest, tenantID, bra
 
 		// 6. Process Orders
 		for _, orderData := range req.Orders {
-			serverOrderID, err := s.processOrder(tx, &orderData, tenantID, branchID, userID, req.ClientID)
+			serverOrderID, conflict, err := s.processOrder(tx, &orderData, tenantID, branchID, userID, req.ClientID, &approvals)
 			if err != nil {
 				response.FailedOrders++
 				response.Errors = append(response.Errors, dto.SyncErrorInfo{
@@ -147,6 +160,9 @@ func (s *SyncService) UploadFromClient(req *dto.SyncUploadRequest, tenantID, bra
 			}
 			response.ProcessedOrders++
 			response.OrderMapping[orderData.LocalID] = serverOrderID
+			if conflict != nil {
+				response.Conflicts = append(response.Conflicts, *conflict)
+			}
 		}
 
 		// 7. Process Payments
@@ -189,6 +205,9 @@ func (s *SyncService) UploadFromClient(req *dto.SyncUploadRequest, tenantID, bra
 		syncLog.ErrorMessage = err.Error()
 	} else {
 		syncLog.Status = "completed"
+		for _, approval := range approvals {
+			s.approvalService.Record(tenantID, &approval.order.BranchID, approval.approved, "order", approval.order.ID, approval.details)
+		}
 	}
 
 	duration := time.Since(startTime)
@@ -204,32 +223,331 @@ func (s *SyncService) UploadFromClient(req *dto.SyncUploadRequest, tenantID, bra
 	return response, err
 }
 
-// processOrder - Process single order
-func (s *SyncService) processOrder(tx *gorm.DB, orderData *dto.SyncOrderData, tenantID, branchID, userID uint, clientID string) (uint, error) {
+// syncOrderPricing - Totals of an offline order computed on the server from its items
+type syncOrderPricing struct {
+	Products       map[uint]models.Product
+	Lines          []PromotionLine // Discount includes the share of the order level discount
+	GrossAmount    money.Amount
+	DiscountAmount money.Amount
+	Taxes          *TaxResult
+}
+
+// priceSyncOrder computes the service charges, taxes and grand total of an offline order the same
+// way CreateOrder does, from the discounted items. A discount the client gave on the whole order is
+// spread over the lines first so category exemptions see it.
+func (s *SyncService) priceSyncOrder(tx *gorm.DB, orderData *dto.SyncOrderData, tenantID, branchID uint) (*syncOrderPricing, error) {
+	productIDs := make([]uint, len(orderData.Items))
+	for i, item := range orderData.Items {
+		productIDs[i] = item.ProductID
+	}
+	var products []models.Product
+	if err := tx.Select("id, category_id, cost_price, is_gift_card").Where("id IN ? AND tenant_id = ?", productIDs, tenantID).
+		Find(&products).Error; err != nil {
+		return nil, err
+	}
+	pricing := &syncOrderPricing{Products: make(map[uint]models.Product, len(products))}
+	for _, product := range products {
+		pricing.Products[product.ID] = product
+	}
+
+	var lineDiscount money.Amount
+	pricing.Lines = make([]PromotionLine, len(orderData.Items))
+	for i, item := range orderData.Items {
+		product, ok := pricing.Products[item.ProductID]
+		if !ok {
+			return nil, fmt.Errorf("product ID %d not found", item.ProductID)
+		}
+		pricing.Lines[i] = PromotionLine{
+			ProductID:  item.ProductID,
+			CategoryID: product.CategoryID,
+			Quantity:   item.Quantity,
+			Price:      item.Price,
+			Subtotal:   item.Subtotal,
+			Discount:   item.Discount,
+			NoDiscount: product.IsGiftCard,
+		}
+		// Gift cards sell at face value whatever the client discounted
+		if product.IsGiftCard {
+			pricing.Lines[i].Discount = 0
+		}
+		pricing.GrossAmount += item.Subtotal
+		lineDiscount += item.Discount
+	}
+
+	cur, err := tenantCurrency(tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	if orderData.DiscountAmount > lineDiscount {
+		allocateOrderDiscount(pricing.Lines, cur, orderData.DiscountAmount-lineDiscount)
+	}
+
+	taxLines := make([]TaxLine, len(pricing.Lines))
+	for i, line := range pricing.Lines {
+		pricing.DiscountAmount += line.Discount
+		taxLines[i] = TaxLine{CategoryID: line.CategoryID, NetAmount: line.Subtotal - line.Discount, TaxExempt: pricing.Products[line.ProductID].IsGiftCard}
+	}
+	pricing.Taxes, err = s.taxService.CalculateTaxes(tx, tenantID, branchID, taxLines)
+	if err != nil {
+		return nil, err
+	}
+	return pricing, nil
+}
+
+// applySyncOrderPricing stores the server computed totals and tax breakdown on the order. When the
+// client computed different totals the server's are kept and a conflict is recorded for review.
+func applySyncOrderPricing(tx *gorm.DB, order *models.Order, orderData *dto.SyncOrderData, pricing *syncOrderPricing, clientID string) (*dto.SyncConflictInfo, error) {
+	taxes := pricing.Taxes
+	order.GrossAmount = pricing.GrossAmount
+	order.DiscountAmount = pricing.DiscountAmount
+	order.SubtotalAmount = taxes.SubtotalAmount
+	order.ServiceChargeAmount = taxes.ServiceChargeAmount
+	order.TaxAmount = taxes.TaxAmount
+	order.InclusiveTaxAmount = taxes.InclusiveTaxAmount
+	order.TotalAmount = taxes.GrandTotal
+
+	if err := tx.Where("order_id = ?", order.ID).Delete(&models.OrderTax{}).Error; err != nil {
+		return nil, err
+	}
+	if len(taxes.Taxes) > 0 {
+		for i := range taxes.Taxes {
+			taxes.Taxes[i].OrderID = order.ID
+		}
+		if err := tx.Create(&taxes.Taxes).Error; err != nil {
+			return nil, err
+		}
+	}
+
+	if orderData.TotalAmount == taxes.GrandTotal && orderData.ServiceChargeAmount == taxes.ServiceChargeAmount &&
+		orderData.TaxAmount == taxes.TaxAmount && orderData.InclusiveTaxAmount == taxes.InclusiveTaxAmount {
+		order.SyncStatus = "synced"
+		order.ConflictData = nil
+		return nil, tx.Model(order).Select("gross_amount", "discount_amount", "subtotal_amount", "service_charge_amount",
+			"tax_amount", "inclusive_tax_amount", "total_amount", "sync_status", "conflict_data").Updates(order).Error
+	}
+
+	clientTotals := map[string]interface{}{
+		"total_amount":          orderData.TotalAmount,
+		"service_charge_amount": orderData.ServiceChargeAmount,
+		"tax_amount":            orderData.TaxAmount,
+		"inclusive_tax_amount":  orderData.InclusiveTaxAmount,
+	}
+	serverTotals := map[string]interface{}{
+		"total_amount":          taxes.GrandTotal,
+		"service_charge_amount": taxes.ServiceChargeAmount,
+		"tax_amount":            taxes.TaxAmount,
+		"inclusive_tax_amount":  taxes.InclusiveTaxAmount,
+	}
+	clientData, _ := json.Marshal(clientTotals)
+	serverData, _ := json.Marshal(serverTotals)
+	conflictData, _ := json.Marshal(map[string]interface{}{"client": clientTotals, "server": serverTotals})
+	conflictStr := string(conflictData)
+	order.SyncStatus = "conflict"
+	order.ConflictData = &conflictStr
+	if err := tx.Model(order).Select("gross_amount", "discount_amount", "subtotal_amount", "service_charge_amount",
+		"tax_amount", "inclusive_tax_amount", "total_amount", "sync_status", "conflict_data").Updates(order).Error; err != nil {
+		return nil, err
+	}
+
+	branchID := order.BranchID
+	if err := tx.Create(&models.SyncConflict{
+		TenantID:      order.TenantID,
+		BranchID:      &branchID,
+		EntityType:    "order",
+		EntityID:      order.ID,
+		ClientID:      clientID,
+		ClientVersion: orderData.Version,
+		ServerVersion: order.Version,
+		ClientData:    string(clientData),
+		ServerData:    string(serverData),
+		ConflictType:  "total_mismatch",
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.SyncConflictInfo{
+		EntityType:    "order",
+		LocalID:       orderData.LocalID,
+		ConflictType:  "total_mismatch",
+		ClientVersion: orderData.Version,
+		ServerVersion: order.Version,
+		Resolution:    "server_wins",
+		Message:       fmt.Sprintf("order total recomputed on the server: client %s, server %s", orderData.TotalAmount, taxes.GrandTotal),
+	}, nil
+}
+
+// syncNewOrderStatus returns the status an order new to the server starts in. Orders start before
+// payment, or cancelled or voided: an order completed offline starts ready and its synced payments
+// complete it, so gift cards and loyalty points are only issued for orders that were paid. Refunds
+// are only made online.
+func syncNewOrderStatus(status string) (string, error) {
+	switch status {
+	case "pending", "confirmed", "preparing", "ready", "cancelled", "voided":
+		return status, nil
+	case "completed":
+		return "ready", nil
+	case "partially_refunded", "refunded":
+		return "", fmt.Errorf("order status %s cannot be synced, refunds are only made online", status)
+	}
+	return "", fmt.Errorf("invalid order status: %s", status)
+}
+
+// syncOrderItemsConflict compares the items of an order synced again with the ones it was first
+// synced with. Items are priced and take stock when an order first syncs, so changes made to them
+// afterwards are not applied: the server's items and totals are kept and a conflict is recorded for
+// review. Returns nil when the items are unchanged.
+func syncOrderItemsConflict(tx *gorm.DB, order *models.Order, orderData *dto.SyncOrderData, clientID string) (*dto.SyncConflictInfo, error) {
+	var items []models.OrderItem
+	if err := tx.Where("order_id = ?", order.ID).Order("id ASC").Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	changed := len(items) != len(orderData.Items)
+	for i := 0; !changed && i < len(items); i++ {
+		item, itemData := items[i], orderData.Items[i]
+		changed = item.ProductID != itemData.ProductID || item.Quantity != itemData.Quantity ||
+			item.Price != itemData.Price || item.Subtotal != itemData.Subtotal
+	}
+	if !changed {
+		return nil, nil
+	}
+
+	serverItems := make([]dto.SyncOrderItemData, len(items))
+	for i, item := range items {
+		serverItems[i] = dto.SyncOrderItemData{
+			ProductID: item.ProductID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+			Subtotal:  item.Subtotal,
+			Discount:  item.DiscountAmount,
+		}
+	}
+	clientData, _ := json.Marshal(map[string]interface{}{"items": orderData.Items})
+	serverData, _ := json.Marshal(map[string]interface{}{"items": serverItems})
+	conflictData, _ := json.Marshal(map[string]interface{}{"client": orderData.Items, "server": serverItems})
+	conflictStr := string(conflictData)
+	order.SyncStatus = "conflict"
+	order.ConflictData = &conflictStr
+
+	branchID := order.BranchID
+	if err := tx.Create(&models.SyncConflict{
+		TenantID:      order.TenantID,
+		BranchID:      &branchID,
+		EntityType:    "order",
+		EntityID:      order.ID,
+		ClientID:      clientID,
+		ClientVersion: orderData.Version,
+		ServerVersion: order.Version,
+		ClientData:    string(clientData),
+		ServerData:    string(serverData),
+		ConflictType:  "items_changed",
+	}).Error; err != nil {
+		return nil, err
+	}
+
+	return &dto.SyncConflictInfo{
+		EntityType:    "order",
+		LocalID:       orderData.LocalID,
+		ConflictType:  "items_changed",
+		ClientVersion: orderData.Version,
+		ServerVersion: order.Version,
+		Resolution:    "server_wins",
+		Message:       "items of an order cannot change once synced; the server's items and totals are kept",
+	}, nil
+}
+
+// processOrder - Process single order. Totals and taxes are computed on the server; a conflict is
+// returned when the client's differ.
+func (s *SyncService) processOrder(tx *gorm.DB, orderData *dto.SyncOrderData, tenantID, branchID, userID uint, clientID string, approvals *[]syncApproval) (uint, *dto.SyncConflictInfo, error) {
+	customerID, err := resolveOrderCustomer(tx, tenantID, orderData.CustomerID, orderData.MemberPhone)
+	if err != nil {
+		return 0, nil, err
+	}
+
 	// Check if order already exists (by client_id + local_id)
 	var existing models.Order
-	err := tx.Where("tenant_id = ? AND branch_id = ? AND client_id = ?", tenantID, branchID, clientID+"_"+orderData.LocalID).First(&existing).Error
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("tenant_id = ? AND branch_id = ? AND client_id = ?", tenantID, branchID, clientID+"_"+orderData.LocalID).First(&existing).Error
 
 	if err == nil {
 		// Order exists - check version for conflict
 		if existing.Version > orderData.Version {
-			return existing.ID, fmt.Errorf("version conflict: server version %d > client version %d", existing.Version, orderData.Version)
+			return existing.ID, nil, fmt.Errorf("version conflict: server version %d > client version %d", existing.Version, orderData.Version)
+		}
+		// Status changes made offline follow the same transitions as online ones
+		oldStatus := existing.Status
+		if orderData.Status != oldStatus && !CanTransitionOrderStatus(oldStatus, orderData.Status) {
+			return existing.ID, nil, fmt.Errorf("cannot change order status from %s to %s", oldStatus, orderData.Status)
+		}
+		// Like online, an order is only completed once paid; payments synced later complete it
+		status := orderData.Status
+		if status == "completed" && existing.PaidAmount < existing.TotalAmount {
+			status = oldStatus
 		}
+		// Cancelling an order that already took money needs a supervisor, offline as much as online
+		var approved *ApprovedAction
+		if status != oldStatus && orderStatusRestoresStock(status) && existing.PaidAmount > 0 {
+			approved, err = s.approvalService.Verify(tenantID, &userID, ApprovalCancelPaidOrder, orderData.Approval)
+			if err != nil {
+				return existing.ID, nil, err
+			}
+		}
+
+		// Items and totals are kept as first synced; changes to them are only reported
+		conflict, err := syncOrderItemsConflict(tx, &existing, orderData, clientID)
+		if err != nil {
+			return 0, nil, err
+		}
+
 		// Update existing order
-		existing.TotalAmount = orderData.TotalAmount
-		existing.Status = orderData.Status
+		existing.Status = status
 		existing.Notes = orderData.Notes
+		existing.CustomerID = customerID
 		existing.Version = orderData.Version + 1
-		existing.SyncStatus = "synced"
+		if conflict == nil {
+			existing.SyncStatus = "synced"
+			existing.ConflictData = nil
+		}
 		existing.UpdatedBy = &userID
 		if err := tx.Save(&existing).Error; err != nil {
-			return 0, err
+			return 0, nil, err
 		}
-		return existing.ID, nil
+		if oldStatus != existing.Status {
+			if orderStatusRestoresStock(existing.Status) && !orderStatusRestoresStock(oldStatus) {
+				if _, err := restoreOrderStock(tx, &existing, "order "+existing.Status+": offline sync", &userID); err != nil {
+					return 0, nil, err
+				}
+				if err := returnPromotionUsage(tx, existing.ID); err != nil {
+					return 0, nil, err
+				}
+			}
+			if err := recordOrderStatusChange(tx, &existing, oldStatus, existing.Status, "offline sync", &userID); err != nil {
+				return 0, nil, err
+			}
+		}
+		if approved != nil {
+			*approvals = append(*approvals, syncApproval{approved: approved, order: existing, details: map[string]interface{}{
+				"order_number": existing.OrderNumber,
+				"from_status":  oldStatus,
+				"to_status":    existing.Status,
+				"paid_amount":  existing.PaidAmount,
+				"reason":       "offline sync",
+			}})
+		}
+		return existing.ID, conflict, nil
 	}
 
 	if !errors.Is(err, gorm.ErrRecordNotFound) {
-		return 0, err
+		return 0, nil, err
+	}
+
+	status, err := syncNewOrderStatus(orderData.Status)
+	if err != nil {
+		return 0, nil, err
+	}
+	pricing, err := s.priceSyncOrder(tx, orderData, tenantID, branchID)
+	if err != nil {
+		return 0, nil, err
 	}
 
 	// Create new order
@@ -238,8 +556,9 @@ func (s *SyncService) processOrder(tx *gorm.DB, orderData *dto.SyncOrderData, te
 		BranchID:       branchID,
 		UserID:         userID,
 		OrderNumber:    orderData.OrderNumber,
-		TotalAmount:    orderData.TotalAmount,
-		Status:         orderData.Status,
+		CouponCode:     orderData.CouponCode,
+		CustomerID:     customerID,
+		Status:         status,
 		Notes:          orderData.Notes,
 		SyncStatus:     "synced",
 		ClientID:       clientID + "_" + orderData.LocalID, // Kombinasi untuk uniqueness
@@ -249,42 +568,91 @@ func (s *SyncService) processOrder(tx *gorm.DB, orderData *dto.SyncOrderData, te
 		UpdatedBy:      &userID,
 	}
 
-	// Generate order number if not provided
+	// Generate order number if not provided; clients working offline send numbers from a reserved block
 	if order.OrderNumber == "" {
-		order.OrderNumber = fmt.Sprintf("ORD-%s-%d", time.Now().Format("20060102"), time.Now().Unix())
+		orderNumber, err := s.orderNumberService.NextOrderNumber(tx, tenantID, branchID, orderData.LocalTimestamp)
+		if err != nil {
+			return 0, nil, err
+		}
+		order.OrderNumber = orderNumber
+	} else if err := s.orderNumberService.ClaimReservedNumber(tx, tenantID, branchID, clientID, order.OrderNumber); err != nil {
+		return 0, nil, err
 	}
 
 	if err := tx.Create(&order).Error; err != nil {
-		return 0, err
+		return 0, nil, err
 	}
 
 	// Create order items
-	for _, itemData := range orderData.Items {
+	for i, itemData := range orderData.Items {
+		line := pricing.Lines[i]
 		orderItem := models.OrderItem{
-			OrderID:    order.ID,
-			ProductID:  itemData.ProductID,
-			Quantity:   itemData.Quantity,
-			Price:      itemData.Price,
-			Subtotal:   itemData.Subtotal,
-			SyncStatus: "synced",
-			ClientID:   clientID + "_" + orderData.LocalID,
+			OrderID:        order.ID,
+			ProductID:      itemData.ProductID,
+			Quantity:       itemData.Quantity,
+			Price:          itemData.Price,
+			Subtotal:       itemData.Subtotal,
+			DiscountAmount: line.Discount,
+			NetAmount:      line.Subtotal - line.Discount,
+			UnitCost:       pricing.Products[itemData.ProductID].CostPrice,
+			SyncStatus:     "synced",
+			ClientID:       clientID + "_" + orderData.LocalID,
 		}
 		if err := tx.Create(&orderItem).Error; err != nil {
-			return 0, err
+			return 0, nil, err
 		}
 
-		// Update product stock
-		if err := tx.Model(&models.Product{}).Where("id = ?", itemData.ProductID).
-			UpdateColumn("stock", gorm.Expr("stock - ?", itemData.Quantity)).Error; err != nil {
-			return 0, err
+		// The sale already happened offline, so it is recorded even when stock runs short. Orders
+		// cancelled or voided before they synced never took stock.
+		if orderStatusRestoresStock(order.Status) {
+			continue
 		}
+		movement := orderStockMovement(&order, itemData.ProductID, -itemData.Quantity, "offline sale", &userID)
+		movement.Type = StockMovementSync
+		if err := recordStockMovement(tx, movement); err != nil {
+			return 0, nil, err
+		}
+	}
+
+	conflict, err := applySyncOrderPricing(tx, &order, orderData, pricing, clientID)
+	if err != nil {
+		return 0, nil, err
+	}
+
+	// Recorded after the items so the kitchen receives the complete order
+	if err := recordOrderStatusChange(tx, &order, "", order.Status, "offline sync", &userID); err != nil {
+		return 0, nil, err
+	}
+
+	return order.ID, conflict, nil
+}
+
+// syncTenderedAmount - Amount handed over by the customer; clients that don't track change send only the amount
+func syncTenderedAmount(paymentData *dto.SyncPaymentData) money.Amount {
+	if paymentData.TenderedAmount > 0 {
+		return paymentData.TenderedAmount
 	}
+	return paymentData.Amount + paymentData.TipAmount + paymentData.ChangeAmount
+}
 
-	return order.ID, nil
+// syncTipUserID - Offline tips go to the cashier syncing them
+func syncTipUserID(paymentData *dto.SyncPaymentData, userID uint) *uint {
+	if paymentData.TipAmount <= 0 {
+		return nil
+	}
+	return &userID
 }
 
 // processPayment - Process single payment
 func (s *SyncService) processPayment(tx *gorm.DB, paymentData *dto.SyncPaymentData, tenantID, branchID, userID uint, clientID string, orderMapping map[string]uint) (uint, error) {
+	// Point and gift card balances can only be checked against the ledger online
+	if paymentData.PaymentMethod == LoyaltyPaymentMethod {
+		return 0, errors.New("loyalty points cannot be redeemed offline")
+	}
+	if paymentData.PaymentMethod == GiftCardPaymentMethod {
+		return 0, errors.New("gift cards cannot be redeemed offline")
+	}
+
 	// Get server order ID from mapping
 	serverOrderID, ok := orderMapping[paymentData.OrderLocalID]
 	if !ok {
@@ -307,6 +675,11 @@ func (s *SyncService) processPayment(tx *gorm.DB, paymentData *dto.SyncPaymentDa
 		}
 		// Update existing payment
 		existing.Amount = paymentData.Amount
+		existing.TenderedAmount = syncTenderedAmount(paymentData)
+		existing.ChangeAmount = paymentData.ChangeAmount
+		existing.RoundingAmount = paymentData.RoundingAmount
+		existing.TipAmount = paymentData.TipAmount
+		existing.TipUserID = syncTipUserID(paymentData, userID)
 		existing.PaymentMethod = paymentData.PaymentMethod
 		existing.Status = paymentData.Status
 		existing.Notes = paymentData.Notes
@@ -316,6 +689,14 @@ func (s *SyncService) processPayment(tx *gorm.DB, paymentData *dto.SyncPaymentDa
 		if err := tx.Save(&existing).Error; err != nil {
 			return 0, err
 		}
+
+		var order models.Order
+		if err := tx.First(&order, existing.OrderID).Error; err != nil {
+			return 0, err
+		}
+		if _, err := applyOrderPayments(tx, &order, "offline sync payment", &userID); err != nil {
+			return 0, err
+		}
 		return existing.ID, nil
 	}
 
@@ -329,6 +710,11 @@ func (s *SyncService) processPayment(tx *gorm.DB, paymentData *dto.SyncPaymentDa
 		BranchID:       branchID,
 		OrderID:        serverOrderID,
 		Amount:         paymentData.Amount,
+		TenderedAmount: syncTenderedAmount(paymentData),
+		ChangeAmount:   paymentData.ChangeAmount,
+		RoundingAmount: paymentData.RoundingAmount,
+		TipAmount:      paymentData.TipAmount,
+		TipUserID:      syncTipUserID(paymentData, userID),
 		PaymentMethod:  paymentData.PaymentMethod,
 		Status:         paymentData.Status,
 		Notes:          paymentData.Notes,
@@ -340,17 +726,26 @@ func (s *SyncService) processPayment(tx *gorm.DB, paymentData *dto.SyncPaymentDa
 		UpdatedBy:      &userID,
 	}
 
+	// Cash taken offline has already changed hands, so it joins the cashier's shift when one is
+	// open but is never refused for lack of one
+	shift, err := openCashShift(tx, tenantID, branchID, &userID)
+	if err != nil {
+		return 0, err
+	}
+	if shift != nil {
+		payment.ShiftID = &shift.ID
+	}
+
 	if err := tx.Create(&payment).Error; err != nil {
 		return 0, err
 	}
 
-	// Update order status to completed
-	if err := tx.Model(&models.Order{}).Where("id = ?", serverOrderID).
-		Updates(map[string]interface{}{
-			"status":     "completed",
-			"updated_by": userID,
-			"updated_at": time.Now(),
-		}).Error; err != nil {
+	// Update paid amount and complete the order once fully paid
+	var order models.Order
+	if err := tx.First(&order, serverOrderID).Error; err != nil {
+		return 0, err
+	}
+	if _, err := applyOrderPayments(tx, &order, "offline sync payment", &userID); err != nil {
 		return 0, err
 	}
 
@@ -519,7 +914,9 @@ func (s *SyncService) processUser(tx *gorm.DB, userData *dto.SyncUserData, tenan
 	return user.ID, nil
 }
 
-// processProduct - Process product data from client
+// processProduct - Process product data from client. The client's stock is not taken: it already
+// has the offline sales taken out, which their synced orders record, and it would overwrite sales
+// made online in the meantime. Counted stock goes through stock adjustments.
 func (s *SyncService) processProduct(tx *gorm.DB, productData *dto.SyncProductData, tenantID, userID uint, clientID string) (uint, error) {
 	var existing models.Product
 	err := tx.Where("client_id = ?", clientID+"_"+productData.LocalID).First(&existing).Error
@@ -532,7 +929,6 @@ func (s *SyncService) processProduct(tx *gorm.DB, productData *dto.SyncProductDa
 		existing.Description = productData.Description
 		existing.SKU = productData.SKU
 		existing.Price = productData.Price
-		existing.Stock = productData.Stock
 		categoryID := productData.CategoryID
 		existing.CategoryID = &categoryID
 		existing.Image = productData.Image
@@ -558,7 +954,6 @@ func (s *SyncService) processProduct(tx *gorm.DB, productData *dto.SyncProductDa
 		Description:    productData.Description,
 		SKU:            productData.SKU,
 		Price:          productData.Price,
-		Stock:          productData.Stock,
 		Image:          productData.Image,
 		IsActive:       productData.IsActive,
 		SyncStatus:     "synced",
@@ -645,8 +1040,8 @@ func (s *SyncService) processAudit(tx *gorm.DB, auditData *dto.SyncAuditData, te
 	return audit.ID, nil
 }
 
-// DownloadToClient - Download master data ke mobile client
-func (s *SyncService) DownloadToClient(req *dto.SyncDownloadRequest, tenantID uint) (*dto.SyncDownloadResponse, error) {
+// DownloadToClient - Download master data ke mobile client, stock is the stock at the client's branch
+func (s *SyncService) DownloadToClient(req *dto.SyncDownloadRequest, tenantID, branchID uint) (*dto.SyncDownloadResponse, error) {
 	// Start sync log
 	syncLog := &models.SyncLog{
 		TenantID:  tenantID,
@@ -711,7 +1106,7 @@ func (s *SyncService) DownloadToClient(req *dto.SyncDownloadRequest, tenantID ui
 			recordsCount += len(users)
 
 		case "products":
-			products, err := s.getProductsForSync(tenantID, req.LastSyncAt)
+			products, err := s.getProductsForSync(tenantID, branchID, req.LastSyncAt)
 			if err != nil {
 				syncLog.Status = "failed"
 				syncLog.ErrorMessage = err.Error()
@@ -745,20 +1140,30 @@ func (s *SyncService) DownloadToClient(req *dto.SyncDownloadRequest, tenantID ui
 	return response, nil
 }
 
-// getProductsForSync - Get products for sync (delta or full)
-func (s *SyncService) getProductsForSync(tenantID uint, lastSyncAt *time.Time) ([]dto.ProductResponse, error) {
+// getProductsForSync - Get products for sync (delta or full) with their stock at the branch
+func (s *SyncService) getProductsForSync(tenantID, branchID uint, lastSyncAt *time.Time) ([]dto.ProductResponse, error) {
 	var products []models.Product
 	query := s.db.Where("tenant_id = ? AND deleted_at IS NULL", tenantID)
 
-	// Delta sync: only get updated products
+	// Delta sync: only get updated products, or products whose stock at the branch moved
 	if lastSyncAt != nil {
-		query = query.Where("updated_at > ?", lastSyncAt)
+		query = query.Where("updated_at > ? OR id IN (?)", lastSyncAt,
+			s.db.Model(&models.ProductStock{}).Select("product_id").Where("branch_id = ? AND updated_at > ?", branchID, lastSyncAt))
 	}
 
 	if err := query.Preload("CategoryDetail").Find(&products).Error; err != nil {
 		return nil, err
 	}
 
+	productIDs := make([]uint, len(products))
+	for i, p := range products {
+		productIDs[i] = p.ID
+	}
+	stockLevels, err := branchStockLevels(s.db, branchID, productIDs)
+	if err != nil {
+		return nil, err
+	}
+
 	// Convert to response DTO
 	var response []dto.ProductResponse
 	for _, p := range products {
@@ -769,11 +1174,14 @@ func (s *SyncService) getProductsForSync(tenantID uint, lastSyncAt *time.Time) (
 			Description: p.Description,
 			SKU:         p.SKU,
 			Price:       p.Price,
-			Stock:       p.Stock,
+			Stock:       stockLevels[p.ID],
 			IsActive:    p.IsActive,
 			Image:       p.Image,
 			CreatedAt:   p.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt:   p.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+
+			IsGiftCard:           p.IsGiftCard,
+			GiftCardValidityDays: p.GiftCardValidityDays,
 		}
 
 		if p.CategoryID != nil {
@@ -882,6 +1290,7 @@ func (s *SyncService) getBranchesForSync(tenantID uint, lastSyncAt *time.Time) (
 			Country:    b.Country,
 			PostalCode: b.PostalCode,
 			Image:      b.Image,
+			Timezone:   b.Timezone,
 			IsActive:   b.IsActive,
 			CreatedAt:  b.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
 			UpdatedAt:  b.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
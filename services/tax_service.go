@@ -0,0 +1,371 @@
+package services
+
+import (
+	"errors"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"sort"
+
+	"gorm.io/gorm"
+)
+
+type TaxService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewTaxService(db *gorm.DB, auditTrailService *AuditTrailService) *TaxService {
+	return &TaxService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// TaxLine is an order line (after discounts) subject to tax
+type TaxLine struct {
+	CategoryID *uint
+	NetAmount  money.Amount
+	TaxExempt  bool // Gift cards: taxed when the card is redeemed, not when it is sold
+}
+
+// TaxResult holds the receipt breakdown of an order
+type TaxResult struct {
+	SubtotalAmount      money.Amount
+	ServiceChargeAmount money.Amount
+	TaxAmount           money.Amount
+	InclusiveTaxAmount  money.Amount
+	GrandTotal          money.Amount
+	Taxes               []models.OrderTax
+}
+
+// GetApplicableRules returns the active rules for a branch, ordered by sequence.
+// Rules defined for the branch replace the tenant wide rules entirely.
+func (s *TaxService) GetApplicableRules(tx *gorm.DB, tenantID, branchID uint) ([]models.TaxRule, error) {
+	var rules []models.TaxRule
+	if err := tx.Preload("Exemptions").
+		Where("tenant_id = ? AND branch_id = ? AND is_active = ?", tenantID, branchID, true).
+		Order("sequence ASC, id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	if len(rules) > 0 {
+		return rules, nil
+	}
+
+	if err := tx.Preload("Exemptions").
+		Where("tenant_id = ? AND branch_id IS NULL AND is_active = ?", tenantID, true).
+		Order("sequence ASC, id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+// CalculateTaxes computes service charges and taxes for the lines using the branch rules
+func (s *TaxService) CalculateTaxes(tx *gorm.DB, tenantID, branchID uint, lines []TaxLine) (*TaxResult, error) {
+	rules, err := s.GetApplicableRules(tx, tenantID, branchID)
+	if err != nil {
+		return nil, err
+	}
+	cur, err := tenantCurrency(tx, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	return ComputeTaxes(rules, cur, lines), nil
+}
+
+// ComputeTaxes applies the rules in sequence order. Exclusive charges are added on top of the
+// subtotal; compound rules also charge the exclusive amounts of earlier rules. Inclusive taxes are
+// extracted from the price and do not change the grand total. Each amount is rounded to the minor
+// unit of cur.
+func ComputeTaxes(rules []models.TaxRule, cur money.Currency, lines []TaxLine) *TaxResult {
+	result := &TaxResult{}
+	for _, line := range lines {
+		result.SubtotalAmount += line.NetAmount
+	}
+
+	sorted := make([]models.TaxRule, len(rules))
+	copy(sorted, rules)
+	sort.SliceStable(sorted, func(i, j int) bool { return sorted[i].Sequence < sorted[j].Sequence })
+
+	var previousCharges money.Amount
+	for _, rule := range sorted {
+		exempt := make(map[uint]bool, len(rule.Exemptions))
+		for _, exemption := range rule.Exemptions {
+			exempt[exemption.CategoryID] = true
+		}
+
+		var base money.Amount
+		for _, line := range lines {
+			if line.TaxExempt || (line.CategoryID != nil && exempt[*line.CategoryID]) {
+				continue
+			}
+			base += line.NetAmount
+		}
+		if rule.IsCompound && !rule.IsInclusive {
+			base += previousCharges
+		}
+		if base <= 0 {
+			continue
+		}
+
+		var amount money.Amount
+		if rule.IsInclusive {
+			amount = base - cur.ExcludePercent(base, rule.Rate)
+			result.InclusiveTaxAmount += amount
+		} else {
+			amount = cur.Percent(base, rule.Rate)
+			previousCharges += amount
+			if rule.Type == "service_charge" {
+				result.ServiceChargeAmount += amount
+			} else {
+				result.TaxAmount += amount
+			}
+		}
+
+		ruleID := rule.ID
+		result.Taxes = append(result.Taxes, models.OrderTax{
+			TaxRuleID:     &ruleID,
+			Name:          rule.Name,
+			Type:          rule.Type,
+			Rate:          rule.Rate,
+			IsInclusive:   rule.IsInclusive,
+			TaxableAmount: base,
+			Amount:        amount,
+		})
+	}
+
+	result.GrandTotal = result.SubtotalAmount + result.ServiceChargeAmount + result.TaxAmount
+
+	return result
+}
+
+func (s *TaxService) CreateTaxRule(tenantID uint, req dto.CreateTaxRuleRequest) (*models.TaxRule, error) {
+	if err := s.validateBranch(tenantID, req.BranchID); err != nil {
+		return nil, err
+	}
+
+	rule := models.TaxRule{
+		TenantID:    tenantID,
+		BranchID:    req.BranchID,
+		Name:        req.Name,
+		Type:        req.Type,
+		Rate:        req.Rate,
+		IsInclusive: req.IsInclusive,
+		Sequence:    req.Sequence,
+		IsCompound:  req.IsCompound,
+		IsActive:    true,
+		CreatedBy:   req.CreatedBy,
+	}
+	if req.IsActive != nil {
+		rule.IsActive = *req.IsActive
+	}
+
+	err := s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&rule).Error; err != nil {
+			return err
+		}
+		return s.replaceExemptions(tx, tenantID, rule.ID, req.ExemptCategoryIDs)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"name":                rule.Name,
+		"type":                rule.Type,
+		"rate":                rule.Rate,
+		"is_inclusive":        rule.IsInclusive,
+		"sequence":            rule.Sequence,
+		"is_compound":         rule.IsCompound,
+		"exempt_category_ids": req.ExemptCategoryIDs,
+		"is_active":           rule.IsActive,
+	}
+	var auditUserID uint
+	if req.CreatedBy != nil {
+		auditUserID = *req.CreatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, rule.BranchID, auditUserID, "tax_rule", rule.ID, "create", changes, "", "")
+
+	return s.GetTaxRule(rule.ID, tenantID)
+}
+
+func (s *TaxService) GetTaxRule(id, tenantID uint) (*models.TaxRule, error) {
+	var rule models.TaxRule
+	if err := s.db.Preload("Exemptions.Category").Preload("Creator").Preload("Updater").
+		Where("id = ? AND tenant_id = ?", id, tenantID).
+		First(&rule).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("tax rule not found")
+		}
+		return nil, err
+	}
+	return &rule, nil
+}
+
+// ListTaxRules returns all rules of the tenant; branchID > 0 limits to rules of that branch plus tenant wide rules
+func (s *TaxService) ListTaxRules(tenantID, branchID uint) ([]models.TaxRule, error) {
+	var rules []models.TaxRule
+	query := s.db.Preload("Exemptions.Category").Preload("Creator").Preload("Updater").
+		Where("tenant_id = ?", tenantID)
+	if branchID > 0 {
+		query = query.Where("branch_id IS NULL OR branch_id = ?", branchID)
+	}
+	if err := query.Order("branch_id ASC NULLS FIRST, sequence ASC, id ASC").Find(&rules).Error; err != nil {
+		return nil, err
+	}
+	return rules, nil
+}
+
+func (s *TaxService) UpdateTaxRule(id, tenantID uint, req dto.UpdateTaxRuleRequest) (*models.TaxRule, error) {
+	rule, err := s.GetTaxRule(id, tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	oldValues := map[string]interface{}{
+		"name":         rule.Name,
+		"type":         rule.Type,
+		"rate":         rule.Rate,
+		"is_inclusive": rule.IsInclusive,
+		"sequence":     rule.Sequence,
+		"is_compound":  rule.IsCompound,
+		"is_active":    rule.IsActive,
+	}
+
+	updates := make(map[string]interface{})
+	if req.Name != nil {
+		updates["name"] = *req.Name
+	}
+	if req.Type != nil {
+		updates["type"] = *req.Type
+	}
+	if req.Rate != nil {
+		updates["rate"] = *req.Rate
+	}
+	if req.IsInclusive != nil {
+		updates["is_inclusive"] = *req.IsInclusive
+	}
+	if req.Sequence != nil {
+		updates["sequence"] = *req.Sequence
+	}
+	if req.IsCompound != nil {
+		updates["is_compound"] = *req.IsCompound
+	}
+	if req.IsActive != nil {
+		updates["is_active"] = *req.IsActive
+	}
+	if req.UpdatedBy != nil {
+		updates["updated_by"] = *req.UpdatedBy
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if len(updates) > 0 {
+			if err := tx.Model(&models.TaxRule{}).Where("id = ?", rule.ID).Updates(updates).Error; err != nil {
+				return err
+			}
+		}
+		if req.ExemptCategoryIDs != nil {
+			return s.replaceExemptions(tx, tenantID, rule.ID, *req.ExemptCategoryIDs)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Create audit trail with changes
+	changes := make(map[string]interface{})
+	for key, newVal := range updates {
+		if oldVal, exists := oldValues[key]; exists {
+			changes[key] = map[string]interface{}{
+				"old": oldVal,
+				"new": newVal,
+			}
+		}
+	}
+	if req.ExemptCategoryIDs != nil {
+		changes["exempt_category_ids"] = *req.ExemptCategoryIDs
+	}
+	var auditUserID uint
+	if req.UpdatedBy != nil {
+		auditUserID = *req.UpdatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, rule.BranchID, auditUserID, "tax_rule", rule.ID, "update", changes, "", "")
+
+	return s.GetTaxRule(rule.ID, tenantID)
+}
+
+func (s *TaxService) DeleteTaxRule(id, tenantID uint, deletedBy *uint) error {
+	rule, err := s.GetTaxRule(id, tenantID)
+	if err != nil {
+		return err
+	}
+
+	if deletedBy != nil {
+		if err := s.db.Model(&models.TaxRule{}).Where("id = ?", rule.ID).Update("deleted_by", deletedBy).Error; err != nil {
+			return err
+		}
+	}
+
+	if err := s.db.Delete(&models.TaxRule{}, rule.ID).Error; err != nil {
+		return err
+	}
+
+	// Create audit trail
+	changes := map[string]interface{}{
+		"name": rule.Name,
+		"type": rule.Type,
+		"rate": rule.Rate,
+	}
+	var auditUserID uint
+	if deletedBy != nil {
+		auditUserID = *deletedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, rule.BranchID, auditUserID, "tax_rule", rule.ID, "delete", changes, "", "")
+
+	return nil
+}
+
+func (s *TaxService) validateBranch(tenantID uint, branchID *uint) error {
+	if branchID == nil {
+		return nil
+	}
+	var count int64
+	if err := s.db.Model(&models.Branch{}).Where("id = ? AND tenant_id = ?", *branchID, tenantID).Count(&count).Error; err != nil {
+		return err
+	}
+	if count == 0 {
+		return errors.New("branch not found or doesn't belong to this tenant")
+	}
+	return nil
+}
+
+// replaceExemptions swaps the exempt categories of a rule for the given list
+func (s *TaxService) replaceExemptions(tx *gorm.DB, tenantID, ruleID uint, categoryIDs []uint) error {
+	if err := tx.Where("tax_rule_id = ?", ruleID).Delete(&models.TaxRuleExemption{}).Error; err != nil {
+		return err
+	}
+	if len(categoryIDs) == 0 {
+		return nil
+	}
+
+	var count int64
+	if err := tx.Model(&models.Category{}).Where("id IN ? AND tenant_id = ?", categoryIDs, tenantID).Count(&count).Error; err != nil {
+		return err
+	}
+	if int(count) != len(categoryIDs) {
+		return errors.New("some exempt categories not found")
+	}
+
+	exemptions := make([]models.TaxRuleExemption, len(categoryIDs))
+	for i, categoryID := range categoryIDs {
+		exemptions[i] = models.TaxRuleExemption{
+			TaxRuleID:  ruleID,
+			CategoryID: categoryID,
+		}
+	}
+	return tx.Create(&exemptions).Error
+}
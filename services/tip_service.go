@@ -0,0 +1,227 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"myposcore/dto"
+	"myposcore/models"
+	"myposcore/money"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Tip preset types
+const (
+	TipPresetFixed   = "fixed"
+	TipPresetPercent = "percent"
+)
+
+type TipService struct {
+	db                *gorm.DB
+	auditTrailService *AuditTrailService
+}
+
+func NewTipService(db *gorm.DB, auditTrailService *AuditTrailService) *TipService {
+	return &TipService{
+		db:                db,
+		auditTrailService: auditTrailService,
+	}
+}
+
+// tipListFields applies the shared list filter to the tip report; user_id is the staff member tipped
+var tipListFields = listFilterFields{
+	CreatedAt:     "payments.created_at",
+	Status:        "payments.status",
+	PaymentMethod: "payments.payment_method IN ?",
+	UserID:        "payments.tip_user_id",
+	CustomerID:    "orders.customer_id",
+	Amount:        "payments.tip_amount",
+	OrderNumber:   "orders.order_number",
+}
+
+// GetPresets returns the tenant's tip presets in the order they are offered
+func (s *TipService) GetPresets(tenantID uint) ([]models.TipPreset, error) {
+	var presets []models.TipPreset
+	if err := s.db.Where("tenant_id = ?", tenantID).Order("sort_order ASC, id ASC").Find(&presets).Error; err != nil {
+		return nil, err
+	}
+	return presets, nil
+}
+
+// SavePresets replaces the tenant's tip presets. Tips already taken are not affected.
+func (s *TipService) SavePresets(tenantID uint, req dto.SaveTipPresetsRequest, updatedBy *uint) ([]models.TipPreset, error) {
+	for i, p := range req.Presets {
+		if p.Type == TipPresetFixed && p.Amount <= 0 {
+			return nil, fmt.Errorf("preset %d: amount must be greater than zero for a fixed tip", i+1)
+		}
+		if p.Type == TipPresetPercent && p.Percent <= 0 {
+			return nil, fmt.Errorf("preset %d: percent must be greater than zero for a percent tip", i+1)
+		}
+	}
+
+	cur, err := tenantCurrency(s.db, tenantID)
+	if err != nil {
+		return nil, err
+	}
+	old, err := s.GetPresets(tenantID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = s.db.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("tenant_id = ?", tenantID).Delete(&models.TipPreset{}).Error; err != nil {
+			return err
+		}
+		if len(req.Presets) == 0 {
+			return nil
+		}
+		presets := make([]models.TipPreset, len(req.Presets))
+		for i, p := range req.Presets {
+			presets[i] = models.TipPreset{TenantID: tenantID, Type: p.Type, SortOrder: i + 1, CreatedBy: updatedBy}
+			if p.Type == TipPresetFixed {
+				presets[i].Amount = cur.Round(p.Amount)
+			} else {
+				presets[i].Percent = p.Percent
+			}
+		}
+		return tx.Create(&presets).Error
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	oldPresets := make([]dto.TipPresetRequest, len(old))
+	for i, p := range old {
+		oldPresets[i] = dto.TipPresetRequest{Type: p.Type, Amount: p.Amount, Percent: p.Percent}
+	}
+	var auditUserID uint
+	if updatedBy != nil {
+		auditUserID = *updatedBy
+	}
+	_ = s.auditTrailService.CreateAuditTrail(&tenantID, nil, auditUserID, "tip_presets", tenantID, "update", map[string]interface{}{
+		"presets": map[string]interface{}{"old": oldPresets, "new": req.Presets},
+	}, "", "")
+
+	return s.GetPresets(tenantID)
+}
+
+// tipPresetAmount returns the tip a preset suggests for an order total
+func tipPresetAmount(cur money.Currency, preset *models.TipPreset, total money.Amount) money.Amount {
+	if preset.Type == TipPresetPercent {
+		return cur.Percent(total, preset.Percent)
+	}
+	return cur.Round(preset.Amount)
+}
+
+// resolvePaymentTip returns the tip left with a payment and the staff member it is for. Tips go to
+// the order's cashier unless another user of the tenant is named.
+func resolvePaymentTip(tx *gorm.DB, cur money.Currency, order *models.Order, req dto.PaymentTipRequest) (money.Amount, *uint, error) {
+	tip := cur.Round(req.TipAmount)
+	if req.TipPresetID != nil {
+		var preset models.TipPreset
+		if err := tx.Where("id = ? AND tenant_id = ?", *req.TipPresetID, order.TenantID).First(&preset).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return 0, nil, errors.New("tip preset not found")
+			}
+			return 0, nil, err
+		}
+		tip = tipPresetAmount(cur, &preset, order.TotalAmount)
+	}
+	if tip <= 0 {
+		return 0, nil, nil
+	}
+
+	userID := order.UserID
+	if req.TipUserID != nil {
+		var count int64
+		if err := tx.Model(&models.User{}).Where("id = ? AND tenant_id = ?", *req.TipUserID, order.TenantID).Count(&count).Error; err != nil {
+			return 0, nil, err
+		}
+		if count == 0 {
+			return 0, nil, errors.New("tip user not found")
+		}
+		userID = *req.TipUserID
+	}
+	return tip, &userID, nil
+}
+
+// summarizeTips totals the tips of the payments in query per staff member and cash shift. Tips stay
+// with the staff member when the sale is refunded.
+func summarizeTips(query *gorm.DB) ([]dto.TipStaffTotal, error) {
+	type tipTotal struct {
+		UserID      uint
+		UserName    string
+		ShiftID     *uint
+		OpenedAt    *time.Time
+		ClosedAt    *time.Time
+		Count       int
+		CashTips    money.Amount
+		NonCashTips money.Amount
+	}
+
+	var totals []tipTotal
+	if err := query.
+		Select(`payments.tip_user_id AS user_id, users.full_name AS user_name, payments.shift_id,
+			cash_shifts.opened_at, cash_shifts.closed_at, COUNT(*) AS count,
+			COALESCE(SUM(CASE WHEN payments.payment_method = 'cash' THEN payments.tip_amount ELSE 0 END), 0) AS cash_tips,
+			COALESCE(SUM(CASE WHEN payments.payment_method <> 'cash' THEN payments.tip_amount ELSE 0 END), 0) AS non_cash_tips`).
+		Joins("LEFT JOIN users ON users.id = payments.tip_user_id").
+		Joins("LEFT JOIN cash_shifts ON cash_shifts.id = payments.shift_id").
+		Where("payments.tip_amount > 0 AND payments.status IN ?", paidPaymentStatuses).
+		Group("payments.tip_user_id, users.full_name, payments.shift_id, cash_shifts.opened_at, cash_shifts.closed_at").
+		Order("users.full_name ASC, payments.tip_user_id ASC, cash_shifts.opened_at ASC").
+		Scan(&totals).Error; err != nil {
+		return nil, err
+	}
+
+	staff := []dto.TipStaffTotal{}
+	for _, t := range totals {
+		if len(staff) == 0 || staff[len(staff)-1].UserID != t.UserID {
+			staff = append(staff, dto.TipStaffTotal{UserID: t.UserID, UserName: t.UserName})
+		}
+		member := &staff[len(staff)-1]
+
+		shift := dto.TipShiftTotal{
+			ShiftID:      t.ShiftID,
+			PaymentCount: t.Count,
+			CashTips:     t.CashTips,
+			NonCashTips:  t.NonCashTips,
+			TotalTips:    t.CashTips + t.NonCashTips,
+		}
+		if t.OpenedAt != nil {
+			openedAt := t.OpenedAt.Format("2006-01-02 15:04:05")
+			shift.OpenedAt = &openedAt
+		}
+		if t.ClosedAt != nil {
+			closedAt := t.ClosedAt.Format("2006-01-02 15:04:05")
+			shift.ClosedAt = &closedAt
+		}
+		member.Shifts = append(member.Shifts, shift)
+		member.PaymentCount += shift.PaymentCount
+		member.CashTips += shift.CashTips
+		member.NonCashTips += shift.NonCashTips
+		member.TotalTips += shift.TotalTips
+	}
+	return staff, nil
+}
+
+// GetReport totals the tips owed to each staff member, per cash shift, so they can be paid out.
+// Dates, payment methods and user_id (the staff member tipped) come from the shared list filter.
+func (s *TipService) GetReport(tenantID, branchID uint, filter *dto.ListFilter, shiftID *uint) ([]dto.TipStaffTotal, error) {
+	query := s.db.Model(&models.Payment{}).
+		Joins("JOIN orders ON orders.id = payments.order_id").
+		Where("payments.tenant_id = ?", tenantID)
+	if branchID > 0 {
+		query = query.Where("payments.branch_id = ?", branchID)
+	}
+	if shiftID != nil {
+		query = query.Where("payments.shift_id = ?", *shiftID)
+	}
+
+	query, err := applyListFilter(s.db, query, tenantID, branchID, filter, tipListFields)
+	if err != nil {
+		return nil, err
+	}
+	return summarizeTips(query)
+}